@@ -0,0 +1,181 @@
+package ledger
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AccountInfo is what is known of an account from its declaration by an
+// account directive and from the postings to it.
+//
+// An account directive may be followed by indented sub-directives:
+//
+//	account Assets:Checking
+//	    note Joint account at the credit union
+//	    commodity USD
+//	    open 2020/01/15
+type AccountInfo struct {
+	Name string
+	// Declared is whether an account directive declares the account.
+	Declared bool
+	// Note is the text of the note sub-directive.
+	Note string
+	// Currency is the default currency of the account, of the commodity
+	// sub-directive, or else of the first posting to the account.
+	Currency string
+	// Opened is the date of the open sub-directive, or else of the first
+	// posting to the account.
+	Opened time.Time
+	// Postings is the number of postings to the account.
+	Postings int
+
+	// Filename and Line locate the account directive, if declared.
+	Filename string `json:"-"`
+	Line     int    `json:"-"`
+}
+
+// AccountRegistry holds the accounts of a ledger, declared or used by a
+// posting. It is not safe for concurrent use.
+type AccountRegistry struct {
+	accounts map[string]*registryEntry
+}
+
+type registryEntry struct {
+	info AccountInfo
+
+	// whether the currency and opening date are declared, rather than
+	// taken from the postings
+	declaredCurrency, declaredOpened bool
+	// date of the first posting
+	first time.Time
+}
+
+// NewAccountRegistry returns an empty account registry.
+func NewAccountRegistry() *AccountRegistry {
+	return &AccountRegistry{accounts: make(map[string]*registryEntry)}
+}
+
+func (r *AccountRegistry) entry(name string) *registryEntry {
+	e, ok := r.accounts[name]
+	if !ok {
+		e = &registryEntry{info: AccountInfo{Name: name}}
+		r.accounts[name] = e
+	}
+	return e
+}
+
+// Declare adds the declaration of an account. A later declaration of the same
+// account sets what it declares again, and keeps the rest.
+func (r *AccountRegistry) Declare(decl AccountInfo) {
+	e := r.entry(decl.Name)
+	if !e.info.Declared {
+		e.info.Filename, e.info.Line = decl.Filename, decl.Line
+	}
+	e.info.Declared = true
+	if decl.Note != "" {
+		e.info.Note = decl.Note
+	}
+	if decl.Currency != "" {
+		e.info.Currency = decl.Currency
+		e.declaredCurrency = true
+	}
+	if !decl.Opened.IsZero() {
+		e.info.Opened = decl.Opened
+		e.declaredOpened = true
+	}
+}
+
+// Observe adds the postings of the transactions, registering the accounts
+// that are not declared.
+func (r *AccountRegistry) Observe(trans ...*Transaction) {
+	for _, t := range trans {
+		for _, acc := range t.AccountChanges {
+			e := r.entry(acc.Name)
+			e.info.Postings++
+			if e.info.Postings > 1 && !t.Date.Before(e.first) {
+				continue
+			}
+			e.first = t.Date
+			if !e.declaredOpened {
+				e.info.Opened = t.Date
+			}
+			if !e.declaredCurrency {
+				e.info.Currency = acc.Currency
+			}
+		}
+	}
+}
+
+// Lookup returns what is known of the account of the name.
+func (r *AccountRegistry) Lookup(name string) (AccountInfo, bool) {
+	e, ok := r.accounts[name]
+	if !ok {
+		return AccountInfo{}, false
+	}
+	return e.info, true
+}
+
+// IsDeclared reports whether the account of the name is declared.
+func (r *AccountRegistry) IsDeclared(name string) bool {
+	e, ok := r.accounts[name]
+	return ok && e.info.Declared
+}
+
+// Accounts returns the accounts of the registry, sorted by name.
+func (r *AccountRegistry) Accounts() []AccountInfo {
+	accounts := make([]AccountInfo, 0, len(r.accounts))
+	for _, e := range r.accounts {
+		accounts = append(accounts, e.info)
+	}
+	slices.SortFunc(accounts, func(a, b AccountInfo) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return accounts
+}
+
+// Undeclared returns the accounts used by postings without being declared,
+// sorted by name.
+func (r *AccountRegistry) Undeclared() []AccountInfo {
+	return slices.DeleteFunc(r.Accounts(), func(info AccountInfo) bool {
+		return info.Declared
+	})
+}
+
+// ParseAccountRegistry parses a ledger file and returns the registry of the
+// accounts declared in it and used by its transactions, including those of any
+// included files.
+func ParseAccountRegistry(filename string) (registry *AccountRegistry, err error) {
+	ifile, ierr := os.Open(filename)
+	if ierr != nil {
+		return nil, ierr
+	}
+	defer ifile.Close()
+
+	var mu sync.Mutex
+	var transactions []*Transaction
+	registry = NewAccountRegistry()
+	parseLedger(filename, ifile, func(r *parseResult, e error) (stop bool) {
+		if e != nil {
+			err = e
+			stop = true
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		transactions = append(transactions, r.transactions...)
+		for _, decl := range r.accounts {
+			registry.Declare(decl)
+		}
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	registry.Observe(transactions...)
+	return registry, nil
+}
@@ -0,0 +1,71 @@
+package ledger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseAccountRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.dat")
+	content := `account Assets:Checking
+    note Joint account  ; at the credit union
+    commodity USD
+    open 2020/01/15
+account Expenses:Food
+
+2024/02/01 Grocery Store
+    Expenses:Food    EUR 30
+    Assets:Checking
+
+2024/01/01 Grocery Store
+    Expenses:Food    20
+    Assets:Checking
+
+2024/01/05 Gas Station
+    Expenses:Auto:Fuel    40
+    Assets:Checking
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	registry, err := ParseAccountRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	date := func(d string) time.Time {
+		tm, _ := time.Parse("2006/01/02", d)
+		return tm
+	}
+	want := []AccountInfo{
+		{Name: "Assets:Checking", Declared: true, Note: "Joint account", Currency: "USD", Opened: date("2020/01/15"), Postings: 3, Filename: path, Line: 1},
+		{Name: "Expenses:Auto:Fuel", Opened: date("2024/01/05"), Postings: 1},
+		{Name: "Expenses:Food", Declared: true, Opened: date("2024/01/01"), Postings: 2, Filename: path, Line: 5},
+	}
+	got := registry.Accounts()
+	if len(got) != len(want) {
+		t.Fatalf("Accounts() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Accounts()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if undeclared := registry.Undeclared(); len(undeclared) != 1 || undeclared[0].Name != "Expenses:Auto:Fuel" {
+		t.Errorf("Undeclared() = %+v", undeclared)
+	}
+	if !registry.IsDeclared("Expenses:Food") || registry.IsDeclared("Expenses") {
+		t.Error("IsDeclared() of declared and unknown accounts")
+	}
+	if _, ok := registry.Lookup("Expenses"); ok {
+		t.Error("Lookup() found an account neither declared nor used")
+	}
+
+	registry.Declare(AccountInfo{Name: "Expenses:Food", Currency: "EUR", Line: 10})
+	if info, _ := registry.Lookup("Expenses:Food"); info.Currency != "EUR" || info.Line != 5 || info.Postings != 2 {
+		t.Errorf("Lookup() after Declare() = %+v", info)
+	}
+}
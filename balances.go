@@ -1,6 +1,7 @@
 package ledger
 
 import (
+	"iter"
 	"slices"
 	"strings"
 
@@ -76,3 +77,144 @@ func GetBalances(generalLedger []*Transaction, filterArr []string) []*Account {
 	})
 	return accList
 }
+
+// BalanceNode is an account of a tree of balances, with a balance per
+// currency of the postings to the account itself and of those to it and its
+// sub-accounts.
+type BalanceNode struct {
+	// Name is the full name of the account, "" for the root of the tree.
+	Name string
+	// Own has the balances of the postings to the account itself, sorted by
+	// currency.
+	Own []Account
+	// Total has the balances of the account and its sub-accounts, sorted by
+	// currency.
+	Total []Account
+	// Children are the sub-accounts, sorted by name.
+	Children []*BalanceNode
+}
+
+// GetBalanceTree returns the tree of the accounts of the transactions that
+// have any filter as a substring of the account name, or all accounts without
+// filters. The root has the top level accounts as children and the totals of
+// all accounts. Parents of the accounts in the filter are in the tree for its
+// structure, with the balances of the filtered accounts only.
+func GetBalanceTree(generalLedger []*Transaction, filterArr []string) *BalanceNode {
+	root := &BalanceNode{}
+	nodes := make(map[string]*BalanceNode)
+	var node func(name string) *BalanceNode
+	node = func(name string) *BalanceNode {
+		if n, ok := nodes[name]; ok {
+			return n
+		}
+		n := &BalanceNode{Name: name}
+		nodes[name] = n
+		parent := root
+		if colIdx := strings.LastIndex(name, ":"); colIdx >= 0 {
+			parent = node(name[:colIdx])
+		}
+		parent.Children = append(parent.Children, n)
+		return n
+	}
+
+	for _, trans := range generalLedger {
+		for _, accChange := range trans.AccountChanges {
+			inFilter := len(filterArr) == 0
+			for i := 0; i < len(filterArr) && !inFilter; i++ {
+				inFilter = strings.Contains(accChange.Name, filterArr[i])
+			}
+			if inFilter {
+				n := node(accChange.Name)
+				n.Own = addBalance(n.Own, accChange.Name, accChange.Currency, accChange.Balance)
+			}
+		}
+	}
+
+	var total func(n *BalanceNode)
+	total = func(n *BalanceNode) {
+		slices.SortFunc(n.Children, func(a, b *BalanceNode) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+		slices.SortFunc(n.Own, func(a, b Account) int {
+			return strings.Compare(a.Currency, b.Currency)
+		})
+		n.Total = slices.Clone(n.Own)
+		for _, child := range n.Children {
+			total(child)
+			for _, acc := range child.Total {
+				n.Total = addBalance(n.Total, n.Name, acc.Currency, acc.Balance)
+			}
+		}
+		slices.SortFunc(n.Total, func(a, b Account) int {
+			return strings.Compare(a.Currency, b.Currency)
+		})
+	}
+	total(root)
+	return root
+}
+
+// addBalance adds the amount of the currency to the balances of the account.
+func addBalance(balances []Account, name, currency string, amount decimal.Decimal) []Account {
+	for i := range balances {
+		if balances[i].Currency == currency {
+			balances[i].Balance = balances[i].Balance.Add(amount)
+			return balances
+		}
+	}
+	return append(balances, Account{Name: name, Currency: currency, Balance: amount})
+}
+
+// ShortName returns the last segment of the name of the account, as Food of
+// Expenses:Food.
+func (n *BalanceNode) ShortName() string {
+	return n.Name[strings.LastIndex(n.Name, ":")+1:]
+}
+
+// Depth returns the number of segments of the name of the account, 0 for the
+// root.
+func (n *BalanceNode) Depth() int {
+	if n.Name == "" {
+		return 0
+	}
+	return strings.Count(n.Name, ":") + 1
+}
+
+// Find returns the node of the account of the name in the tree, or nil.
+func (n *BalanceNode) Find(name string) *BalanceNode {
+	for node := range n.All() {
+		if node.Name == name {
+			return node
+		}
+	}
+	return nil
+}
+
+// All returns an iterator over the accounts of the tree, each before its
+// sub-accounts, without the root.
+func (n *BalanceNode) All() iter.Seq[*BalanceNode] {
+	return func(yield func(*BalanceNode) bool) {
+		var walk func(n *BalanceNode) bool
+		walk = func(n *BalanceNode) bool {
+			for _, child := range n.Children {
+				if !yield(child) || !walk(child) {
+					return false
+				}
+			}
+			return true
+		}
+		walk(n)
+	}
+}
+
+// Accounts returns the total balances of the accounts of the tree, an
+// account per currency, each account before its sub-accounts, as
+// GetBalances returns them.
+func (n *BalanceNode) Accounts() []*Account {
+	var accounts []*Account
+	for node := range n.All() {
+		for i := range node.Total {
+			accounts = append(accounts, &node.Total[i])
+		}
+	}
+	return accounts
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/rand"
+	"strings"
 	"testing"
 	"time"
 
@@ -135,6 +136,71 @@ func TestBalanceLedger(t *testing.T) {
 	}
 }
 
+func TestGetBalanceTree(t *testing.T) {
+	for _, tc := range testBalCases {
+		transactions, _ := ParseLedger(bytes.NewBufferString(tc.data))
+		exp, _ := json.Marshal(tc.balances)
+		got, _ := json.Marshal(GetBalanceTree(transactions, nil).Accounts())
+		if string(exp) != string(got) {
+			t.Errorf("Error(%s): expected \n`%s`, \ngot \n`%s`", tc.name, exp, got)
+		}
+	}
+
+	transactions, err := ParseLedger(bytes.NewBufferString(`2024/01/02 Shop
+	Expenses:Food:Groceries  30
+	Expenses:Food  5
+	Assets:Cash
+
+2024/01/02 Bookshop
+	Expenses:Books  USD 20
+	Assets:Cash  USD -20
+
+2024/01/03 Restaurant
+	Expenses:Food:TakeOut  80
+	Assets:Cash
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	balance := func(accounts []Account) string {
+		var parts []string
+		for _, acc := range accounts {
+			parts = append(parts, strings.TrimSpace(acc.Currency+" "+acc.Balance.String()))
+		}
+		return strings.Join(parts, ", ")
+	}
+
+	tree := GetBalanceTree(transactions, nil)
+	if got := balance(tree.Total); got != "0, USD 0" {
+		t.Errorf("root total = %s, want 0, USD 0", got)
+	}
+	var names []string
+	for n := range tree.All() {
+		names = append(names, strings.Repeat("  ", n.Depth()-1)+n.ShortName())
+	}
+	if got, want := strings.Join(names, "|"), "Assets|  Cash|Expenses|  Books|  Food|    Groceries|    TakeOut"; got != want {
+		t.Errorf("tree = %q, want %q", got, want)
+	}
+	food := tree.Find("Expenses:Food")
+	if food == nil {
+		t.Fatal("no Expenses:Food")
+	}
+	if own, total := balance(food.Own), balance(food.Total); own != "5" || total != "115" {
+		t.Errorf("Expenses:Food own %s, total %s, want 5, 115", own, total)
+	}
+	if got := balance(tree.Find("Expenses").Total); got != "115, USD 20" {
+		t.Errorf("Expenses total = %s, want 115, USD 20", got)
+	}
+
+	filtered := GetBalanceTree(transactions, []string{"Food"})
+	if got := balance(filtered.Find("Expenses").Total); got != "115" {
+		t.Errorf("filtered Expenses total = %s, want 115", got)
+	}
+	if filtered.Find("Expenses:Books") != nil || filtered.Find("Assets") != nil {
+		t.Error("filtered tree has accounts not in the filter")
+	}
+}
+
 func BenchmarkGetBalances(b *testing.B) {
 	trans := make([]*Transaction, 0, 100000)
 	for i := range 100000 {
@@ -212,3 +278,80 @@ func TestBalancesByPeriod(t *testing.T) {
 		t.Error("range balances for non-existent transactions")
 	}
 }
+
+func TestBalanceMatrixByPeriod(t *testing.T) {
+	b := bytes.NewBufferString(`
+2022/01/02 Payee
+	Expenses:Food     50
+	Assets
+
+2022/02/02 Payee
+	Expenses:Rent     500
+	Assets
+
+2022/02/10 Payee
+	Expenses:Food     20
+	Assets
+
+2022/04/02 Payee
+	Expenses:Food     30
+	Assets
+
+2022/04/03 Payee
+	Expenses:Fuel     EUR 40
+	Assets            EUR -40
+`)
+	trans, err := ParseLedger(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matrix := BalanceMatrixByPeriod(trans, PeriodMonth, []string{"Expenses"})
+	if len(matrix.Periods) != 4 {
+		t.Fatalf("periods = %d, want 4", len(matrix.Periods))
+	}
+	if start := matrix.Periods[1].Start.Format("2006/01/02"); start != "2022/02/01" {
+		t.Errorf("second period starts %s, want 2022/02/01", start)
+	}
+
+	want := []struct {
+		name, currency string
+		total          int64
+		balances       []int64
+	}{
+		{"Expenses", "", 600, []int64{50, 520, 0, 30}},
+		{"Expenses", "EUR", 40, []int64{0, 0, 0, 40}},
+		{"Expenses:Food", "", 100, []int64{50, 20, 0, 30}},
+		{"Expenses:Fuel", "EUR", 40, []int64{0, 0, 0, 40}},
+		{"Expenses:Rent", "", 500, []int64{0, 500, 0, 0}},
+	}
+	if len(matrix.Accounts) != len(want) || len(matrix.Balances) != len(want) {
+		t.Fatalf("rows = %d, want %d", len(matrix.Accounts), len(want))
+	}
+	for i, w := range want {
+		acc := matrix.Accounts[i]
+		if acc.Name != w.name || acc.Currency != w.currency || !acc.Balance.Equal(decimal.NewFromInt(w.total)) {
+			t.Errorf("row %d = %s %s %s, want %s %s %d", i, acc.Name, acc.Currency, acc.Balance, w.name, w.currency, w.total)
+		}
+		for col, bal := range w.balances {
+			if !matrix.Balances[i][col].Equal(decimal.NewFromInt(bal)) {
+				t.Errorf("%s %s period %d = %s, want %d", w.name, w.currency, col, matrix.Balances[i][col], bal)
+			}
+		}
+	}
+
+	cumulative := matrix.Cumulative()
+	food := cumulative.Balances[2]
+	for col, bal := range []int64{50, 70, 70, 100} {
+		if !food[col].Equal(decimal.NewFromInt(bal)) {
+			t.Errorf("cumulative food period %d = %s, want %d", col, food[col], bal)
+		}
+	}
+	if !matrix.Balances[2][1].Equal(decimal.NewFromInt(20)) {
+		t.Error("Cumulative changed the matrix")
+	}
+
+	if empty := BalanceMatrixByPeriod(nil, PeriodMonth, nil); len(empty.Accounts) != 0 {
+		t.Error("balance matrix for non-existent transactions")
+	}
+}
@@ -0,0 +1,99 @@
+package ledger
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Budget is the amounts budgeted for accounts by periodic transactions, each
+// occurrence of a periodic transaction budgeting its postings.
+type Budget struct {
+	Periodic []*PeriodicTransaction
+	// Query selects the budgeted accounts, and the postings counted against
+	// them. A nil Query selects all.
+	Query *Query
+}
+
+// BudgetLine is the amount budgeted for an account in a currency, and the
+// actual balance of the account, over a date range.
+type BudgetLine struct {
+	Account  string
+	Currency string
+	Budgeted decimal.Decimal
+	Actual   decimal.Decimal
+}
+
+// Remaining returns the amount of the budget not spent, negative when the
+// actual amount exceeds it.
+func (l BudgetLine) Remaining() decimal.Decimal {
+	return l.Budgeted.Sub(l.Actual)
+}
+
+// Over reports whether the actual amount exceeds the budget.
+func (l BudgetLine) Over() bool {
+	return l.Actual.Abs().GreaterThan(l.Budgeted.Abs())
+}
+
+// BudgetPeriod is the budget lines of a date range, the end inclusive.
+type BudgetPeriod struct {
+	Start, End time.Time
+	Lines      []BudgetLine
+}
+
+// Evaluate returns the budget lines of each period of the transactions, as
+// TransactionsByPeriod splits them.
+func (b *Budget) Evaluate(trans []*Transaction, per Period) []*BudgetPeriod {
+	var periods []*BudgetPeriod
+	for _, rt := range TransactionsByPeriod(trans, per) {
+		periods = append(periods, &BudgetPeriod{
+			Start: rt.Start,
+			End:   rt.End,
+			Lines: b.EvaluateRange(rt.Transactions, rt.Start, rt.End.AddDate(0, 0, 1)),
+		})
+	}
+	return periods
+}
+
+// EvaluateRange compares, per account and currency, the amounts budgeted by
+// the periodic transactions that occur within start (inclusive) and end
+// (exclusive) with the actual balance of those accounts in the transactions.
+// Only accounts that have a budget are returned, sorted by name and then
+// currency.
+func (b *Budget) EvaluateRange(trans []*Transaction, start, end time.Time) []BudgetLine {
+	type accountKey struct {
+		name     string
+		currency string
+	}
+	budgets := make(map[accountKey]decimal.Decimal)
+	for _, pt := range b.Periodic {
+		occurrences := int64(len(pt.Occurrences(start, end)))
+		if occurrences < 1 {
+			continue
+		}
+		budgetTrans := &Transaction{Date: start, Payee: pt.Payee, Comments: pt.Comments}
+		for _, accChange := range pt.AccountChanges {
+			if b.Query.Match(budgetTrans, &accChange) {
+				key := accountKey{accChange.Name, accChange.Currency}
+				budgets[key] = budgets[key].Add(accChange.Balance.Mul(decimal.NewFromInt(occurrences)))
+			}
+		}
+	}
+
+	actuals := make(map[accountKey]decimal.Decimal)
+	for _, acc := range GetBalances(b.Query.Filter(trans), nil) {
+		actuals[accountKey{acc.Name, acc.Currency}] = acc.Balance
+	}
+
+	lines := make([]BudgetLine, 0, len(budgets))
+	for key, bud := range budgets {
+		lines = append(lines, BudgetLine{Account: key.name, Currency: key.currency, Budgeted: bud, Actual: actuals[key]})
+	}
+	slices.SortFunc(lines, func(a, b BudgetLine) int {
+		return cmp.Or(strings.Compare(a.Account, b.Account), strings.Compare(a.Currency, b.Currency))
+	})
+	return lines
+}
@@ -0,0 +1,77 @@
+package ledger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestBudgetEvaluate(t *testing.T) {
+	generalLedger := []*Transaction{
+		{
+			Date: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC),
+			AccountChanges: []Account{
+				{Name: "Expenses:Food:Groceries", Balance: decimal.NewFromInt(350)},
+				{Name: "Assets:Checking", Balance: decimal.NewFromInt(-350)},
+			},
+		},
+		{
+			Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			AccountChanges: []Account{
+				{Name: "Expenses:Rent", Balance: decimal.NewFromInt(1000)},
+				{Name: "Assets:Checking", Balance: decimal.NewFromInt(-1000)},
+			},
+		},
+	}
+	query, err := ParseQuery("Expenses")
+	if err != nil {
+		t.Fatal(err)
+	}
+	budget := &Budget{
+		Periodic: []*PeriodicTransaction{
+			{
+				Period: PeriodMonth,
+				AccountChanges: []Account{
+					{Name: "Expenses:Food", Balance: decimal.NewFromInt(300)},
+					{Name: "Expenses:Rent", Balance: decimal.NewFromInt(1000)},
+					{Name: "Assets:Checking", Balance: decimal.NewFromInt(-1300)},
+				},
+			},
+		},
+		Query: query,
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	lines := budget.EvaluateRange(generalLedger, start, end)
+	want := []BudgetLine{
+		{Account: "Expenses:Food", Budgeted: decimal.NewFromInt(900), Actual: decimal.NewFromInt(350)},
+		{Account: "Expenses:Rent", Budgeted: decimal.NewFromInt(3000), Actual: decimal.NewFromInt(1000)},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d", len(lines), len(want))
+	}
+	for i := range lines {
+		if lines[i].Account != want[i].Account || !lines[i].Budgeted.Equal(want[i].Budgeted) || !lines[i].Actual.Equal(want[i].Actual) {
+			t.Errorf("line %d = %+v, want %+v", i, lines[i], want[i])
+		}
+	}
+	if remaining := lines[0].Remaining(); !remaining.Equal(decimal.NewFromInt(550)) || lines[0].Over() {
+		t.Errorf("Expenses:Food remaining %s, over %v", remaining, lines[0].Over())
+	}
+
+	periods := budget.Evaluate(generalLedger, PeriodMonth)
+	if len(periods) != 3 {
+		t.Fatalf("got %d periods, want 3", len(periods))
+	}
+	if food := periods[0].Lines[0]; food.Account != "Expenses:Food" || !food.Budgeted.Equal(decimal.NewFromInt(300)) || !food.Over() {
+		t.Errorf("January Expenses:Food = %+v", food)
+	}
+	if rent := periods[2].Lines[1]; rent.Account != "Expenses:Rent" || !rent.Remaining().IsZero() {
+		t.Errorf("March Expenses:Rent = %+v", rent)
+	}
+	if start := periods[1].Start.Format("2006/01/02"); start != "2024/02/01" {
+		t.Errorf("second period starts %s", start)
+	}
+}
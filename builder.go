@@ -0,0 +1,155 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrElidedMultiCurrency is returned by TransactionBuilder.Build when the
+// amount of a posting is left out of a transaction with postings in several
+// currencies and no price to convert between them.
+var ErrElidedMultiCurrency = errors.New("unable to elide amount: postings in several currencies")
+
+var builderCurrencyRegex = regexp.MustCompile(`^[A-Z\$]+$`)
+
+// TransactionBuilder builds a transaction posting by posting, validating it as
+// the parser would a transaction read from a ledger file:
+//
+//	trans, err := ledger.NewTransaction(date, "Grocery Store").
+//		Post("Expenses:Food", decimal.NewFromInt(30)).
+//		Elide("Assets:Checking").
+//		Build()
+//
+// The first error of a step is returned by Build.
+type TransactionBuilder struct {
+	trans    Transaction
+	currency string
+	elided   int
+	err      error
+}
+
+// NewTransaction starts a transaction of the payee on the date.
+func NewTransaction(date time.Time, payee string) *TransactionBuilder {
+	b := &TransactionBuilder{trans: Transaction{Date: date, Payee: strings.TrimSpace(payee)}, elided: -1}
+	if date.IsZero() {
+		b.fail(errors.New("transaction without a date"))
+	}
+	if b.trans.Payee == "" {
+		b.fail(errors.New("transaction without a payee"))
+	}
+	return b
+}
+
+func (b *TransactionBuilder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// normalizeCurrency returns the currency upper-cased, as ledger files write
+// currencies.
+func (b *TransactionBuilder) normalizeCurrency(currency string) string {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency != "" && !builderCurrencyRegex.MatchString(currency) {
+		b.fail(fmt.Errorf("invalid currency: %q", currency))
+	}
+	return currency
+}
+
+// Status sets the clearing status of the transaction.
+func (b *TransactionBuilder) Status(status Status) *TransactionBuilder {
+	b.trans.Status = status
+	return b
+}
+
+// Comment adds a comment line to the transaction.
+func (b *TransactionBuilder) Comment(comment string) *TransactionBuilder {
+	comment = strings.TrimSpace(strings.TrimPrefix(comment, ";"))
+	b.trans.Comments = append(b.trans.Comments, "; "+comment)
+	return b
+}
+
+// Currency sets the currency of the postings added without one.
+func (b *TransactionBuilder) Currency(currency string) *TransactionBuilder {
+	b.currency = b.normalizeCurrency(currency)
+	return b
+}
+
+// Post adds a posting of the amount to the account, in the currency set by
+// Currency.
+func (b *TransactionBuilder) Post(account string, amount decimal.Decimal) *TransactionBuilder {
+	return b.PostIn(account, "", amount)
+}
+
+// PostIn adds a posting of the amount in the currency to the account.
+func (b *TransactionBuilder) PostIn(account, currency string, amount decimal.Decimal) *TransactionBuilder {
+	account = strings.TrimSpace(account)
+	switch {
+	case account == "":
+		b.fail(errors.New("posting without an account"))
+	case strings.Contains(account, "  ") || strings.ContainsAny(account, "\t\n;"):
+		b.fail(fmt.Errorf("invalid account name: %q", account))
+	}
+	currency = b.normalizeCurrency(currency)
+	if currency == "" {
+		currency = b.currency
+	}
+	b.trans.AccountChanges = append(b.trans.AccountChanges, Account{Name: account, Currency: currency, Balance: amount})
+	return b
+}
+
+// Elide adds a posting to the account with the amount left out, so that it
+// balances the transaction, in the currency of the other postings.
+func (b *TransactionBuilder) Elide(account string) *TransactionBuilder {
+	if b.elided >= 0 {
+		b.fail(ErrMoreThanOneEmptyAccountInTx)
+	}
+	b.Post(account, decimal.Zero)
+	b.elided = len(b.trans.AccountChanges) - 1
+	b.trans.AccountChanges[b.elided].Currency = ""
+	return b
+}
+
+// Build returns the transaction, balanced with the amount of the elided
+// posting filled in, or the first error found.
+func (b *TransactionBuilder) Build() (*Transaction, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	trans := b.trans
+	trans.AccountChanges = append([]Account(nil), b.trans.AccountChanges...)
+	trans.Comments = append([]string(nil), b.trans.Comments...)
+
+	if b.elided >= 0 {
+		var currencies []string
+		var converted bool
+		for i, acc := range trans.AccountChanges {
+			if i == b.elided {
+				continue
+			}
+			if acc.Converted != nil || acc.ConversionFactor != nil {
+				converted = true
+			}
+			if !slices.Contains(currencies, acc.Currency) {
+				currencies = append(currencies, acc.Currency)
+			}
+		}
+		switch {
+		case len(currencies) == 1:
+			trans.AccountChanges[b.elided].Currency = currencies[0]
+		case len(currencies) > 1 && !converted:
+			return nil, ErrElidedMultiCurrency
+		}
+	}
+
+	if err := trans.IsBalanced(); err != nil {
+		return nil, err
+	}
+	return &trans, nil
+}
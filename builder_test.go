@@ -0,0 +1,95 @@
+package ledger
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestTransactionBuilder(t *testing.T) {
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	trans, err := NewTransaction(date, " Grocery Store ").
+		Status(StatusCleared).
+		Comment("weekly shopping").
+		Currency("usd").
+		Post(" Expenses:Food ", decimal.NewFromInt(30)).
+		PostIn("Expenses:Household", "USD", decimal.NewFromInt(12)).
+		Elide("Assets:Checking").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trans.Payee != "Grocery Store" || trans.Status != StatusCleared || !trans.Date.Equal(date) {
+		t.Errorf("transaction = %v %v %q", trans.Date, trans.Status, trans.Payee)
+	}
+	if len(trans.Comments) != 1 || trans.Comments[0] != "; weekly shopping" {
+		t.Errorf("comments = %q", trans.Comments)
+	}
+	want := []Account{
+		{Name: "Expenses:Food", Currency: "USD", Balance: decimal.NewFromInt(30)},
+		{Name: "Expenses:Household", Currency: "USD", Balance: decimal.NewFromInt(12)},
+		{Name: "Assets:Checking", Currency: "USD", Balance: decimal.NewFromInt(-42)},
+	}
+	if len(trans.AccountChanges) != len(want) {
+		t.Fatalf("got %d postings, want %d", len(trans.AccountChanges), len(want))
+	}
+	for i, acc := range trans.AccountChanges {
+		if acc.Name != want[i].Name || acc.Currency != want[i].Currency || !acc.Balance.Equal(want[i].Balance) {
+			t.Errorf("posting %d = %s %s %s, want %s %s %s", i,
+				acc.Name, acc.Currency, acc.Balance, want[i].Name, want[i].Currency, want[i].Balance)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		builder *TransactionBuilder
+		wantErr error
+	}{
+		{
+			name:    "unbalanced",
+			builder: NewTransaction(date, "Payee").Post("A", decimal.NewFromInt(1)).Post("B", decimal.NewFromInt(2)),
+			wantErr: ErrNoEmptyAccountForExtraBalance,
+		},
+		{
+			name:    "single posting",
+			builder: NewTransaction(date, "Payee").Post("A", decimal.NewFromInt(1)),
+			wantErr: ErrNeedAtLeastTwoPostings,
+		},
+		{
+			name:    "two elided postings",
+			builder: NewTransaction(date, "Payee").Post("A", decimal.NewFromInt(1)).Elide("B").Elide("C"),
+			wantErr: ErrMoreThanOneEmptyAccountInTx,
+		},
+		{
+			name: "elided posting of several currencies",
+			builder: NewTransaction(date, "Payee").
+				PostIn("A", "USD", decimal.NewFromInt(1)).
+				PostIn("B", "EUR", decimal.NewFromInt(1)).
+				Elide("C"),
+			wantErr: ErrElidedMultiCurrency,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.builder.Build(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Build() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	invalid := map[string]*TransactionBuilder{
+		"no payee":         NewTransaction(date, " ").Post("A", decimal.NewFromInt(1)).Elide("B"),
+		"no date":          NewTransaction(time.Time{}, "Payee").Post("A", decimal.NewFromInt(1)).Elide("B"),
+		"no account":       NewTransaction(date, "Payee").Post("", decimal.NewFromInt(1)).Elide("B"),
+		"account spaces":   NewTransaction(date, "Payee").Post("A  B", decimal.NewFromInt(1)).Elide("B"),
+		"invalid currency": NewTransaction(date, "Payee").PostIn("A", "US1", decimal.NewFromInt(1)).Elide("B"),
+	}
+	for name, b := range invalid {
+		if _, err := b.Build(); err == nil {
+			t.Errorf("%s: Build() succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package ledger
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// CheckKind classifies a problem found by CheckLedgerFile.
+type CheckKind string
+
+// Kinds of problems found by CheckLedgerFile.
+const (
+	CheckParse      CheckKind = "parse"
+	CheckUnbalanced CheckKind = "unbalanced"
+	CheckAssertion  CheckKind = "assertion"
+	CheckUndeclared CheckKind = "undeclared"
+	CheckFuture     CheckKind = "future"
+	CheckStale      CheckKind = "stale"
+	CheckCommodity  CheckKind = "commodity"
+	CheckSign       CheckKind = "sign"
+)
+
+// CheckOptions selects the optional validations of CheckLedgerFile.
+type CheckOptions struct {
+	// Strict reports accounts used without being declared by an account
+	// directive.
+	Strict bool
+	// Transactions dated after Now are reported, unless Now is zero.
+	Now time.Time
+	// Commodities reports postings to an account in another commodity than
+	// that of the account, declared by a commodity sub-directive or else of
+	// its first posting, without a price (@ or @@) converting them, which
+	// usually comes of an import with the wrong currency. Postings without
+	// a commodity, such as those of elided amounts, are not checked.
+	Commodities bool
+	// Signs holds the sign amounts of accounts normally have, by account,
+	// applying to its sub-accounts without one of their own: 1 for
+	// positive, -1 for negative and 0 for either. Postings of the other sign
+	// are reported, such as refunds of expenses, or an import that forgot to
+	// negate its amounts.
+	Signs map[string]int
+	// The journal is reported stale if its latest transaction is dated
+	// before StaleBefore, unless StaleBefore is zero.
+	StaleBefore time.Time
+}
+
+// CheckError is a problem found by CheckLedgerFile. Filename and Line are
+// not set for parse errors, as the parse error includes them.
+type CheckError struct {
+	Kind     CheckKind
+	Filename string
+	Line     int
+	Err      error
+}
+
+func (e *CheckError) Error() string {
+	if e.Filename == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s:%d: %v", e.Filename, e.Line, e.Err)
+}
+
+func (e *CheckError) Unwrap() error {
+	return e.Err
+}
+
+// CheckLedgerFile parses a ledger file, continuing past errors, and validates
+// the transactions: balance assertions ("= AMOUNT" after a posting amount)
+// must hold, and depending on opts, accounts must be declared and hold a
+// single commodity, amounts must have the sign of their account, transactions
+// must not be in the future and the latest must be recent.
+//
+// Problems are returned sorted by file and line, parse errors first.
+func CheckLedgerFile(filename string, opts CheckOptions) []*CheckError {
+	ifile, ierr := os.Open(filename)
+	if ierr != nil {
+		return []*CheckError{{Kind: CheckParse, Err: ierr}}
+	}
+	defer ifile.Close()
+
+	var mu sync.Mutex
+	var problems []*CheckError
+	var transactions []*Transaction
+	registry := NewAccountRegistry()
+	parseLedger(filename, ifile, func(r *parseResult, e error) (stop bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		if e != nil {
+			kind := CheckParse
+			if errors.Is(e, ErrNeedAtLeastTwoPostings) ||
+				errors.Is(e, ErrNoEmptyAccountForExtraBalance) ||
+				errors.Is(e, ErrMoreThanOneEmptyAccountInTx) {
+				kind = CheckUnbalanced
+			}
+			problems = append(problems, &CheckError{Kind: kind, Err: e})
+			return
+		}
+
+		transactions = append(transactions, r.transactions...)
+		for _, decl := range r.accounts {
+			registry.Declare(decl)
+		}
+		return
+	})
+
+	slices.SortFunc(problems, func(a, b *CheckError) int {
+		return cmp.Compare(a.Error(), b.Error())
+	})
+	slices.SortStableFunc(transactions, func(a, b *Transaction) int {
+		return cmp.Or(
+			a.Date.Compare(b.Date),
+			cmp.Compare(a.Filename, b.Filename),
+			cmp.Compare(a.Line, b.Line),
+		)
+	})
+
+	var checked []*CheckError
+	newProblem := func(kind CheckKind, trans *Transaction, err error) {
+		checked = append(checked, &CheckError{Kind: kind, Filename: trans.Filename, Line: trans.Line, Err: err})
+	}
+
+	// commodity of each account, declared or else of its first posting with
+	// one
+	commodities := make(map[string]string)
+
+	// running balance by account name, then currency
+	balances := make(map[string]map[string]decimal.Decimal)
+	reported := make(map[string]bool)
+	for _, trans := range transactions {
+		if !opts.Now.IsZero() && trans.Date.After(opts.Now) {
+			newProblem(CheckFuture, trans, fmt.Errorf("transaction dated in the future: %s", trans.Date.Format("2006/01/02")))
+		}
+
+		for _, acc := range trans.AccountChanges {
+			if opts.Strict && !registry.IsDeclared(acc.Name) && !reported[acc.Name] {
+				reported[acc.Name] = true
+				newProblem(CheckUndeclared, trans, fmt.Errorf("undeclared account: %s", acc.Name))
+			}
+
+			if opts.Commodities && acc.Currency != "" && acc.ConversionFactor == nil && acc.Converted == nil {
+				commodity, ok := commodities[acc.Name]
+				if !ok {
+					info, _ := registry.Lookup(acc.Name)
+					commodity = cmp.Or(info.Currency, acc.Currency)
+					commodities[acc.Name] = commodity
+				}
+				if acc.Currency != commodity {
+					newProblem(CheckCommodity, trans, fmt.Errorf("posting to %s in %s without a price, account is in %s",
+						acc.Name, acc.Currency, commodity))
+				}
+			}
+
+			if sign := accountSign(opts.Signs, acc.Name); sign != 0 && acc.Balance.Sign() == -sign {
+				expected := "positive"
+				if sign < 0 {
+					expected = "negative"
+				}
+				newProblem(CheckSign, trans, fmt.Errorf("amount %s of %s is not %s", acc.Balance.String(), acc.Name, expected))
+			}
+
+			if _, ok := balances[acc.Name]; !ok {
+				balances[acc.Name] = make(map[string]decimal.Decimal)
+			}
+			bal := balances[acc.Name][acc.Currency].Add(acc.Balance)
+			balances[acc.Name][acc.Currency] = bal
+
+			if acc.BalanceAssertion != nil && !bal.Equal(*acc.BalanceAssertion) {
+				newProblem(CheckAssertion, trans, fmt.Errorf("balance assertion failed for %s: expected %s, got %s",
+					acc.Name, acc.BalanceAssertion.String(), bal.String()))
+			}
+		}
+	}
+
+	if !opts.StaleBefore.IsZero() {
+		if len(transactions) == 0 {
+			checked = append(checked, &CheckError{Kind: CheckStale, Err: errors.New("journal has no transactions")})
+		} else if latest := transactions[len(transactions)-1]; latest.Date.Before(opts.StaleBefore) {
+			newProblem(CheckStale, latest, fmt.Errorf("latest transaction dated %s, before %s",
+				latest.Date.Format("2006/01/02"), opts.StaleBefore.Format("2006/01/02")))
+		}
+	}
+
+	slices.SortStableFunc(checked, func(a, b *CheckError) int {
+		return cmp.Or(
+			cmp.Compare(a.Filename, b.Filename),
+			cmp.Compare(a.Line, b.Line),
+		)
+	})
+	return append(problems, checked...)
+}
+
+// accountSign returns the sign of the account in signs, or of its closest
+// parent account with one.
+func accountSign(signs map[string]int, name string) int {
+	for len(signs) > 0 {
+		if sign, ok := signs[name]; ok {
+			return sign
+		}
+		i := strings.LastIndex(name, ":")
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return 0
+}
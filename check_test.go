@@ -0,0 +1,136 @@
+package ledger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCheckLedgerFile(t *testing.T) {
+	problems := CheckLedgerFile("testdata/ledgerCheck.dat", CheckOptions{
+		Strict: true,
+		Now:    time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC),
+	})
+
+	expected := []struct {
+		kind CheckKind
+		msg  string
+	}{
+		{CheckUnbalanced, "testdata/ledgerCheck.dat:15: unable to parse transaction: unable to balance transaction: no empty account to place extra balance"},
+		{CheckUndeclared, "testdata/ledgerCheck.dat:8: undeclared account: Expenses:Food"},
+		{CheckAssertion, "testdata/ledgerCheck.dat:8: balance assertion failed for Assets:Checking: expected 900, got 950"},
+		{CheckFuture, "testdata/ledgerCheck.dat:16: transaction dated in the future: 2024/02/01"},
+	}
+	if len(problems) != len(expected) {
+		t.Fatalf("expected %d problems, got %d: %v", len(expected), len(problems), problems)
+	}
+	for i, p := range problems {
+		if p.Kind != expected[i].kind || p.Error() != expected[i].msg {
+			t.Errorf("problem %d: expected %s %q, got %s %q", i, expected[i].kind, expected[i].msg, p.Kind, p.Error())
+		}
+	}
+
+	if problems := CheckLedgerFile("testdata/ledgerRoot.dat", CheckOptions{}); len(problems) != 0 {
+		t.Errorf("expected no problems, got %v", problems)
+	}
+}
+
+func TestCheckStale(t *testing.T) {
+	// the latest transaction of ledgerRoot.dat is dated 2022/03/01
+	problems := CheckLedgerFile("testdata/ledgerRoot.dat", CheckOptions{
+		StaleBefore: time.Date(2022, time.April, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if len(problems) != 1 || problems[0].Kind != CheckStale ||
+		problems[0].Error() != "testdata/ledgerRoot.dat:9: latest transaction dated 2022/03/01, before 2022/04/01" {
+		t.Errorf("unexpected problems: %v", problems)
+	}
+
+	problems = CheckLedgerFile("testdata/ledgerRoot.dat", CheckOptions{
+		StaleBefore: time.Date(2022, time.March, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if len(problems) != 0 {
+		t.Errorf("expected no problems, got %v", problems)
+	}
+}
+
+func TestCheckCommodities(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "ledger.dat")
+	err := os.WriteFile(filename, []byte(`account Assets:Wise
+    commodity EUR
+
+2024/01/01 Employer
+    Assets:Checking    USD 1000
+    Income:Salary
+
+2024/01/02 Broker
+    Assets:Broker    AAPL 2 @ USD 150
+    Assets:Checking
+
+2024/01/03 Import
+    Assets:Checking    EUR 20
+    Income:Refunds
+
+2024/01/04 Transfer
+    Assets:Wise    USD 100
+    Assets:Checking
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	problems := CheckLedgerFile(filename, CheckOptions{Commodities: true})
+	expected := []string{
+		filename + ":12: posting to Assets:Checking in EUR without a price, account is in USD",
+		filename + ":16: posting to Assets:Wise in USD without a price, account is in EUR",
+	}
+	if len(problems) != len(expected) {
+		t.Fatalf("expected %d problems, got %v", len(expected), problems)
+	}
+	for i, p := range problems {
+		if p.Kind != CheckCommodity || p.Error() != expected[i] {
+			t.Errorf("problem %d: expected %q, got %s %q", i, expected[i], p.Kind, p.Error())
+		}
+	}
+}
+
+func TestCheckSigns(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "ledger.dat")
+	err := os.WriteFile(filename, []byte(`2024/01/01 Employer
+    Assets:Checking    1000
+    Income:Salary
+
+2024/01/02 Import
+    Assets:Checking    42
+    Expenses:Food
+
+2024/01/03 Refund
+    Expenses:Refunds:Shoes    -30
+    Assets:Checking
+
+2024/01/04 Import
+    Assets:Checking    -1000
+    Income:Salary
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	problems := CheckLedgerFile(filename, CheckOptions{Signs: map[string]int{
+		"Expenses":         1,
+		"Expenses:Refunds": 0,
+		"Income":           -1,
+	}})
+	expected := []string{
+		filename + ":5: amount -42 of Expenses:Food is not positive",
+		filename + ":13: amount 1000 of Income:Salary is not negative",
+	}
+	if len(problems) != len(expected) {
+		t.Fatalf("expected %d problems, got %v", len(expected), problems)
+	}
+	for i, p := range problems {
+		if p.Kind != CheckSign || p.Error() != expected[i] {
+			t.Errorf("problem %d: expected %q, got %s %q", i, expected[i], p.Kind, p.Error())
+		}
+	}
+}
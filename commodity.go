@@ -0,0 +1,100 @@
+package ledger
+
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// CommodityFormat is how amounts of a commodity are displayed, such as
+// "$1,234.50", "1.234,50 €" or "¥1,235".
+type CommodityFormat struct {
+	// Symbol is written for the commodity instead of its name, if not empty.
+	// It is written next to the amount, while a name is separated by a space.
+	Symbol string
+	// Suffix writes the symbol or name after the amount instead of before.
+	Suffix bool
+	// Thousands separates groups of three digits, if not empty.
+	Thousands string
+	// Decimal is the decimal mark, "." if empty.
+	Decimal string
+	// Places is the number of decimals amounts are rounded to.
+	Places int32
+}
+
+// DefaultCommodityFormat is the format of commodities without one: the name
+// before the amount, with two decimals.
+var DefaultCommodityFormat = CommodityFormat{Places: 2}
+
+// Format returns the amount of the commodity of the name formatted.
+func (f CommodityFormat) Format(name string, amount decimal.Decimal) string {
+	number := amount.Abs().StringFixedBank(f.Places)
+	intPart, fracPart, _ := strings.Cut(number, ".")
+	if f.Thousands != "" && len(intPart) > 3 {
+		var grouped strings.Builder
+		for i, r := range intPart {
+			if i > 0 && (len(intPart)-i)%3 == 0 {
+				grouped.WriteString(f.Thousands)
+			}
+			grouped.WriteRune(r)
+		}
+		intPart = grouped.String()
+	}
+	number = intPart
+	if fracPart != "" {
+		decimalMark := f.Decimal
+		if decimalMark == "" {
+			decimalMark = "."
+		}
+		number += decimalMark + fracPart
+	}
+	if amount.RoundBank(f.Places).Sign() < 0 {
+		number = "-" + number
+	}
+
+	switch {
+	case f.Symbol == "" && name == "":
+		return number
+	case f.Symbol == "" && f.Suffix:
+		return number + " " + name
+	case f.Symbol == "":
+		return name + " " + number
+	case f.Suffix:
+		return number + f.Symbol
+	}
+	// the sign goes before a symbol, as in -$5.00
+	if abs, ok := strings.CutPrefix(number, "-"); ok {
+		return "-" + f.Symbol + abs
+	}
+	return f.Symbol + number
+}
+
+// CommodityFormats holds the formats of commodities by name.
+type CommodityFormats map[string]CommodityFormat
+
+// Lookup returns the format of the commodity of the name, or
+// DefaultCommodityFormat.
+func (fs CommodityFormats) Lookup(name string) CommodityFormat {
+	if f, ok := fs[name]; ok {
+		return f
+	}
+	return DefaultCommodityFormat
+}
+
+// Format returns the amount of the commodity of the name in its format.
+func (fs CommodityFormats) Format(name string, amount decimal.Decimal) string {
+	return fs.Lookup(name).Format(name, amount)
+}
+
+// InferCommodityFormats returns the formats of the commodities of the
+// transactions, DefaultCommodityFormat with as many decimal places as their
+// amounts have, up to MaxPlaces.
+func InferCommodityFormats(trans []*Transaction) CommodityFormats {
+	formats := make(CommodityFormats)
+	for p := range Postings(trans) {
+		f := formats.Lookup(p.Posting.Currency)
+		f.Places = max(f.Places, min(decimalPlaces(p.Posting.Balance), MaxPlaces))
+		formats[p.Posting.Currency] = f
+	}
+	return formats
+}
@@ -0,0 +1,89 @@
+package ledger
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCommodityFormat(t *testing.T) {
+	usd := CommodityFormat{Symbol: "$", Thousands: ",", Places: 2}
+	eur := CommodityFormat{Symbol: " €", Suffix: true, Thousands: ".", Decimal: ",", Places: 2}
+	jpy := CommodityFormat{Symbol: "¥", Thousands: ",", Places: 0}
+	btc := CommodityFormat{Suffix: true, Places: 8}
+
+	tests := []struct {
+		format CommodityFormat
+		name   string
+		amount string
+		want   string
+	}{
+		{DefaultCommodityFormat, "USD", "1234.5", "USD 1234.50"},
+		{DefaultCommodityFormat, "USD", "-12", "USD -12.00"},
+		{DefaultCommodityFormat, "", "-0.004", "0.00"},
+		{usd, "USD", "1234567.891", "$1,234,567.89"},
+		{usd, "USD", "-5", "-$5.00"},
+		{usd, "USD", "999.99", "$999.99"},
+		{eur, "EUR", "-1234.5", "-1.234,50 €"},
+		{jpy, "JPY", "123456.7", "¥123,457"},
+		{btc, "BTC", "0.5", "0.50000000 BTC"},
+	}
+	for _, tt := range tests {
+		got := tt.format.Format(tt.name, decimal.RequireFromString(tt.amount))
+		if got != tt.want {
+			t.Errorf("Format(%q, %s) = %q, want %q", tt.name, tt.amount, got, tt.want)
+		}
+	}
+
+	formats := CommodityFormats{"JPY": jpy}
+	if got := formats.Format("JPY", decimal.NewFromInt(1000)); got != "¥1,000" {
+		t.Errorf("Format(JPY) = %q", got)
+	}
+	if got := formats.Format("CAD", decimal.NewFromInt(1000)); got != "CAD 1000.00" {
+		t.Errorf("Format(CAD) = %q", got)
+	}
+}
+
+func TestTransactionFormatPlaces(t *testing.T) {
+	trans := &Transaction{
+		Date:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Payee: "Exchange",
+		AccountChanges: []Account{
+			{Name: "Assets:Wallet", Currency: "BTC", Balance: decimal.RequireFromString("0.5")},
+			{Name: "Assets:Yen", Currency: "JPY", Balance: decimal.NewFromInt(-1000)},
+		},
+	}
+	format := TransactionFormat{Columns: 40, Commodities: CommodityFormats{
+		"BTC": {Places: 8},
+		"JPY": {Symbol: "¥", Thousands: ",", Places: 0},
+	}}
+	var buf strings.Builder
+	format.Write(&buf, trans)
+	want := `2024/01/01 Exchange
+    Assets:Wallet         BTC 0.50000000
+    Assets:Yen                 JPY -1000
+
+`
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestInferCommodityFormats(t *testing.T) {
+	trans := []*Transaction{{AccountChanges: []Account{
+		{Name: "Assets:Bitcoin", Currency: "BTC", Balance: decimal.RequireFromString("0.00000001")},
+		{Name: "Assets:Checking", Currency: "USD", Balance: decimal.RequireFromString("-5.5")},
+		{Name: "Assets:Fuel", Currency: "GAL", Balance: decimal.RequireFromString("3.459")},
+	}}}
+	formats := InferCommodityFormats(trans)
+	for name, places := range map[string]int32{"BTC": 8, "USD": 2, "GAL": 3} {
+		if formats.Lookup(name).Places != places {
+			t.Errorf("%s: got %d places, want %d", name, formats.Lookup(name).Places, places)
+		}
+	}
+	if got := formats.Format("BTC", decimal.RequireFromString("1.00000001")); got != "BTC 1.00000001" {
+		t.Errorf("Format(BTC) = %q", got)
+	}
+}
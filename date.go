@@ -1,22 +1,19 @@
 package ledger
 
-import "time"
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 // TransactionsInDateRange returns a new array of transactions that are in the date range
 // specified by start and end. The returned list contains transactions on the same day as start
 // but does not include any transactions on the day of end.
 func TransactionsInDateRange(trans []*Transaction, start, end time.Time) []*Transaction {
-	var newlist []*Transaction
-
-	start = start.Add(-1 * time.Second)
-
-	for _, tran := range trans {
-		if tran.Date.After(start) && tran.Date.Before(end) {
-			newlist = append(newlist, tran)
-		}
-	}
-
-	return newlist
+	return FilterTransactions(trans, ByDateRange(start, end))
 }
 
 // Period is used to specify the length of a date range or frequency
@@ -34,6 +31,92 @@ const (
 	PeriodYear     Period = "Yearly"
 )
 
+var periods = []Period{PeriodDay, PeriodWeek, Period2Week, PeriodMonth, Period2Month, PeriodQuarter, PeriodSemiYear, PeriodYear}
+
+// ParsePeriod returns the Period named s, ignoring case.
+func ParsePeriod(s string) (Period, bool) {
+	for _, per := range periods {
+		if strings.EqualFold(string(per), s) {
+			return per, true
+		}
+	}
+	return "", false
+}
+
+// periodLength returns the length of a period in years, months and days, as
+// used with time.AddDate.
+func periodLength(per Period) (years, months, days int) {
+	switch per {
+	case PeriodDay:
+		days = 1
+	case PeriodWeek:
+		days = 7
+	case Period2Week:
+		days = 14
+	case PeriodMonth:
+		months = 1
+	case Period2Month:
+		months = 2
+	case PeriodQuarter:
+		months = 3
+	case PeriodSemiYear:
+		months = 6
+	case PeriodYear:
+		years = 1
+	}
+	return
+}
+
+// Occurrences returns the dates on which the periodic transaction recurs
+// within start (inclusive) and end (exclusive). When the transaction has a
+// Start date it recurs every period from that date, otherwise it recurs at the
+// start of each period (first of the month, Sunday for weeks, and so on).
+func (pt *PeriodicTransaction) Occurrences(start, end time.Time) []time.Time {
+	if !pt.End.IsZero() && pt.End.Before(end) {
+		end = pt.End
+	}
+	years, months, days := periodLength(pt.Period)
+	if years == 0 && months == 0 && days == 0 {
+		return nil
+	}
+
+	var dates []time.Time
+	if !pt.Start.IsZero() {
+		for i := 0; ; i++ {
+			occurrence := pt.Start.AddDate(i*years, i*months, i*days)
+			if !occurrence.Before(end) {
+				break
+			}
+			if !occurrence.Before(start) {
+				dates = append(dates, occurrence)
+			}
+		}
+		return dates
+	}
+
+	for _, boundary := range getDateBoundaries(pt.Period, start, end) {
+		if !boundary.Before(start) && boundary.Before(end) {
+			dates = append(dates, boundary)
+		}
+	}
+	return dates
+}
+
+// Transactions returns a transaction for each time the periodic transaction
+// recurs within start (inclusive) and end (exclusive).
+func (pt *PeriodicTransaction) Transactions(start, end time.Time) []*Transaction {
+	var trans []*Transaction
+	for _, occurrence := range pt.Occurrences(start, end) {
+		trans = append(trans, &Transaction{
+			Date:           occurrence,
+			Payee:          pt.Payee,
+			AccountChanges: slices.Clone(pt.AccountChanges),
+			Comments:       slices.Clone(pt.Comments),
+		})
+	}
+	return trans
+}
+
 func getDateBoundaries(per Period, start, end time.Time) []time.Time {
 	var incDays, incMonth, incYear int
 	var periodStart time.Time
@@ -184,3 +267,78 @@ func BalancesByPeriod(trans []*Transaction, per Period, rType RangeType) []*Rang
 
 	return results
 }
+
+// DateRange is the start and end time of a date range, the end inclusive.
+type DateRange struct {
+	Start, End time.Time
+}
+
+// BalanceMatrix has the account balances of each period of a date range: a
+// row for each account and currency, a column for each period.
+type BalanceMatrix struct {
+	// Periods are the date ranges of the columns.
+	Periods []DateRange
+	// Accounts are the rows, sorted by name and then currency, each with its
+	// balance over all periods.
+	Accounts []*Account
+	// Balances has the balance of each row in each period.
+	Balances [][]decimal.Decimal
+}
+
+// BalanceMatrixByPeriod will return the balances of the accounts that have any
+// filter as a substring of the account name, or of all accounts without
+// filters, for each period. As GetBalances, it has a row for each account
+// level depth.
+func BalanceMatrixByPeriod(trans []*Transaction, per Period, filterArr []string) *BalanceMatrix {
+	matrix := &BalanceMatrix{}
+	type rowKey struct {
+		name, currency string
+	}
+	rows := make(map[rowKey]*Account)
+	var periodBalances [][]*Account
+	for _, rt := range TransactionsByPeriod(trans, per) {
+		matrix.Periods = append(matrix.Periods, DateRange{Start: rt.Start, End: rt.End})
+		balances := GetBalances(rt.Transactions, filterArr)
+		for _, acc := range balances {
+			key := rowKey{acc.Name, acc.Currency}
+			if _, ok := rows[key]; !ok {
+				rows[key] = &Account{Name: acc.Name, Currency: acc.Currency}
+				matrix.Accounts = append(matrix.Accounts, rows[key])
+			}
+			rows[key].Balance = rows[key].Balance.Add(acc.Balance)
+		}
+		periodBalances = append(periodBalances, balances)
+	}
+
+	slices.SortFunc(matrix.Accounts, func(a, b *Account) int {
+		return cmp.Or(strings.Compare(a.Name, b.Name), strings.Compare(a.Currency, b.Currency))
+	})
+	rowIndex := make(map[rowKey]int, len(matrix.Accounts))
+	matrix.Balances = make([][]decimal.Decimal, len(matrix.Accounts))
+	for i, acc := range matrix.Accounts {
+		rowIndex[rowKey{acc.Name, acc.Currency}] = i
+		matrix.Balances[i] = make([]decimal.Decimal, len(matrix.Periods))
+	}
+	for col, balances := range periodBalances {
+		for _, acc := range balances {
+			matrix.Balances[rowIndex[rowKey{acc.Name, acc.Currency}]][col] = acc.Balance
+		}
+	}
+	return matrix
+}
+
+// Cumulative returns the matrix with the running balance at the end of each
+// period, rather than the balance of the period.
+func (m *BalanceMatrix) Cumulative() *BalanceMatrix {
+	cumulative := &BalanceMatrix{Periods: m.Periods, Accounts: m.Accounts}
+	cumulative.Balances = make([][]decimal.Decimal, len(m.Balances))
+	for i, row := range m.Balances {
+		var running decimal.Decimal
+		cumulative.Balances[i] = make([]decimal.Decimal, len(row))
+		for col, bal := range row {
+			running = running.Add(bal)
+			cumulative.Balances[i][col] = running
+		}
+	}
+	return cumulative
+}
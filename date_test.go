@@ -3,6 +3,8 @@ package ledger
 import (
 	"testing"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 type boundCase struct {
@@ -159,3 +161,60 @@ func TestDateBoundaries(t *testing.T) {
 		}
 	}
 }
+
+func TestPeriodicOccurrences(t *testing.T) {
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)
+
+	monthly := PeriodicTransaction{Period: PeriodMonth}
+	if got := monthly.Occurrences(start, end); len(got) != 3 || !got[2].Equal(time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("monthly occurrences: %v", got)
+	}
+
+	anchored := PeriodicTransaction{Period: Period2Week, Start: time.Date(2023, time.December, 29, 0, 0, 0, 0, time.UTC)}
+	got := anchored.Occurrences(start, end)
+	if len(got) != 6 || !got[0].Equal(time.Date(2024, time.January, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("anchored occurrences: %v", got)
+	}
+
+	bounded := PeriodicTransaction{Period: PeriodMonth, End: time.Date(2024, time.February, 15, 0, 0, 0, 0, time.UTC)}
+	if got := bounded.Occurrences(start, end); len(got) != 2 {
+		t.Errorf("bounded occurrences: %v", got)
+	}
+
+	unknown := PeriodicTransaction{Period: Period("Sometimes")}
+	if got := unknown.Occurrences(start, end); len(got) != 0 {
+		t.Errorf("unknown period occurrences: %v", got)
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	if per, ok := ParsePeriod("biweekly"); !ok || per != Period2Week {
+		t.Errorf("ParsePeriod(biweekly) = %s, %v", per, ok)
+	}
+	if _, ok := ParsePeriod("fortnightly"); ok {
+		t.Error("ParsePeriod(fortnightly) should fail")
+	}
+}
+
+func TestPeriodicTransactions(t *testing.T) {
+	pt := PeriodicTransaction{
+		Period: PeriodMonth,
+		Payee:  "Rent",
+		AccountChanges: []Account{
+			{Name: "Expenses:Rent", Balance: decimal.NewFromInt(1000)},
+			{Name: "Assets:Checking", Balance: decimal.NewFromInt(-1000)},
+		},
+	}
+	trans := pt.Transactions(time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC), time.Date(2024, time.April, 15, 0, 0, 0, 0, time.UTC))
+	if len(trans) != 3 {
+		t.Fatalf("expected 3 transactions, got %d", len(trans))
+	}
+	if !trans[0].Date.Equal(time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)) || trans[0].Payee != "Rent" {
+		t.Errorf("unexpected first transaction: %+v", trans[0])
+	}
+	trans[0].AccountChanges[0].Name = "Changed"
+	if pt.AccountChanges[0].Name != "Expenses:Rent" {
+		t.Error("transactions should not share postings with the periodic transaction")
+	}
+}
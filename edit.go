@@ -0,0 +1,129 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ErrTransactionMoved is returned when editing a transaction that is no longer
+// at the position recorded when it was parsed, as its file changed since.
+var ErrTransactionMoved = errors.New("transaction not found at its position in the file")
+
+// ReplaceTransaction replaces the transaction old in its source file with
+// trans, written with the default TransactionFormat. See
+// TransactionFormat.ReplaceTransaction.
+func ReplaceTransaction(old, trans *Transaction) error {
+	return TransactionFormat{}.ReplaceTransaction(old, trans)
+}
+
+// DeleteTransaction deletes the transaction from its source file. See
+// TransactionFormat.DeleteTransaction.
+func DeleteTransaction(old *Transaction) error {
+	return TransactionFormat{}.DeleteTransaction(old)
+}
+
+// ReplaceTransaction replaces the transaction old in its source file with
+// trans. The transaction is found at the Filename and Line recorded when it
+// was parsed, and checked to still have the same date and payee.
+//
+// Only the lines of the transaction are rewritten: its header, postings and
+// the comment lines directly above it. The position of trans is set to where
+// it is written; transactions after it in the file may have moved, and are
+// to be parsed again before they are edited.
+func (f TransactionFormat) ReplaceTransaction(old, trans *Transaction) error {
+	if err := trans.IsBalanced(); err != nil {
+		return err
+	}
+	return f.editTransaction(old, trans)
+}
+
+// DeleteTransaction deletes the transaction from its source file, with the
+// comment lines directly above it, as ReplaceTransaction finds it.
+func (f TransactionFormat) DeleteTransaction(old *Transaction) error {
+	return f.editTransaction(old, nil)
+}
+
+// editTransaction replaces the lines of old in its file with trans, or
+// deletes them if trans is nil.
+func (f TransactionFormat) editTransaction(old, trans *Transaction) error {
+	fi, err := os.Stat(old.Filename)
+	if err != nil {
+		return err
+	}
+	src, err := os.ReadFile(old.Filename)
+	if err != nil {
+		return err
+	}
+	// keep the line ending of the file, whatever the format's
+	f.LineEnding = "\n"
+	if strings.Contains(string(src), "\r\n") {
+		f.LineEnding = "\r\n"
+	}
+	lines := strings.Split(string(src), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+
+	start, end, err := transactionLines(lines, old)
+	if err != nil {
+		return fmt.Errorf("%s:%d: %w", old.Filename, old.Line, err)
+	}
+
+	var replacement []string
+	if trans != nil {
+		// comments of old parsed from lines above the ones replaced stay in
+		// the file, and are not written again
+		written := *trans
+		kept := len(old.Comments)
+		for _, line := range lines[start:end] {
+			if isCommentLine(line) {
+				kept--
+			}
+		}
+		if kept > 0 && len(written.Comments) >= kept && slices.Equal(written.Comments[:kept], old.Comments[:kept]) {
+			written.Comments = written.Comments[kept:]
+		}
+
+		var buf strings.Builder
+		f.Write(&buf, &written)
+		replacement = strings.Split(strings.TrimSuffix(buf.String(), f.LineEnding+f.LineEnding), f.LineEnding)
+		trans.Filename, trans.Line = old.Filename, start+len(written.Comments)+1
+	} else {
+		// remove the blank line after the transaction, or else before it
+		if end < len(lines) && strings.TrimSpace(lines[end]) == "" {
+			end++
+		} else if start > 0 && strings.TrimSpace(lines[start-1]) == "" {
+			start--
+		}
+	}
+
+	out := slices.Concat(lines[:start], replacement, lines[end:])
+	return os.WriteFile(old.Filename, []byte(strings.Join(out, f.LineEnding)), fi.Mode())
+}
+
+// transactionLines returns the range of lines of the transaction, from the
+// comment lines directly above its header to its last posting.
+func transactionLines(lines []string, trans *Transaction) (start, end int, err error) {
+	header := trans.Line - 1
+	if header < 0 || header >= len(lines) {
+		return 0, 0, ErrTransactionMoved
+	}
+	end = header + 1
+	for end < len(lines) && strings.TrimSpace(lines[end]) != "" {
+		end++
+	}
+
+	found, perr := ParseLedger(strings.NewReader(strings.Join(lines[header:end], "\n")))
+	if perr != nil || len(found) != 1 || found[0].Line != 1 || !found[0].Date.Equal(trans.Date) || found[0].Payee != trans.Payee {
+		return 0, 0, ErrTransactionMoved
+	}
+
+	start = header
+	for start > 0 && isCommentLine(lines[start-1]) {
+		start--
+	}
+	return start, end, nil
+}
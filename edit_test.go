@@ -0,0 +1,136 @@
+package ledger
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestReplaceTransaction(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "ledger.dat")
+	src := `; opening balances
+2024/01/01 Opening
+    Assets:Checking    100
+    Equity
+
+; weekly shopping
+2024/01/10 Grocer
+    ; receipt in the drawer
+    Expenses:Misc    20
+    Assets:Checking
+
+2024/01/20 Cafe
+    Expenses:Food    5
+    Assets:Checking
+`
+	if err := os.WriteFile(filename, []byte(src), 0600); err != nil {
+		t.Fatal(err)
+	}
+	trans, err := ParseLedgerFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// split the grocer between two categories
+	old := *trans[1]
+	grocer := trans[1]
+	grocer.AccountChanges = []Account{
+		{Name: "Expenses:Food", Balance: decimal.NewFromInt(15)},
+		{Name: "Expenses:Household", Balance: decimal.NewFromInt(5)},
+		{Name: "Assets:Checking"},
+	}
+	if err := ReplaceTransaction(&old, grocer); err != nil {
+		t.Fatal(err)
+	}
+	if grocer.Line != 8 {
+		t.Errorf("replaced transaction at line %d, want 8", grocer.Line)
+	}
+
+	// the cafe moved a line down
+	if err := DeleteTransaction(trans[2]); !errors.Is(err, ErrTransactionMoved) {
+		t.Errorf("DeleteTransaction() error = %v, want %v", err, ErrTransactionMoved)
+	}
+	trans[2].Line++
+	if err := DeleteTransaction(trans[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `; opening balances
+2024/01/01 Opening
+    Assets:Checking    100
+    Equity
+
+; weekly shopping
+; receipt in the drawer
+2024/01/10 Grocer
+    Assets:Checking                                                       -20.00
+    Expenses:Food                                                          15.00
+    Expenses:Household                                                      5.00
+`
+	if string(got) != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	if _, err := ParseLedgerFile(filename); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReplaceTransactionCRLF(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "ledger.dat")
+	src := strings.ReplaceAll(`2024/01/01 Opening
+    Assets:Checking    100
+    Equity
+
+2024/01/10 Grocer
+    Expenses:Misc    20
+    Assets:Checking
+
+2024/01/20 Cafe
+    Expenses:Food    5
+    Assets:Checking
+`, "\n", "\r\n")
+	if err := os.WriteFile(filename, []byte(src), 0600); err != nil {
+		t.Fatal(err)
+	}
+	trans, err := ParseLedgerFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := *trans[1]
+	trans[1].AccountChanges = []Account{
+		{Name: "Expenses:Food", Balance: decimal.NewFromInt(20)},
+		{Name: "Assets:Checking"},
+	}
+	if err := ReplaceTransaction(&old, trans[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeleteTransaction(trans[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.ReplaceAll(`2024/01/10 Grocer
+    Assets:Checking                                                       -20.00
+    Expenses:Food                                                          20.00
+
+2024/01/20 Cafe
+    Expenses:Food    5
+    Assets:Checking
+`, "\n", "\r\n")
+	if string(got) != want {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+}
@@ -0,0 +1,127 @@
+package ledger
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// TransactionFilter reports whether a transaction is selected.
+type TransactionFilter func(trans *Transaction) bool
+
+// FilterTransactions returns a new array of the transactions selected by all
+// of the filters, in the same order.
+func FilterTransactions(trans []*Transaction, filters ...TransactionFilter) []*Transaction {
+	filter := Compose(filters...)
+	var newlist []*Transaction
+	for _, tran := range trans {
+		if filter(tran) {
+			newlist = append(newlist, tran)
+		}
+	}
+	return newlist
+}
+
+// Compose returns a filter selecting the transactions selected by all of the
+// filters. Without filters it selects every transaction.
+func Compose(filters ...TransactionFilter) TransactionFilter {
+	return func(trans *Transaction) bool {
+		for _, filter := range filters {
+			if !filter(trans) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Any returns a filter selecting the transactions selected by any of the
+// filters.
+func Any(filters ...TransactionFilter) TransactionFilter {
+	return func(trans *Transaction) bool {
+		for _, filter := range filters {
+			if filter(trans) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not returns a filter selecting the transactions the filter does not.
+func Not(filter TransactionFilter) TransactionFilter {
+	return func(trans *Transaction) bool {
+		return !filter(trans)
+	}
+}
+
+// ByDateRange selects the transactions in the date range, as
+// TransactionsInDateRange: on the day of start, but not on the day of end.
+func ByDateRange(start, end time.Time) TransactionFilter {
+	start = start.Add(-1 * time.Second)
+	return func(trans *Transaction) bool {
+		return trans.Date.After(start) && trans.Date.Before(end)
+	}
+}
+
+// ByPayeeRegex selects the transactions with a payee matching re.
+func ByPayeeRegex(re *regexp.Regexp) TransactionFilter {
+	return func(trans *Transaction) bool {
+		return re.MatchString(trans.Payee)
+	}
+}
+
+// ByAccountRegex selects the transactions with a posting to an account
+// matching re.
+func ByAccountRegex(re *regexp.Regexp) TransactionFilter {
+	return func(trans *Transaction) bool {
+		for _, acc := range trans.AccountChanges {
+			if re.MatchString(acc.Name) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ByMinAmount selects the transactions moving at least amount, the sum of
+// their positive postings.
+func ByMinAmount(amount decimal.Decimal) TransactionFilter {
+	return func(trans *Transaction) bool {
+		var size decimal.Decimal
+		for _, acc := range trans.AccountChanges {
+			if acc.Balance.Sign() > 0 {
+				size = size.Add(acc.Balance)
+			}
+		}
+		return size.GreaterThanOrEqual(amount)
+	}
+}
+
+// ByTag selects the transactions with the tag on the transaction or any of
+// its postings, with the value unless value is "".
+func ByTag(name, value string) TransactionFilter {
+	return func(trans *Transaction) bool {
+		for i := range trans.AccountChanges {
+			if v, ok := trans.PostingTags(&trans.AccountChanges[i])[name]; ok && (value == "" || v == value) {
+				return true
+			}
+		}
+		v, ok := trans.PostingTags(nil)[name]
+		return ok && (value == "" || v == value)
+	}
+}
+
+// ByStatus selects the transactions with every posting at least at the
+// status, so StatusPending selects pending and cleared transactions.
+func ByStatus(status Status) TransactionFilter {
+	return func(trans *Transaction) bool {
+		for i := range trans.AccountChanges {
+			if trans.PostingStatus(&trans.AccountChanges[i]) < status {
+				return false
+			}
+		}
+		return true
+	}
+}
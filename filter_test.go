@@ -0,0 +1,88 @@
+package ledger
+
+import (
+	"bytes"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestFilterTransactions(t *testing.T) {
+	b := bytes.NewBufferString(`
+2024/01/02 Grocery Store
+	Expenses:Food:Groceries     30
+	Assets:Checking
+
+2024/01/10 * Landlord
+	; :rent:
+	Expenses:Rent     500
+	Assets:Checking
+
+2024/02/01 Grocery Store
+	Expenses:Food:Groceries     45  ; trip: Paris
+	Liabilities:Card
+
+2024/02/15 Employer
+	Assets:Checking     2000
+	Income:Salary
+`)
+	trans, err := ParseLedger(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payees := func(filtered []*Transaction) []string {
+		var names []string
+		for _, tr := range filtered {
+			names = append(names, tr.Date.Format("01/02")+" "+tr.Payee)
+		}
+		return names
+	}
+
+	tests := []struct {
+		name    string
+		filters []TransactionFilter
+		want    []string
+	}{
+		{"none", nil, []string{"01/02 Grocery Store", "01/10 Landlord", "02/01 Grocery Store", "02/15 Employer"}},
+		{"payee", []TransactionFilter{ByPayeeRegex(regexp.MustCompile(`^Groc`))}, []string{"01/02 Grocery Store", "02/01 Grocery Store"}},
+		{"account", []TransactionFilter{ByAccountRegex(regexp.MustCompile(`^Liabilities`))}, []string{"02/01 Grocery Store"}},
+		{"min amount", []TransactionFilter{ByMinAmount(decimal.NewFromInt(45))}, []string{"01/10 Landlord", "02/01 Grocery Store", "02/15 Employer"}},
+		{"tag", []TransactionFilter{ByTag("rent", "")}, []string{"01/10 Landlord"}},
+		{"posting tag value", []TransactionFilter{ByTag("trip", "Paris")}, []string{"02/01 Grocery Store"}},
+		{"other tag value", []TransactionFilter{ByTag("trip", "Rome")}, nil},
+		{"status", []TransactionFilter{ByStatus(StatusCleared)}, []string{"01/10 Landlord"}},
+		{
+			"date range",
+			[]TransactionFilter{ByDateRange(time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC))},
+			[]string{"01/10 Landlord", "02/01 Grocery Store"},
+		},
+		{
+			"all of",
+			[]TransactionFilter{ByAccountRegex(regexp.MustCompile(`Checking`)), ByMinAmount(decimal.NewFromInt(100))},
+			[]string{"01/10 Landlord", "02/15 Employer"},
+		},
+		{
+			"any of",
+			[]TransactionFilter{Any(ByTag("rent", ""), ByPayeeRegex(regexp.MustCompile(`Employer`)))},
+			[]string{"01/10 Landlord", "02/15 Employer"},
+		},
+		{
+			"not",
+			[]TransactionFilter{Not(ByPayeeRegex(regexp.MustCompile(`Grocery`)))},
+			[]string{"01/10 Landlord", "02/15 Employer"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := payees(FilterTransactions(trans, tt.filters...)); !slices.Equal(got, tt.want) {
+				t.Errorf("FilterTransactions() = %q, want %q", got, tt.want)
+			}
+			if got := payees(FilterTransactions(trans, Compose(tt.filters...))); !slices.Equal(got, tt.want) {
+				t.Errorf("FilterTransactions(Compose()) = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ go 1.24
 require (
 	github.com/alfredxing/calc v0.0.0-20180827002445-77daf576f976
 	github.com/andybalholm/brotli v1.0.6
+	github.com/fsnotify/fsnotify v1.7.0
 	github.com/hako/durafmt v0.0.0-20210608085754-5c1018a4e16b
 	github.com/ivanpirog/coloredcobra v1.0.1
 	github.com/jbrukh/bayesian v0.0.0-20200318221351-d726b684ca4a
@@ -16,6 +17,7 @@ require (
 	github.com/pelletier/go-toml v1.9.5
 	github.com/shopspring/decimal v1.3.1
 	github.com/spf13/cobra v1.7.0
+	github.com/spf13/pflag v1.0.5
 	golang.org/x/term v0.13.0
 	golang.org/x/time v0.3.0
 )
@@ -24,6 +26,5 @@ require (
 	github.com/fatih/color v1.15.0 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
 	golang.org/x/sys v0.13.0 // indirect
 )
@@ -0,0 +1,81 @@
+package ledger
+
+import (
+	"errors"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateRange is the dates from start up to but not including end. A zero start
+// or end leaves the range open on that side.
+type dateRange struct {
+	start, end time.Time
+}
+
+// contains returns whether the date is in the range.
+func (r dateRange) contains(d time.Time) bool {
+	return (r.start.IsZero() || !d.Before(r.start)) && (r.end.IsZero() || d.Before(r.end))
+}
+
+// overlaps returns whether any date is in both ranges.
+func (r dateRange) overlaps(o dateRange) bool {
+	return (r.start.IsZero() || o.end.IsZero() || o.end.After(r.start)) &&
+		(r.end.IsZero() || o.start.IsZero() || o.start.Before(r.end))
+}
+
+// intersect returns the dates in both ranges.
+func (r dateRange) intersect(o dateRange) dateRange {
+	if r.start.IsZero() || o.start.After(r.start) {
+		r.start = o.start
+	}
+	if r.end.IsZero() || (!o.end.IsZero() && o.end.Before(r.end)) {
+		r.end = o.end
+	}
+	return r
+}
+
+// fileDateRegex matches a year, and optionally a month, in a file name, not
+// as part of a longer number such as a full date.
+var fileDateRegex = regexp.MustCompile(`(?:^|\D)((?:19|20)\d\d)(?:[-_.]?(0[1-9]|1[0-2]))?(?:\D|$)`)
+
+// fileDateRange returns the dates of the year or month a ledger file is named
+// for, such as "2021.ledger" or "ledger-2021-05.dat". A name with no year, or
+// more than one, is named for no dates.
+func fileDateRange(path string) (dateRange, bool) {
+	m := fileDateRegex.FindAllStringSubmatch(filepath.Base(path), -1)
+	if len(m) != 1 {
+		return dateRange{}, false
+	}
+	year, _ := strconv.Atoi(m[0][1])
+	if m[0][2] == "" {
+		start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		return dateRange{start: start, end: start.AddDate(1, 0, 0)}, true
+	}
+	month, _ := strconv.Atoi(m[0][2])
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	return dateRange{start: start, end: start.AddDate(0, 1, 0)}, true
+}
+
+// parseRange parses the rest of a "range" directive line: the first and last
+// dates of the transactions of the file.
+func (lp *parser) parseRange(after string) (dateRange, error) {
+	fields := strings.Fields(after)
+	if len(fields) != 2 {
+		return dateRange{}, errors.New("expected first and last dates")
+	}
+	first, err := lp.parseDate(fields[0])
+	if err != nil {
+		return dateRange{}, err
+	}
+	last, err := lp.parseDate(fields[1])
+	if err != nil {
+		return dateRange{}, err
+	}
+	if last.Before(first) {
+		return dateRange{}, errors.New("last date before first date")
+	}
+	return dateRange{start: first, end: last.AddDate(0, 0, 1)}, nil
+}
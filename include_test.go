@@ -2,7 +2,13 @@ package ledger
 
 import (
 	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestIncludeSimple(t *testing.T) {
@@ -29,11 +35,40 @@ func TestIncludeGlob(t *testing.T) {
 
 func TestIncludeUnbalanced(t *testing.T) {
 	_, err := ParseLedgerFile("testdata/ledgerRootUnbalanced.dat")
-	if err.Error() != "testdata/ledger-2021-05.dat:12: unable to parse transaction: unable to balance transaction: no empty account to place extra balance" {
+	if err.Error() != "testdata/ledgerRootUnbalanced.dat:1 -> testdata/ledger-2021-05.dat:12: unable to parse transaction: unable to balance transaction: no empty account to place extra balance" {
 		t.Fatal(err)
 	}
 }
 
+func TestIncludeChain(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"master.dat":          "; journal\n\ninclude years/*.dat\n",
+		"years/2023.dat":      "2023/01/01 Payee\n    Assets:Wallet    5\n    Expenses:Food\n\ninclude bank/2023.dat\n",
+		"years/bank/2023.dat": "2023/02/01 Payee\n    Assets:Wallet    5\n    Expenses:Food    5\n",
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := ParseLedgerFile(filepath.Join(dir, "master.dat"))
+	want := filepath.Join(dir, "master.dat") + ":3 -> " +
+		filepath.Join(dir, "years/2023.dat") + ":5 -> " +
+		filepath.Join(dir, "years/bank/2023.dat") + ":3: unable to parse transaction: "
+	if err == nil || !strings.HasPrefix(err.Error(), want) {
+		t.Fatalf("got %v, want prefix %q", err, want)
+	}
+	if !errors.Is(err, ErrNoEmptyAccountForExtraBalance) {
+		t.Errorf("got %v, want it to wrap %v", err, ErrNoEmptyAccountForExtraBalance)
+	}
+}
+
 func TestIncludeNonExistant(t *testing.T) {
 	_, err := ParseLedgerFile("testdata/ledgerRootNonExist.dat")
 	if err.Error() != "testdata/ledgerRootNonExist.dat:3: unable to include file(ledger-xxxxx.dat): not found" {
@@ -47,3 +82,129 @@ func TestNonExistant(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestIncludeDateRange(t *testing.T) {
+	start := time.Date(2022, time.February, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2022, time.April, 1, 0, 0, 0, 0, time.UTC)
+	trans, err := ParseLedgerFileRange("testdata/ledgerRootGlob.dat", start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dates []string
+	for _, tr := range trans {
+		dates = append(dates, tr.Date.Format(time.DateOnly))
+	}
+	slices.Sort(dates)
+	if want := []string{"2022-02-01", "2022-02-01", "2022-02-01", "2022-02-01", "2022-03-01", "2022-03-01"}; !slices.Equal(dates, want) {
+		t.Errorf("got %v, want %v", dates, want)
+	}
+}
+
+func TestIncludeDateRangeSkipsFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"root.dat": "include ledger-2020-*.dat\ninclude 2019.dat\ninclude declared.dat\n",
+		// unbalanced, so an error if parsed
+		"ledger-2020-01.dat": "2020/01/05 Payee\n\tAssets:Wallet  5\n\tExpenses:Food  5\n",
+		"2019.dat":           "account Assets:Wallet\n\nP 2019/06/01 EUR USD 1.1\n\n2019/06/05 Payee\n\tAssets:Wallet  5\n\tExpenses:Food  5\n",
+		"declared.dat":       "range 2018/01/01 2018/12/31\n\n2018/06/05 Payee\n\tAssets:Wallet  5\n\tExpenses:Food  5\n",
+		"ledger-2020-02.dat": "2020/02/05 Payee\n\tAssets:Wallet  5\n\tExpenses:Food\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root := filepath.Join(dir, "root.dat")
+
+	start := time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC)
+	trans, err := ParseLedgerFileRange(root, start, start.AddDate(0, 1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trans) != 1 || trans[0].Date != time.Date(2020, time.February, 5, 0, 0, 0, 0, time.UTC) {
+		t.Errorf("got %v", trans)
+	}
+
+	// the other directives of the skipped files are still parsed
+	var prices []*Price
+	var accounts []AccountInfo
+	var mu sync.Mutex
+	parseLedgerRange(root, strings.NewReader(files["root.dat"]), dateRange{start: start, end: start.AddDate(0, 1, 0)}, func(r *parseResult, err error) bool {
+		if err != nil {
+			t.Error(err)
+			return true
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		prices = append(prices, r.prices...)
+		accounts = append(accounts, r.accounts...)
+		return false
+	})
+	if len(prices) != 1 || len(accounts) != 1 {
+		t.Errorf("got prices %v, accounts %v", prices, accounts)
+	}
+
+	if _, err := ParseLedgerFile(root); err == nil {
+		t.Error("expected the unbalanced transactions to be parsed")
+	}
+}
+
+func TestIncludeDeclaredRange(t *testing.T) {
+	_, err := ParseLedger(strings.NewReader("range 2021/01/01 2021/12/31\n\n2022/01/01 Payee\n\tAssets:Wallet  5\n\tExpenses:Food\n"))
+	if err == nil || err.Error() != ":3: unable to parse transaction: date(2022-01-01) outside of declared range" {
+		t.Fatal(err)
+	}
+	_, err = ParseLedger(strings.NewReader("range 2021/12/31 2021/01/01\n"))
+	if err == nil || err.Error() != ":1: unable to parse range: last date before first date" {
+		t.Fatal(err)
+	}
+}
+
+func TestIncludeDeclaredRangeOutside(t *testing.T) {
+	ledger := "account Assets:Wallet\n\nP 2020/06/01 EUR USD 1.1\n\n~ Monthly\n\tExpenses:Rent  5\n\tAssets:Wallet\n\n" +
+		"range 2021/01/01 2021/12/31\n\n2021/01/01 Payee\n\tAssets:Wallet  5\n\tExpenses:Food\n\n" +
+		"account Expenses:Food\n\nP 2021/06/01 EUR USD 1.2\n"
+	dates := dateRange{start: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), end: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+	var got parseResult
+	parseLedgerRange("", strings.NewReader(ledger), dates, func(r *parseResult, err error) bool {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got.transactions = append(got.transactions, r.transactions...)
+		got.periodic = append(got.periodic, r.periodic...)
+		got.accounts = append(got.accounts, r.accounts...)
+		got.prices = append(got.prices, r.prices...)
+		return false
+	})
+	if len(got.transactions) != 0 || len(got.periodic) != 1 || len(got.accounts) != 2 || len(got.prices) != 2 {
+		t.Errorf("got %d transactions, %d periodic, %d accounts, %d prices",
+			len(got.transactions), len(got.periodic), len(got.accounts), len(got.prices))
+	}
+}
+
+func TestFileDateRange(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end string
+		ok         bool
+	}{
+		{"ledger-2021-05.dat", "2021-05-01", "2021-06-01", true},
+		{"2021.ledger", "2021-01-01", "2022-01-01", true},
+		{"journal_202112.dat", "2021-12-01", "2022-01-01", true},
+		{"2021-13.dat", "2021-01-01", "2022-01-01", true},
+		{"ledger.dat", "", "", false},
+		{"20210512.dat", "", "", false},
+		{"2020-to-2021.dat", "", "", false},
+	}
+	for _, tt := range tests {
+		r, ok := fileDateRange(filepath.Join("dir", tt.name))
+		if ok != tt.ok {
+			t.Errorf("%s: got ok %v", tt.name, ok)
+			continue
+		}
+		if ok && (r.start.Format(time.DateOnly) != tt.start || r.end.Format(time.DateOnly) != tt.end) {
+			t.Errorf("%s: got %v to %v", tt.name, r.start, r.end)
+		}
+	}
+}
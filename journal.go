@@ -0,0 +1,310 @@
+package ledger
+
+import (
+	"cmp"
+	"iter"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/howeyc/ledger/ledger/query"
+	"github.com/shopspring/decimal"
+)
+
+// Journal is a ledger loaded from its files: the transactions, sorted by
+// date, with the periodic transactions, prices and accounts declared
+// alongside them.
+type Journal struct {
+	Transactions []*Transaction
+	Periodic     []*PeriodicTransaction
+	// Prices are sorted by date.
+	Prices   []*Price
+	Accounts *AccountRegistry
+	// Files are the files the journal was loaded from, the ledger file
+	// first, then the files it includes, sorted. Transactions locate
+	// themselves in them by their Filename and Line.
+	Files []string
+
+	// index is built by Load and extended by Append, for the transactions
+	// they leave, and used while Transactions is the slice they leave.
+	// Transactions sorted or replaced in that slice, or postings renamed,
+	// added or removed, are not indexed: set a new slice instead.
+	index *journalIndex
+}
+
+// journalIndex holds the postings of a journal by account, so queries visit
+// only the postings of the accounts they may match.
+type journalIndex struct {
+	// transactions is the slice indexed
+	transactions []*Transaction
+	// postings are those of the transactions, in order, and trans the
+	// positions of their transactions.
+	postings []PostingRef
+	trans    []int
+	// byAccount holds the positions in postings of the postings of each
+	// account, in order.
+	byAccount map[string][]int
+}
+
+// buildIndex indexes the postings of the transactions of the journal.
+func (j *Journal) buildIndex() {
+	idx := &journalIndex{transactions: j.Transactions, byAccount: make(map[string][]int)}
+	for t, trans := range j.Transactions {
+		for i := range trans.AccountChanges {
+			name := trans.AccountChanges[i].Name
+			idx.byAccount[name] = append(idx.byAccount[name], len(idx.postings))
+			idx.postings = append(idx.postings, PostingRef{Transaction: trans, Posting: &trans.AccountChanges[i], Index: i})
+			idx.trans = append(idx.trans, t)
+		}
+	}
+	j.index = idx
+}
+
+// current returns whether the index is of the transactions of the journal,
+// judging by the slice alone, so as not to visit them.
+func (idx *journalIndex) current(j *Journal) bool {
+	return len(idx.transactions) == len(j.Transactions) &&
+		(len(j.Transactions) == 0 || &idx.transactions[0] == &j.Transactions[0])
+}
+
+// insert indexes the postings of the transaction inserted at position i of
+// the transactions indexed.
+func (idx *journalIndex) insert(i int, trans *Transaction) {
+	// the postings of the transactions after it move up
+	p, _ := slices.BinarySearch(idx.trans, i)
+	n := len(trans.AccountChanges)
+	if p < len(idx.trans) {
+		for q := p; q < len(idx.trans); q++ {
+			idx.trans[q]++
+		}
+		for _, positions := range idx.byAccount {
+			for k, pos := range positions {
+				if pos >= p {
+					positions[k] = pos + n
+				}
+			}
+		}
+	}
+	refs := make([]PostingRef, n)
+	for k := range trans.AccountChanges {
+		refs[k] = PostingRef{Transaction: trans, Posting: &trans.AccountChanges[k], Index: k}
+		name := trans.AccountChanges[k].Name
+		at, _ := slices.BinarySearch(idx.byAccount[name], p+k)
+		idx.byAccount[name] = slices.Insert(idx.byAccount[name], at, p+k)
+	}
+	idx.postings = slices.Insert(idx.postings, p, refs...)
+	idx.trans = slices.Insert(idx.trans, p, slices.Repeat([]int{i}, n)...)
+}
+
+// matching returns an iterator over the postings of the journal that match
+// the query, in order, with the positions of their transactions. Without an
+// index, such as for a Journal of Transactions set directly, or one with
+// Transactions set to a slice other than the one indexed, every posting is
+// matched.
+func (j *Journal) matching(q *Query) iter.Seq2[int, PostingRef] {
+	return func(yield func(int, PostingRef) bool) {
+		idx := j.index
+		if idx == nil || !idx.current(j) {
+			for t, trans := range j.Transactions {
+				for i := range trans.AccountChanges {
+					if q.Match(trans, &trans.AccountChanges[i]) &&
+						!yield(t, PostingRef{Transaction: trans, Posting: &trans.AccountChanges[i], Index: i}) {
+						return
+					}
+				}
+			}
+			return
+		}
+
+		var positions []int
+		if q == nil {
+			positions = make([]int, len(idx.postings))
+			for i := range positions {
+				positions[i] = i
+			}
+		} else {
+			for name, postings := range idx.byAccount {
+				if query.MayMatchAccount(q.expr, name) {
+					positions = append(positions, postings...)
+				}
+			}
+			slices.Sort(positions)
+		}
+		for _, pos := range positions {
+			p := idx.postings[pos]
+			if q.Match(p.Transaction, p.Posting) && !yield(idx.trans[pos], p) {
+				return
+			}
+		}
+	}
+}
+
+// Postings returns an iterator over the postings of the journal that match
+// the query, in order. Only the postings of the accounts the query may match
+// are visited.
+func (j *Journal) Postings(q *Query) iter.Seq[PostingRef] {
+	return func(yield func(PostingRef) bool) {
+		for _, p := range j.matching(q) {
+			if !yield(p) {
+				return
+			}
+		}
+	}
+}
+
+// Matching returns an iterator over the transactions of the journal that
+// have postings matching the query, in order, with their positions in
+// Transactions.
+func (j *Journal) Matching(q *Query) iter.Seq2[int, *Transaction] {
+	return func(yield func(int, *Transaction) bool) {
+		last := -1
+		for t, p := range j.matching(q) {
+			if t == last {
+				continue
+			}
+			last = t
+			if !yield(t, p.Transaction) {
+				return
+			}
+		}
+	}
+}
+
+// Filter returns copies of the transactions of the journal that have postings
+// matching the query, holding only the matching postings, as Query.Filter.
+func (j *Journal) Filter(q *Query) []*Transaction {
+	if q == nil {
+		return j.Transactions
+	}
+	var filtered []*Transaction
+	last := -1
+	for t, p := range j.matching(q) {
+		if t != last {
+			trans := *p.Transaction
+			trans.AccountChanges = nil
+			filtered = append(filtered, &trans)
+			last = t
+		}
+		trans := filtered[len(filtered)-1]
+		trans.AccountChanges = append(trans.AccountChanges, *p.Posting)
+	}
+	return filtered
+}
+
+// Load parses a ledger file, including any included files, into the journal,
+// replacing what it held. On error the journal is left as it was.
+func (j *Journal) Load(filename string) (err error) {
+	ifile, ierr := os.Open(filename)
+	if ierr != nil {
+		return ierr
+	}
+	defer ifile.Close()
+
+	var mu sync.Mutex
+	loaded := Journal{Accounts: NewAccountRegistry()}
+	parseLedger(filename, ifile, func(r *parseResult, e error) (stop bool) {
+		if e != nil {
+			err = e
+			stop = true
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		loaded.Transactions = append(loaded.Transactions, r.transactions...)
+		loaded.Periodic = append(loaded.Periodic, r.periodic...)
+		loaded.Prices = append(loaded.Prices, r.prices...)
+		for _, decl := range r.accounts {
+			loaded.Accounts.Declare(decl)
+		}
+		if r.filename != filename {
+			loaded.Files = append(loaded.Files, r.filename)
+		}
+		return
+	})
+	if err != nil {
+		return err
+	}
+
+	slices.SortStableFunc(loaded.Transactions, func(a, b *Transaction) int {
+		return a.Date.Compare(b.Date)
+	})
+	slices.SortStableFunc(loaded.Prices, func(a, b *Price) int {
+		return a.Date.Compare(b.Date)
+	})
+	slices.Sort(loaded.Files)
+	loaded.Files = append([]string{filename}, loaded.Files...)
+	loaded.Accounts.Observe(loaded.Transactions...)
+	loaded.buildIndex()
+	*j = loaded
+	return nil
+}
+
+// Append adds transactions to the journal, each after those of the same date.
+// Unless all of them balance, none are added and the error of the first that
+// does not is returned.
+func (j *Journal) Append(trans ...*Transaction) error {
+	for _, t := range trans {
+		if err := t.IsBalanced(); err != nil {
+			return err
+		}
+	}
+	if j.Accounts == nil {
+		j.Accounts = NewAccountRegistry()
+	}
+	idx := j.index
+	if idx != nil && !idx.current(j) {
+		idx = nil
+	}
+	for _, t := range trans {
+		i, _ := slices.BinarySearchFunc(j.Transactions, t.Date, func(e *Transaction, date time.Time) int {
+			return cmp.Or(e.Date.Compare(date), -1)
+		})
+		j.Transactions = slices.Insert(j.Transactions, i, t)
+		if idx != nil {
+			idx.insert(i, t)
+		}
+	}
+	j.Accounts.Observe(trans...)
+	if idx == nil {
+		j.buildIndex()
+	} else {
+		idx.transactions = j.Transactions
+	}
+	return nil
+}
+
+// BalanceAt returns the balances, as GetBalances, of the transactions up to
+// and including the day of date.
+func (j *Journal) BalanceAt(date time.Time, filters ...string) []*Account {
+	end := date.AddDate(0, 0, 1)
+	n := slices.IndexFunc(j.Transactions, func(t *Transaction) bool {
+		return !t.Date.Before(end)
+	})
+	if n < 0 {
+		n = len(j.Transactions)
+	}
+	return GetBalances(j.Transactions[:n], filters)
+}
+
+// RegisterEntry is a posting of a register, with the running balance of the
+// postings of the register in its currency.
+type RegisterEntry struct {
+	Transaction *Transaction
+	Posting     *Account
+	Balance     decimal.Decimal
+}
+
+// Register returns the postings of the journal matching the query, in the
+// order of the transactions, with their running balance.
+func (j *Journal) Register(q *Query) []RegisterEntry {
+	var entries []RegisterEntry
+	totals := make(map[string]decimal.Decimal)
+	for p := range j.Postings(q) {
+		cur := p.Posting.Currency
+		totals[cur] = totals[cur].Add(p.Posting.Balance)
+		entries = append(entries, RegisterEntry{Transaction: p.Transaction, Posting: p.Posting, Balance: totals[cur]})
+	}
+	return entries
+}
@@ -0,0 +1,177 @@
+package ledger
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestJournal(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.ledger")
+	files := map[string]string{
+		main: `account Assets:Checking
+    commodity USD
+
+P 2024/01/15 EUR USD 1.10
+P 2024/01/01 EUR USD 1.08
+
+include food.ledger
+
+~ Monthly
+    Expenses:Food    300
+    Assets:Checking
+
+2024/01/20 Employer
+    Assets:Checking    1000
+    Income:Salary
+`,
+		filepath.Join(dir, "food.ledger"): `2024/01/05 Grocery Store
+    Expenses:Food    30
+    Assets:Checking
+
+2024/01/25 Grocery Store
+    Expenses:Food    45
+    Assets:Checking
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var j Journal
+	if err := j.Load(main); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{main, filepath.Join(dir, "food.ledger")}; !slices.Equal(j.Files, want) {
+		t.Errorf("Files = %q, want %q", j.Files, want)
+	}
+	var payees []string
+	for _, trans := range j.Transactions {
+		payees = append(payees, trans.Date.Format("01/02")+" "+trans.Payee)
+	}
+	if want := []string{"01/05 Grocery Store", "01/20 Employer", "01/25 Grocery Store"}; !slices.Equal(payees, want) {
+		t.Errorf("Transactions = %q, want %q", payees, want)
+	}
+	if len(j.Periodic) != 1 || len(j.Prices) != 2 || !j.Prices[0].Price.Equal(decimal.RequireFromString("1.08")) {
+		t.Errorf("Periodic = %d, Prices = %v", len(j.Periodic), j.Prices)
+	}
+	if info, _ := j.Accounts.Lookup("Assets:Checking"); !info.Declared || info.Currency != "USD" || info.Postings != 3 {
+		t.Errorf("Accounts Assets:Checking = %+v", info)
+	}
+
+	balance := func(accounts []*Account, name string) string {
+		for _, acc := range accounts {
+			if acc.Name == name {
+				return acc.Balance.String()
+			}
+		}
+		return ""
+	}
+	date := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	if got := balance(j.BalanceAt(date(20)), "Assets:Checking"); got != "970" {
+		t.Errorf("BalanceAt(01/20) Assets:Checking = %s, want 970", got)
+	}
+	if got := balance(j.BalanceAt(date(19), "Expenses"), "Expenses:Food"); got != "30" {
+		t.Errorf("BalanceAt(01/19) Expenses:Food = %s, want 30", got)
+	}
+
+	err := j.Append(&Transaction{Date: date(20), Payee: "Cafe", AccountChanges: []Account{
+		{Name: "Expenses:Food:Coffee", Balance: decimal.NewFromInt(5)},
+		{Name: "Assets:Checking", Balance: decimal.NewFromInt(-5)},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.Transactions[2].Payee != "Cafe" {
+		t.Errorf("Append() placed the transaction at %q", j.Transactions[2].Payee)
+	}
+	if err := j.Append(&Transaction{Date: date(21), Payee: "Unbalanced", AccountChanges: []Account{
+		{Name: "Expenses:Food", Balance: decimal.NewFromInt(5)},
+		{Name: "Assets:Checking", Balance: decimal.NewFromInt(-4)},
+	}}); err == nil || len(j.Transactions) != 4 {
+		t.Errorf("Append() of an unbalanced transaction = %v, with %d transactions", err, len(j.Transactions))
+	}
+
+	q, _ := ParseQuery("Expenses:Food")
+	var register []string
+	for _, e := range j.Register(q) {
+		register = append(register, e.Posting.Name+" "+e.Balance.String())
+	}
+	if want := []string{"Expenses:Food 30", "Expenses:Food:Coffee 35", "Expenses:Food 80"}; !slices.Equal(register, want) {
+		t.Errorf("Register() = %q, want %q", register, want)
+	}
+
+	if err := j.Load(filepath.Join(dir, "missing.ledger")); err == nil || len(j.Transactions) != 4 {
+		t.Errorf("Load() of a missing file = %v, with %d transactions", err, len(j.Transactions))
+	}
+}
+
+func TestJournalIndex(t *testing.T) {
+	var j Journal
+	if err := j.Load("testdata/ledgerBench.dat"); err != nil {
+		t.Fatal(err)
+	}
+	check := func(when string) {
+		t.Helper()
+		for _, expr := range []string{"", "Expenses", "Food or payee:Grocery", "not Assets", "Assets and amount>100", "status:cleared", "Expenses:Index"} {
+			q, err := ParseQuery(expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want, got []*Account
+			for p := range Postings(j.Transactions) {
+				if q.Match(p.Transaction, p.Posting) {
+					want = append(want, p.Posting)
+				}
+			}
+			for p := range j.Postings(q) {
+				got = append(got, p.Posting)
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("%s: %q: got %d postings, want %d", when, expr, len(got), len(want))
+			}
+			if filtered := j.Filter(q); len(filtered) != len(q.Filter(j.Transactions)) {
+				t.Errorf("%s: %q: filtered %d transactions, want %d", when, expr, len(filtered), len(q.Filter(j.Transactions)))
+			}
+			for id, trans := range j.Matching(q) {
+				if j.Transactions[id] != trans || !q.MatchTransaction(trans) {
+					t.Errorf("%s: %q: transaction %d does not match", when, expr, id)
+				}
+			}
+		}
+	}
+	check("loaded")
+
+	// appended first, in the middle and last, the index extended
+	day := func(t *Transaction) time.Time { return t.Date }
+	first, last := day(j.Transactions[0]), day(j.Transactions[len(j.Transactions)-1])
+	var added []*Transaction
+	for _, date := range []time.Time{first.AddDate(0, 0, -1), first.Add(last.Sub(first) / 2), last, last.AddDate(0, 0, 1)} {
+		added = append(added, &Transaction{Date: date, Payee: "Indexed", AccountChanges: []Account{
+			{Name: "Expenses:Index", Balance: decimal.NewFromInt(5)},
+			{Name: "Assets:Cash", Balance: decimal.NewFromInt(-5)},
+			{Name: "Expenses:Index", Balance: decimal.NewFromInt(-5)},
+			{Name: "Assets:Cash", Balance: decimal.NewFromInt(5)},
+		}})
+	}
+	index := j.index
+	if err := j.Append(added...); err != nil {
+		t.Fatal(err)
+	}
+	if j.index != index || !j.index.current(&j) {
+		t.Error("index rebuilt by Append")
+	}
+	check("appended")
+
+	// a new slice of the transactions is matched without the index
+	j.Transactions = slices.Clone(j.Transactions)
+	slices.Reverse(j.Transactions)
+	check("reversed")
+}
@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/howeyc/ledger/ledger/internal/fastcolor"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+)
+
+var activityBy string
+var activityAmount bool
+
+// activityShades are the cells of the heatmap, from no activity to the most.
+var activityShades = []string{"·", "░", "▒", "▓", "█"}
+
+// activityDay returns the date of t at midnight UTC, so days can be counted
+// without daylight saving changes.
+func activityDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// activityWeek returns the Monday of the week of day.
+func activityWeek(day time.Time) time.Time {
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// activityTotals returns, per day or per week (keyed by its Monday), the
+// number of transactions with postings that match the query or, with amount
+// set, the total of the absolute amounts of those postings.
+func activityTotals(generalLedger []*ledger.Transaction, query *ledger.Query, byWeek, amount bool) map[time.Time]decimal.Decimal {
+	totals := make(map[time.Time]decimal.Decimal)
+	for _, trans := range generalLedger {
+		key := activityDay(trans.Date)
+		if byWeek {
+			key = activityWeek(key)
+		}
+		matched := false
+		for i := range trans.AccountChanges {
+			acc := &trans.AccountChanges[i]
+			if !query.Match(trans, acc) {
+				continue
+			}
+			matched = true
+			if amount {
+				totals[key] = totals[key].Add(acc.Balance.Abs())
+			}
+		}
+		if matched && !amount {
+			totals[key] = totals[key].Add(decimal.NewFromInt(1))
+		}
+	}
+	return totals
+}
+
+// activityShade returns the cell for a total, shaded by its share of the
+// largest total.
+func activityShade(total, largest decimal.Decimal) string {
+	if total.Sign() <= 0 || largest.Sign() <= 0 {
+		return activityShades[0]
+	}
+	steps := decimal.NewFromInt(int64(len(activityShades) - 1))
+	level := int(total.Mul(steps).Div(largest).Ceil().IntPart())
+	return activityShades[min(max(level, 1), len(activityShades)-1)]
+}
+
+// activityLegend writes the shades and what the largest one stands for.
+func activityLegend(buf *bufio.Writer, largest decimal.Decimal, amount bool) {
+	buf.WriteString("Less " + strings.Join(activityShades, " ") + " More")
+	if amount {
+		buf.WriteString("  (max " + largest.StringFixedBank(2) + ")")
+	} else {
+		buf.WriteString("  (max " + largest.String() + " transactions)")
+	}
+	buf.WriteString(newLine)
+}
+
+func largestTotal(totals map[time.Time]decimal.Decimal) decimal.Decimal {
+	var largest decimal.Decimal
+	for _, total := range totals {
+		largest = decimal.Max(largest, total)
+	}
+	return largest
+}
+
+// PrintActivityDays prints a heatmap of daily totals with a row per weekday
+// and a column per week, in blocks of weeks that fit a width of columns.
+func PrintActivityDays(w io.Writer, totals map[time.Time]decimal.Decimal, columns int, amount bool) {
+	if len(totals) == 0 {
+		return
+	}
+	days := slices.SortedFunc(func(yield func(time.Time) bool) {
+		for day := range totals {
+			if !yield(day) {
+				return
+			}
+		}
+	}, time.Time.Compare)
+	first, last := activityWeek(days[0]), days[len(days)-1]
+	largest := largestTotal(totals)
+	weeksPerBlock := max(1, columns-5)
+
+	colorHeader := fastcolor.CurrentTheme.Header
+
+	buf := bufio.NewWriter(w)
+	for blockStart := first; !blockStart.After(last); blockStart = blockStart.AddDate(0, 0, 7*weeksPerBlock) {
+		var weeks []time.Time
+		for week := blockStart; len(weeks) < weeksPerBlock && !week.After(last); week = week.AddDate(0, 0, 7) {
+			weeks = append(weeks, week)
+		}
+
+		// month names over the weeks the months start in, unless the name
+		// of the month before is still in the way
+		header := []rune(strings.Repeat(" ", len(weeks)+3))
+		nameEnd := 0
+		for i, week := range weeks {
+			month := week.AddDate(0, 0, 6).Month()
+			if (i == 0 || month != weeks[i-1].AddDate(0, 0, 6).Month()) && i >= nameEnd {
+				copy(header[i:], []rune(week.AddDate(0, 0, 6).Format("Jan")))
+				nameEnd = i + 4
+			}
+		}
+		if blockStart != first {
+			buf.WriteString(newLine)
+		}
+		months := strings.TrimRight(string(header), " ")
+		colorHeader.WriteStringFixed(buf, blockStart.AddDate(0, 0, 6).Format("2006"), 5, false)
+		colorHeader.WriteStringFixed(buf, months, len([]rune(months)), false)
+		buf.WriteString(newLine)
+
+		for weekday := range 7 {
+			buf.WriteString(blockStart.AddDate(0, 0, weekday).Format("Mon")[:2] + "   ")
+			for _, week := range weeks {
+				day := week.AddDate(0, 0, weekday)
+				if day.Before(days[0]) || day.After(last) {
+					buf.WriteString(" ")
+					continue
+				}
+				buf.WriteString(activityShade(totals[day], largest))
+			}
+			buf.WriteString(newLine)
+		}
+	}
+	buf.WriteString(newLine)
+	activityLegend(buf, largest, amount)
+	buf.Flush()
+}
+
+// PrintActivityWeeks prints a heatmap of weekly totals, keyed by the Monday
+// of each week, with a row per year and a column per week of the year.
+func PrintActivityWeeks(w io.Writer, totals map[time.Time]decimal.Decimal, amount bool) {
+	if len(totals) == 0 {
+		return
+	}
+	var firstYear, lastYear int
+	for week := range totals {
+		if firstYear == 0 || week.Year() < firstYear {
+			firstYear = week.Year()
+		}
+		lastYear = max(lastYear, week.Year())
+	}
+	largest := largestTotal(totals)
+
+	colorHeader := fastcolor.CurrentTheme.Header
+
+	buf := bufio.NewWriter(w)
+	header := []rune(strings.Repeat(" ", 53+3))
+	for month := time.January; month <= time.December; month++ {
+		start := time.Date(2001, month, 1, 0, 0, 0, 0, time.UTC)
+		copy(header[start.YearDay()/7:], []rune(start.Format("Jan")))
+	}
+	months := strings.TrimRight(string(header), " ")
+	buf.WriteString(strings.Repeat(" ", 6))
+	colorHeader.WriteStringFixed(buf, months, len([]rune(months)), false)
+	buf.WriteString(newLine)
+
+	for year := firstYear; year <= lastYear; year++ {
+		buf.WriteString(fmt.Sprintf("%d  ", year))
+		for week := activityWeek(time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)); week.Year() <= year; week = week.AddDate(0, 0, 7) {
+			if week.Year() < year {
+				// the week is shown in the year it starts in
+				continue
+			}
+			buf.WriteString(activityShade(totals[week], largest))
+		}
+		buf.WriteString(newLine)
+	}
+	buf.WriteString(newLine)
+	activityLegend(buf, largest, amount)
+	buf.Flush()
+}
+
+// activityCmd represents the activity command
+var activityCmd = &cobra.Command{
+	Use:   "activity [query]...",
+	Short: "Print a calendar heatmap of transactions",
+	Long: `Print a calendar heatmap of the number of transactions with postings that
+match the query, per day or per week. With --amount the heatmap shows the total
+of the absolute amounts of the matching postings instead.`,
+	Run: func(_ *cobra.Command, args []string) {
+		generalLedger, err := cliTransactions()
+		if err != nil {
+			fatalln(err)
+		}
+		query := cliQuery(args)
+		switch activityBy {
+		case "day":
+			PrintActivityDays(cliOutput, activityTotals(generalLedger, query, false, activityAmount), columnWidth, activityAmount)
+		case "week":
+			PrintActivityWeeks(cliOutput, activityTotals(generalLedger, query, true, activityAmount), activityAmount)
+		default:
+			fatalln("unknown activity period:", activityBy)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(activityCmd)
+	watchCommand(activityCmd)
+
+	var startDate, endDate time.Time
+	startDate = time.Date(1970, 1, 1, 0, 0, 0, 0, time.Local)
+	endDate = time.Now().Add(1<<63 - 1)
+	activityCmd.Flags().StringVarP(&startString, "begin-date", "b", startDate.Format(transactionDateFormat), "Begin date of transaction processing.")
+	activityCmd.Flags().StringVarP(&endString, "end-date", "e", endDate.Format(transactionDateFormat), "End date of transaction processing.")
+	activityCmd.Flags().StringVar(&payeeFilter, "payee", "", "Filter output to payees that contain this string.")
+	completeLedgerCommand(activityCmd)
+	activityCmd.Flags().IntVar(&columnWidth, "columns", 80, "Set a column width for output.")
+	activityCmd.Flags().BoolVar(&columnWide, "wide", false, "Wide output (use terminal width).")
+
+	activityCmd.Flags().StringVar(&activityBy, "by", "day", "Cell of the heatmap (day, week).")
+	activityCmd.Flags().BoolVar(&activityAmount, "amount", false, "Shade by the total amount instead of the number of transactions.")
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/shopspring/decimal"
+)
+
+func Test_activityTotals(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC) }
+	generalLedger := []*ledger.Transaction{
+		// Wednesday, Thursday and the Monday after
+		{Date: day(1), AccountChanges: []ledger.Account{
+			{Name: "Expenses:Food", Balance: decimal.NewFromInt(30)},
+			{Name: "Expenses:Home", Balance: decimal.NewFromInt(10)},
+			{Name: "Assets:Cash", Balance: decimal.NewFromInt(-40)},
+		}},
+		{Date: day(2), AccountChanges: []ledger.Account{
+			{Name: "Expenses:Food", Balance: decimal.NewFromInt(-5)},
+			{Name: "Assets:Cash", Balance: decimal.NewFromInt(5)},
+		}},
+		{Date: day(6), AccountChanges: []ledger.Account{
+			{Name: "Income:Salary", Balance: decimal.NewFromInt(-100)},
+			{Name: "Assets:Cash", Balance: decimal.NewFromInt(100)},
+		}},
+	}
+	query := mustParseQuery(t, "Expenses")
+
+	tests := []struct {
+		name   string
+		byWeek bool
+		amount bool
+		want   map[time.Time]int64
+	}{
+		{"days", false, false, map[time.Time]int64{day(1): 1, day(2): 1}},
+		{"day amounts", false, true, map[time.Time]int64{day(1): 40, day(2): 5}},
+		{"weeks", true, false, map[time.Time]int64{time.Date(2024, 4, 29, 0, 0, 0, 0, time.UTC): 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := activityTotals(generalLedger, query, tt.byWeek, tt.amount)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for key, want := range tt.want {
+				if !got[key].Equal(decimal.NewFromInt(want)) {
+					t.Errorf("%s: got %s, want %d", key.Format(time.DateOnly), got[key], want)
+				}
+			}
+		})
+	}
+}
+
+func Test_activityShade(t *testing.T) {
+	largest := decimal.NewFromInt(100)
+	tests := []struct {
+		total int64
+		want  string
+	}{
+		{0, "·"},
+		{1, "░"},
+		{25, "░"},
+		{26, "▒"},
+		{75, "▓"},
+		{100, "█"},
+	}
+	for _, tt := range tests {
+		if got := activityShade(decimal.NewFromInt(tt.total), largest); got != tt.want {
+			t.Errorf("activityShade(%d) = %q, want %q", tt.total, got, tt.want)
+		}
+	}
+}
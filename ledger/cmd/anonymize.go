@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+
+	"github.com/howeyc/ledger"
+	"github.com/shopspring/decimal"
+)
+
+var printAnonymize bool
+
+// anonHash returns a short hash of the parts, the same for the same parts.
+func anonHash(parts ...string) []byte {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return sum[:]
+}
+
+// anonAccount renames the last segment of an account name. Top level
+// accounts (Assets, Expenses, ...) keep their names so reports stay readable.
+func anonAccount(name string) string {
+	colIdx := strings.LastIndex(name, ":")
+	if colIdx < 0 {
+		return name
+	}
+	return name[:colIdx+1] + "Account" + hex.EncodeToString(anonHash("account", name)[:3])
+}
+
+// anonymizeTransaction returns a copy of trans with the payee hashed, leaf
+// accounts renamed and amounts scaled by a factor between 0.9 and 1.1. All
+// amounts of the transaction are scaled by the same factor, and rounding is
+// corrected on the largest posting of each currency, so the transaction still
+// balances. Comments and balance assertions are dropped.
+func anonymizeTransaction(trans *ledger.Transaction) *ledger.Transaction {
+	t := *trans
+	h := anonHash("payee", trans.Date.Format(transactionDateFormat), trans.Payee)
+	t.Payee = "Payee " + hex.EncodeToString(anonHash("payee", trans.Payee)[:4])
+	t.PayeeComment = ""
+	t.Comments = nil
+
+	// 0.9000 to 1.1000
+	factor := decimal.New(int64(9000+binary.BigEndian.Uint32(h)%2001), -4)
+
+	t.AccountChanges = make([]ledger.Account, len(trans.AccountChanges))
+	originalSums := make(map[string]decimal.Decimal)
+	sums := make(map[string]decimal.Decimal)
+	largest := make(map[string]int)
+	for i, acc := range trans.AccountChanges {
+		places := max(0, -acc.Balance.Exponent())
+		scaled := acc
+		scaled.Name = anonAccount(acc.Name)
+		scaled.Comment = ""
+		scaled.BalanceAssertion = nil
+		scaled.Balance = acc.Balance.Mul(factor).Round(places)
+		if acc.Converted != nil {
+			converted := acc.Converted.Mul(factor).Round(max(0, -acc.Converted.Exponent()))
+			scaled.Converted = &converted
+		}
+		t.AccountChanges[i] = scaled
+
+		originalSums[acc.Currency] = originalSums[acc.Currency].Add(acc.Balance)
+		sums[acc.Currency] = sums[acc.Currency].Add(scaled.Balance)
+		if j, ok := largest[acc.Currency]; !ok || acc.Balance.Abs().GreaterThan(trans.AccountChanges[j].Balance.Abs()) {
+			largest[acc.Currency] = i
+		}
+	}
+	for cur, i := range largest {
+		places := max(0, -trans.AccountChanges[i].Balance.Exponent())
+		target := originalSums[cur].Mul(factor).Round(places)
+		acc := &t.AccountChanges[i]
+		acc.Balance = acc.Balance.Add(target.Sub(sums[cur]))
+	}
+	return &t
+}
+
+// anonymizeTransactions returns anonymized copies of the transactions, see
+// anonymizeTransaction. The same ledger is always anonymized the same way.
+func anonymizeTransactions(generalLedger []*ledger.Transaction) []*ledger.Transaction {
+	anonymized := make([]*ledger.Transaction, len(generalLedger))
+	for i, trans := range generalLedger {
+		anonymized[i] = anonymizeTransaction(trans)
+	}
+	return anonymized
+}
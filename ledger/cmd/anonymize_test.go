@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/shopspring/decimal"
+)
+
+func Test_anonymizeTransaction(t *testing.T) {
+	trans := &ledger.Transaction{
+		Date:         time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local),
+		Payee:        "Corner Store",
+		PayeeComment: "private",
+		AccountChanges: []ledger.Account{
+			{Name: "Expenses:Food:Groceries", Balance: decimal.RequireFromString("33.33")},
+			{Name: "Expenses:Household", Balance: decimal.RequireFromString("33.33")},
+			{Name: "Assets:Checking", Balance: decimal.RequireFromString("-66.66"), Comment: "card 1234"},
+		},
+	}
+
+	anon := anonymizeTransaction(trans)
+	if anon.Payee == trans.Payee || !strings.HasPrefix(anon.Payee, "Payee ") || anon.PayeeComment != "" {
+		t.Errorf("payee not anonymized: %q %q", anon.Payee, anon.PayeeComment)
+	}
+	if again := anonymizeTransaction(trans); again.Payee != anon.Payee || !again.AccountChanges[0].Balance.Equal(anon.AccountChanges[0].Balance) {
+		t.Error("anonymizing is not deterministic")
+	}
+
+	var sum decimal.Decimal
+	for i, acc := range anon.AccountChanges {
+		orig := trans.AccountChanges[i]
+		if acc.Name == orig.Name || acc.Name[:strings.LastIndex(acc.Name, ":")] != orig.Name[:strings.LastIndex(orig.Name, ":")] {
+			t.Errorf("account %q renamed to %q", orig.Name, acc.Name)
+		}
+		ratio := acc.Balance.Div(orig.Balance)
+		if ratio.LessThan(decimal.RequireFromString("0.89")) || ratio.GreaterThan(decimal.RequireFromString("1.11")) {
+			t.Errorf("amount %s scaled to %s", orig.Balance, acc.Balance)
+		}
+		if acc.Comment != "" {
+			t.Errorf("posting comment kept: %q", acc.Comment)
+		}
+		sum = sum.Add(acc.Balance)
+	}
+	if !sum.IsZero() {
+		t.Errorf("anonymized transaction does not balance: %s", sum)
+	}
+	if trans.Payee != "Corner Store" || trans.AccountChanges[0].Name != "Expenses:Food:Groceries" {
+		t.Error("original transaction modified")
+	}
+}
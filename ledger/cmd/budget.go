@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/howeyc/ledger/ledger/internal/fastcolor"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+)
+
+var budgetFilePath string
+var budgetPeriod string
+
+// PrintBudget prints actual, budgeted and over/under (actual minus budget)
+// amounts for each row formatted to a window set to a width of columns. The
+// over/under amount is highlighted when the actual amount exceeds the budget.
+func PrintBudget(w io.Writer, rows []ledger.BudgetLine, columns int) {
+	printBudgetTable(w, rows, columns, [3]string{"Actual", "Budget", "Over/Under"}, func(row ledger.BudgetLine) [3]decimal.Decimal {
+		return [3]decimal.Decimal{row.Actual, row.Budgeted, row.Remaining().Neg()}
+	})
+}
+
+// PrintBudgetRemaining prints budgeted, actual and remaining (budget minus
+// actual) amounts for each row formatted to a window set to a width of
+// columns. The remaining amount is highlighted when the actual amount exceeds
+// the budget.
+func PrintBudgetRemaining(w io.Writer, rows []ledger.BudgetLine, columns int) {
+	printBudgetTable(w, rows, columns, [3]string{"Budget", "Actual", "Remaining"}, func(row ledger.BudgetLine) [3]decimal.Decimal {
+		return [3]decimal.Decimal{row.Budgeted, row.Actual, row.Remaining()}
+	})
+}
+
+// printBudgetTable prints the three amounts returned by cells for each row,
+// highlighting the last one when the actual amount exceeds the budget.
+func printBudgetTable(w io.Writer, rows []ledger.BudgetLine, columns int, titles [3]string, cells func(ledger.BudgetLine) [3]decimal.Decimal) {
+	// 3 10-width columns for amounts, each with a leading space
+	if columns < 35 {
+		columns = 35
+		fmt.Fprintf(os.Stderr, "warning: `columns` too small, setting to %d\n", columns)
+	}
+	accWidth := columns - (11 * 3)
+
+	colorNeg := fastcolor.CurrentTheme.Negative
+	colorAccount := fastcolor.CurrentTheme.Account
+	colorHeader := fastcolor.CurrentTheme.Header
+	colorReset := fastcolor.Reset
+
+	buf := bufio.NewWriter(w)
+	colorHeader.WriteStringFixed(buf, "Account", accWidth, false)
+	for _, title := range titles {
+		buf.WriteString(" ")
+		colorHeader.WriteStringFixed(buf, title, 10, true)
+	}
+	buf.WriteString(newLine)
+
+	for _, row := range rows {
+		lastColor := colorReset
+		if row.Over() {
+			lastColor = colorNeg
+		}
+
+		colorAccount.WriteStringFixed(buf, row.Account, accWidth, false)
+		for i, amount := range cells(row) {
+			cellColor := colorReset
+			if i == len(titles)-1 {
+				cellColor = lastColor
+			}
+			buf.WriteString(" ")
+			cellColor.WriteStringFixed(buf, formatAmount(row.Currency, amount), 10, true)
+		}
+		buf.WriteString(newLine)
+	}
+	buf.Flush()
+}
+
+// budgetCmd represents the budget command
+var budgetCmd = &cobra.Command{
+	Use:   "budget [query]...",
+	Short: "Print budgeted and actual amounts per period",
+	Long: `Compare the amounts budgeted by periodic transactions ("~ Monthly" blocks)
+with the actual balances of the budgeted accounts, for each period.`,
+	Run: func(_ *cobra.Command, args []string) {
+		generalLedger, err := cliTransactions()
+		if err != nil {
+			fatalln(err)
+		}
+		query := cliQuery(args)
+
+		if budgetFilePath == "" {
+			budgetFilePath = ledgerFilePath
+		}
+		periodic, perr := ledger.ParsePeriodicTransactions(budgetFilePath)
+		if perr != nil {
+			fatalln(perr)
+		}
+
+		lperiod, ok := ledger.ParsePeriod(budgetPeriod)
+		if !ok {
+			fatalln("unknown period:", budgetPeriod)
+		}
+		budget := &ledger.Budget{Periodic: periodic, Query: query}
+		for rIdx, bp := range budget.Evaluate(generalLedger, lperiod) {
+			if len(bp.Lines) < 1 {
+				continue
+			}
+
+			if rIdx > 0 {
+				fmt.Fprintln(cliOutput, "")
+			}
+			fmt.Fprintln(cliOutput, bp.Start.Format(transactionDateFormat), "-", bp.End.Format(transactionDateFormat))
+			fmt.Fprintln(cliOutput, strings.Repeat("=", columnWidth))
+			PrintBudget(cliOutput, bp.Lines, columnWidth)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(budgetCmd)
+	watchCommand(budgetCmd)
+
+	var startDate, endDate time.Time
+	startDate = time.Date(1970, 1, 1, 0, 0, 0, 0, time.Local)
+	endDate = time.Now().Add(1<<63 - 1)
+	budgetCmd.Flags().StringVarP(&startString, "begin-date", "b", startDate.Format(transactionDateFormat), "Begin date of transaction processing.")
+	budgetCmd.Flags().StringVarP(&endString, "end-date", "e", endDate.Format(transactionDateFormat), "End date of transaction processing.")
+	budgetCmd.Flags().StringVar(&payeeFilter, "payee", "", "Filter output to payees that contain this string.")
+	budgetCmd.Flags().IntVar(&columnWidth, "columns", 80, "Set a column width for output.")
+	budgetCmd.Flags().BoolVar(&columnWide, "wide", false, "Wide output (use terminal width).")
+
+	budgetCmd.Flags().StringVar(&budgetPeriod, "period", "Monthly", "Budget period (Weekly,Monthly,Quarterly,SemiYearly,Yearly).")
+	budgetCmd.Flags().StringVar(&budgetFilePath, "budget-file", "", "File with periodic transactions (default is the ledger file).")
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/howeyc/ledger"
+	"github.com/shopspring/decimal"
+)
+
+func TestPrintBudgetRemaining(t *testing.T) {
+	rows := []ledger.BudgetLine{
+		{Account: "Expenses:Food", Budgeted: decimal.NewFromInt(300), Actual: decimal.NewFromInt(350)},
+		{Account: "Expenses:Rent", Budgeted: decimal.NewFromInt(1000), Actual: decimal.NewFromInt(1000)},
+	}
+	var buf strings.Builder
+	PrintBudgetRemaining(&buf, rows, 60)
+	want := "Account                         Budget     Actual  Remaining\n" +
+		"Expenses:Food                   300.00     350.00     -50.00\n" +
+		"Expenses:Rent                  1000.00    1000.00       0.00\n"
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
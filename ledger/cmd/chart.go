@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/howeyc/ledger/ledger/internal/fastcolor"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+)
+
+var chartPeriod string
+var chartCumulative bool
+
+// chartPoint is a single bar of a chart.
+type chartPoint struct {
+	Label string
+	Value decimal.Decimal
+}
+
+// chartPoints returns, per currency, the total of the postings in each period
+// of generalLedger, or the running balance at the end of each period when
+// cumulative is set.
+func chartPoints(generalLedger []*ledger.Transaction, per ledger.Period, cumulative bool) map[string][]chartPoint {
+	rtrans := ledger.TransactionsByPeriod(generalLedger, per)
+	periodTotals := make([]map[string]decimal.Decimal, len(rtrans))
+	currencies := make(map[string]decimal.Decimal)
+	for i, rt := range rtrans {
+		periodTotals[i] = postingTotals(rt.Transactions, nil)
+		for cur := range periodTotals[i] {
+			currencies[cur] = decimal.Zero
+		}
+	}
+
+	points := make(map[string][]chartPoint)
+	for cur := range currencies {
+		var running decimal.Decimal
+		for i, rt := range rtrans {
+			value := periodTotals[i][cur]
+			if cumulative {
+				running = running.Add(value)
+				value = running
+			}
+			points[cur] = append(points[cur], chartPoint{Label: rt.Start.Format(transactionDateFormat), Value: value})
+		}
+	}
+	return points
+}
+
+// PrintChart prints a horizontal bar chart of the points, one line per point,
+// scaled to fit in columns. Negative values are drawn in red.
+func PrintChart(w io.Writer, points []chartPoint, columns int) {
+	// label, space, 12-width value, space, bar
+	barWidth := columns - 10 - 1 - 12 - 1
+	if barWidth < 10 {
+		barWidth = 10
+		fmt.Fprintf(os.Stderr, "warning: `columns` too small, setting to %d\n", barWidth+24)
+	}
+
+	var maxValue decimal.Decimal
+	for _, p := range points {
+		maxValue = decimal.Max(maxValue, p.Value.Abs())
+	}
+
+	buf := bufio.NewWriter(w)
+	for _, p := range points {
+		barColor := fastcolor.CurrentTheme.Positive
+		if p.Value.Sign() < 0 {
+			barColor = fastcolor.CurrentTheme.Negative
+		}
+		barLen := 0
+		if !maxValue.IsZero() {
+			barLen = int(p.Value.Abs().Mul(decimal.NewFromInt(int64(barWidth))).Div(maxValue).Round(0).IntPart())
+		}
+
+		fastcolor.Reset.WriteStringFixed(buf, p.Label, 10, false)
+		buf.WriteString(" ")
+		barColor.WriteStringFixed(buf, p.Value.StringFixedBank(2), 12, true)
+		buf.WriteString(" ")
+		barColor.WriteStringFixed(buf, strings.Repeat("#", barLen), barLen, false)
+		buf.WriteString(newLine)
+	}
+	buf.Flush()
+}
+
+// chartCmd represents the chart command
+var chartCmd = &cobra.Command{
+	Use:   "chart [query]...",
+	Short: "Print a bar chart of totals per period",
+	Long: `Print a bar chart of the total of the postings matching the query in each
+period, or with --cumulative of the balance at the end of each period.`,
+	Run: func(_ *cobra.Command, args []string) {
+		generalLedger, err := cliTransactions()
+		if err != nil {
+			fatalln(err)
+		}
+
+		lperiod, ok := ledger.ParsePeriod(chartPeriod)
+		if !ok {
+			fatalln("unknown period:", chartPeriod)
+		}
+
+		generalLedger = cliQuery(args).Filter(generalLedger)
+		if len(generalLedger) < 1 {
+			return
+		}
+		points := chartPoints(generalLedger, lperiod, chartCumulative)
+		totals := make(map[string]decimal.Decimal, len(points))
+		for cur := range points {
+			totals[cur] = decimal.Zero
+		}
+		for i, cur := range sortedCurrencies(totals) {
+			if len(points) > 1 {
+				if i > 0 {
+					fmt.Fprintln(cliOutput, "")
+				}
+				if cur == "" {
+					fmt.Fprintln(cliOutput, "(no currency)")
+				} else {
+					fmt.Fprintln(cliOutput, cur)
+				}
+			}
+			PrintChart(cliOutput, points[cur], columnWidth)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(chartCmd)
+	watchCommand(chartCmd)
+
+	var startDate, endDate time.Time
+	startDate = time.Date(1970, 1, 1, 0, 0, 0, 0, time.Local)
+	endDate = time.Now().Add(1<<63 - 1)
+	chartCmd.Flags().StringVarP(&startString, "begin-date", "b", startDate.Format(transactionDateFormat), "Begin date of transaction processing.")
+	chartCmd.Flags().StringVarP(&endString, "end-date", "e", endDate.Format(transactionDateFormat), "End date of transaction processing.")
+	chartCmd.Flags().StringVar(&payeeFilter, "payee", "", "Filter output to payees that contain this string.")
+	chartCmd.Flags().IntVar(&columnWidth, "columns", 80, "Set a column width for output.")
+	chartCmd.Flags().BoolVar(&columnWide, "wide", false, "Wide output (use terminal width).")
+
+	chartCmd.Flags().StringVar(&chartPeriod, "period", "Monthly", "Period of each bar (Weekly,Monthly,Quarterly,SemiYearly,Yearly).")
+	chartCmd.Flags().BoolVar(&chartCumulative, "cumulative", false, "Chart the running balance instead of the total of each period.")
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/shopspring/decimal"
+)
+
+func Test_chartPoints(t *testing.T) {
+	generalLedger := []*ledger.Transaction{
+		{
+			Date: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC),
+			AccountChanges: []ledger.Account{
+				{Name: "Expenses:Food", Balance: decimal.NewFromInt(100)},
+			},
+		},
+		{
+			Date: time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC),
+			AccountChanges: []ledger.Account{
+				{Name: "Expenses:Food", Balance: decimal.NewFromInt(50)},
+				{Name: "Expenses:Travel", Currency: "EUR", Balance: decimal.NewFromInt(20)},
+			},
+		},
+	}
+
+	points := chartPoints(generalLedger, ledger.PeriodMonth, false)
+	if len(points) != 2 || len(points[""]) != 3 || len(points["EUR"]) != 3 {
+		t.Fatalf("unexpected points: %v", points)
+	}
+	for i, want := range []int64{100, 0, 50} {
+		if !points[""][i].Value.Equal(decimal.NewFromInt(want)) {
+			t.Errorf("point %d: got %s, want %d", i, points[""][i].Value, want)
+		}
+	}
+	if points[""][1].Label != "2024/02/01" {
+		t.Errorf("unexpected label: %s", points[""][1].Label)
+	}
+
+	points = chartPoints(generalLedger, ledger.PeriodMonth, true)
+	for i, want := range []int64{100, 100, 150} {
+		if !points[""][i].Value.Equal(decimal.NewFromInt(want)) {
+			t.Errorf("cumulative point %d: got %s, want %d", i, points[""][i].Value, want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/spf13/cobra"
+)
+
+var checkStrict bool
+var checkCommodities bool
+var checkSigns bool
+var checkAllowFuture bool
+var checkJSON bool
+var checkFutureDays, checkStaleDays int
+
+// checkSignConfig holds the signs of accounts for --signs, set by the signs
+// configuration, and defaultSigns those used without one.
+var checkSignConfig map[string]string
+var defaultSigns = map[string]string{"Expenses": "positive", "Income": "negative"}
+
+// accountSigns are the configured signs of accounts as ledger.CheckOptions
+// holds them.
+var accountSigns = map[string]int{"positive": 1, "negative": -1, "any": 0}
+
+// cliSigns returns the signs of accounts of the configuration, or the
+// default signs, for ledger.CheckOptions.
+func cliSigns() map[string]int {
+	config := checkSignConfig
+	if len(config) == 0 {
+		config = defaultSigns
+	}
+	signs := make(map[string]int, len(config))
+	for name, sign := range config {
+		// checked by loadConfig
+		signs[name] = accountSigns[sign]
+	}
+	return signs
+}
+
+var checkKinds = []ledger.CheckKind{
+	ledger.CheckParse,
+	ledger.CheckUnbalanced,
+	ledger.CheckAssertion,
+	ledger.CheckUndeclared,
+	ledger.CheckFuture,
+	ledger.CheckStale,
+	ledger.CheckCommodity,
+	ledger.CheckSign,
+}
+
+type checkProblem struct {
+	Kind    ledger.CheckKind `json:"kind"`
+	File    string           `json:"file,omitempty"`
+	Line    int              `json:"line,omitempty"`
+	Message string           `json:"message"`
+}
+
+type checkSummary struct {
+	OK       bool                     `json:"ok"`
+	Counts   map[ledger.CheckKind]int `json:"counts"`
+	Problems []checkProblem           `json:"problems"`
+}
+
+func newCheckSummary(problems []*ledger.CheckError) checkSummary {
+	summary := checkSummary{
+		OK:       len(problems) == 0,
+		Counts:   make(map[ledger.CheckKind]int),
+		Problems: []checkProblem{},
+	}
+	for _, kind := range checkKinds {
+		summary.Counts[kind] = 0
+	}
+	for _, p := range problems {
+		summary.Counts[p.Kind]++
+		summary.Problems = append(summary.Problems, checkProblem{
+			Kind:    p.Kind,
+			File:    p.Filename,
+			Line:    p.Line,
+			Message: p.Err.Error(),
+		})
+	}
+	return summary
+}
+
+// checkCmd represents the check command
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Validate ledger file",
+	Long: `Validate the ledger file in one pass. Parse errors, unbalanced transactions,
+failed balance assertions and transactions dated in the future, or more than
+--future-days ahead, are problems.
+
+With --stale-days, a latest transaction older than that many days is a problem.
+With --commodities, so is a posting without a price to an account in another
+commodity than its own. With --signs, so is an amount of the other sign than
+its account normally has. With --strict, so is an account that is not declared.
+
+Each problem is printed on its own line followed by a summary line. Exits with
+status 1 if any problems are found.`,
+	Run: func(_ *cobra.Command, _ []string) {
+		if checkFutureDays < 0 || checkStaleDays < 0 {
+			fatalln("--future-days and --stale-days must not be negative")
+		}
+		opts := ledger.CheckOptions{Strict: checkStrict, Commodities: checkCommodities}
+		now := time.Now()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		if !checkAllowFuture {
+			opts.Now = today.AddDate(0, 0, checkFutureDays)
+		}
+		if checkSigns {
+			opts.Signs = cliSigns()
+		}
+		if checkStaleDays > 0 {
+			opts.StaleBefore = today.AddDate(0, 0, -checkStaleDays)
+		}
+		problems := ledger.CheckLedgerFile(ledgerFilePath, opts)
+		summary := newCheckSummary(problems)
+
+		if checkJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			enc.Encode(summary)
+		} else {
+			for _, p := range problems {
+				fmt.Printf("%s: %v\n", p.Kind, p)
+			}
+			counts := make([]string, 0, len(checkKinds))
+			for _, kind := range checkKinds {
+				counts = append(counts, fmt.Sprintf("%s=%d", kind, summary.Counts[kind]))
+			}
+			status := "ok"
+			if !summary.OK {
+				status = "failed"
+			}
+			fmt.Printf("check: %s: %d problems (%s)\n", status, len(problems), strings.Join(counts, " "))
+		}
+
+		if !summary.OK {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+
+	checkCmd.Flags().BoolVar(&checkStrict, "strict", false, "Report accounts not declared with an account directive.")
+	checkCmd.Flags().BoolVar(&checkCommodities, "commodities", false, "Report postings to an account in another commodity than its own without a price.")
+	checkCmd.Flags().BoolVar(&checkAllowFuture, "allow-future", false, "Do not report transactions dated in the future.")
+	checkCmd.Flags().BoolVar(&checkSigns, "signs", false, "Report amounts of the other sign than their account normally has, by the signs configuration.")
+	checkCmd.Flags().IntVar(&checkFutureDays, "future-days", 0, "Only report transactions dated more than this many days in the future.")
+	checkCmd.Flags().IntVar(&checkStaleDays, "stale-days", 0, "Report the journal if its latest transaction is older than this many days.")
+	checkCmd.Flags().BoolVar(&checkJSON, "json", false, "Print the problems and summary as JSON.")
+}
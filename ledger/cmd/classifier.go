@@ -0,0 +1,310 @@
+package cmd
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jbrukh/bayesian"
+)
+
+var importClassifier string
+var importClassifierRules string
+
+// accountClassifier predicts the account of an imported transaction from the
+// words of its payee.
+type accountClassifier interface {
+	// Rank returns the accounts ordered from the most to the least likely
+	// for the payee words. Only the differences between scores have a
+	// meaning, compared with the minimum confidence.
+	Rank(payeeWords []string) []accountScore
+	// Learn teaches the classifier that the payee words are of account.
+	Learn(payeeWords []string, account string)
+}
+
+// accountScore is the score of an account for a payee.
+type accountScore struct {
+	Account string
+	Score   float64
+}
+
+// classifierConfidence is the default minimum confidence of each classifier,
+// the lead of the most likely account over the next: a difference of log
+// scores for bayes, of similarities for tfidf, while a rule match always
+// leads by 1.
+var classifierConfidence = map[string]float64{
+	"bayes": 10,
+	"tfidf": 0.1,
+	"rules": 0.5,
+}
+
+// bayesClassifier is a naive Bayes classifier trained on the payee words of
+// each account.
+type bayesClassifier struct {
+	*bayesian.Classifier
+}
+
+func (bc bayesClassifier) Rank(payeeWords []string) []accountScore {
+	scores, _, _ := bc.LogScores(payeeWords)
+	ranked := make([]accountScore, len(scores))
+	for j, score := range scores {
+		ranked[j] = accountScore{Account: string(bc.Classes[j]), Score: score}
+	}
+	slices.SortStableFunc(ranked, func(a, b accountScore) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	return ranked
+}
+
+// WordWeights returns the log probability of each payee word in the payees of
+// the account, which the score of the account adds to its log prior.
+func (bc bayesClassifier) WordWeights(payeeWords []string, account string) []float64 {
+	i := slices.Index(bc.Classes, bayesian.Class(account))
+	if i < 0 {
+		return nil
+	}
+	freqs := bc.WordFrequencies(payeeWords)[i]
+	weights := make([]float64, len(freqs))
+	for j, freq := range freqs {
+		weights[j] = math.Log(freq)
+	}
+	return weights
+}
+
+// Learn teaches accounts the classifier was trained with, its classes can not
+// be extended.
+func (bc bayesClassifier) Learn(payeeWords []string, account string) {
+	if slices.Contains(bc.Classes, bayesian.Class(account)) {
+		bc.Classifier.Learn(payeeWords, bayesian.Class(account))
+	}
+}
+
+// tfidfClassifier is a nearest-neighbor classifier: the account of a payee is
+// the account of the most similar payee learned, comparing the TF-IDF
+// weighted words by cosine similarity. Unlike naive Bayes it does well on
+// short payees, where a single rare word decides.
+type tfidfClassifier struct {
+	docs     []tfidfDoc
+	docCount map[string]int // number of docs with each word
+}
+
+type tfidfDoc struct {
+	account string
+	words   map[string]int
+}
+
+func newTfidfClassifier() *tfidfClassifier {
+	return &tfidfClassifier{docCount: make(map[string]int)}
+}
+
+func tfidfWords(payeeWords []string) map[string]int {
+	words := make(map[string]int, len(payeeWords))
+	for _, word := range payeeWords {
+		words[strings.ToLower(word)]++
+	}
+	return words
+}
+
+func (tc *tfidfClassifier) Learn(payeeWords []string, account string) {
+	words := tfidfWords(payeeWords)
+	if len(words) == 0 {
+		return
+	}
+	tc.docs = append(tc.docs, tfidfDoc{account: account, words: words})
+	for word := range words {
+		tc.docCount[word]++
+	}
+}
+
+// weights returns the TF-IDF weight of each word and the norm of the weights.
+func (tc *tfidfClassifier) weights(words map[string]int) (map[string]float64, float64) {
+	weighted := make(map[string]float64, len(words))
+	var norm float64
+	for word, count := range words {
+		idf := math.Log(float64(len(tc.docs)+1) / float64(tc.docCount[word]+1))
+		weighted[word] = float64(count) * idf
+		norm += weighted[word] * weighted[word]
+	}
+	return weighted, math.Sqrt(norm)
+}
+
+func (tc *tfidfClassifier) Rank(payeeWords []string) []accountScore {
+	query, queryNorm := tc.weights(tfidfWords(payeeWords))
+	if queryNorm == 0 {
+		return nil
+	}
+	best := make(map[string]float64)
+	for _, doc := range tc.docs {
+		weighted, norm := tc.weights(doc.words)
+		if norm == 0 {
+			continue
+		}
+		var dot float64
+		for word, w := range query {
+			dot += w * weighted[word]
+		}
+		similarity := dot / (queryNorm * norm)
+		if s, ok := best[doc.account]; !ok || similarity > s {
+			best[doc.account] = similarity
+		}
+	}
+
+	ranked := make([]accountScore, 0, len(best))
+	for account, similarity := range best {
+		ranked = append(ranked, accountScore{Account: account, Score: similarity})
+	}
+	slices.SortFunc(ranked, func(a, b accountScore) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Account, b.Account)
+	})
+	return ranked
+}
+
+// WordWeights returns what each payee word adds to the similarity of the payee
+// to the most similar payee learned for the account, which is its score. A
+// repeated word counts at its first place.
+func (tc *tfidfClassifier) WordWeights(payeeWords []string, account string) []float64 {
+	query, queryNorm := tc.weights(tfidfWords(payeeWords))
+	best := make([]float64, len(payeeWords))
+	if queryNorm == 0 {
+		return best
+	}
+	bestSimilarity := math.Inf(-1)
+	for _, doc := range tc.docs {
+		if doc.account != account {
+			continue
+		}
+		weighted, norm := tc.weights(doc.words)
+		if norm == 0 {
+			continue
+		}
+		weights := make([]float64, len(payeeWords))
+		seen := make(map[string]bool)
+		var similarity float64
+		for j, word := range payeeWords {
+			word = strings.ToLower(word)
+			if seen[word] {
+				continue
+			}
+			seen[word] = true
+			weights[j] = query[word] * weighted[word] / (queryNorm * norm)
+			similarity += weights[j]
+		}
+		if similarity > bestSimilarity {
+			best, bestSimilarity = weights, similarity
+		}
+	}
+	return best
+}
+
+// rulesClassifier assigns the account of the first rule whose regular
+// expression matches the payee.
+type rulesClassifier struct {
+	rules []classifierRule
+}
+
+type classifierRule struct {
+	account string
+	regexp  *regexp.Regexp
+}
+
+// parseClassifierRules reads rules, a line each with an account and a regular
+// expression separated by two spaces or a tab, matched ignoring case:
+//
+//	Expenses:Food      grocer|supermarket
+//	Expenses:Transport \bbus\b|train
+//
+// Blank lines and lines starting with '#' or ';' are ignored.
+func parseClassifierRules(r io.Reader) (*rulesClassifier, error) {
+	rc := &rulesClassifier{}
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		account, pattern, ok := strings.Cut(line, "\t")
+		if !ok {
+			account, pattern, ok = strings.Cut(line, "  ")
+		}
+		if !ok {
+			return nil, fmt.Errorf("line %d: missing pattern after account", lineNum)
+		}
+		re, err := regexp.Compile("(?i)" + strings.TrimSpace(pattern))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		rc.rules = append(rc.rules, classifierRule{account: strings.TrimSpace(account), regexp: re})
+	}
+	return rc, scanner.Err()
+}
+
+// Rank returns the accounts of the matching rules in order, the first scored
+// 1 and the others 0.
+func (rc *rulesClassifier) Rank(payeeWords []string) []accountScore {
+	payee := strings.Join(payeeWords, " ")
+	var ranked []accountScore
+	seen := make(map[string]bool)
+	for _, rule := range rc.rules {
+		if seen[rule.account] || !rule.regexp.MatchString(payee) {
+			continue
+		}
+		seen[rule.account] = true
+		score := 0.0
+		if len(ranked) == 0 {
+			score = 1
+		}
+		ranked = append(ranked, accountScore{Account: rule.account, Score: score})
+	}
+	return ranked
+}
+
+// Learn adds a rule matching the payee exactly, ahead of the others.
+func (rc *rulesClassifier) Learn(payeeWords []string, account string) {
+	if len(payeeWords) == 0 {
+		return
+	}
+	re := regexp.MustCompile("(?i)^" + regexp.QuoteMeta(strings.Join(payeeWords, " ")) + "$")
+	rc.rules = slices.Insert(rc.rules, 0, classifierRule{account: account, regexp: re})
+}
+
+// newClassifier returns the classifier selected by --classifier for the
+// matching account, trained on the ledger or read from the rules file.
+func (imp *Importer) newClassifier(matchingAccount string) (accountClassifier, error) {
+	switch importClassifier {
+	case "tfidf":
+		tc := newTfidfClassifier()
+		imp.trainingExamples(matchingAccount, tc.Learn)
+		return tc, nil
+	case "rules":
+		if importClassifierRules == "" {
+			return nil, errors.New("the rules classifier needs --classifier-rules")
+		}
+		f, err := os.Open(importClassifierRules)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		rc, err := parseClassifierRules(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%w", importClassifierRules, err)
+		}
+		return rc, nil
+	}
+	classifier, err := imp.modelClassifier(matchingAccount)
+	if err != nil {
+		return nil, err
+	}
+	return bayesClassifier{classifier}, nil
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/howeyc/ledger"
+)
+
+func Test_tfidfClassifier(t *testing.T) {
+	tc := newTfidfClassifier()
+	tc.Learn(strings.Fields("ACME SUPERMARKET 1234"), "Expenses:Food")
+	tc.Learn(strings.Fields("ACME HARDWARE 99"), "Expenses:House")
+	tc.Learn(strings.Fields("SHELL 4411"), "Expenses:Fuel")
+
+	ranked := tc.Rank(strings.Fields("acme supermarket 5678"))
+	if len(ranked) < 2 || ranked[0].Account != "Expenses:Food" || ranked[1].Account != "Expenses:House" {
+		t.Fatalf("Rank() = %v, want Expenses:Food then Expenses:House", ranked)
+	}
+	if ranked[0].Score <= ranked[1].Score {
+		t.Errorf("Rank() = %v, want a lead for Expenses:Food", ranked)
+	}
+	if ranked := tc.Rank(strings.Fields("unseen words")); len(ranked) > 0 && ranked[0].Score != 0 {
+		t.Errorf("Rank() of unseen words = %v, want no similarity", ranked)
+	}
+}
+
+func Test_rulesClassifier(t *testing.T) {
+	rules := `# food first
+Expenses:Food	grocer|supermarket
+Expenses:Transport  \bbus\b|train
+Expenses:Misc  .
+`
+	rc, err := parseClassifierRules(strings.NewReader(rules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"Corner GROCER":  "Expenses:Food",
+		"City Bus":       "Expenses:Transport",
+		"Business lunch": "Expenses:Misc",
+	}
+	for payee, want := range tests {
+		if ranked := rc.Rank(strings.Fields(payee)); len(ranked) == 0 || ranked[0].Account != want {
+			t.Errorf("Rank(%q) = %v, want %s first", payee, ranked, want)
+		}
+	}
+
+	rc.Learn(strings.Fields("Business lunch"), "Expenses:Dining")
+	if ranked := rc.Rank(strings.Fields("business LUNCH")); ranked[0].Account != "Expenses:Dining" || ranked[0].Score-ranked[1].Score != 1 {
+		t.Errorf("Rank() after Learn = %v, want Expenses:Dining leading by 1", ranked)
+	}
+
+	if _, err := parseClassifierRules(strings.NewReader("Expenses:Food\n")); err == nil {
+		t.Error("rule without pattern parsed")
+	}
+}
+
+func Test_newClassifier(t *testing.T) {
+	imp := &Importer{generalLedger: []*ledger.Transaction{
+		{Payee: "Corner Grocer", AccountChanges: []ledger.Account{{Name: "Assets:Checking"}, {Name: "Expenses:Food"}}},
+		{Payee: "Gas Station", AccountChanges: []ledger.Account{{Name: "Assets:Checking"}, {Name: "Expenses:Fuel"}}},
+	}}
+	defer func(old string) { importClassifier = old }(importClassifier)
+
+	for _, name := range []string{"bayes", "tfidf"} {
+		importClassifier = name
+		classifier, err := imp.newClassifier("Assets:Checking")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ranked := classifier.Rank([]string{"Grocer"}); len(ranked) == 0 || ranked[0].Account != "Expenses:Food" {
+			t.Errorf("%s: Rank(Grocer) = %v, want Expenses:Food first", name, ranked)
+		}
+	}
+
+	importClassifier = "rules"
+	if _, err := imp.newClassifier("Assets:Checking"); err == nil {
+		t.Error("rules classifier without a rules file")
+	}
+}
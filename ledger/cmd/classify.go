@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/howeyc/ledger"
+	"github.com/spf13/cobra"
+)
+
+var classifyTop int
+
+// wordWeigher is a classifier that can tell what each word of a payee adds to
+// the score of an account.
+type wordWeigher interface {
+	WordWeights(payeeWords []string, account string) []float64
+}
+
+// learnedAccounts returns the accounts the classifier learns from the ledger
+// for the matching account, with the number of payees of each, the most
+// learned first.
+func (imp *Importer) learnedAccounts(matchingAccount string) []accountScore {
+	counts := make(map[string]int)
+	imp.trainingExamples(matchingAccount, func(_ []string, account string) {
+		counts[account]++
+	})
+	learned := make([]accountScore, 0, len(counts))
+	for account, count := range counts {
+		learned = append(learned, accountScore{Account: account, Score: float64(count)})
+	}
+	slices.SortFunc(learned, func(a, b accountScore) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Account, b.Account)
+	})
+	return learned
+}
+
+// PrintClassification explains the account guessed for a payee: the accounts
+// learned, the top accounts by score with the lead of each over the next and,
+// for classifiers that weigh words, what each payee word adds to the score,
+// then the account import would use.
+func PrintClassification(w io.Writer, imp *Importer, payee string, top int) {
+	buf := bufio.NewWriter(w)
+	defer buf.Flush()
+
+	learned := imp.learnedAccounts(imp.matchingAccount)
+	total := 0
+	for _, as := range learned {
+		total += int(as.Score)
+	}
+	fmt.Fprintf(buf, "Classifier %s, trained on %d payees of %s:%s", importClassifier, total, imp.matchingAccount, newLine)
+	for _, as := range learned {
+		fmt.Fprintf(buf, "%6d  %s%s", int(as.Score), as.Account, newLine)
+	}
+
+	words := strings.Fields(imp.normalizePayee(payee))
+	fmt.Fprintf(buf, "%sPayee words: %s%s%s", newLine, strings.Join(words, " "), newLine, newLine)
+
+	var ranked []accountScore
+	for _, as := range imp.rankAccounts(words) {
+		if !math.IsInf(as.Score, -1) {
+			ranked = append(ranked, as)
+		}
+	}
+	if len(ranked) == 0 {
+		fmt.Fprintf(buf, "No account scores%s", newLine)
+	}
+
+	weigher, _ := imp.classifier.(wordWeigher)
+	fmt.Fprintf(buf, "%10s %10s  %-30s", "Score", "Lead", "Account")
+	if weigher != nil {
+		for _, word := range words {
+			fmt.Fprintf(buf, " %10s", truncate(word, 10))
+		}
+	}
+	buf.WriteString(newLine)
+	for i, as := range ranked[:min(len(ranked), top)] {
+		lead := ""
+		if i+1 < len(ranked) {
+			lead = fmt.Sprintf("%.4g", as.Score-ranked[i+1].Score)
+		}
+		fmt.Fprintf(buf, "%10.4g %10s  %-30s", as.Score, lead, as.Account)
+		if weigher != nil {
+			for _, weight := range weigher.WordWeights(words, as.Account) {
+				fmt.Fprintf(buf, " %10.4g", weight)
+			}
+		}
+		buf.WriteString(newLine)
+	}
+
+	account := imp.predictAccount(words)
+	fmt.Fprintf(buf, "%sAccount: %s", newLine, account)
+	if len(ranked) > 0 && account != ranked[0].Account {
+		fmt.Fprintf(buf, ", the lead of %s is not above the minimum confidence %g", ranked[0].Account, importMinConfidence)
+	}
+	buf.WriteString(newLine)
+}
+
+// truncate shortens s to at most n runes.
+func truncate(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
+
+// classifyCmd represents the classify command
+var classifyCmd = &cobra.Command{
+	Use:   "classify <account-substring> <payee>...",
+	Args:  cobra.MinimumNArgs(2),
+	Short: "Explain the account import guesses for a payee",
+	Long: `Explain the account import guesses for a payee of transactions of the
+matching account: the accounts the classifier learned from the ledger, the
+most likely accounts by score with the lead of each over the next, what each
+word of the payee adds to the score, and the account import would use.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if _, ok := classifierConfidence[importClassifier]; !ok {
+			fatalln("unknown classifier:", importClassifier)
+		}
+		if !cmd.Flags().Changed("min-confidence") {
+			importMinConfidence = classifierConfidence[importClassifier]
+		}
+		if ledgerFilePath == "" {
+			fatalln("classify needs a ledger file")
+		}
+
+		generalLedger, err := ledger.ParseLedgerFile(ledgerFilePath)
+		if err != nil {
+			fatalln(fmt.Sprintf("%s:%s", ledgerFilePath, err.Error()))
+		}
+		payeeRules, err := loadPayeeRules()
+		if err != nil {
+			fatalln(err)
+		}
+		imp := &Importer{generalLedger: generalLedger, payeeRules: payeeRules}
+		if imp.matchingAccount, err = imp.findMatchingAccount(args[0]); err != nil {
+			fatalln(err)
+		}
+		if imp.classifier, err = imp.newClassifier(imp.matchingAccount); err != nil {
+			fatalln(err)
+		}
+		PrintClassification(cliOutput, imp, strings.Join(args[1:], " "), classifyTop)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(classifyCmd)
+
+	classifyCmd.Flags().StringVar(&importClassifier, "classifier", "bayes", "Account classifier: bayes, tfidf or rules.")
+	classifyCmd.Flags().StringVar(&importClassifierRules, "classifier-rules", "", "Rules file of the rules classifier.")
+	classifyCmd.Flags().Float64Var(&importMinConfidence, "min-confidence", 10, "Lead in score the most likely account needs over the next to be used\n(default 10 for bayes, 0.1 for tfidf, 0.5 for rules).")
+	classifyCmd.Flags().StringVar(&importModelFile, "model", "", "File to save the trained classifier to, and load it from while the ledger is unchanged.")
+	classifyCmd.Flags().StringVar(&importPayeeRules, "payee-rules", "", "Rules file of regular expressions and replacements cleaning up payees.")
+	classifyCmd.Flags().IntVar(&classifyTop, "top", 5, "Number of accounts to show.")
+}
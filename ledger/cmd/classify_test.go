@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/howeyc/ledger"
+)
+
+func TestPrintClassification(t *testing.T) {
+	defer func(classifier string, confidence float64) {
+		importClassifier, importMinConfidence = classifier, confidence
+	}(importClassifier, importMinConfidence)
+
+	generalLedger, err := ledger.ParseLedger(strings.NewReader(`2024/01/02 Corner Grocer
+    Expenses:Food    12
+    Assets:Checking
+
+2024/01/09 Corner Grocer
+    Expenses:Food    15
+    Assets:Checking
+
+2024/01/10 Corner Cafe
+    Expenses:Dining    5
+    Assets:Checking
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		classifier string
+		confidence float64
+		want       []string
+	}{
+		{"tfidf", 0.1, []string{
+			"Classifier tfidf, trained on 3 payees of Assets:Checking:",
+			"     2  Expenses:Food",
+			"Payee words: Corner Grocer",
+			"     Score       Lead  Account                            Corner     Grocer",
+			// corner is in every payee, so it weighs nothing
+			"         1          1  Expenses:Food                           0          1",
+			"Account: Expenses:Food",
+		}},
+		{"bayes", 100, []string{
+			"Classifier bayes, trained on 3 payees of Assets:Checking:",
+			"Expenses:Dining ",
+			"Account: unknown:unknown, the lead of Expenses:Food is not above the minimum confidence 100",
+		}},
+	}
+	for _, tt := range tests {
+		importClassifier, importMinConfidence = tt.classifier, tt.confidence
+		imp := &Importer{generalLedger: generalLedger, matchingAccount: "Assets:Checking"}
+		if imp.classifier, err = imp.newClassifier(imp.matchingAccount); err != nil {
+			t.Fatal(err)
+		}
+		var buf strings.Builder
+		PrintClassification(&buf, imp, "Corner Grocer", 5)
+		for _, w := range tt.want {
+			if !strings.Contains(buf.String(), w) {
+				t.Errorf("%s: got %q, want %q", tt.classifier, buf.String(), w)
+			}
+		}
+	}
+}
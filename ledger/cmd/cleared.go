@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/howeyc/ledger/ledger/internal/fastcolor"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+)
+
+type clearedRow struct {
+	Account     string
+	Currency    string
+	Cleared     decimal.Decimal
+	Pending     decimal.Decimal // pending and uncleared postings
+	LastCleared time.Time       // date of the last cleared posting, if any
+}
+
+// clearedReport sums, per account and currency, the cleared and the not yet
+// cleared postings that match the query. Parent accounts include their
+// sub-accounts, as in the balance report.
+func clearedReport(generalLedger []*ledger.Transaction, query *ledger.Query) []clearedRow {
+	type accountKey struct {
+		name     string
+		currency string
+	}
+	rows := make(map[accountKey]*clearedRow)
+	for p := range ledger.Postings(generalLedger) {
+		trans, acc := p.Transaction, p.Posting
+		if !query.Match(trans, acc) {
+			continue
+		}
+		cleared := trans.PostingStatus(acc) == ledger.StatusCleared
+		name := acc.Name
+		for {
+			key := accountKey{name, acc.Currency}
+			row, ok := rows[key]
+			if !ok {
+				row = &clearedRow{Account: name, Currency: acc.Currency}
+				rows[key] = row
+			}
+			if cleared {
+				row.Cleared = row.Cleared.Add(acc.Balance)
+				if trans.Date.After(row.LastCleared) {
+					row.LastCleared = trans.Date
+				}
+			} else {
+				row.Pending = row.Pending.Add(acc.Balance)
+			}
+
+			colIdx := strings.LastIndex(name, ":")
+			if colIdx < 0 {
+				break
+			}
+			name = name[:colIdx]
+		}
+	}
+
+	report := make([]clearedRow, 0, len(rows))
+	for _, row := range rows {
+		report = append(report, *row)
+	}
+	slices.SortFunc(report, func(a, b clearedRow) int {
+		if c := strings.Compare(a.Account, b.Account); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Currency, b.Currency)
+	})
+	return report
+}
+
+// PrintCleared prints the cleared balance, the pending amount and the date of
+// the last cleared posting for each row formatted to a window set to a width
+// of columns. Only shows accounts with names less than or equal to the given
+// depth.
+func PrintCleared(w io.Writer, rows []clearedRow, depth, columns int) {
+	// 3 10-width columns, each with a leading space
+	if columns < 35 {
+		columns = 35
+		fmt.Fprintf(os.Stderr, "warning: `columns` too small, setting to %d\n", columns)
+	}
+	accWidth := columns - (11 * 3)
+
+	colorNeg := fastcolor.CurrentTheme.Negative
+	colorAccount := fastcolor.CurrentTheme.Account
+	colorHeader := fastcolor.CurrentTheme.Header
+	colorReset := fastcolor.Reset
+
+	buf := bufio.NewWriter(w)
+	colorHeader.WriteStringFixed(buf, "Account", accWidth, false)
+	for _, title := range []string{"Cleared", "Pending", "Reconciled"} {
+		buf.WriteString(" ")
+		colorHeader.WriteStringFixed(buf, title, 10, true)
+	}
+	buf.WriteString(newLine)
+
+	for _, row := range rows {
+		if depth >= 0 && strings.Count(row.Account, ":")+1 > depth {
+			continue
+		}
+		colorAccount.WriteStringFixed(buf, row.Account, accWidth, false)
+		for _, amount := range []decimal.Decimal{row.Cleared, row.Pending} {
+			amountColor := colorReset
+			if amount.Sign() < 0 {
+				amountColor = colorNeg
+			}
+			buf.WriteString(" ")
+			amountColor.WriteStringFixed(buf, formatAmount(row.Currency, amount), 10, true)
+		}
+		var lastCleared string
+		if !row.LastCleared.IsZero() {
+			lastCleared = row.LastCleared.Format(transactionDateFormat)
+		}
+		buf.WriteString(" ")
+		colorReset.WriteStringFixed(buf, lastCleared, 10, true)
+		buf.WriteString(newLine)
+	}
+	buf.Flush()
+}
+
+// clearedCmd represents the cleared command
+var clearedCmd = &cobra.Command{
+	Use:   "cleared [query]...",
+	Short: "Print cleared and pending balances of accounts",
+	Long: `Print, for each account, the cleared balance, the amount of the pending and
+uncleared postings, and the date of the last cleared posting.`,
+	Run: func(_ *cobra.Command, args []string) {
+		generalLedger, err := cliTransactions()
+		if err != nil {
+			fatalln(err)
+		}
+		PrintCleared(cliOutput, clearedReport(generalLedger, cliQuery(args)), transactionDepth, columnWidth)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(clearedCmd)
+	watchCommand(clearedCmd)
+
+	var startDate, endDate time.Time
+	startDate = time.Date(1970, 1, 1, 0, 0, 0, 0, time.Local)
+	endDate = time.Now().Add(1<<63 - 1)
+	clearedCmd.Flags().StringVarP(&startString, "begin-date", "b", startDate.Format(transactionDateFormat), "Begin date of transaction processing.")
+	clearedCmd.Flags().StringVarP(&endString, "end-date", "e", endDate.Format(transactionDateFormat), "End date of transaction processing.")
+	clearedCmd.Flags().StringVar(&payeeFilter, "payee", "", "Filter output to payees that contain this string.")
+	completeLedgerCommand(clearedCmd)
+	clearedCmd.Flags().IntVar(&columnWidth, "columns", 80, "Set a column width for output.")
+	clearedCmd.Flags().BoolVar(&columnWide, "wide", false, "Wide output (use terminal width).")
+
+	clearedCmd.Flags().IntVar(&transactionDepth, "depth", -1, "Depth of accounts shown.")
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/shopspring/decimal"
+)
+
+func Test_clearedReport(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+	generalLedger := []*ledger.Transaction{
+		{Date: day(1), Status: ledger.StatusCleared, Payee: "Paycheck", AccountChanges: []ledger.Account{
+			{Name: "Assets:Bank:Checking", Balance: decimal.NewFromInt(1000)},
+			{Name: "Income:Salary", Balance: decimal.NewFromInt(-1000)},
+		}},
+		{Date: day(5), Payee: "Rent", AccountChanges: []ledger.Account{
+			{Name: "Expenses:Rent", Balance: decimal.NewFromInt(600)},
+			{Name: "Assets:Bank:Checking", Balance: decimal.NewFromInt(-600), Status: ledger.StatusCleared},
+		}},
+		{Date: day(9), Status: ledger.StatusPending, Payee: "Grocer", AccountChanges: []ledger.Account{
+			{Name: "Expenses:Food", Balance: decimal.NewFromInt(50)},
+			{Name: "Assets:Bank:Savings", Balance: decimal.NewFromInt(-50)},
+		}},
+	}
+
+	want := []clearedRow{
+		{Account: "Assets", Cleared: decimal.NewFromInt(400), Pending: decimal.NewFromInt(-50), LastCleared: day(5)},
+		{Account: "Assets:Bank", Cleared: decimal.NewFromInt(400), Pending: decimal.NewFromInt(-50), LastCleared: day(5)},
+		{Account: "Assets:Bank:Checking", Cleared: decimal.NewFromInt(400), Pending: decimal.Zero, LastCleared: day(5)},
+		{Account: "Assets:Bank:Savings", Cleared: decimal.Zero, Pending: decimal.NewFromInt(-50)},
+	}
+	rows := clearedReport(generalLedger, mustParseQuery(t, "Assets"))
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(rows), len(want))
+	}
+	for i := range rows {
+		if rows[i].Account != want[i].Account || !rows[i].Cleared.Equal(want[i].Cleared) ||
+			!rows[i].Pending.Equal(want[i].Pending) || !rows[i].LastCleared.Equal(want[i].LastCleared) {
+			t.Errorf("row %d = %+v, want %+v", i, rows[i], want[i])
+		}
+	}
+}
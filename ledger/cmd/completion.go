@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/howeyc/ledger"
+	"github.com/spf13/cobra"
+)
+
+// completionLedger parses the ledger file for shell completion. Completion
+// offers nothing when the ledger is read from stdin or fails to parse.
+func completionLedger() []*ledger.Transaction {
+	if ledgerFilePath == "" || ledgerFilePath == "-" {
+		return nil
+	}
+	generalLedger, err := ledger.ParseLedgerFile(ledgerFilePath)
+	if err != nil {
+		return nil
+	}
+	return generalLedger
+}
+
+// completeAccounts completes query arguments with the account names, parent
+// accounts included, of the ledger file, those declared by an account
+// directive as well as those used.
+func completeAccounts(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if ledgerFilePath == "" || ledgerFilePath == "-" {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	registry, err := ledger.ParseAccountRegistry(ledgerFilePath)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, info := range registry.Accounts() {
+		for name := info.Name; name != ""; name = name[:max(strings.LastIndex(name, ":"), 0)] {
+			if strings.HasPrefix(name, toComplete) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return slices.Compact(names), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completePayees completes the --payee flag with the payees of the ledger file.
+func completePayees(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var payees []string
+	for _, trans := range completionLedger() {
+		if strings.HasPrefix(trans.Payee, toComplete) {
+			payees = append(payees, trans.Payee)
+		}
+	}
+	slices.Sort(payees)
+	return slices.Compact(payees), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeLedgerCommand completes the query arguments of a report command
+// with account names and its --payee flag with payees.
+func completeLedgerCommand(cmd *cobra.Command) {
+	cmd.ValidArgsFunction = completeAccounts
+	cmd.RegisterFlagCompletionFunc("payee", completePayees)
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func Test_completion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.dat")
+	content := `account Expenses:Fees
+    note Bank charges
+
+2024/01/01 Grocery Store
+    Expenses:Food:Groceries    10
+    Assets:Cash
+
+2024/01/02 Gas Station
+    Expenses:Auto:Fuel    20
+    Assets:Cash
+
+2024/01/03 Grocery Store
+    Expenses:Food:Groceries    5
+    Assets:Cash
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	saved := ledgerFilePath
+	defer func() { ledgerFilePath = saved }()
+	ledgerFilePath = path
+
+	accounts, _ := completeAccounts(nil, nil, "Expenses:F")
+	if want := []string{"Expenses:Fees", "Expenses:Food", "Expenses:Food:Groceries"}; !slices.Equal(accounts, want) {
+		t.Errorf("accounts: got %v, want %v", accounts, want)
+	}
+	payees, _ := completePayees(nil, nil, "")
+	if want := []string{"Gas Station", "Grocery Store"}; !slices.Equal(payees, want) {
+		t.Errorf("payees: got %v, want %v", payees, want)
+	}
+}
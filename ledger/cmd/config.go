@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/howeyc/ledger"
+	"github.com/howeyc/ledger/ledger/internal/fastcolor"
+	"github.com/pelletier/go-toml"
+	"github.com/spf13/cobra"
+)
+
+// ledgerConfig holds the defaults read from the configuration files. Flags
+// given on the command line take precedence.
+type ledgerConfig struct {
+	File       string      `toml:"file"`
+	DateFormat string      `toml:"date_format"`
+	Columns    int         `toml:"columns"`
+	Wide       bool        `toml:"wide"`
+	Currency   string      `toml:"currency"`
+	PriceDB    string      `toml:"price_db"`
+	Color      string      `toml:"color"` // auto, always, never
+	Locale     string      `toml:"locale"`
+	Theme      themeConfig `toml:"theme"`
+	// MaxLineLength is the longest line, in bytes, of the ledger files read
+	// line by line by fmt, sort, split and merge.
+	MaxLineLength int `toml:"max_line_length"`
+
+	Commodities map[string]commodityConfig `toml:"commodity"`
+	// Signs holds the sign amounts of accounts normally have for
+	// check --signs: positive, negative or any.
+	Signs map[string]string `toml:"signs"`
+	// Auth holds the users of the web and serve commands.
+	Auth authConfig `toml:"auth"`
+}
+
+// commodityConfig holds the configured format of amounts of a commodity.
+type commodityConfig struct {
+	Symbol    string `toml:"symbol"`
+	Suffix    bool   `toml:"suffix"`
+	Thousands string `toml:"thousands"`
+	Decimal   string `toml:"decimal"`
+	// Places defaults to the decimal places of the amounts of the ledger
+	// when left out.
+	Places *int32 `toml:"places"`
+}
+
+// format returns the commodity format of the configuration, with places
+// unless configured.
+func (c commodityConfig) format(places int32) ledger.CommodityFormat {
+	f := ledger.CommodityFormat{
+		Symbol:    c.Symbol,
+		Suffix:    c.Suffix,
+		Thousands: c.Thousands,
+		Decimal:   c.Decimal,
+		Places:    places,
+	}
+	if c.Places != nil {
+		f.Places = *c.Places
+	}
+	return f
+}
+
+// themeConfig holds the configured colors of report roles, each parsed by
+// fastcolor.ParseColor.
+type themeConfig struct {
+	Negative string `toml:"negative"`
+	Positive string `toml:"positive"`
+	Account  string `toml:"account"`
+	Payee    string `toml:"payee"`
+	Header   string `toml:"header"`
+}
+
+// theme returns the default theme with the configured colors applied.
+func (t themeConfig) theme() (fastcolor.Theme, error) {
+	theme := fastcolor.DefaultTheme
+	roles := map[string]string{
+		"negative": t.Negative,
+		"positive": t.Positive,
+		"account":  t.Account,
+		"payee":    t.Payee,
+		"header":   t.Header,
+	}
+	for role, color := range roles {
+		if color == "" {
+			continue
+		}
+		if err := theme.SetRole(role, color); err != nil {
+			return theme, err
+		}
+	}
+	return theme, nil
+}
+
+var cliConfig ledgerConfig
+
+// configFiles returns the configuration files in the order they are read, so
+// the .ledger.toml of the current directory overrides ~/.ledgerrc.
+func configFiles() []string {
+	var files []string
+	if home, err := os.UserHomeDir(); err == nil {
+		files = append(files, filepath.Join(home, ".ledgerrc"))
+	}
+	return append(files, ".ledger.toml")
+}
+
+// loadConfig reads the configuration files that exist into a ledgerConfig.
+// Paths in a file are relative to the directory of that file, and may start
+// with ~ for the home directory.
+func loadConfig(filenames []string) (ledgerConfig, error) {
+	var config ledgerConfig
+	for _, filename := range filenames {
+		src, err := os.ReadFile(filename)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return config, err
+		}
+
+		var fileConfig ledgerConfig
+		if err := toml.Unmarshal(src, &fileConfig); err != nil {
+			return config, fmt.Errorf("%s: %w", filename, err)
+		}
+		switch fileConfig.Color {
+		case "", "auto", "always", "never":
+		default:
+			return config, fmt.Errorf("%s: color must be auto, always or never, not %q", filename, fileConfig.Color)
+		}
+		if _, ok := ledger.LookupLocale(fileConfig.Locale); fileConfig.Locale != "" && !ok {
+			return config, fmt.Errorf("%s: unknown locale %q", filename, fileConfig.Locale)
+		}
+		for name, c := range fileConfig.Commodities {
+			if c.Places != nil && (*c.Places < 0 || *c.Places > 18) {
+				return config, fmt.Errorf("%s: commodity %s: places must be between 0 and 18", filename, name)
+			}
+		}
+		for name, sign := range fileConfig.Signs {
+			if _, ok := accountSigns[sign]; !ok {
+				return config, fmt.Errorf("%s: signs: %s must be positive, negative or any, not %q", filename, name, sign)
+			}
+		}
+		if fileConfig.MaxLineLength < 0 {
+			return config, fmt.Errorf("%s: max_line_length must not be negative", filename)
+		}
+		if err := fileConfig.Auth.check(); err != nil {
+			return config, fmt.Errorf("%s: auth: %w", filename, err)
+		}
+		if _, err := fileConfig.Theme.theme(); err != nil {
+			return config, fmt.Errorf("%s: theme: %w", filename, err)
+		}
+
+		// keys the file leaves out keep the values of earlier files
+		if err := toml.Unmarshal(src, &config); err != nil {
+			return config, fmt.Errorf("%s: %w", filename, err)
+		}
+		if fileConfig.File != "" {
+			config.File = configPath(filename, fileConfig.File)
+		}
+		if fileConfig.PriceDB != "" {
+			config.PriceDB = configPath(filename, fileConfig.PriceDB)
+		}
+	}
+	return config, nil
+}
+
+// configPath resolves a path given in the configuration file filename.
+func configPath(filename, path string) string {
+	if path == "" || path == "-" {
+		return path
+	}
+	if after, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, after)
+		}
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(filename), path)
+}
+
+// applyConfig sets the flags of cmd that were not given on the command line
+// to the configured defaults. The ledger file from $LEDGER_FILE takes
+// precedence over the configured one.
+func applyConfig(cmd *cobra.Command, config ledgerConfig) {
+	set := func(name, value string) {
+		if f := cmd.Flag(name); f != nil && !f.Changed && value != "" {
+			f.Value.Set(value)
+		}
+	}
+	if os.Getenv("LEDGER_FILE") == "" {
+		set("file", config.File)
+	}
+	if config.Columns > 0 {
+		set("columns", strconv.Itoa(config.Columns))
+	}
+	if config.Wide {
+		set("wide", "true")
+	}
+	set("exchange", config.Currency)
+	set("price-db", config.PriceDB)
+
+	if config.DateFormat != "" {
+		transactionDateFormat = config.DateFormat
+	}
+	set("locale", config.Locale)
+	reportLocale = nil
+	if locale, ok := ledger.LookupLocale(localeName); ok {
+		reportLocale = &locale
+	}
+	commodityConfigs = config.Commodities
+	setCommodityFormats(nil)
+	checkSignConfig = config.Signs
+	webAuthConfig = config.Auth
+	if config.MaxLineLength > 0 {
+		maxLineLength = config.MaxLineLength
+	}
+	// checked by loadConfig
+	fastcolor.CurrentTheme, _ = config.Theme.theme()
+	switch config.Color {
+	case "always":
+		fastcolor.NoColor = false
+	case "never":
+		fastcolor.NoColor = true
+	}
+}
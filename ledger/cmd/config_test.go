@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func Test_loadConfig(t *testing.T) {
+	dir := t.TempDir()
+	home := filepath.Join(dir, ".ledgerrc")
+	local := filepath.Join(dir, "project", ".ledger.toml")
+	if err := os.MkdirAll(filepath.Dir(local), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(home, []byte("file = \"ledger.dat\"\ncolumns = 100\ncurrency = \"USD\"\n\n[theme]\naccount = \"cyan\"\nheader = \"bold\"\n\n[commodity.JPY]\nsymbol = \"¥\"\nthousands = \",\"\nplaces = 0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(local, []byte("currency = \"CAD\"\nprice_db = \"/prices.dat\"\ncolor = \"never\"\n\n[theme]\nnegative = \"#d70000\"\nheader = \"underline\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := loadConfig([]string{home, local, filepath.Join(dir, "missing.toml")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ledgerConfig{
+		File:     filepath.Join(dir, "ledger.dat"),
+		Columns:  100,
+		Currency: "CAD",
+		PriceDB:  "/prices.dat",
+		Color:    "never",
+		Theme: themeConfig{
+			Negative: "#d70000",
+			Account:  "cyan",
+			Header:   "underline",
+		},
+		Commodities: map[string]commodityConfig{
+			"JPY": {Symbol: "¥", Thousands: ",", Places: new(int32)},
+		},
+	}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("got %+v, want %+v", config, want)
+	}
+
+	if err := os.WriteFile(local, []byte("color = \"sometimes\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig([]string{local}); err == nil {
+		t.Error("expected error for unknown color")
+	}
+	if err := os.WriteFile(local, []byte("[theme]\naccount = \"purple\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig([]string{local}); err == nil {
+		t.Error("expected error for unknown theme color")
+	}
+	if err := os.WriteFile(local, []byte("[commodity.BTC]\nplaces = -1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig([]string{local}); err == nil {
+		t.Error("expected error for negative places")
+	}
+	if err := os.WriteFile(local, []byte("locale = \"xx_XX\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig([]string{local}); err == nil {
+		t.Error("expected error for unknown locale")
+	}
+	if err := os.WriteFile(local, []byte("max_line_length = -1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig([]string{local}); err == nil {
+		t.Error("expected error for negative max_line_length")
+	}
+	if err := os.WriteFile(local, []byte("[signs]\nExpenses = \"up\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig([]string{local}); err == nil {
+		t.Error("expected error for unknown sign")
+	}
+	if err := os.WriteFile(local, []byte("[auth.users.alice]\nrole = \"admin\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig([]string{local}); err == nil {
+		t.Error("expected error for unknown role")
+	}
+	if err := os.WriteFile(local, []byte("[auth.oidc]\nissuer = \"http://id.example.com\"\nclient_id = \"ledger\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig([]string{local}); err == nil {
+		t.Error("expected error for insecure oidc issuer")
+	}
+}
+
+func Test_applyConfig(t *testing.T) {
+	var columns int
+	var currency string
+	cmd := &cobra.Command{Use: "test"}
+	cmd.Flags().IntVar(&columns, "columns", 80, "")
+	cmd.Flags().StringVar(&currency, "exchange", "", "")
+	if err := cmd.ParseFlags([]string{"--exchange", "EUR"}); err != nil {
+		t.Fatal(err)
+	}
+
+	applyConfig(cmd, ledgerConfig{Columns: 120, Currency: "USD"})
+	if columns != 120 {
+		t.Errorf("columns: got %d, want 120", columns)
+	}
+	if currency != "EUR" {
+		t.Errorf("exchange: got %q, want the command-line EUR", currency)
+	}
+
+	applyConfig(cmd, ledgerConfig{Signs: map[string]string{"Liabilities": "negative", "Expenses:Refunds": "any"}})
+	if got, want := cliSigns(), map[string]int{"Liabilities": -1, "Expenses:Refunds": 0}; !maps.Equal(got, want) {
+		t.Errorf("signs: got %v, want %v", got, want)
+	}
+	applyConfig(cmd, ledgerConfig{})
+	if got, want := cliSigns(), map[string]int{"Expenses": 1, "Income": -1}; !maps.Equal(got, want) {
+		t.Errorf("default signs: got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+)
+
+var convertFrom string
+var convertAccount string
+
+// newConverter returns an importer of filename that writes the transactions
+// to output without a ledger: every transaction is kept, posted against
+// account, and balanced by unknown:unknown.
+func newConverter(account, filename string, output io.StringWriter) (*Importer, error) {
+	fileReader, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	payeeRules, err := loadPayeeRules()
+	if err != nil {
+		fileReader.Close()
+		return nil, err
+	}
+	return &Importer{
+		filename:        filename,
+		reader:          fileReader,
+		decScale:        decimal.NewFromFloat(scaleFactor),
+		matchingAccount: account,
+		output:          output,
+		payeeRules:      payeeRules,
+	}, nil
+}
+
+// convertCmd represents the convert command
+var convertCmd = &cobra.Command{
+	Use:   "convert <input-file> [output-file]",
+	Args:  cobra.RangeArgs(1, 2),
+	Short: "Convert transactions from another format to ledger format",
+	Long: `Convert the transactions of a csv, qif, qfx/ofx, camt (xml), iif, mt940 or
+json file to ledger format, written to the output file or standard output.
+
+Unlike import, convert neither reads the ledger file nor guesses accounts: every
+transaction is kept, posted against --account and balanced by unknown:unknown.
+The format is taken from the extension of the input file, unless set by --from.
+A csv file with the header of a PayPal activity download, a Stripe balance
+report, a Coinbase transaction history or a Kraken trades export is read as
+one.`,
+	Run: func(_ *cobra.Command, args []string) {
+		format := convertFrom
+		if format == "" {
+			format = importFormat(args[0])
+		}
+
+		var output io.StringWriter = os.Stdout
+		if len(args) > 1 {
+			outFile, err := os.Create(args[1])
+			if err != nil {
+				fatalln(err)
+			}
+			defer outFile.Close()
+			output = outFile
+		}
+
+		conv, err := newConverter(convertAccount, args[0], output)
+		if err != nil {
+			fatalln(err)
+		}
+		defer conv.Close()
+
+		if err := conv.importFile(format); err != nil {
+			fatalln(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(convertCmd)
+
+	convertCmd.Flags().StringVar(&convertFrom, "from", "", "Format of the input file (csv, qif, qfx, ofx, camt, iif, mt940, json, paypal, stripe, coinbase, kraken).")
+	convertCmd.Flags().StringVar(&convertAccount, "account", "Assets:Unknown", "Account of the converted transactions.")
+	convertCmd.Flags().BoolVar(&negateAmount, "neg", false, "Negate amount column value.")
+	convertCmd.Flags().Float64Var(&scaleFactor, "scale", 1.0, "Scale factor to multiply against every converted amount.")
+	convertCmd.Flags().StringVar(&csvDateFormat, "date-format", "01/02/2006", "Date format.")
+	convertCmd.Flags().StringVar(&fieldDelimiter, "delimiter", ",", "Field delimiter.")
+	convertCmd.Flags().StringVar(&overrideCurrency, "override-currency", "", "Currency of converted transactions when the file states none.")
+	convertCmd.Flags().BoolVar(&csvNoHeader, "no-header", false, "The csv file has no header, its columns are given with --fields.")
+	convertCmd.Flags().StringVar(&csvFields, "fields", "", "Comma separated names of the csv columns in order, such as \"date,payee,,amount\",\nin place of the header.")
+	convertCmd.Flags().StringVar(&importPayeeRules, "payee-rules", "", "Rules file of regular expressions and replacements cleaning up payees.")
+	convertCmd.Flags().StringVar(&jsonMappingFile, "json-mapping", "", "Mapping file selecting the transactions and their fields of a json file.")
+	convertCmd.Flags().StringVar(&paypalFundingAccount, "paypal-funding", "unknown:funding", "Account of the bank account or card funding PayPal payments.")
+	convertCmd.Flags().StringVar(&stripeFeeAccount, "stripe-fees", "Expenses:Fees", "Account of the fees of Stripe.")
+	convertCmd.Flags().StringVar(&stripePayoutAccount, "stripe-payout", "unknown:payout", "Account of the bank account Stripe pays out to.")
+	convertCmd.Flags().StringVar(&stripeRevenueAccount, "stripe-revenue", "Income:Sales", "Account of the charges and refunds of Stripe.")
+	convertCmd.Flags().StringVar(&csvRulesFile, "rules", "", "Rules file describing the columns and accounts of the csv file.")
+}
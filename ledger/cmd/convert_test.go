@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_importFormat(t *testing.T) {
+	tests := map[string]string{
+		"bank.CSV":       "csv",
+		"bank.txt":       "csv",
+		"bank.qif":       "qif",
+		"bank.QFX":       "qfx",
+		"bank.ofx":       "qfx",
+		"statement.xml":  "camt",
+		"quickbooks.iif": "iif",
+		"statement.sta":  "mt940",
+		"report.942":     "mt940",
+	}
+	for filename, want := range tests {
+		if got := importFormat(filename); got != want {
+			t.Errorf("importFormat(%q) = %q, want %q", filename, got, want)
+		}
+	}
+}
+
+func Test_convertCSV(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "bank.csv")
+	csvData := "Date,Description,Amount\n01/02/2024,Grocer,50\n01/03/2024,Grocer,50\n"
+	if err := os.WriteFile(filename, []byte(csvData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf strings.Builder
+	conv, err := newConverter("Assets:Checking", filename, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conv.Close()
+	if err := conv.importFile("csv"); err != nil {
+		t.Fatal(err)
+	}
+
+	// both transactions are kept, nothing is matched against a ledger
+	got := buf.String()
+	if strings.Count(got, "Grocer") != 2 {
+		t.Errorf("got %q, want two transactions", got)
+	}
+	for _, want := range []string{"2024/01/02 Grocer", "Assets:Checking", "unknown:unknown"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("got %q, want it to contain %q", got, want)
+		}
+	}
+
+	if err := conv.importFile("pdf"); err == nil {
+		t.Error("importFile(pdf) succeeded unexpectedly")
+	}
+}
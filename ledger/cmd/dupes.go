@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/spf13/cobra"
+)
+
+var dupesWindow int
+
+// dupeKey identifies transactions that look alike: the payee, ignoring case
+// and spacing, and the amounts of the postings, ignoring their accounts since
+// imports may book the same payment to different accounts.
+func dupeKey(trans *ledger.Transaction) string {
+	amounts := make([]string, len(trans.AccountChanges))
+	for i, acc := range trans.AccountChanges {
+		amounts[i] = acc.Currency + " " + acc.Balance.String()
+	}
+	slices.Sort(amounts)
+	payee := strings.ToLower(strings.Join(strings.Fields(trans.Payee), " "))
+	return payee + "\x00" + strings.Join(amounts, "\x00")
+}
+
+// dupeGroups returns the groups of probable duplicates in generalLedger, which
+// must be sorted by date: transactions with the same key (see dupeKey) whose
+// dates are at most window days apart from the one before. Groups are ordered
+// by the date of their first transaction.
+func dupeGroups(generalLedger []*ledger.Transaction, window int) [][]*ledger.Transaction {
+	byKey := make(map[string][]*ledger.Transaction)
+	var keys []string
+	for _, trans := range generalLedger {
+		key := dupeKey(trans)
+		if _, ok := byKey[key]; !ok {
+			keys = append(keys, key)
+		}
+		byKey[key] = append(byKey[key], trans)
+	}
+
+	var groups [][]*ledger.Transaction
+	for _, key := range keys {
+		similar := byKey[key]
+		start := 0
+		for i := 1; i <= len(similar); i++ {
+			if i < len(similar) && !similar[i].Date.After(similar[i-1].Date.AddDate(0, 0, window)) {
+				continue
+			}
+			if i-start > 1 {
+				groups = append(groups, similar[start:i])
+			}
+			start = i
+		}
+	}
+	slices.SortStableFunc(groups, func(a, b []*ledger.Transaction) int {
+		return a[0].Date.Compare(b[0].Date)
+	})
+	return groups
+}
+
+// PrintDupes prints each group of probable duplicates, a line per transaction
+// with its file and line, and groups separated by a blank line.
+func PrintDupes(w io.Writer, groups [][]*ledger.Transaction) {
+	buf := bufio.NewWriter(w)
+	for gIdx, group := range groups {
+		if gIdx > 0 {
+			buf.WriteString(newLine)
+		}
+		for _, trans := range group {
+			fmt.Fprintf(buf, "%s:%d: %s %s %s%s", trans.Filename, trans.Line,
+				trans.Date.Format(transactionDateFormat), trans.Payee,
+				transactionSize(trans).StringFixedBank(2), newLine)
+		}
+	}
+	buf.Flush()
+}
+
+// dupesCmd represents the dupes command
+var dupesCmd = &cobra.Command{
+	Use:   "dupes [query]...",
+	Short: "List probable duplicate transactions",
+	Long: `List transactions that are probably duplicates, such as the same bank
+transaction imported twice: transactions with the same payee and amounts, on the
+same day or, with --window, within that many days of each other. Each is listed
+with its file and line.`,
+	Run: func(_ *cobra.Command, args []string) {
+		if dupesWindow < 0 {
+			fatalln("--window must not be negative")
+		}
+		generalLedger, err := cliTransactions()
+		if err != nil {
+			fatalln(err)
+		}
+		query := cliQuery(args)
+		var matched []*ledger.Transaction
+		for _, trans := range generalLedger {
+			if query.MatchTransaction(trans) {
+				matched = append(matched, trans)
+			}
+		}
+		PrintDupes(cliOutput, dupeGroups(matched, dupesWindow))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dupesCmd)
+
+	var startDate, endDate time.Time
+	startDate = time.Date(1970, 1, 1, 0, 0, 0, 0, time.Local)
+	endDate = time.Now().Add(1<<63 - 1)
+	dupesCmd.Flags().StringVarP(&startString, "begin-date", "b", startDate.Format(transactionDateFormat), "Begin date of transaction processing.")
+	dupesCmd.Flags().StringVarP(&endString, "end-date", "e", endDate.Format(transactionDateFormat), "End date of transaction processing.")
+	dupesCmd.Flags().StringVar(&payeeFilter, "payee", "", "Filter output to payees that contain this string.")
+	completeLedgerCommand(dupesCmd)
+
+	dupesCmd.Flags().IntVar(&dupesWindow, "window", 0, "Days apart transactions may be and still be duplicates.")
+}
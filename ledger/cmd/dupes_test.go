@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/shopspring/decimal"
+)
+
+func Test_dupeGroups(t *testing.T) {
+	trans := func(line, d int, payee, account, amount string) *ledger.Transaction {
+		return &ledger.Transaction{
+			Line:  line,
+			Date:  time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC),
+			Payee: payee,
+			AccountChanges: []ledger.Account{
+				{Name: account, Balance: decimal.RequireFromString(amount)},
+				{Name: "Assets:Checking", Balance: decimal.RequireFromString(amount).Neg()},
+			},
+		}
+	}
+	generalLedger := []*ledger.Transaction{
+		trans(1, 5, "Grocer", "Expenses:Food", "50.00"),
+		trans(2, 5, "GROCER ", "Expenses:Misc", "50"),
+		trans(3, 6, "Bakery", "Expenses:Food", "4.50"),
+		trans(4, 7, "Grocer", "Expenses:Food", "50.00"),
+		trans(5, 7, "Grocer", "Expenses:Food", "51.00"),
+		trans(6, 20, "Grocer", "Expenses:Food", "50.00"),
+	}
+
+	tests := []struct {
+		window int
+		want   [][]int
+	}{
+		{0, [][]int{{1, 2}}},
+		{2, [][]int{{1, 2, 4}}},
+		{13, [][]int{{1, 2, 4, 6}}},
+	}
+	for _, tt := range tests {
+		groups := dupeGroups(generalLedger, tt.window)
+		var got [][]int
+		for _, group := range groups {
+			var lines []int
+			for _, trans := range group {
+				lines = append(lines, trans.Line)
+			}
+			got = append(got, lines)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("window %d: got %v, want %v", tt.window, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if !slices.Equal(got[i], tt.want[i]) {
+				t.Errorf("window %d: got %v, want %v", tt.window, got, tt.want)
+			}
+		}
+	}
+}
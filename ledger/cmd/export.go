@@ -1,28 +1,48 @@
 package cmd
 
 import (
-	"log"
+	"strings"
 	"time"
 
+	"github.com/howeyc/ledger"
 	"github.com/spf13/cobra"
 )
 
 // exportCmd represents the export command
 var exportCmd = &cobra.Command{
 	Aliases: []string{"exp"},
-	Use:     "export [account-substring-filter]...",
+	Use:     "export [query]...",
 	Short:   "export to CSV",
 	Run: func(_ *cobra.Command, args []string) {
 		generalLedger, err := cliTransactions()
 		if err != nil {
-			log.Fatalln(err)
+			fatalln(err)
+		}
+		query := cliQuery(args)
+		if period == "" {
+			PrintCSV(cliOutput, generalLedger, query)
+		} else {
+			lperiod := ledger.Period(strings.Title(period))
+			rtrans := ledger.TransactionsByPeriod(generalLedger, lperiod)
+			var lastEnd time.Time
+			for _, rt := range rtrans {
+				if len(rt.Transactions) < 1 {
+					continue
+				}
+				PrintCSV(cliOutput, rt.Transactions, query)
+				PrintCSVTotal(cliOutput, rt.Transactions, query, "Subtotal "+rt.Start.Format(transactionDateFormat)+" - "+rt.End.Format(transactionDateFormat), rt.End)
+				lastEnd = rt.End
+			}
+			if !lastEnd.IsZero() {
+				PrintCSVTotal(cliOutput, generalLedger, query, "Total", lastEnd)
+			}
 		}
-		PrintCSV(generalLedger, args)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(exportCmd)
+	watchCommand(exportCmd)
 
 	var startDate, endDate time.Time
 	startDate = time.Date(1970, 1, 1, 0, 0, 0, 0, time.Local)
@@ -31,4 +51,5 @@ func init() {
 	exportCmd.Flags().StringVarP(&endString, "end-date", "e", endDate.Format(transactionDateFormat), "End date of transaction processing.")
 	exportCmd.Flags().StringVar(&payeeFilter, "payee", "", "Filter output to payees that contain this string.")
 	exportCmd.Flags().StringVar(&fieldDelimiter, "delimiter", ",", "Field delimiter.")
+	exportCmd.Flags().StringVar(&period, "period", "", "Split output into periods (Weekly,Monthly,Quarterly,SemiYearly,Yearly) with subtotals.")
 }
@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	date "github.com/joyt/godate"
+	"github.com/spf13/cobra"
+)
+
+var fmtCheck bool
+
+var postingSplitRegex = regexp.MustCompile(`^(.+?)(?:\s{2,}|\t)\s*(.+)$`)
+
+// ledgerFormatter rewrites ledger text line by line. Transactions and
+// periodic transactions are reformatted, everything else (directives and
+// comments between transactions) is kept as is.
+type ledgerFormatter struct {
+	dateParser
+
+	w       *bufio.Writer
+	columns int
+
+	inBlock   bool
+	blankLine bool
+}
+
+// dateParser parses transaction dates, remembering the layout of the last
+// date parsed as the next date most likely has the same layout.
+type dateParser struct {
+	layout string
+}
+
+// formatLedger writes the ledger read from r to w in canonical style: dates
+// as YYYY/MM/DD, postings indented by four spaces with amounts right-aligned
+// to columns, and single blank lines between transactions.
+func formatLedger(w io.Writer, r io.Reader, columns int) error {
+	if len(spaceStr) < columns {
+		spaceStr = strings.Repeat(" ", columns)
+	}
+
+	f := ledgerFormatter{w: bufio.NewWriter(w), columns: columns}
+	scanner := newLineScanner(r)
+	lineNum := 0
+	wroteAny := false
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRightFunc(scanner.Text(), func(r rune) bool {
+			return r == ' ' || r == '\t' || r == '\r'
+		})
+
+		if len(strings.TrimSpace(line)) == 0 {
+			f.inBlock = false
+			f.blankLine = wroteAny
+			continue
+		}
+		if f.blankLine {
+			f.w.WriteString(newLine)
+			f.blankLine = false
+		}
+		wroteAny = true
+
+		if f.inBlock {
+			f.writePosting(strings.TrimSpace(line))
+			continue
+		}
+
+		if err := f.writeLine(line); err != nil {
+			return fmt.Errorf("line %d: %w", lineNum, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return scanError(err, lineNum)
+	}
+	return f.w.Flush()
+}
+
+// writeLine writes a line outside of any transaction, starting a new
+// transaction if the line is a transaction header.
+func (f *ledgerFormatter) writeLine(line string) error {
+	if line[0] == ' ' || line[0] == '\t' || line[0] == ';' || line[0] == '#' {
+		f.w.WriteString(line)
+		f.w.WriteString(newLine)
+		return nil
+	}
+
+	content, comment := splitComment(line)
+	before, after, _ := strings.Cut(content, " ")
+	switch before {
+	case "account", "include", "P", "range":
+		f.w.WriteString(line)
+		f.w.WriteString(newLine)
+		return nil
+	case "~":
+		f.w.WriteString("~ ")
+		f.w.WriteString(strings.TrimSpace(after))
+		if comment != "" {
+			f.w.WriteString(spaceStr[:2])
+			f.w.WriteString(comment)
+		}
+		f.w.WriteString(newLine)
+		f.inBlock = true
+		return nil
+	}
+
+	transDate, err := f.parseDate(before)
+	if err != nil {
+		return err
+	}
+	payee := strings.TrimSpace(after)
+	f.w.WriteString(transDate.Format(transactionDateFormat))
+	f.w.WriteString(spaceStr[:1])
+	f.w.WriteString(payee)
+	if comment != "" {
+		spaceCount := f.columns - 10 - utf8.RuneCountInString(payee)
+		if spaceCount < 1 {
+			spaceCount = 1
+		}
+		f.w.WriteString(spaceStr[:spaceCount])
+		f.w.WriteString(comment)
+	}
+	f.w.WriteString(newLine)
+	f.inBlock = true
+	return nil
+}
+
+// writePosting writes a posting (or comment) line of a transaction.
+func (f *ledgerFormatter) writePosting(line string) {
+	content, comment := splitComment(line)
+	f.w.WriteString(spaceStr[:4])
+	if content == "" {
+		f.w.WriteString(comment)
+		f.w.WriteString(newLine)
+		return
+	}
+
+	name, amount := content, ""
+	if m := postingSplitRegex.FindStringSubmatch(content); m != nil {
+		name = m[1]
+		amount = strings.Join(strings.Fields(m[2]), " ")
+	}
+	f.w.WriteString(name)
+	if amount != "" {
+		spaceCount := f.columns - 4 - utf8.RuneCountInString(name) - utf8.RuneCountInString(amount)
+		if spaceCount < 2 {
+			spaceCount = 2
+		}
+		f.w.WriteString(spaceStr[:spaceCount])
+		f.w.WriteString(amount)
+	}
+	if comment != "" {
+		f.w.WriteString(spaceStr[:1])
+		f.w.WriteString(comment)
+	}
+	f.w.WriteString(newLine)
+}
+
+func (dp *dateParser) parseDate(dateString string) (transDate time.Time, err error) {
+	if dp.layout != "" {
+		if transDate, err = time.Parse(dp.layout, dateString); err == nil {
+			return
+		}
+	}
+	transDate, dp.layout, err = date.ParseAndGetLayout(dateString)
+	if err != nil {
+		err = fmt.Errorf("unable to parse date(%s): %w", dateString, err)
+	}
+	return
+}
+
+// splitComment splits a line into its content and comment (starting at ";"),
+// both with surrounding space removed.
+func splitComment(line string) (content, comment string) {
+	content = line
+	if commentIdx := strings.Index(line, ";"); commentIdx >= 0 {
+		comment = strings.TrimSpace(line[commentIdx:])
+		content = line[:commentIdx]
+	}
+	return strings.TrimSpace(content), comment
+}
+
+// rewriteLedgerFile runs rewrite over the contents of filename and, unless
+// dryRun is set, replaces the file with the result if it differs. A filename
+// of "-" rewrites standard input to standard output.
+func rewriteLedgerFile(filename string, dryRun bool, rewrite func(w io.Writer, r io.Reader) error) (changed bool, err error) {
+	if filename == "-" {
+		return false, rewrite(os.Stdout, os.Stdin)
+	}
+
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return false, err
+	}
+	var out bytes.Buffer
+	if err := rewrite(&out, bytes.NewReader(src)); err != nil {
+		return false, fmt.Errorf("%s: %w", filename, err)
+	}
+	if bytes.Equal(src, out.Bytes()) {
+		return false, nil
+	}
+	if dryRun {
+		return true, nil
+	}
+
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return true, err
+	}
+	return true, os.WriteFile(filename, out.Bytes(), fi.Mode())
+}
+
+// fmtCmd represents the fmt command
+var fmtCmd = &cobra.Command{
+	Use:   "fmt [file]...",
+	Short: "Rewrite ledger files in canonical format",
+	Long: `Rewrite ledger files in canonical format: dates as YYYY/MM/DD,
+postings indented by four spaces with amounts aligned, and single blank
+lines between transactions. Directives and comments are kept as is.
+
+Formats the ledger file if no files are given.`,
+	Run: func(_ *cobra.Command, args []string) {
+		if len(args) == 0 {
+			args = []string{ledgerFilePath}
+		}
+
+		changed := false
+		for _, filename := range args {
+			fileChanged, err := rewriteLedgerFile(filename, fmtCheck, func(w io.Writer, r io.Reader) error {
+				return formatLedger(w, r, columnWidth)
+			})
+			if err != nil {
+				log.Fatalln(err)
+			}
+			if fileChanged && fmtCheck {
+				fmt.Println(filename)
+			}
+			changed = changed || fileChanged
+		}
+
+		if fmtCheck && changed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(fmtCmd)
+
+	fmtCmd.Flags().BoolVar(&fmtCheck, "check", false, "List files that are not formatted and exit with status 1, without rewriting them.")
+	fmtCmd.Flags().IntVar(&columnWidth, "columns", 80, "Set a column width for output.")
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_formatLedger(t *testing.T) {
+	input := `; journal header
+include other.ledger
+
+
+2024-01-05   Grocery Store ; weekly
+  Expenses:Food	 USD   42.50  ; food
+	Assets:Checking
+
+
+~ Monthly  Rent
+ Expenses:Rent  1000
+ Assets:Checking
+
+2024/01/07 Employer
+    ; paycheck
+    Assets:Checking    (1000 + 500)
+    Income:Salary
+
+
+
+`
+	expected := `; journal header
+include other.ledger
+
+2024/01/05 Grocery Store                      ; weekly
+    Expenses:Food                   USD 42.50 ; food
+    Assets:Checking
+
+~ Monthly  Rent
+    Expenses:Rent                        1000
+    Assets:Checking
+
+2024/01/07 Employer
+    ; paycheck
+    Assets:Checking              (1000 + 500)
+    Income:Salary
+`
+
+	var out bytes.Buffer
+	if err := formatLedger(&out, strings.NewReader(input), 45); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != expected {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+
+	// formatting is idempotent
+	var again bytes.Buffer
+	if err := formatLedger(&again, strings.NewReader(out.String()), 45); err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != out.String() {
+		t.Errorf("formatting is not idempotent:\n%s", again.String())
+	}
+
+	if err := formatLedger(&out, strings.NewReader("notadate Payee\n"), 45); err == nil {
+		t.Error("expected error for invalid transaction header")
+	}
+
+	defer func(max int) { maxLineLength = max }(maxLineLength)
+	maxLineLength = 1024
+	long := "2024/01/01 Payee\n    Assets:Cash  5\n    Income\n\n; " + strings.Repeat("x", 2000) + "\n"
+	err := formatLedger(&out, strings.NewReader(long), 45)
+	if err == nil || err.Error() != "line 5: line too long, longer than 1024 bytes (see max_line_length)" {
+		t.Errorf("unexpected error for a long line: %v", err)
+	}
+	if _, err := readParagraphs(strings.NewReader(long)); err == nil || !strings.HasPrefix(err.Error(), "line 5: line too long") {
+		t.Errorf("unexpected error for a long line: %v", err)
+	}
+}
+
+func Test_formatLedgerDirectives(t *testing.T) {
+	input := `range 2024/01/01 2024/12/31
+P 2024/01/01 EUR USD 1.1
+P 2024/01/02 EUR USD 1.2 ; close
+
+2024/01/05 Exchange
+    Assets:Euro    EUR 10 @ 1.1
+    Assets:Checking
+`
+	var out bytes.Buffer
+	if err := formatLedger(&out, strings.NewReader(input), 45); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.SplitAfterN(out.String(), "\n", 4); lines[0]+lines[1]+lines[2] != "range 2024/01/01 2024/12/31\nP 2024/01/01 EUR USD 1.1\nP 2024/01/02 EUR USD 1.2 ; close\n" {
+		t.Errorf("directives not kept:\n%s", out.String())
+	}
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"slices"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/spf13/cobra"
+)
+
+var forecastMonths int
+var forecastBalance bool
+
+// forecastTransactions expands the periodic transactions into transactions
+// for the given number of months after the last transaction in generalLedger
+// (or after today for an empty ledger). Forecast payees are prefixed with "~"
+// so they stand out from recorded transactions.
+func forecastTransactions(generalLedger []*ledger.Transaction, periodic []*ledger.PeriodicTransaction, months int) []*ledger.Transaction {
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if len(generalLedger) > 0 {
+		start = generalLedger[len(generalLedger)-1].Date.AddDate(0, 0, 1)
+	}
+	end := start.AddDate(0, months, 0)
+
+	var forecast []*ledger.Transaction
+	for _, pt := range periodic {
+		for _, trans := range pt.Transactions(start, end) {
+			if trans.Payee == "" {
+				trans.Payee = "~ Forecast"
+			} else {
+				trans.Payee = "~ " + trans.Payee
+			}
+			forecast = append(forecast, trans)
+		}
+	}
+	slices.SortStableFunc(forecast, func(a, b *ledger.Transaction) int {
+		return a.Date.Compare(b.Date)
+	})
+	return forecast
+}
+
+// forecastCmd represents the forecast command
+var forecastCmd = &cobra.Command{
+	Use:   "forecast [query]...",
+	Short: "Print register or balances including forecast transactions",
+	Long: `Expand periodic transactions ("~ Monthly" blocks) into future transactions
+after the last recorded transaction, and print them after the recorded ones.
+Forecast transactions have their payee prefixed with "~".`,
+	Run: func(_ *cobra.Command, args []string) {
+		generalLedger, err := cliTransactions()
+		if err != nil {
+			fatalln(err)
+		}
+
+		periodic, perr := ledger.ParsePeriodicTransactions(ledgerFilePath)
+		if perr != nil {
+			fatalln(perr)
+		}
+
+		generalLedger = append(generalLedger, forecastTransactions(generalLedger, periodic, forecastMonths)...)
+		if forecastBalance {
+			PrintBalances(cliOutput, ledger.GetBalances(cliQuery(args).Filter(generalLedger), nil), showEmptyAccounts, transactionDepth, columnWidth, false)
+		} else {
+			PrintRegister(cliOutput, generalLedger, cliQuery(args), columnWidth, false)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(forecastCmd)
+	watchCommand(forecastCmd)
+
+	var startDate, endDate time.Time
+	startDate = time.Date(1970, 1, 1, 0, 0, 0, 0, time.Local)
+	endDate = time.Now().Add(1<<63 - 1)
+	forecastCmd.Flags().StringVarP(&startString, "begin-date", "b", startDate.Format(transactionDateFormat), "Begin date of transaction processing.")
+	forecastCmd.Flags().StringVarP(&endString, "end-date", "e", endDate.Format(transactionDateFormat), "End date of transaction processing.")
+	forecastCmd.Flags().StringVar(&payeeFilter, "payee", "", "Filter output to payees that contain this string.")
+	forecastCmd.Flags().IntVar(&columnWidth, "columns", 80, "Set a column width for output.")
+	forecastCmd.Flags().BoolVar(&columnWide, "wide", false, "Wide output (use terminal width).")
+
+	forecastCmd.Flags().IntVar(&forecastMonths, "months", 12, "Number of months to forecast.")
+	forecastCmd.Flags().BoolVar(&forecastBalance, "balance", false, "Print balances at the end of the forecast instead of a register.")
+	forecastCmd.Flags().IntVar(&transactionDepth, "depth", -1, "Depth of transaction output (balance).")
+	forecastCmd.Flags().BoolVar(&showEmptyAccounts, "empty", false, "Show empty (zero balance) accounts.")
+}
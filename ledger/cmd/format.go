@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/howeyc/ledger"
+	"github.com/shopspring/decimal"
+)
+
+var reportFormat string
+
+// registerFormatRow is a register line as given to a --format template.
+type registerFormatRow struct {
+	Date     string // in the report date format
+	Status   string // "*", "!" or ""
+	Payee    string
+	Account  string
+	Currency string
+	Amount   string // the posting amount, with its currency
+	Total    string // running total of the currency, with the currency
+	Comment  string
+}
+
+// balanceFormatRow is a balance line as given to a --format template.
+type balanceFormatRow struct {
+	Account  string
+	Name     string // last segment of the account name
+	Depth    int
+	Currency string
+	Amount   string // with the currency
+}
+
+// parseReportFormat parses a --format template. A newline is written after
+// each line unless the template ends with one.
+func parseReportFormat(format string) (*template.Template, error) {
+	if !strings.HasSuffix(format, newLine) {
+		format += newLine
+	}
+	return template.New("format").Parse(format)
+}
+
+// FormatRegister writes a register line through tmpl for each posting that
+// matches the query.
+func FormatRegister(w io.Writer, generalLedger []*ledger.Transaction, query *ledger.Query, tmpl *template.Template) error {
+	buf := bufio.NewWriter(w)
+	runningBalance := make(map[string]decimal.Decimal)
+	for _, trans := range generalLedger {
+		for _, accChange := range trans.AccountChanges {
+			if !query.Match(trans, &accChange) {
+				continue
+			}
+			runningBalance[accChange.Currency] = runningBalance[accChange.Currency].Add(accChange.Balance)
+			row := registerFormatRow{
+				Date:     trans.Date.Format(transactionDateFormat),
+				Status:   strings.TrimSpace(statusMarker(trans.PostingStatus(&accChange))),
+				Payee:    trans.Payee,
+				Account:  accChange.Name,
+				Currency: accChange.Currency,
+				Amount:   formatAmount(accChange.Currency, accChange.Balance),
+				Total:    formatAmount(accChange.Currency, runningBalance[accChange.Currency]),
+				Comment:  accChange.Comment,
+			}
+			if err := tmpl.Execute(buf, row); err != nil {
+				return err
+			}
+		}
+	}
+	return buf.Flush()
+}
+
+// FormatBalances writes a balance line through tmpl for each account shown
+// by PrintBalances, without the tree view and the total.
+func FormatBalances(w io.Writer, accountList []*ledger.Account, printZeroBalances bool, depth int, tmpl *template.Template) error {
+	buf := bufio.NewWriter(w)
+	for _, account := range accountList {
+		accDepth := strings.Count(account.Name, ":") + 1
+		if (!printZeroBalances && account.Balance.Sign() == 0) || (depth >= 0 && accDepth > depth) {
+			continue
+		}
+		row := balanceFormatRow{
+			Account:  account.Name,
+			Name:     account.Name[strings.LastIndex(account.Name, ":")+1:],
+			Depth:    accDepth,
+			Currency: account.Currency,
+			Amount:   formatAmount(account.Currency, account.Balance),
+		}
+		if err := tmpl.Execute(buf, row); err != nil {
+			return err
+		}
+	}
+	return buf.Flush()
+}
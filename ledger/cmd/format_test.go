@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/shopspring/decimal"
+)
+
+func TestFormatRegister(t *testing.T) {
+	date := time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local)
+	generalLedger := []*ledger.Transaction{
+		{Date: date, Payee: "Store", Status: ledger.StatusCleared, AccountChanges: []ledger.Account{
+			{Name: "Expenses:Food", Balance: decimal.NewFromInt(10)},
+			{Name: "Assets:Cash", Balance: decimal.NewFromInt(-10)},
+		}},
+		{Date: date, Payee: "Market", AccountChanges: []ledger.Account{
+			{Name: "Expenses:Food", Currency: "EUR", Balance: decimal.NewFromInt(4)},
+			{Name: "Expenses:Food", Balance: decimal.NewFromInt(5)},
+			{Name: "Assets:Cash", Balance: decimal.NewFromInt(-5)},
+		}},
+	}
+	query, _ := ledger.ParseQuery("Food")
+	tmpl, err := parseReportFormat("{{.Date}}|{{.Status}}|{{.Payee}}|{{.Amount}}|{{.Total}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := FormatRegister(&buf, generalLedger, query, tmpl); err != nil {
+		t.Fatal(err)
+	}
+	want := "2024/01/02|*|Store|10.00|10.00\n" +
+		"2024/01/02||Market|EUR 4.00|EUR 4.00\n" +
+		"2024/01/02||Market|5.00|15.00\n"
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	tmpl, _ = parseReportFormat("{{.Missing}}")
+	if err := FormatRegister(&buf, generalLedger, query, tmpl); err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
+
+func TestFormatBalances(t *testing.T) {
+	accounts := []*ledger.Account{
+		{Name: "Assets", Balance: decimal.NewFromInt(-10)},
+		{Name: "Assets:Cash", Balance: decimal.NewFromInt(-10)},
+		{Name: "Equity", Balance: decimal.Zero},
+	}
+	tmpl, err := parseReportFormat("{{.Depth}} {{.Name}} {{.Amount}}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := FormatBalances(&buf, accounts, false, 1, tmpl); err != nil {
+		t.Fatal(err)
+	}
+	if want := "1 Assets -10.00\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/howeyc/ledger"
+	date "github.com/joyt/godate"
+	"github.com/spf13/cobra"
+)
+
+var generateTemplates string
+var generateUntil string
+var generateDryRun bool
+
+// generatePayee returns the payee as recurring transactions are matched to
+// recorded ones, ignoring case and spacing.
+func generatePayee(payee string) string {
+	return strings.ToLower(strings.Join(strings.Fields(payee), " "))
+}
+
+// dueTransactions returns, in date order, the transactions of the templates
+// that are due up to and including until and not yet in generalLedger. A
+// template is due after the last transaction in generalLedger with its payee
+// or, when there is none, from its start date or else from today.
+func dueTransactions(generalLedger []*ledger.Transaction, templates []*ledger.PeriodicTransaction, today, until time.Time) []*ledger.Transaction {
+	lastRecorded := make(map[string]time.Time)
+	for _, trans := range generalLedger {
+		payee := generatePayee(trans.Payee)
+		if trans.Date.After(lastRecorded[payee]) {
+			lastRecorded[payee] = trans.Date
+		}
+	}
+
+	end := time.Date(until.Year(), until.Month(), until.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+	var due []*ledger.Transaction
+	for _, pt := range templates {
+		payee := generatePayee(pt.Payee)
+		var start time.Time
+		if last, ok := lastRecorded[payee]; ok {
+			start = time.Date(last.Year(), last.Month(), last.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+		} else if !pt.Start.IsZero() {
+			start = pt.Start
+		} else {
+			start = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+		}
+		due = append(due, pt.Transactions(start, end)...)
+	}
+	slices.SortStableFunc(due, func(a, b *ledger.Transaction) int {
+		return a.Date.Compare(b.Date)
+	})
+	return due
+}
+
+// appendTransactions writes the transactions at the end of the file, after a
+// blank line.
+func appendTransactions(filename string, transactions []*ledger.Transaction) error {
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	// end the file with a blank line before the transactions
+	tail := make([]byte, min(size, 2))
+	if _, err := f.ReadAt(tail, size-int64(len(tail))); err != nil {
+		return err
+	}
+	buf := bufio.NewWriter(f)
+	switch {
+	case size == 0, string(tail) == "\n\n":
+	case tail[len(tail)-1] == '\n':
+		buf.WriteString(newLine)
+	default:
+		buf.WriteString(newLine + newLine)
+	}
+	for _, trans := range transactions {
+		WriteTransaction(buf, trans, 80)
+	}
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// generateCmd represents the generate command
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Append due recurring transactions to the ledger file",
+	Long: `Append the recurring transactions, such as rent, salary or subscriptions, that
+are due up to --until (default today) and not yet in the ledger file.
+
+Recurring transactions are templates in the periodic transaction format of the
+ledger file or of --templates, each with a schedule, a payee and postings:
+
+    ~ Monthly from 2024/01/01  Rent
+        Expenses:Rent       1500
+        Assets:Checking
+
+A template is due after the last transaction with its payee, or from its start
+date when there is none.`,
+	Args: cobra.NoArgs,
+	Run: func(_ *cobra.Command, _ []string) {
+		if ledgerFilePath == "-" {
+			fatalln("can not append to stdin")
+		}
+		until := time.Now()
+		if generateUntil != "" {
+			var err error
+			if until, err = date.Parse(generateUntil); err != nil {
+				fatalln("unable to parse until date:", err)
+			}
+		}
+		templatesPath := generateTemplates
+		if templatesPath == "" {
+			templatesPath = ledgerFilePath
+		}
+
+		generalLedger, err := ledger.ParseLedgerFile(ledgerFilePath)
+		if err != nil {
+			fatalln(err)
+		}
+		templates, err := ledger.ParsePeriodicTransactions(templatesPath)
+		if err != nil {
+			fatalln(err)
+		}
+
+		due := dueTransactions(generalLedger, templates, time.Now(), until)
+		if generateDryRun {
+			buf := bufio.NewWriter(cliOutput)
+			for _, trans := range due {
+				WriteTransaction(buf, trans, 80)
+			}
+			buf.Flush()
+			return
+		}
+		if len(due) == 0 {
+			return
+		}
+		if err := appendTransactions(ledgerFilePath, due); err != nil {
+			fatalln(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(generateCmd)
+
+	generateCmd.Flags().StringVar(&generateTemplates, "templates", "", "File of recurring transaction templates (default is the ledger file).")
+	generateCmd.Flags().StringVar(&generateUntil, "until", "", "Generate transactions due up to this date (default is today).")
+	generateCmd.Flags().BoolVar(&generateDryRun, "dry-run", false, "Print the due transactions instead of appending them.")
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/shopspring/decimal"
+)
+
+func Test_dueTransactions(t *testing.T) {
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2024, month, d, 0, 0, 0, 0, time.UTC)
+	}
+	postings := []ledger.Account{
+		{Name: "Expenses:Rent", Balance: decimal.NewFromInt(1500)},
+		{Name: "Assets:Checking", Balance: decimal.NewFromInt(-1500)},
+	}
+	templates := []*ledger.PeriodicTransaction{
+		{Period: ledger.PeriodMonth, Payee: "Rent", AccountChanges: postings},
+		{Period: ledger.PeriodMonth, Start: day(time.March, 15), Payee: "Salary", AccountChanges: postings},
+		{Period: ledger.PeriodMonth, Payee: "Gym", AccountChanges: postings},
+	}
+	generalLedger := []*ledger.Transaction{
+		{Date: day(time.February, 1), Payee: "rent"},
+		{Date: day(time.February, 15), Payee: "Groceries"},
+	}
+
+	due := dueTransactions(generalLedger, templates, day(time.April, 10), day(time.May, 1))
+	var got []string
+	for _, trans := range due {
+		got = append(got, trans.Date.Format("01/02")+" "+trans.Payee)
+	}
+	// rent after the last recorded, salary from its start, gym from today
+	want := []string{"03/01 Rent", "03/15 Salary", "04/01 Rent", "04/15 Salary", "05/01 Rent", "05/01 Gym"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("dueTransactions() = %v, want %v", got, want)
+	}
+}
+
+func Test_appendTransactions(t *testing.T) {
+	trans := &ledger.Transaction{
+		Date:  time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+		Payee: "Rent",
+		AccountChanges: []ledger.Account{
+			{Name: "Expenses:Rent", Balance: decimal.NewFromInt(1500)},
+			{Name: "Assets:Checking", Balance: decimal.NewFromInt(-1500)},
+		},
+	}
+	for _, existing := range []string{"", "; comment", "; comment\n", "; comment\n\n"} {
+		filename := filepath.Join(t.TempDir(), "test.ledger")
+		if err := os.WriteFile(filename, []byte(existing), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := appendTransactions(filename, []*ledger.Transaction{trans}); err != nil {
+			t.Fatal(err)
+		}
+		contents, _ := os.ReadFile(filename)
+		want := "2024/03/01 Rent"
+		if existing != "" {
+			want = "; comment\n\n" + want
+		}
+		if !strings.HasPrefix(string(contents), want) {
+			t.Errorf("after %q got %q, want it to start with %q", existing, contents, want)
+		}
+		if _, err := ledger.ParseLedgerFile(filename); err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -1,19 +1,27 @@
 package cmd
 
 import (
+	"bufio"
+	"bytes"
+	"cmp"
 	"encoding/csv"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"math"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 	"unicode/utf8"
 
 	"github.com/howeyc/ledger"
 	"github.com/howeyc/ledger/ledger/camt"
+	"github.com/howeyc/ledger/ledger/csvrules"
 	"github.com/howeyc/ledger/ledger/iif"
+	"github.com/howeyc/ledger/ledger/mt940"
 	"github.com/howeyc/ledger/ledger/qfx"
 	"github.com/howeyc/ledger/ledger/qif"
 	"github.com/jbrukh/bayesian"
@@ -31,6 +39,17 @@ var allowMatching bool
 var fieldDelimiter string
 var scaleFactor float64
 var overrideCurrency string
+var csvRulesFile string
+var csvNoHeader bool
+var csvFields string
+var importStateFile string
+var importMinConfidence float64
+var importCandidates bool
+var importWrite bool
+var importAppendTo string
+
+// feeAccount is the account of the fees PayPal and exchanges charge.
+const feeAccount = "Expenses:Fees"
 
 type Importer struct {
 	filename        string
@@ -38,13 +57,22 @@ type Importer struct {
 	decScale        decimal.Decimal
 	matchingAccount string
 	generalLedger   []*ledger.Transaction
-	classifier      *bayesian.Classifier
+	classifier      accountClassifier
+	output          io.StringWriter
+	state           *importState
+	batch           map[string]bool // ids imported from the files of a directory
+	imported        []*ledger.Transaction
+	payeeRules      []payeeRule
+	review          func(trans *ledger.Transaction) bool
+	preview         *importPreview // with --dry-run
+	amazonOrders    []*amazonOrder
 }
 
 func NewImporter(accountSubstring, filename string) *Importer {
 	imp := Importer{
 		filename: filename,
 		decScale: decimal.NewFromFloat(scaleFactor),
+		output:   os.Stdout,
 	}
 
 	fileReader, err := os.Open(filename)
@@ -54,6 +82,27 @@ func NewImporter(accountSubstring, filename string) *Importer {
 	}
 	imp.reader = fileReader
 
+	payeeRules, err := loadPayeeRules()
+	if err != nil {
+		fmt.Println(err)
+		return nil
+	}
+	imp.payeeRules = payeeRules
+
+	if imp.amazonOrders, err = loadAmazonOrders(); err != nil {
+		fmt.Println(err)
+		return nil
+	}
+
+	if importStateFile != "" {
+		state, err := loadImportState(importStateFile)
+		if err != nil {
+			fmt.Println(err)
+			return nil
+		}
+		imp.state = state
+	}
+
 	// If a ledger file path is provided, load it and train the classifier.
 	// Otherwise, skip loading and prediction will fall back to "unknown:unknown".
 	if ledgerFilePath != "" {
@@ -71,7 +120,12 @@ func NewImporter(accountSubstring, filename string) *Importer {
 		}
 		imp.matchingAccount = matchingAccount
 
-		imp.classifier = imp.trainClassifier(imp.matchingAccount)
+		classifier, err := imp.newClassifier(imp.matchingAccount)
+		if err != nil {
+			fmt.Println(err)
+			return nil
+		}
+		imp.classifier = classifier
 	} else {
 		imp.matchingAccount = accountSubstring
 	}
@@ -83,21 +137,38 @@ func (imp *Importer) Close() {
 	imp.reader.Close()
 }
 
-func (imp *Importer) trainClassifier(matchingAccount string) *bayesian.Classifier {
-	allAccounts := ledger.GetBalances(imp.generalLedger, []string{})
-	uniqueAccounts := make(map[string]bool)
-	for _, acc := range allAccounts {
-		if ok, _ := uniqueAccounts[acc.Name]; !ok {
-			uniqueAccounts[acc.Name] = true
+// emit writes the imported transaction to the output, unless the ledger has
+// it or its review skips it. With --candidates, a transaction with an unknown
+// account gets a comment with the accounts it most likely is. With --dry-run
+// only a preview line is written.
+func (imp *Importer) emit(trans *ledger.Transaction) {
+	if imp.preview != nil {
+		imp.previewTransaction(trans)
+		return
+	}
+	if imp.state == nil && !allowMatching && imp.existingTransaction(trans) {
+		return
+	}
+	if imp.amazonOrders != nil {
+		imp.enrichAmazon(trans)
+	}
+	if importCandidates && slices.ContainsFunc(trans.AccountChanges, func(acc ledger.Account) bool {
+		return acc.Name == "unknown:unknown"
+	}) {
+		if comment := imp.candidatesComment(strings.Fields(trans.Payee)); comment != "" {
+			trans.Comments = append(trans.Comments, comment)
 		}
 	}
-
-	classes := []bayesian.Class{}
-	for name := range uniqueAccounts {
-		classes = append(classes, bayesian.Class(name))
+	if imp.review != nil && !imp.review(trans) {
+		return
 	}
+	imp.imported = append(imp.imported, trans)
+	WriteTransaction(imp.output, trans, 80)
+}
 
-	classifier := bayesian.NewClassifier(classes...)
+// trainingExamples calls learn with the payee words and each other account
+// of the transactions with a posting to matchingAccount.
+func (imp *Importer) trainingExamples(matchingAccount string, learn func(payeeWords []string, account string)) {
 	for _, tran := range imp.generalLedger {
 		payeeWords := strings.Fields(tran.Payee)
 		// learn accounts names (except matchingAccount) for transactions where matchingAccount is present
@@ -111,43 +182,79 @@ func (imp *Importer) trainClassifier(matchingAccount string) *bayesian.Classifie
 		if learnName {
 			for _, accChange := range tran.AccountChanges {
 				if accChange.Name != matchingAccount {
-					classifier.Learn(payeeWords, bayesian.Class(accChange.Name))
+					learn(payeeWords, accChange.Name)
 				}
 			}
 		}
 	}
+}
+
+func (imp *Importer) trainClassifier(matchingAccount string) *bayesian.Classifier {
+	allAccounts := ledger.GetBalances(imp.generalLedger, []string{})
+	uniqueAccounts := make(map[string]bool)
+	for _, acc := range allAccounts {
+		if ok, _ := uniqueAccounts[acc.Name]; !ok {
+			uniqueAccounts[acc.Name] = true
+		}
+	}
+
+	classes := []bayesian.Class{}
+	for name := range uniqueAccounts {
+		classes = append(classes, bayesian.Class(name))
+	}
+
+	classifier := bayesian.NewClassifier(classes...)
+	imp.trainingExamples(matchingAccount, func(payeeWords []string, account string) {
+		classifier.Learn(payeeWords, bayesian.Class(account))
+	})
 
 	return classifier
 }
 
-func (imp *Importer) predictAccount(inputPayeeWords []string) string {
+// rankAccounts returns the accounts ordered from the most to the least likely
+// for the payee words, see accountClassifier.
+func (imp *Importer) rankAccounts(inputPayeeWords []string) []accountScore {
 	if imp.classifier == nil {
-		return "unknown:unknown"
+		return nil
 	}
+	return imp.classifier.Rank(inputPayeeWords)
+}
 
+func (imp *Importer) predictAccount(inputPayeeWords []string) string {
 	// Classify into expense account
+	ranked := imp.rankAccounts(inputPayeeWords)
+	if len(ranked) == 0 {
+		return "unknown:unknown"
+	}
 
-	// Find the highest and second highest scores
-	highScore1 := math.Inf(-1)
-	highScore2 := math.Inf(-1)
-	matchIdx := 0
-	scores, _, _ := imp.classifier.LogScores(inputPayeeWords)
-	for j, score := range scores {
-		if score > highScore1 {
-			highScore2 = highScore1
-			highScore1 = score
-			matchIdx = j
-		}
-	}
-	// If the difference between the highest and second highest scores is greater than 10
-	// then it indicates that highscore is a high confidence match
-	if highScore1-highScore2 > 10 {
-		return string(imp.classifier.Classes[matchIdx])
+	// If the difference between the highest and second highest scores is
+	// greater than the minimum confidence then it indicates that highscore
+	// is a high confidence match
+	second := math.Inf(-1)
+	if len(ranked) > 1 {
+		second = ranked[1].Score
+	}
+	if ranked[0].Score-second > importMinConfidence {
+		return ranked[0].Account
 	} else {
 		return "unknown:unknown"
 	}
 }
 
+// candidatesComment returns a comment with the most likely accounts for the
+// payee words, for transactions the classifier is not confident about.
+func (imp *Importer) candidatesComment(inputPayeeWords []string) string {
+	ranked := imp.rankAccounts(inputPayeeWords)
+	var names []string
+	for _, as := range ranked[:min(len(ranked), 3)] {
+		names = append(names, as.Account)
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return ";candidates: " + strings.Join(names, ", ")
+}
+
 func (imp *Importer) findMatchingAccount(accountSubstring string) (string, error) {
 	var matchingAccount string
 	matchingAccounts := ledger.GetBalances(imp.generalLedger, []string{accountSubstring})
@@ -167,56 +274,102 @@ func (imp *Importer) findMatchingAccount(accountSubstring string) (string, error
 	return matchingAccount, nil
 }
 
-func (imp *Importer) importCSV() {
-	csvReader := csv.NewReader(imp.reader)
+// readCSV reads the records of the csv file, which may have any number of
+// fields.
+func (imp *Importer) readCSV() ([][]string, error) {
+	csvReader := csv.NewReader(skipBOM(imp.reader))
 	csvReader.Comma, _ = utf8.DecodeRuneInString(fieldDelimiter)
-	csvRecords, cerr := csvReader.ReadAll()
+	csvReader.FieldsPerRecord = -1
+	return csvReader.ReadAll()
+}
+
+// importCSVExport imports the csv records of a PayPal activity download, a
+// Stripe balance report, a Kraken trades export or a Coinbase transaction
+// history, recognized by the header, and returns whether they were one.
+func (imp *Importer) importCSVExport(csvRecords [][]string) bool {
+	if len(csvRecords) == 0 {
+		return false
+	}
+	if _, ok := findPayPalColumns(csvRecords[0]); ok {
+		imp.importPayPalRecords(csvRecords)
+		return true
+	}
+	if _, ok := findStripeColumns(csvRecords[0]); ok {
+		imp.importStripeRecords(csvRecords)
+		return true
+	}
+	if _, ok := findKrakenColumns(csvRecords[0]); ok {
+		imp.importKrakenRecords(csvRecords)
+		return true
+	}
+	if _, _, ok := findCoinbaseColumns(csvRecords); ok {
+		imp.importCoinbaseRecords(csvRecords)
+		return true
+	}
+	return false
+}
+
+// skipBOM returns a reader of r without the byte order mark spreadsheets
+// write at the start of csv files.
+func skipBOM(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	if bom, err := br.Peek(3); err == nil && string(bom) == "\ufeff" {
+		br.Discard(3)
+	}
+	return br
+}
+
+func (imp *Importer) importCSV() {
+	if csvRulesFile != "" {
+		imp.importCSVRules()
+		return
+	}
+
+	csvRecords, cerr := imp.readCSV()
 	if cerr != nil {
 		fmt.Println("CSV parse error:", cerr.Error())
 		return
 	}
+	if csvFields == "" && !csvNoHeader && imp.importCSVExport(csvRecords) {
+		return
+	}
 
-	// Find columns from header
-	var dateColumn, payeeColumn, amountColumn, commentColumn int
-	dateColumn, payeeColumn, amountColumn, commentColumn = -1, -1, -1, -1
-	for fieldIndex, fieldName := range csvRecords[0] {
-		fieldName = strings.ToLower(fieldName)
-		if strings.Contains(fieldName, "date") {
-			dateColumn = fieldIndex
-		} else if strings.Contains(fieldName, "description") {
-			payeeColumn = fieldIndex
-		} else if strings.Contains(fieldName, "payee") {
-			payeeColumn = fieldIndex
-		} else if strings.Contains(fieldName, "amount") {
-			amountColumn = fieldIndex
-		} else if strings.Contains(fieldName, "expense") {
-			amountColumn = fieldIndex
-		} else if strings.Contains(fieldName, "note") {
-			commentColumn = fieldIndex
-		} else if strings.Contains(fieldName, "comment") {
-			commentColumn = fieldIndex
-		}
+	// Find columns from header, or from the fields given in its place
+	var header []string
+	switch {
+	case csvFields != "":
+		header = strings.Split(csvFields, ",")
+	case csvNoHeader:
+		fmt.Println("Columns of a csv file without header must be given with --fields.")
+		return
+	case len(csvRecords) > 0:
+		header = csvRecords[0]
+	}
+	if !csvNoHeader && len(csvRecords) > 0 {
+		csvRecords = csvRecords[1:]
 	}
 
-	if dateColumn < 0 || payeeColumn < 0 || amountColumn < 0 {
+	columns := findCSVColumns(header)
+	if !columns.complete() {
 		fmt.Println("Unable to find columns required from header field names.")
 		return
 	}
+	lastColumn := max(columns.date, columns.payee, columns.amount, columns.debit, columns.credit, columns.currency, columns.comment)
 
 	expenseAccount := ledger.Account{Name: "unknown:unknown", Balance: decimal.Zero}
 	csvAccount := ledger.Account{Name: imp.matchingAccount, Balance: decimal.Zero}
-	for _, record := range csvRecords[1:] {
-		inputPayeeWords := strings.Fields(record[payeeColumn])
-		csvDate, _ := time.Parse(csvDateFormat, record[dateColumn])
-		if allowMatching || !imp.existingTransaction(csvDate, record[payeeColumn]) {
+	recordHash := recordHashes()
+	for _, record := range csvRecords {
+		if len(record) <= lastColumn {
+			fmt.Printf("CSV record %q is missing columns\n", strings.Join(record, fieldDelimiter))
+			continue
+		}
+		payee := imp.normalizePayee(record[columns.payee])
+		inputPayeeWords := strings.Fields(payee)
+		csvDate, _ := time.Parse(csvDateFormat, record[columns.date])
+		if imp.newTransaction(csvDate, payee, recordHash(record...)) {
 			expenseAccount.Name = imp.predictAccount(inputPayeeWords)
-
-			// Parse error, set to zero
-			if dec, derr := decimal.NewFromString(record[amountColumn]); derr != nil {
-				expenseAccount.Balance = decimal.Zero
-			} else {
-				expenseAccount.Balance = dec
-			}
+			expenseAccount.Balance = columns.value(record)
 
 			// Negate amount if required
 			if negateAmount {
@@ -229,19 +382,155 @@ func (imp *Importer) importCSV() {
 			// Csv amount is the negative of the expense amount
 			csvAccount.Balance = expenseAccount.Balance.Neg()
 
-			trans := &ledger.Transaction{Date: csvDate, Payee: record[payeeColumn]}
+			trans := &ledger.Transaction{Date: csvDate, Payee: payee}
 			trans.AccountChanges = []ledger.Account{csvAccount, expenseAccount}
 
-			if overrideCurrency != "" {
-				for i := range trans.AccountChanges {
-					trans.AccountChanges[i].Currency = overrideCurrency
-				}
+			currency := ""
+			if columns.currency >= 0 {
+				currency = strings.TrimSpace(record[columns.currency])
 			}
-			if commentColumn >= 0 && record[commentColumn] != "" {
-				trans.Comments = []string{";" + record[commentColumn]}
+			setCurrency(trans.AccountChanges, currency)
+			if columns.comment >= 0 && record[columns.comment] != "" {
+				trans.Comments = []string{";" + record[columns.comment]}
 			}
-			WriteTransaction(os.Stdout, trans, 80)
+			imp.emit(trans)
+		}
+	}
+}
+
+// csvColumns are the columns of the fields of a csv file, -1 for those
+// missing. Files have an amount column, or debit and credit columns of which
+// one is usually blank.
+type csvColumns struct {
+	date, payee, amount, debit, credit, currency, comment int
+}
+
+// findCSVColumns returns the columns of the fields named in the header. Names
+// are matched ignoring case by what they contain, such as "Transaction Date"
+// for the date; "Withdrawal" and "Deposit" are taken as debit and credit.
+func findCSVColumns(header []string) csvColumns {
+	columns := csvColumns{-1, -1, -1, -1, -1, -1, -1}
+	for fieldIndex, fieldName := range header {
+		fieldName = strings.ToLower(fieldName)
+		if strings.Contains(fieldName, "date") {
+			columns.date = fieldIndex
+		} else if strings.Contains(fieldName, "description") {
+			columns.payee = fieldIndex
+		} else if strings.Contains(fieldName, "payee") {
+			columns.payee = fieldIndex
+		} else if strings.Contains(fieldName, "currency") {
+			columns.currency = fieldIndex
+		} else if strings.Contains(fieldName, "debit") || strings.Contains(fieldName, "withdrawal") {
+			columns.debit = fieldIndex
+		} else if strings.Contains(fieldName, "credit") || strings.Contains(fieldName, "deposit") {
+			columns.credit = fieldIndex
+		} else if strings.Contains(fieldName, "amount") {
+			columns.amount = fieldIndex
+		} else if strings.Contains(fieldName, "expense") {
+			columns.amount = fieldIndex
+		} else if strings.Contains(fieldName, "note") {
+			columns.comment = fieldIndex
+		} else if strings.Contains(fieldName, "comment") {
+			columns.comment = fieldIndex
+		}
+	}
+	return columns
+}
+
+// complete returns whether the columns of a transaction are all found.
+func (c csvColumns) complete() bool {
+	return c.date >= 0 && c.payee >= 0 && (c.amount >= 0 || c.debit >= 0 || c.credit >= 0)
+}
+
+// value returns the amount of the record, the debit less the credit for
+// files without an amount column. Blank and unreadable values are zero, and
+// the sign of debits and credits is ignored, as some files write debits
+// negative.
+func (c csvColumns) value(record []string) decimal.Decimal {
+	parse := func(column int) decimal.Decimal {
+		if column < 0 {
+			return decimal.Zero
+		}
+		dec, err := decimal.NewFromString(strings.TrimSpace(record[column]))
+		if err != nil {
+			return decimal.Zero
+		}
+		return dec
+	}
+	if c.amount >= 0 {
+		return parse(c.amount)
+	}
+	return parse(c.debit).Abs().Sub(parse(c.credit).Abs())
+}
+
+// importCSVRules imports the csv file as described by the rules file, see
+// package csvrules. The amount of a record goes to account1, by default the
+// matching account, and its negative to account2, by default the predicted
+// account.
+func (imp *Importer) importCSVRules() {
+	rulesReader, err := os.Open(csvRulesFile)
+	if err != nil {
+		fmt.Println("CSV rules:", err)
+		return
+	}
+	rules, err := csvrules.Parse(rulesReader)
+	rulesReader.Close()
+	if err != nil {
+		fmt.Printf("%s:%s\n", csvRulesFile, err.Error())
+		return
+	}
+	records, err := rules.Records(imp.reader)
+	if err != nil {
+		fmt.Println("CSV parse error:", err.Error())
+		return
+	}
+
+	dateFormat := rules.DateFormat
+	if dateFormat == "" {
+		dateFormat = csvDateFormat
+	}
+	recordHash := recordHashes()
+	for _, record := range records {
+		id := recordHash(record.Date, record.Description, record.Amount, record.Comment)
+		record.Description = imp.normalizePayee(record.Description)
+		csvDate, err := time.Parse(dateFormat, record.Date)
+		if err != nil {
+			fmt.Println("CSV date parse error:", err.Error())
+			continue
+		}
+		if !imp.newTransaction(csvDate, record.Description, id) {
+			continue
 		}
+
+		amount, err := decimal.NewFromString(record.Amount)
+		if err != nil {
+			fmt.Println("CSV amount parse error:", err.Error())
+			continue
+		}
+		if rules.NegateAmount {
+			amount = amount.Neg()
+		}
+		if negateAmount {
+			amount = amount.Neg()
+		}
+		amount = amount.Mul(imp.decScale)
+
+		csvAccount := ledger.Account{Name: record.Account1, Balance: amount}
+		if csvAccount.Name == "" {
+			csvAccount.Name = imp.matchingAccount
+		}
+		otherAccount := ledger.Account{Name: record.Account2, Balance: amount.Neg()}
+		if otherAccount.Name == "" {
+			otherAccount.Name = imp.predictAccount(strings.Fields(record.Description))
+		}
+
+		trans := &ledger.Transaction{Date: csvDate, Payee: record.Description}
+		trans.AccountChanges = []ledger.Account{csvAccount, otherAccount}
+		setCurrency(trans.AccountChanges, record.Currency)
+		if record.Comment != "" {
+			trans.Comments = []string{";" + record.Comment}
+		}
+		imp.emit(trans)
 	}
 }
 
@@ -281,6 +570,7 @@ func (imp *Importer) importCamt() {
 			// Use additional entry info as fallback
 			payee = entry.AddtlNtryInf
 		}
+		payee = imp.normalizePayee(payee)
 		inputPayeeWords := strings.Fields(payee)
 
 		expenseAccount.Name = imp.predictAccount(inputPayeeWords)
@@ -300,32 +590,36 @@ func (imp *Importer) importCamt() {
 
 		trans := &ledger.Transaction{Date: dateTime, Payee: payee}
 		trans.AccountChanges = []ledger.Account{camtAccount, expenseAccount}
-		if overrideCurrency != "" {
-			for i := range trans.AccountChanges {
-				trans.AccountChanges[i].Currency = overrideCurrency
-			}
-		} else if entry.Amt.Ccy != "" {
-			for i := range trans.AccountChanges {
-				trans.AccountChanges[i].Currency = entry.Amt.Ccy
-			}
-		}
+		setCurrency(trans.AccountChanges, entry.Amt.Ccy)
+
 		if reference != "" {
 			trans.Comments = []string{";" + reference}
 		}
-		WriteTransaction(os.Stdout, trans, 80)
+		imp.emit(trans)
 	}
 }
 
 func (imp *Importer) importQIF() {
-	entries, err := qif.ParseQIF(imp.reader)
+	file, err := qif.ParseFile(imp.reader)
 	if err != nil {
 		fmt.Println("QIF parse error:", err.Error())
 		return
 	}
 
+	qifAccounts := qifAccountMap{categories: make(map[string]qif.Category)}
+	for _, acc := range ledger.GetBalances(imp.generalLedger, nil) {
+		qifAccounts.accounts = append(qifAccounts.accounts, acc.Name)
+	}
+	for _, cat := range file.Categories {
+		qifAccounts.categories[strings.ToLower(cat.Name)] = cat
+	}
+	// transfers between accounts of the file are in both, imported from
+	// the first
+	transfers := make(map[string]bool)
+
 	expenseAccount := ledger.Account{Name: "unknown:unknown", Balance: decimal.Zero}
 	qifAccount := ledger.Account{Name: imp.matchingAccount, Balance: decimal.Zero}
-	for _, entry := range entries {
+	for _, entry := range file.Transactions {
 		// Parse date (QIF dates are often locale-specific; assume mm/dd/yyyy here)
 		dateTime, err := time.Parse("01/02/2006", entry.Date)
 		if err != nil {
@@ -344,32 +638,136 @@ func (imp *Importer) importQIF() {
 			continue
 		}
 
-		payee := entry.Payee
+		qifAccount.Name = imp.matchingAccount
+		if entry.Account != "" {
+			qifAccount.Name = qifAccounts.account("[" + entry.Account + "]")
+		}
+		if isQIFTransfer(entry.Category) && len(entry.Splits) == 0 {
+			to := qifAccounts.account(entry.Category)
+			key := func(from, to string, amount decimal.Decimal) string {
+				return strings.Join([]string{dateTime.Format(time.DateOnly), from, to, amount.String()}, "|")
+			}
+			if transfers[key(to, qifAccount.Name, amount.Neg())] {
+				continue
+			}
+			transfers[key(qifAccount.Name, to, amount)] = true
+		}
+
+		payee := imp.normalizePayee(entry.Payee)
 		inputPayeeWords := strings.Fields(payee)
 
-		expenseAccount.Name = imp.predictAccount(inputPayeeWords)
-		expenseAccount.Balance = amount
+		trans := &ledger.Transaction{Date: dateTime, Payee: payee}
+		if splits := imp.qifSplitPostings(entry, qifAccounts); len(splits) > 1 {
+			// Account side is the opposite of the splits
+			qifAccount.Balance = decimal.Zero
+			for _, split := range splits {
+				qifAccount.Balance = qifAccount.Balance.Sub(split.Balance)
+			}
+			trans.AccountChanges = append([]ledger.Account{qifAccount}, splits...)
+		} else {
+			expenseAccount.Name = imp.predictAccount(inputPayeeWords)
+			if isQIFTransfer(entry.Category) {
+				expenseAccount.Name = qifAccounts.account(entry.Category)
+			}
+			expenseAccount.Balance = amount
 
-		// Apply scale
-		expenseAccount.Balance = expenseAccount.Balance.Mul(imp.decScale)
+			// Apply scale
+			expenseAccount.Balance = expenseAccount.Balance.Mul(imp.decScale)
 
-		// Account side is the opposite of expense
-		qifAccount.Balance = expenseAccount.Balance.Neg()
+			// Account side is the opposite of expense
+			qifAccount.Balance = expenseAccount.Balance.Neg()
 
-		trans := &ledger.Transaction{Date: dateTime, Payee: payee}
-		trans.AccountChanges = []ledger.Account{qifAccount, expenseAccount}
-		if overrideCurrency != "" {
-			for i := range trans.AccountChanges {
-				trans.AccountChanges[i].Currency = overrideCurrency
-			}
+			trans.AccountChanges = []ledger.Account{qifAccount, expenseAccount}
 		}
+		setCurrency(trans.AccountChanges, "")
 		if len(entry.RawLines) > 0 {
 			// Join all raw lines except header/type line
 			comment := strings.Join(entry.RawLines, " ")
 			trans.Comments = []string{";" + comment}
 		}
-		WriteTransaction(os.Stdout, trans, 80)
+		imp.emit(trans)
+	}
+}
+
+// qifSplitPostings returns a posting for each split of the entry with an
+// amount, to the account of its category or, for splits without one, the
+// account predicted for the payee. Split memos become posting comments.
+func (imp *Importer) qifSplitPostings(entry *qif.Transaction, qifAccounts qifAccountMap) []ledger.Account {
+	var postings []ledger.Account
+	for _, split := range entry.Splits {
+		amount, err := decimal.NewFromString(strings.TrimSpace(split.Amount))
+		if err != nil || amount.IsZero() {
+			continue
+		}
+		posting := ledger.Account{
+			Name:    qifAccounts.account(split.Category),
+			Balance: amount.Mul(imp.decScale),
+		}
+		if posting.Name == "" {
+			posting.Name = imp.predictAccount(strings.Fields(imp.normalizePayee(entry.Payee)))
+		}
+		if split.Memo != "" {
+			posting.Comment = "; " + split.Memo
+		}
+		postings = append(postings, posting)
+	}
+	return postings
+}
+
+// qifAccountMap maps the categories and accounts of a QIF file to accounts.
+type qifAccountMap struct {
+	accounts   []string                // of the ledger
+	categories map[string]qif.Category // of the category list, by lower case name
+}
+
+// qifCategoryName returns the category without its class, after '/', and
+// whether it is a transfer, "[Savings]", with the name of the account
+// transferred to.
+func qifCategoryName(category string) (string, bool) {
+	category, _, _ = strings.Cut(category, "/")
+	category = strings.TrimSpace(category)
+	if strings.HasPrefix(category, "[") && strings.HasSuffix(category, "]") {
+		return strings.TrimSpace(category[1 : len(category)-1]), true
+	}
+	return category, false
+}
+
+// isQIFTransfer returns whether the category is a transfer to an account.
+func isQIFTransfer(category string) bool {
+	_, transfer := qifCategoryName(category)
+	return transfer
+}
+
+// account returns the account of a QIF category, which separates
+// subcategories with ':' as accounts do: the account named by the category,
+// ignoring case, or else the account ending with it. Otherwise a category of
+// the category list is an Income or Expenses account, and others, such as
+// transfers, are the category itself. An empty category has no account.
+func (m qifAccountMap) account(category string) string {
+	name, transfer := qifCategoryName(category)
+	if name == "" {
+		return ""
+	}
+
+	var suffixed string
+	for _, acc := range m.accounts {
+		if strings.EqualFold(acc, name) {
+			return acc
+		}
+		if suffixed == "" && strings.HasSuffix(strings.ToLower(acc), ":"+strings.ToLower(name)) {
+			suffixed = acc
+		}
+	}
+	if suffixed != "" {
+		return suffixed
+	}
+	if cat, ok := m.categories[strings.ToLower(name)]; ok && !transfer {
+		if cat.Income {
+			return "Income:" + name
+		}
+		return "Expenses:" + name
 	}
+	return name
 }
 
 func (imp *Importer) importIIF() {
@@ -392,7 +790,7 @@ func (imp *Importer) importIIF() {
 	for _, itx := range tx {
 		trans := &ledger.Transaction{
 			Date:  itx.Tr.Date,
-			Payee: itx.Tr.Class + " " + itx.Tr.Memo,
+			Payee: imp.normalizePayee(itx.Tr.Class + " " + itx.Tr.Memo),
 		}
 		trans.AccountChanges = []ledger.Account{
 			{
@@ -411,25 +809,28 @@ func (imp *Importer) importIIF() {
 			)
 		}
 
-		if overrideCurrency != "" {
-			for i := range trans.AccountChanges {
-				trans.AccountChanges[i].Currency = overrideCurrency
-			}
-		}
-		WriteTransaction(os.Stdout, trans, 80)
+		setCurrency(trans.AccountChanges, "")
+		imp.emit(trans)
 	}
 
 }
 
 func (imp *Importer) importQFX() {
-	entries, err := qfx.ParseQFX(imp.reader)
+	data, err := io.ReadAll(imp.reader)
+	if err != nil {
+		fmt.Println("QFX read error:", err.Error())
+		return
+	}
+	statement, err := qfx.ParseStatement(bytes.NewReader(data))
 	if err != nil {
 		fmt.Println("QFX parse error:", err.Error())
 		return
 	}
+	entries := statement.BankTranList.StmtTrn
 
 	expenseAccount := ledger.Account{Name: "unknown:unknown", Balance: decimal.Zero}
 	qfxAccount := ledger.Account{Name: imp.matchingAccount, Balance: decimal.Zero}
+	recordHash := recordHashes()
 	for _, entry := range entries {
 		// QFX DTPOSTED is typically YYYYMMDDHHMMSS.XXX; we only care about the date.
 		// Take the first 8 characters as YYYYMMDD.
@@ -450,57 +851,371 @@ func (imp *Importer) importQFX() {
 			continue
 		}
 
-		payee := entry.Memo
+		payee := imp.normalizePayee(cmp.Or(entry.Name, entry.Memo))
+		if imp.state != nil || imp.batch != nil {
+			id := entry.FitID
+			if id == "" {
+				id = recordHash(entry.DtPosted, entry.TrnAmt, entry.Memo)
+			}
+			if !imp.newTransaction(dateTime, payee, id) {
+				continue
+			}
+		}
 		inputPayeeWords := strings.Fields(payee)
 
-		expenseAccount.Name = imp.predictAccount(inputPayeeWords)
-		expenseAccount.Balance = amount
+		// The amount is the account's: negative for bank debits and card
+		// charges
+		qfxAccount.Balance = amount.Mul(imp.decScale)
 
-		// Apply scale
-		expenseAccount.Balance = expenseAccount.Balance.Mul(imp.decScale)
-
-		// Account side is the opposite of expense
-		qfxAccount.Balance = expenseAccount.Balance.Neg()
+		// Expense side is the opposite of the account
+		expenseAccount.Name = imp.predictAccount(inputPayeeWords)
+		expenseAccount.Balance = qfxAccount.Balance.Neg()
 
 		trans := &ledger.Transaction{Date: dateTime, Payee: payee}
 		trans.AccountChanges = []ledger.Account{qfxAccount, expenseAccount}
-		if overrideCurrency != "" {
-			for i := range trans.AccountChanges {
-				trans.AccountChanges[i].Currency = overrideCurrency
-			}
-		}
+		setCurrency(trans.AccountChanges, statement.CurDef)
 		if entry.FitID != "" {
 			trans.Comments = []string{";" + entry.FitID}
 		}
-		WriteTransaction(os.Stdout, trans, 80)
+		if entry.Name != "" && entry.Memo != "" && entry.Memo != entry.Name {
+			trans.Comments = append(trans.Comments, ";"+entry.Memo)
+		}
+		imp.emit(trans)
+	}
+
+	if imp.generalLedger != nil {
+		// the available balance of a card is the credit left
+		balance := statement.LedgerBal
+		if balance.BalAmt == "" && statement.CCAcctFrom == (qfx.AcctFrom{}) {
+			balance = statement.AvailBal
+		}
+		if warning := imp.statementBalanceWarning(balance); warning != "" {
+			fmt.Fprintln(os.Stderr, warning)
+		}
+	}
+
+	// brokerage statements have investment transactions instead
+	investments, err := qfx.ParseInvestments(bytes.NewReader(data))
+	if err != nil {
+		fmt.Println("QFX parse error:", err.Error())
+		return
+	}
+	imp.importQFXInvestments(investments)
+}
+
+// setCurrency sets the currency of the postings, other than those of a
+// commodity priced in it: the currency the file states, or else the one of
+// --override-currency.
+func setCurrency(postings []ledger.Account, currency string) {
+	currency = cmp.Or(currency, overrideCurrency)
+	for i := range postings {
+		if postings[i].ConversionFactor == nil && postings[i].Converted == nil {
+			postings[i].Currency = currency
+		}
+	}
+}
+
+// statementBalanceWarning returns a warning when the balance of a statement
+// differs from the balance of the matching account as of the statement date,
+// in the ledger with the transactions imported, or "" when they agree or the
+// statement has no balance.
+func (imp *Importer) statementBalanceWarning(balance qfx.Bal) string {
+	statementBalance, err := decimal.NewFromString(strings.TrimSpace(balance.BalAmt))
+	if err != nil {
+		return ""
+	}
+	statementBalance = statementBalance.Mul(imp.decScale)
+
+	asOf, asOfText := time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC), "the statement end"
+	if len(balance.DtAsOf) >= 8 {
+		if date, err := time.Parse("20060102", balance.DtAsOf[:8]); err == nil {
+			asOf, asOfText = date, date.Format(transactionDateFormat)
+		}
+	}
+
+	ledgerBalance := decimal.Zero
+	for _, trans := range slices.Concat(imp.generalLedger, imp.imported) {
+		if trans.Date.After(asOf) {
+			continue
+		}
+		for _, acc := range trans.AccountChanges {
+			if acc.Name == imp.matchingAccount && acc.ConversionFactor == nil && acc.Converted == nil {
+				ledgerBalance = ledgerBalance.Add(acc.Balance)
+			}
+		}
+	}
+	if ledgerBalance.Equal(statementBalance) {
+		return ""
+	}
+	return fmt.Sprintf("WARNING: %s balance as of %s is %s in the statement but %s in the ledger, a difference of %s",
+		imp.matchingAccount, asOfText, statementBalance.StringFixedBank(2),
+		ledgerBalance.StringFixedBank(2), statementBalance.Sub(ledgerBalance).StringFixedBank(2))
+}
+
+// qfxInvestmentPayees are the payees of investment transactions without a
+// memo, by kind and income type.
+var qfxInvestmentPayees = map[string]string{
+	"BUYSTOCK":  "Buy",
+	"BUYMF":     "Buy",
+	"SELLSTOCK": "Sell",
+	"SELLMF":    "Sell",
+	"REINVEST":  "Reinvest",
+	"DIV":       "Dividend",
+	"INTEREST":  "Interest",
+	"CGLONG":    "Capital gain",
+	"CGSHORT":   "Capital gain",
+	"MISC":      "Income",
+}
+
+// qfxCommodity returns the commodity of the security of an investment
+// transaction: its ticker or, when not listed, its name, as capital letters.
+func qfxCommodity(trn qfx.InvStmtTrn) string {
+	letters := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if r >= 'A' && r <= 'Z' {
+				return r
+			}
+			return -1
+		}, strings.ToUpper(s))
+	}
+	for _, name := range []string{trn.Ticker, trn.SecName, trn.UniqueID} {
+		if commodity := letters(name); commodity != "" {
+			return commodity
+		}
+	}
+	return "SECURITY"
+}
+
+// importQFXInvestments imports the transactions of an investment statement.
+// Buys, sells and reinvestments post the units of the security, priced at
+// the unit price, to the matching account; the cash of buys, sells and
+// income is also the matching account's, while reinvested income comes from
+// the account predicted for the payee. Commissions and fees, the difference
+// between the total and the price of the units, go to Expenses:Commissions.
+func (imp *Importer) importQFXInvestments(trns []qfx.InvStmtTrn) {
+	parse := func(s string) decimal.Decimal {
+		dec, err := decimal.NewFromString(strings.TrimSpace(s))
+		if err != nil {
+			return decimal.Zero
+		}
+		return dec
+	}
+
+	for _, trn := range trns {
+		dateStr := trn.DtTrade
+		if len(dateStr) >= 8 {
+			dateStr = dateStr[:8]
+		}
+		dateTime, err := time.Parse("20060102", dateStr)
+		if err != nil {
+			fmt.Println("QFX date parse error:", err.Error())
+			continue
+		}
+
+		commodity := qfxCommodity(trn)
+		payee := imp.normalizePayee(trn.Memo)
+		if payee == "" {
+			verb := qfxInvestmentPayees[trn.Kind]
+			if trn.Kind == "INCOME" {
+				verb = cmp.Or(qfxInvestmentPayees[trn.IncomeType], "Income")
+			}
+			payee = verb + " " + cmp.Or(trn.Ticker, trn.SecName, commodity)
+		}
+		if (imp.state != nil || imp.batch != nil) && trn.FitID != "" && !imp.newTransaction(dateTime, payee, trn.FitID) {
+			continue
+		}
+
+		total := parse(trn.Total).Mul(imp.decScale)
+		cash := ledger.Account{Name: imp.matchingAccount, Balance: total}
+		var postings []ledger.Account
+		if trn.Kind == "INCOME" {
+			other := ledger.Account{Name: imp.predictAccount(strings.Fields(payee)), Balance: total.Neg()}
+			postings = []ledger.Account{cash, other}
+		} else {
+			units := parse(trn.Units)
+			price := parse(trn.UnitPrice).Mul(imp.decScale)
+			shares := ledger.Account{Name: imp.matchingAccount, Currency: commodity, Balance: units, ConversionFactor: &price}
+			if trn.Kind == "REINVEST" {
+				cash.Name = imp.predictAccount(strings.Fields(payee))
+			}
+			postings = []ledger.Account{shares, cash}
+			if fees := units.Mul(price).Add(total).Neg(); !fees.IsZero() {
+				postings = append(postings, ledger.Account{Name: "Expenses:Commissions", Balance: fees})
+			}
+		}
+		setCurrency(postings, trn.Currency)
+
+		trans := &ledger.Transaction{Date: dateTime, Payee: payee, AccountChanges: postings}
+		if trn.FitID != "" {
+			trans.Comments = []string{";" + trn.FitID}
+		}
+		imp.emit(trans)
+	}
+}
+
+// importFormat returns the format of the file to import, going by its
+// extension: camt (.xml), qfx (.qfx, .ofx), qif, iif, mt940 (.sta, .mt940,
+// .940, .942) or else csv.
+func importFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".xml":
+		return "camt"
+	case ".qfx", ".ofx":
+		return "qfx"
+	case ".qif":
+		return "qif"
+	case ".iif":
+		return "iif"
+	case ".sta", ".mt940", ".940", ".942":
+		return "mt940"
+	case ".json":
+		return "json"
+	}
+	return "csv"
+}
+
+// importFile imports the file in the format, see importFormat.
+func (imp *Importer) importFile(format string) error {
+	switch format {
+	case "camt":
+		imp.importCamt()
+	case "qfx", "ofx":
+		imp.importQFX()
+	case "qif":
+		imp.importQIF()
+	case "iif":
+		imp.importIIF()
+	case "mt940", "mt942":
+		imp.importMT940()
+	case "json":
+		imp.importJSON()
+	case "paypal":
+		imp.importPayPal()
+	case "stripe":
+		imp.importStripe()
+	case "kraken":
+		imp.importKraken()
+	case "coinbase":
+		imp.importCoinbase()
+	case "csv":
+		imp.importCSV()
+	default:
+		return fmt.Errorf("unknown import format: %s", format)
+	}
+	return nil
+}
+
+// importMT940 imports the statements of an MT940 or MT942 file. The amount
+// of a statement line goes to the matching account, its negative to the
+// predicted account.
+func (imp *Importer) importMT940() {
+	statements, err := mt940.Parse(imp.reader)
+	if err != nil {
+		fmt.Println("MT940 parse error:", err.Error())
+		return
+	}
+
+	recordHash := recordHashes()
+	for _, statement := range statements {
+		for _, entry := range statement.Transactions {
+			dateTime := entry.EntryDate
+			if dateTime.IsZero() {
+				dateTime = entry.ValueDate
+			}
+
+			payee := entry.Payee
+			for _, alt := range []string{entry.Description, entry.Supplementary, entry.Reference} {
+				if payee == "" {
+					payee = alt
+				}
+			}
+			payee = imp.normalizePayee(payee)
+			if imp.state != nil || imp.batch != nil {
+				id := entry.BankReference
+				if id == "" {
+					id = recordHash(entry.ValueDate.Format(time.DateOnly), entry.Amount.String(), entry.Reference, entry.Description)
+				}
+				if !imp.newTransaction(dateTime, payee, id) {
+					continue
+				}
+			}
+
+			amount := entry.Amount.Mul(imp.decScale)
+			mt940Account := ledger.Account{Name: imp.matchingAccount, Balance: amount}
+			otherAccount := ledger.Account{Name: imp.predictAccount(strings.Fields(payee)), Balance: amount.Neg()}
+
+			trans := &ledger.Transaction{Date: dateTime, Payee: payee}
+			trans.AccountChanges = []ledger.Account{mt940Account, otherAccount}
+			setCurrency(trans.AccountChanges, statement.Currency)
+			if entry.Payee != "" && entry.Description != "" {
+				trans.Comments = append(trans.Comments, ";"+entry.Description)
+			}
+			if entry.Reference != "" && entry.Reference != "NONREF" {
+				trans.Comments = append(trans.Comments, ";"+entry.Reference)
+			}
+			imp.emit(trans)
+		}
 	}
 }
 
 // importCmd represents the import command
 var importCmd = &cobra.Command{
-	Use:   "import <account-substring> <csv-file>",
+	Use:   "import <account-substring> <csv-file|directory>",
 	Args:  cobra.ExactArgs(2),
 	Short: "Import transactions from csv to ledger format",
-	Run: func(_ *cobra.Command, args []string) {
+	Run: func(cmd *cobra.Command, args []string) {
+		if _, ok := classifierConfidence[importClassifier]; !ok {
+			fatalln("unknown classifier:", importClassifier)
+		}
+		if !cmd.Flags().Changed("min-confidence") {
+			importMinConfidence = classifierConfidence[importClassifier]
+		}
+		if importWrite && ledgerFilePath == "" {
+			fatalln("--write needs a ledger file")
+		}
 		accountSubstring := args[0]
 		fileName := args[1]
 
 		imp := NewImporter(accountSubstring, fileName)
 		defer imp.Close()
 
-		lower := strings.ToLower(fileName)
-		if strings.HasSuffix(lower, ".xml") {
-			imp.importCamt()
-		} else if strings.HasSuffix(lower, ".qfx") || strings.HasSuffix(lower, ".ofx") {
-			imp.importQFX()
-		} else if strings.HasSuffix(lower, ".qif") {
-			imp.importQIF()
-		} else if strings.HasSuffix(lower, ".iif") {
-			imp.importIIF()
+		if importInteractive {
+			imp.review = imp.reviewTransactions(bufio.NewScanner(os.Stdin), os.Stderr)
+		}
+		if importDryRun {
+			imp.preview = &importPreview{w: imp.output}
+		}
+		if info, err := os.Stat(fileName); err == nil && info.IsDir() {
+			if err := imp.importDir(fileName); err != nil {
+				fatalln(err)
+			}
 		} else {
-			imp.importCSV()
+			imp.importFile(importFormat(fileName))
+		}
+		if imp.preview != nil {
+			imp.preview.summary()
+			return
+		}
+		switch {
+		case importAppendTo != "":
+			if err := appendTransactions(importAppendTo, imp.imported); err != nil {
+				fatalln(err)
+			}
+		case importWrite:
+			if err := transactionFormat(80).AppendToFile(ledgerFilePath, imp.imported...); err != nil {
+				fatalln(err)
+			}
+		}
+		if importModelFile != "" && importClassifier == "bayes" && imp.generalLedger != nil {
+			if err := recordPredictions(importModelFile, imp.matchingAccount, imp.imported); err != nil {
+				fatalln(err)
+			}
+		}
+		if imp.state != nil {
+			if err := imp.state.save(); err != nil {
+				fatalln(err)
+			}
 		}
-
 	},
 }
 
@@ -512,14 +1227,50 @@ func init() {
 	importCmd.Flags().Float64Var(&scaleFactor, "scale", 1.0, "Scale factor to multiply against every imported amount.")
 	importCmd.Flags().StringVar(&csvDateFormat, "date-format", "01/02/2006", "Date format.")
 	importCmd.Flags().StringVar(&fieldDelimiter, "delimiter", ",", "Field delimiter.")
-	importCmd.Flags().StringVar(&overrideCurrency, "override-currency", "", "Override detected currency for imported transactions.")
+	importCmd.Flags().StringVar(&overrideCurrency, "override-currency", "", "Currency of imported transactions when the file states none.")
+	importCmd.Flags().BoolVar(&importInteractive, "interactive", false, "Review each transaction, to accept, skip or change its account.")
+	importCmd.Flags().StringVar(&importClassifier, "classifier", "bayes", "Account classifier: bayes, tfidf or rules.")
+	importCmd.Flags().StringVar(&importClassifierRules, "classifier-rules", "", "Rules file of the rules classifier.")
+	importCmd.Flags().Float64Var(&importMinConfidence, "min-confidence", 10, "Lead in score the most likely account needs over the next to be used\n(default 10 for bayes, 0.1 for tfidf, 0.5 for rules).")
+	importCmd.Flags().BoolVar(&importCandidates, "candidates", false, "Comment transactions with an unknown account with the 3 most likely accounts.")
+	importCmd.Flags().StringVar(&importModelFile, "model", "", "File to save the trained classifier to, and load it from while the ledger is unchanged.")
+	importCmd.Flags().StringVar(&importStateFile, "state", "", "State file of imported transactions, to import each only once.")
+	importCmd.Flags().BoolVar(&csvNoHeader, "no-header", false, "The csv file has no header, its columns are given with --fields.")
+	importCmd.Flags().StringVar(&csvFields, "fields", "", "Comma separated names of the csv columns in order, such as \"date,payee,,amount\",\nin place of the header.")
+	importCmd.Flags().StringVar(&importPayeeRules, "payee-rules", "", "Rules file of regular expressions and replacements cleaning up payees.")
+	importCmd.Flags().StringVar(&jsonMappingFile, "json-mapping", "", "Mapping file selecting the transactions and their fields of a json file.")
+	importCmd.Flags().StringVar(&amazonOrdersFile, "amazon-orders", "", "Amazon order history adding the items of Amazon charges to their comments.")
+	importCmd.Flags().StringVar(&paypalFundingAccount, "paypal-funding", "unknown:funding", "Account of the bank account or card funding PayPal payments.")
+	importCmd.Flags().StringVar(&stripeFeeAccount, "stripe-fees", "Expenses:Fees", "Account of the fees of Stripe.")
+	importCmd.Flags().StringVar(&stripePayoutAccount, "stripe-payout", "unknown:payout", "Account of the bank account Stripe pays out to.")
+	importCmd.Flags().StringVar(&stripeRevenueAccount, "stripe-revenue", "Income:Sales", "Account of the charges and refunds of Stripe.")
+	importCmd.Flags().StringVar(&csvRulesFile, "rules", "", "Rules file describing the columns and accounts of the csv file.")
+	importCmd.Flags().IntVar(&importMatchDays, "match-days", 0, "Days apart a ledger transaction may be dated and still match an imported one.")
+	importCmd.Flags().Float64Var(&importMatchPayee, "match-payee", 1, "Similarity from 0 to 1 of the payee a ledger transaction needs to match an imported one.")
+	importCmd.Flags().BoolVar(&importMatchAmount, "match-amount", false, "A ledger transaction must move the same amount to match an imported one.")
+	importCmd.Flags().BoolVar(&importWrite, "write", false, "Also insert the imported transactions into the ledger file, in date order.")
+	importCmd.Flags().StringVar(&importAppendTo, "append-to", "", "Also append the imported transactions to the end of this file.")
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Only show which transactions are new, which the ledger has and which are ambiguous.")
+	importCmd.MarkFlagsMutuallyExclusive("write", "append-to")
+	importCmd.MarkFlagsMutuallyExclusive("dry-run", "write")
+	importCmd.MarkFlagsMutuallyExclusive("dry-run", "append-to")
+	importCmd.MarkFlagsMutuallyExclusive("dry-run", "interactive")
 }
 
-func (imp *Importer) existingTransaction(transDate time.Time, payee string) bool {
-	for _, trans := range imp.generalLedger {
-		if trans.Date == transDate && strings.TrimSpace(trans.Payee) == strings.TrimSpace(payee) {
-			return true
+// newTransaction returns whether the transaction is to be imported. With a
+// state file that is when its id was not imported before, otherwise emit
+// leaves out the transactions the ledger has, see existingTransaction.
+// Importing a directory, an id imported from an earlier file is not imported
+// again.
+func (imp *Importer) newTransaction(transDate time.Time, payee, id string) bool {
+	if imp.batch != nil {
+		if imp.batch[id] && !allowMatching {
+			return false
 		}
+		imp.batch[id] = true
 	}
-	return false
+	if imp.state != nil {
+		return imp.state.record(imp.matchingAccount, id) || allowMatching
+	}
+	return true
 }
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/shopspring/decimal"
+)
+
+var amazonOrdersFile string
+
+// amazonDays is how many days apart from the shipment, or else the order, a
+// card charge may be dated to be that of an Amazon order.
+const amazonDays = 7
+
+// amazonPayee matches the payees of card charges by Amazon.
+var amazonPayee = regexp.MustCompile(`(?i)\b(amzn|amazon)`)
+
+// amazonOrder is the items of an order of the Amazon order history shipped
+// together, and so charged together.
+type amazonOrder struct {
+	id      string
+	date    time.Time // shipped, or else ordered
+	total   decimal.Decimal
+	items   []string
+	charged bool
+}
+
+// parseAmazonOrders reads an Amazon order history, a csv file with an item
+// per line. The columns are found by their names: the order id, the order
+// date, the name of the product and the total owed for it, and the ship date
+// if any, as in the order history of Amazon's data download or the older
+// items report. The items of an order shipped the same day make up one order.
+func parseAmazonOrders(r io.Reader) ([]*amazonOrder, error) {
+	csvReader := csv.NewReader(skipBOM(r))
+	csvReader.FieldsPerRecord = -1
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	id, date, shipped, title, total := -1, -1, -1, -1, -1
+	fields := map[string]*int{
+		"order id":      &id,
+		"order date":    &date,
+		"ship date":     &shipped,
+		"shipment date": &shipped,
+		"product name":  &title,
+		"title":         &title,
+		"total owed":    &total,
+		"item total":    &total,
+	}
+	for i, name := range records[0] {
+		if column, ok := fields[strings.ToLower(strings.TrimSpace(name))]; ok && *column < 0 {
+			*column = i
+		}
+	}
+	if id < 0 || date < 0 || title < 0 || total < 0 {
+		return nil, fmt.Errorf("no order id, order date, product name and total owed columns")
+	}
+
+	var orders []*amazonOrder
+	for line, record := range records[1:] {
+		if len(record) <= max(id, date, shipped, title, total) {
+			continue
+		}
+		orderDate, err := amazonDate(record[date])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line+2, err)
+		}
+		if shipped >= 0 {
+			if shipDate, err := amazonDate(record[shipped]); err == nil {
+				orderDate = shipDate
+			}
+		}
+		amount, err := parseMoney(record[total])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line+2, err)
+		}
+
+		orderID := strings.TrimSpace(record[id])
+		i := slices.IndexFunc(orders, func(o *amazonOrder) bool {
+			return o.id == orderID && o.date.Equal(orderDate)
+		})
+		if i < 0 {
+			orders = append(orders, &amazonOrder{id: orderID, date: orderDate})
+			i = len(orders) - 1
+		}
+		orders[i].total = orders[i].total.Add(amount)
+		orders[i].items = append(orders[i].items, strings.TrimSpace(record[title]))
+	}
+	return orders, nil
+}
+
+// amazonDate parses a date of the order history, such as
+// "2024-01-02T10:00:00Z" or "01/02/24".
+func amazonDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{"01/02/06", "01/02/2006"} {
+		if date, err := time.Parse(layout, s); err == nil {
+			return date, nil
+		}
+	}
+	return timestampDate(s)
+}
+
+// loadAmazonOrders reads the Amazon order history given by --amazon-orders,
+// if any.
+func loadAmazonOrders() ([]*amazonOrder, error) {
+	if amazonOrdersFile == "" {
+		return nil, nil
+	}
+	f, err := os.Open(amazonOrdersFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	orders, err := parseAmazonOrders(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", amazonOrdersFile, err)
+	}
+	return orders, nil
+}
+
+// enrichAmazon adds the items of the Amazon order charged by an imported
+// transaction with an Amazon payee to its comments: the order not charged
+// yet of the same total dated nearest to it, at most amazonDays apart.
+func (imp *Importer) enrichAmazon(trans *ledger.Transaction) {
+	if !amazonPayee.MatchString(trans.Payee) {
+		return
+	}
+	size := transactionSize(trans)
+	var order *amazonOrder
+	days := func(o *amazonOrder) float64 {
+		d := trans.Date.Sub(o.date).Hours() / 24
+		return max(d, -d)
+	}
+	for _, o := range imp.amazonOrders {
+		if o.charged || !o.total.Abs().Equal(size) || days(o) > amazonDays {
+			continue
+		}
+		if order == nil || days(o) < days(order) {
+			order = o
+		}
+	}
+	if order == nil {
+		return
+	}
+	order.charged = true
+	trans.Comments = append(trans.Comments, ";Amazon order "+order.id)
+	for _, item := range order.items {
+		trans.Comments = append(trans.Comments, ";  "+item)
+	}
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/shopspring/decimal"
+)
+
+func Test_enrichAmazon(t *testing.T) {
+	orders, err := parseAmazonOrders(strings.NewReader(`"Website","Order ID","Order Date","Currency","Total Owed","Ship Date","Product Name"
+"Amazon.com","111-1","2024-01-02T10:00:00Z","USD","12.99","2024-01-03T08:00:00Z","USB Cable"
+"Amazon.com","111-1","2024-01-02T10:00:00Z","USD","7.01","2024-01-03T08:00:00Z","Phone Stand"
+"Amazon.com","111-1","2024-01-02T10:00:00Z","USD","30.00","2024-01-10T08:00:00Z","Desk Lamp"
+"Amazon.com","222-2","2024-01-05T10:00:00Z","USD","20.00","Not Available","Paperback"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 3 {
+		t.Fatalf("got %d orders, want 3 shipments", len(orders))
+	}
+
+	var buf strings.Builder
+	imp := &Importer{matchingAccount: "Liabilities:Card", output: &buf, amazonOrders: orders}
+	charge := func(date, payee, amount string) *ledger.Transaction {
+		d, _ := time.Parse(time.DateOnly, date)
+		a := decimal.RequireFromString(amount)
+		return &ledger.Transaction{Date: d, Payee: payee, AccountChanges: []ledger.Account{
+			{Name: "Liabilities:Card", Balance: a.Neg()},
+			{Name: "unknown:unknown", Balance: a},
+		}}
+	}
+	tests := []struct {
+		trans *ledger.Transaction
+		want  []string
+	}{
+		{charge("2024-01-04", "AMZN Mktp US*2A4", "20.00"), []string{";Amazon order 111-1", ";  USB Cable", ";  Phone Stand"}},
+		{charge("2024-01-06", "Amazon.com*RT5", "20.00"), []string{";Amazon order 222-2", ";  Paperback"}},
+		{charge("2024-01-11", "Corner Grocer", "30.00"), nil},
+		{charge("2024-01-30", "AMZN Mktp US*9Z1", "30.00"), nil},
+		{charge("2024-01-11", "AMZN Mktp US*7Q2", "30.00"), []string{";Amazon order 111-1", ";  Desk Lamp"}},
+	}
+	for _, tt := range tests {
+		imp.emit(tt.trans)
+		if strings.Join(tt.trans.Comments, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("%s: got comments %q, want %q", tt.trans.Payee, tt.trans.Comments, tt.want)
+		}
+	}
+}
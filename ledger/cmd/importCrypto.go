@@ -0,0 +1,315 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/howeyc/ledger"
+	"github.com/shopspring/decimal"
+)
+
+// parseMoney parses an amount of an export, leaving out currency symbols and
+// thousands separators, as in "-$1,234.56".
+func parseMoney(s string) (decimal.Decimal, error) {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) || r == '.' || r == '-' {
+			return r
+		}
+		return -1
+	}, s)
+	if s == "" {
+		return decimal.Zero, nil
+	}
+	return decimal.NewFromString(s)
+}
+
+// tradePostings returns the postings of buying units of the commodity, or
+// selling them for negative units, at the price in the currency: the units,
+// priced, and the currency paid or received go to the matching account, the
+// fee to Expenses:Fees. The currency paid is the units at the price plus the
+// fee, so that the transaction balances.
+func (imp *Importer) tradePostings(commodity, currency string, units, price, fee decimal.Decimal) []ledger.Account {
+	units = units.Round(ledger.MaxPlaces)
+	price = price.Mul(imp.decScale)
+	fee = fee.Mul(imp.decScale)
+	postings := []ledger.Account{
+		{Name: imp.matchingAccount, Currency: commodity, Balance: units, ConversionFactor: &price},
+		{Name: imp.matchingAccount, Currency: currency, Balance: units.Mul(price).Add(fee).Neg()},
+	}
+	if !fee.IsZero() {
+		postings = append(postings, ledger.Account{Name: feeAccount, Currency: currency, Balance: fee})
+	}
+	return postings
+}
+
+// timestampDate parses the date of a timestamp such as "2024-01-02 10:00:00" or
+// "2024-01-02T10:00:00Z".
+func timestampDate(s string) (time.Time, error) {
+	return time.Parse(time.DateOnly, s[:min(len(s), len(time.DateOnly))])
+}
+
+// krakenColumns are the columns of a Kraken trades export, -1 for those
+// missing.
+type krakenColumns struct {
+	txid, pair, time, kind, price, cost, fee, vol int
+}
+
+// findKrakenColumns returns the columns of a Kraken trades export, and
+// whether the header is of one.
+func findKrakenColumns(header []string) (krakenColumns, bool) {
+	columns := krakenColumns{-1, -1, -1, -1, -1, -1, -1, -1}
+	fields := map[string]*int{
+		"txid":  &columns.txid,
+		"pair":  &columns.pair,
+		"time":  &columns.time,
+		"type":  &columns.kind,
+		"price": &columns.price,
+		"cost":  &columns.cost,
+		"fee":   &columns.fee,
+		"vol":   &columns.vol,
+	}
+	for i, name := range header {
+		if column, ok := fields[strings.ToLower(strings.TrimSpace(name))]; ok && *column < 0 {
+			*column = i
+		}
+	}
+	ok := columns.txid >= 0 && columns.pair >= 0 && columns.time >= 0 && columns.kind >= 0 &&
+		columns.price >= 0 && columns.cost >= 0 && columns.vol >= 0
+	return columns, ok
+}
+
+// krakenQuotes are the currencies Kraken prices its pairs in, the longest
+// first.
+var krakenQuotes = []string{
+	"ZUSD", "ZEUR", "ZGBP", "ZCAD", "ZJPY", "ZAUD", "ZCHF", "XXBT", "XETH", "USDT", "USDC",
+	"USD", "EUR", "GBP", "CAD", "JPY", "AUD", "CHF", "XBT", "ETH", "DAI",
+}
+
+// krakenPair returns the commodity and the currency of a Kraken pair, such as
+// BTC and USD of XXBTZUSD or BTC/USD.
+func krakenPair(pair string) (base, quote string) {
+	pair = strings.ToUpper(strings.TrimSpace(pair))
+	if i := strings.IndexAny(pair, "/-"); i >= 0 {
+		return krakenAsset(pair[:i]), krakenAsset(pair[i+1:])
+	}
+	for _, q := range krakenQuotes {
+		if len(pair) > len(q) && strings.HasSuffix(pair, q) {
+			return krakenAsset(strings.TrimSuffix(pair, q)), krakenAsset(q)
+		}
+	}
+	return pair, ""
+}
+
+// krakenAsset returns the usual name of a Kraken asset: without the X or Z
+// of its four letter names, as XETH or ZUSD, and BTC for XBT, DOGE for XDG.
+func krakenAsset(asset string) string {
+	if len(asset) == 4 && (asset[0] == 'X' || asset[0] == 'Z') {
+		asset = asset[1:]
+	}
+	switch asset {
+	case "XBT":
+		return "BTC"
+	case "XDG":
+		return "DOGE"
+	}
+	return asset
+}
+
+// importKraken imports a Kraken trades export, see importKrakenRecords.
+func (imp *Importer) importKraken() {
+	csvRecords, err := imp.readCSV()
+	if err != nil {
+		fmt.Println("CSV parse error:", err.Error())
+		return
+	}
+	if len(csvRecords) == 0 {
+		return
+	}
+	if _, ok := findKrakenColumns(csvRecords[0]); !ok {
+		fmt.Println("Unable to find the columns of a Kraken trades export from the header.")
+		return
+	}
+	imp.importKrakenRecords(csvRecords)
+}
+
+// importKrakenRecords imports the records of a Kraken trades export, header
+// first: a transaction for each trade, buying or selling the commodity of
+// the pair at its price, with the fee.
+func (imp *Importer) importKrakenRecords(csvRecords [][]string) {
+	columns, _ := findKrakenColumns(csvRecords[0])
+	value := func(record []string, column int) string {
+		if column < 0 || column >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[column])
+	}
+
+	for _, record := range csvRecords[1:] {
+		date, err := timestampDate(value(record, columns.time))
+		if err != nil {
+			fmt.Println("Kraken date parse error:", err.Error())
+			continue
+		}
+		price, priceErr := parseMoney(value(record, columns.price))
+		fee, feeErr := parseMoney(value(record, columns.fee))
+		units, unitsErr := parseMoney(value(record, columns.vol))
+		if err := cmp.Or(priceErr, feeErr, unitsErr); err != nil {
+			fmt.Println("Kraken amount parse error:", err.Error())
+			continue
+		}
+
+		commodity, currency := krakenPair(value(record, columns.pair))
+		payee := "Buy " + commodity
+		if strings.EqualFold(value(record, columns.kind), "sell") {
+			payee, units = "Sell "+commodity, units.Neg()
+		}
+		id := value(record, columns.txid)
+		if !imp.newTransaction(date, payee, id) {
+			continue
+		}
+
+		trans := &ledger.Transaction{Date: date, Payee: payee,
+			AccountChanges: imp.tradePostings(commodity, currency, units, price, fee)}
+		trans.Comments = []string{";" + id}
+		imp.emit(trans)
+	}
+}
+
+// coinbaseColumns are the columns of a Coinbase transaction history, -1 for
+// those missing.
+type coinbaseColumns struct {
+	id, timestamp, kind, asset, quantity, currency, price, fee, notes int
+}
+
+// findCoinbaseColumns returns the columns of a Coinbase transaction history
+// and the index of its header, which follows a few lines about the account,
+// and whether the records are of one.
+func findCoinbaseColumns(csvRecords [][]string) (coinbaseColumns, int, bool) {
+	for i, header := range csvRecords[:min(len(csvRecords), 10)] {
+		columns := coinbaseColumns{-1, -1, -1, -1, -1, -1, -1, -1, -1}
+		fields := map[string]*int{
+			"id":                        &columns.id,
+			"timestamp":                 &columns.timestamp,
+			"transaction type":          &columns.kind,
+			"asset":                     &columns.asset,
+			"quantity transacted":       &columns.quantity,
+			"price currency":            &columns.currency,
+			"spot price currency":       &columns.currency,
+			"price at transaction":      &columns.price,
+			"spot price at transaction": &columns.price,
+			"fees and/or spread":        &columns.fee,
+			"notes":                     &columns.notes,
+		}
+		for j, name := range header {
+			if column, ok := fields[strings.ToLower(strings.TrimSpace(name))]; ok && *column < 0 {
+				*column = j
+			}
+		}
+		if columns.timestamp >= 0 && columns.kind >= 0 && columns.asset >= 0 && columns.quantity >= 0 &&
+			columns.currency >= 0 && columns.price >= 0 {
+			return columns, i, true
+		}
+	}
+	return coinbaseColumns{}, 0, false
+}
+
+// importCoinbase imports a Coinbase transaction history, see
+// importCoinbaseRecords.
+func (imp *Importer) importCoinbase() {
+	csvRecords, err := imp.readCSV()
+	if err != nil {
+		fmt.Println("CSV parse error:", err.Error())
+		return
+	}
+	if _, _, ok := findCoinbaseColumns(csvRecords); !ok {
+		fmt.Println("Unable to find the columns of a Coinbase transaction history from the header.")
+		return
+	}
+	imp.importCoinbaseRecords(csvRecords)
+}
+
+// importCoinbaseRecords imports the records of a Coinbase transaction
+// history. Buys and sells trade the asset at the price with the fees and
+// spread, rewards and staking income add the asset at the price from the
+// account predicted for them, and sends and receives move the asset between
+// the matching account and the account predicted from the notes. Other
+// transactions, such as conversions between assets, are left out with a
+// warning.
+func (imp *Importer) importCoinbaseRecords(csvRecords [][]string) {
+	columns, header, _ := findCoinbaseColumns(csvRecords)
+	value := func(record []string, column int) string {
+		if column < 0 || column >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[column])
+	}
+
+	recordHash := recordHashes()
+	for _, record := range csvRecords[header+1:] {
+		kind := value(record, columns.kind)
+		date, err := timestampDate(value(record, columns.timestamp))
+		if err != nil {
+			fmt.Println("Coinbase date parse error:", err.Error())
+			continue
+		}
+		units, unitsErr := parseMoney(value(record, columns.quantity))
+		price, priceErr := parseMoney(value(record, columns.price))
+		fee, feeErr := parseMoney(value(record, columns.fee))
+		if err := cmp.Or(unitsErr, priceErr, feeErr); err != nil {
+			fmt.Println("Coinbase amount parse error:", err.Error())
+			continue
+		}
+		units, fee = units.Abs(), fee.Abs()
+
+		asset := strings.ToUpper(value(record, columns.asset))
+		currency := strings.ToUpper(value(record, columns.currency))
+		notes := value(record, columns.notes)
+		payee := imp.normalizePayee(kind + " " + asset)
+
+		var postings []ledger.Account
+		switch k := strings.ToLower(kind); {
+		case strings.Contains(k, "buy"):
+			postings = imp.tradePostings(asset, currency, units, price, fee)
+		case strings.Contains(k, "sell"):
+			postings = imp.tradePostings(asset, currency, units.Neg(), price, fee)
+		case strings.Contains(k, "income") || strings.Contains(k, "reward"):
+			units = units.Round(ledger.MaxPlaces)
+			price = price.Mul(imp.decScale)
+			postings = []ledger.Account{
+				{Name: imp.matchingAccount, Currency: asset, Balance: units, ConversionFactor: &price},
+				{Name: imp.predictAccount(strings.Fields(payee)), Currency: currency, Balance: units.Mul(price).Neg()},
+			}
+		case k == "send" || k == "withdrawal" || k == "receive" || k == "deposit":
+			if k == "send" || k == "withdrawal" {
+				units = units.Neg()
+			}
+			units = units.Round(ledger.MaxPlaces)
+			other := imp.predictAccount(strings.Fields(imp.normalizePayee(cmp.Or(notes, payee))))
+			postings = []ledger.Account{
+				{Name: imp.matchingAccount, Currency: asset, Balance: units},
+				{Name: other, Currency: asset, Balance: units.Neg()},
+			}
+		default:
+			fmt.Fprintf(os.Stderr, "Coinbase %s of %s on %s not imported\n", kind, asset, date.Format(time.DateOnly))
+			continue
+		}
+
+		id := value(record, columns.id)
+		if id == "" {
+			id = recordHash(record...)
+		}
+		if !imp.newTransaction(date, payee, id) {
+			continue
+		}
+		trans := &ledger.Transaction{Date: date, Payee: payee, AccountChanges: postings}
+		trans.Comments = []string{";" + id}
+		if notes != "" {
+			trans.Comments = append(trans.Comments, ";"+notes)
+		}
+		imp.emit(trans)
+	}
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/howeyc/ledger"
+)
+
+// importedPostings imports the file with convert to the account and returns
+// a line for each transaction with its postings.
+func importedPostings(t *testing.T, account, filename string) []string {
+	t.Helper()
+	var buf strings.Builder
+	imp, err := newConverter(account, filename, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer imp.Close()
+	if err := imp.importFile(importFormat(filename)); err != nil {
+		t.Fatal(err)
+	}
+
+	trans, err := ledger.ParseLedger(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("%v in %q", err, buf.String())
+	}
+	var lines []string
+	for _, tr := range trans {
+		var postings []string
+		for _, acc := range tr.AccountChanges {
+			posting := acc.Name + " " + acc.Currency + " " + acc.Balance.String()
+			if acc.ConversionFactor != nil {
+				posting += " @ " + acc.ConversionFactor.String()
+			}
+			postings = append(postings, posting)
+		}
+		lines = append(lines, tr.Date.Format(transactionDateFormat)+" "+tr.Payee+": "+strings.Join(postings, ", "))
+	}
+	return lines
+}
+
+func Test_importKraken(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "trades.csv")
+	csvData := `"txid","ordertxid","pair","time","type","ordertype","price","cost","fee","vol","margin","misc","ledgers"
+"TX1","OX1","XXBTZUSD","2024-01-02 10:00:00.1234","buy","limit","42000.10000","518.51","0.82962","0.01234567","0.00000","",""
+"TX2","OX2","ETH/EUR","2024-01-03 11:00:00","sell","market","2000.00","1000.00","2.60","0.5","0.00000","",""
+`
+	if err := os.WriteFile(filename, []byte(csvData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := importedPostings(t, "Assets:Kraken", filename)
+	want := []string{
+		"2024/01/02 Buy BTC: Assets:Kraken BTC 0.01234567 @ 42000.1, Assets:Kraken USD -519.348994567, Expenses:Fees USD 0.82962",
+		"2024/01/03 Sell ETH: Assets:Kraken ETH -0.5 @ 2000, Assets:Kraken EUR 997.4, Expenses:Fees EUR 2.6",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func Test_importCoinbase(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "coinbase.csv")
+	csvData := `Transactions
+User,Jane Doe,abc123
+ID,Timestamp,Transaction Type,Asset,Quantity Transacted,Price Currency,Price at Transaction,Subtotal,Total (inclusive of fees and/or spread),Fees and/or Spread,Notes
+cb1,2024-01-02 10:00:00 UTC,Buy,BTC,0.001,USD,"$42,000.00",$42.00,$43.99,$1.99,Bought 0.001 BTC for $43.99 USD
+cb2,2024-01-05 10:00:00 UTC,Sell,BTC,-0.0005,USD,"$44,000.00",$22.00,$21.01,$0.99,Sold 0.0005 BTC for $21.01 USD
+cb3,2024-01-06 10:00:00 UTC,Staking Income,ETH,0.00012345,USD,"$2,000.00",$0.25,$0.25,$0.00,
+cb4,2024-01-07 10:00:00 UTC,Send,BTC,-0.0002,USD,"$44,000.00",$8.80,$8.80,$0.00,Sent to wallet
+cb5,2024-01-08 10:00:00 UTC,Convert,ETH,-0.0001,USD,"$2,000.00",$0.20,$0.20,$0.00,Converted 0.0001 ETH to 0.000005 BTC
+`
+	if err := os.WriteFile(filename, []byte(csvData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := importedPostings(t, "Assets:Coinbase", filename)
+	want := []string{
+		"2024/01/02 Buy BTC: Assets:Coinbase BTC 0.001 @ 42000, Assets:Coinbase USD -43.99, Expenses:Fees USD 1.99",
+		"2024/01/05 Sell BTC: Assets:Coinbase BTC -0.0005 @ 44000, Assets:Coinbase USD 21.01, Expenses:Fees USD 0.99",
+		"2024/01/06 Staking Income ETH: Assets:Coinbase ETH 0.00012345 @ 2000, unknown:unknown USD -0.2469",
+		"2024/01/07 Send BTC: Assets:Coinbase BTC -0.0002, unknown:unknown BTC 0.0002",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func Test_krakenPair(t *testing.T) {
+	for pair, want := range map[string]string{
+		"XXBTZUSD": "BTC USD",
+		"XETHXXBT": "ETH BTC",
+		"SOLEUR":   "SOL EUR",
+		"ADA/USDT": "ADA USDT",
+		"XDGUSD":   "DOGE USD",
+	} {
+		if base, quote := krakenPair(pair); base+" "+quote != want {
+			t.Errorf("krakenPair(%q) = %s %s, want %s", pair, base, quote, want)
+		}
+	}
+}
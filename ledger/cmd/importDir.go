@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// importExtensions are the extensions of the files imported from a directory.
+var importExtensions = []string{".csv", ".xml", ".qfx", ".ofx", ".qif", ".iif", ".sta", ".mt940", ".940", ".942", ".json"}
+
+// importDirFiles returns the files of dir with a recognized extension, the
+// oldest first by modification time, which is when a statement was
+// downloaded, and by name for files of the same time. Hidden files and
+// subdirectories are left out.
+func importDirFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	type dirFile struct {
+		path    string
+		modTime time.Time
+	}
+	var files []dirFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") ||
+			!slices.Contains(importExtensions, strings.ToLower(filepath.Ext(name))) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, dirFile{path: filepath.Join(dir, name), modTime: info.ModTime()})
+	}
+	slices.SortStableFunc(files, func(a, b dirFile) int {
+		if c := a.modTime.Compare(b.modTime); c != 0 {
+			return c
+		}
+		return strings.Compare(a.path, b.path)
+	})
+
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.path
+	}
+	return paths, nil
+}
+
+// importDir imports every file of dir, each in the format of its extension
+// and preceded by a comment naming it. A transaction is imported once, even
+// when statements overlap: ids imported from an earlier file are skipped in
+// the files that follow.
+func (imp *Importer) importDir(dir string) error {
+	paths, err := importDirFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	imp.batch = make(map[string]bool)
+	dirReader, dirFilename := imp.reader, imp.filename
+	defer func() { imp.reader, imp.filename = dirReader, dirFilename }()
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		imp.reader, imp.filename = f, path
+		imp.output.WriteString("; import: " + path + newLine + newLine)
+		err = imp.importFile(importFormat(path))
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_importDir(t *testing.T) {
+	dir := t.TempDir()
+	files := []struct {
+		name    string
+		data    string
+		modTime time.Time
+	}{
+		{"b-feb.csv", "Date,Description,Amount\n01/31/2024,Grocer,50\n02/02/2024,Cinema,12\n", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"a-jan.csv", "Date,Description,Amount\n01/02/2024,Bakery,5\n01/31/2024,Grocer,50\n", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"notes.txt", "not a statement\n", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{".hidden.csv", "Date,Description,Amount\n01/05/2024,Hidden,1\n", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, f.name)
+		if err := os.WriteFile(path, []byte(f.data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, f.modTime, f.modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths, err := importDirFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "a-jan.csv"), filepath.Join(dir, "b-feb.csv")}
+	if !slices.Equal(paths, want) {
+		t.Errorf("importDirFiles = %q, want %q", paths, want)
+	}
+
+	var buf strings.Builder
+	imp, err := newConverter("Assets:Checking", dir, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer imp.Close()
+	if err := imp.importDir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	// the grocer of both statements is imported once, from the first
+	if n := strings.Count(got, "Grocer"); n != 1 {
+		t.Errorf("got %q, want one Grocer transaction, got %d", got, n)
+	}
+	order := []string{"; import: " + want[0], "Bakery", "Grocer", "; import: " + want[1], "Cinema"}
+	last := -1
+	for _, s := range order {
+		i := strings.Index(got, s)
+		if i <= last {
+			t.Errorf("got %q, want %q after the previous entries", got, s)
+		}
+		last = i
+	}
+	if imp.reader == nil || imp.filename != dir {
+		t.Errorf("importDir did not restore the directory reader")
+	}
+}
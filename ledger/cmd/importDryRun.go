@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/howeyc/ledger"
+	"github.com/howeyc/ledger/ledger/internal/fastcolor"
+	"github.com/shopspring/decimal"
+)
+
+var importDryRun bool
+
+// previewStatus is how an imported transaction relates to the ledger.
+type previewStatus int
+
+const (
+	previewNew       previewStatus = iota // no transaction of the ledger is like it
+	previewMatch                          // one transaction of the ledger matches it
+	previewAmbiguous                      // several match, or one is like it but does not match
+)
+
+// importPreview counts the transactions previewed by status.
+type importPreview struct {
+	w      io.StringWriter
+	counts [3]int
+}
+
+// nearDays is how many days apart, beyond --match-days, a transaction of the
+// ledger moving the same amount is taken as possibly the imported one.
+const nearDays = 3
+
+// previewMatches returns the status of the imported transaction and the
+// transactions of the ledger it is compared with: those matching it, see
+// matchesTransaction, or else those moving the same amount dated nearby.
+func (imp *Importer) previewMatches(trans *ledger.Transaction) (previewStatus, []*ledger.Transaction) {
+	var matches, near []*ledger.Transaction
+	size := transactionSize(trans)
+	for _, existing := range imp.generalLedger {
+		if matchesTransaction(existing, trans) {
+			matches = append(matches, existing)
+			continue
+		}
+		days := importMatchDays + nearDays
+		if !existing.Date.Before(trans.Date.AddDate(0, 0, -days)) &&
+			!existing.Date.After(trans.Date.AddDate(0, 0, days)) &&
+			transactionSize(existing).Equal(size) {
+			near = append(near, existing)
+		}
+	}
+	switch {
+	case len(matches) == 1:
+		return previewMatch, matches
+	case len(matches) > 1:
+		return previewAmbiguous, matches
+	case len(near) > 0:
+		return previewAmbiguous, near
+	}
+	return previewNew, nil
+}
+
+// previewTransaction writes a line for the imported transaction instead of
+// the transaction: '+' for a new transaction with its predicted account, '='
+// for one the ledger has and '?' for one that may be in the ledger, with the
+// transaction of the ledger it is like.
+func (imp *Importer) previewTransaction(trans *ledger.Transaction) {
+	status, matches := imp.previewMatches(trans)
+	imp.preview.counts[status]++
+
+	color, marker := fastcolor.CurrentTheme.Positive, "+"
+	switch status {
+	case previewMatch:
+		color, marker = fastcolor.Reset, "="
+	case previewAmbiguous:
+		color, marker = fastcolor.FgYellow, "?"
+	}
+
+	var amount decimal.Decimal
+	currency := ""
+	other := ""
+	for _, acc := range trans.AccountChanges {
+		if acc.Name == imp.matchingAccount {
+			amount, currency = amount.Add(acc.Balance), acc.Currency
+		} else if other == "" {
+			other = acc.Name
+		}
+	}
+	if status != previewNew {
+		other = matches[0].Date.Format(transactionDateFormat) + " " + matches[0].Payee
+		if len(matches) > 1 {
+			other += " and " + strconv.Itoa(len(matches)-1) + " more"
+		}
+	}
+
+	w := imp.preview.w
+	color.WriteStringFixed(w, marker+" "+trans.Date.Format(transactionDateFormat), 13, false)
+	color.WriteStringFixed(w, " "+trans.Payee, 37, false)
+	color.WriteStringFixed(w, formatAmount(currency, amount), 14, true)
+	color.WriteStringFixed(w, "  "+marker+" "+other, len([]rune(other))+4, false)
+	w.WriteString(newLine)
+}
+
+// summary writes the number of transactions of each status.
+func (p *importPreview) summary() {
+	p.w.WriteString(fmt.Sprintf("%d new, %d matching, %d ambiguous%s",
+		p.counts[previewNew], p.counts[previewMatch], p.counts[previewAmbiguous], newLine))
+}
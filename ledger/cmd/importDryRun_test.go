@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/howeyc/ledger"
+	"github.com/howeyc/ledger/ledger/internal/fastcolor"
+)
+
+func Test_importDryRun(t *testing.T) {
+	defer func(noColor bool) { fastcolor.NoColor = noColor }(fastcolor.NoColor)
+	fastcolor.NoColor = true
+
+	generalLedger, err := ledger.ParseLedger(strings.NewReader(`2024/01/02 Coffee Shop
+    Expenses:Coffee    4.50
+    Assets:Checking
+
+2024/01/05 Corner Grocer
+    Expenses:Food    12
+    Assets:Checking
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(t.TempDir(), "bank.csv")
+	csvData := "Date,Description,Amount\n01/02/2024,Coffee Shop,-4.50\n01/06/2024,CORNER GROCER,-12\n01/07/2024,Bakery,-3\n"
+	if err := os.WriteFile(filename, []byte(csvData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf strings.Builder
+	imp, err := newConverter("Assets:Checking", filename, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer imp.Close()
+	imp.generalLedger = generalLedger
+	imp.preview = &importPreview{w: &buf}
+	imp.importCSV()
+	imp.preview.summary()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []struct{ prefix, suffix string }{
+		{"= 2024/01/02  Coffee Shop", "= 2024/01/02 Coffee Shop"},
+		{"? 2024/01/06  CORNER GROCER", "? 2024/01/05 Corner Grocer"},
+		{"+ 2024/01/07  Bakery", "+ unknown:unknown"},
+		{"1 new, 1 matching, 1 ambiguous", ""},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %q, want %d lines", buf.String(), len(want))
+	}
+	for i, w := range want {
+		if !strings.HasPrefix(lines[i], w.prefix) || !strings.HasSuffix(lines[i], w.suffix) {
+			t.Errorf("line %d = %q, want %q ... %q", i, lines[i], w.prefix, w.suffix)
+		}
+	}
+	if len(imp.imported) != 0 {
+		t.Errorf("dry run imported %d transactions", len(imp.imported))
+	}
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/howeyc/ledger"
+	"github.com/howeyc/ledger/ledger/jsonmap"
+	"github.com/shopspring/decimal"
+)
+
+var jsonMappingFile string
+
+// importJSON imports the json file as described by the mapping file, see
+// package jsonmap. The amount of a transaction goes to the matching account,
+// its negative to the predicted account.
+func (imp *Importer) importJSON() {
+	if jsonMappingFile == "" {
+		fmt.Println("JSON import needs a mapping file, see --json-mapping")
+		return
+	}
+	mappingReader, err := os.Open(jsonMappingFile)
+	if err != nil {
+		fmt.Println("JSON mapping:", err)
+		return
+	}
+	mapping, err := jsonmap.Parse(mappingReader)
+	mappingReader.Close()
+	if err != nil {
+		fmt.Printf("%s:%s\n", jsonMappingFile, err.Error())
+		return
+	}
+	records, err := mapping.Records(imp.reader)
+	if err != nil {
+		fmt.Println("JSON parse error:", err.Error())
+		return
+	}
+
+	recordHash := recordHashes()
+	for _, record := range records {
+		id := record.ID
+		if id == "" {
+			id = recordHash(record.Date, record.Payee, record.Amount, record.Comment)
+		}
+		payee := imp.normalizePayee(record.Payee)
+		jsonDate, err := mapping.ParseDate(record.Date, csvDateFormat)
+		if err != nil {
+			fmt.Println("JSON date parse error:", err.Error())
+			continue
+		}
+		if !imp.newTransaction(jsonDate, payee, id) {
+			continue
+		}
+
+		amount, err := decimal.NewFromString(record.Amount)
+		if err != nil {
+			fmt.Println("JSON amount parse error:", err.Error())
+			continue
+		}
+		if mapping.NegateAmount {
+			amount = amount.Neg()
+		}
+		if negateAmount {
+			amount = amount.Neg()
+		}
+		amount = amount.Mul(imp.decScale)
+
+		jsonAccount := ledger.Account{Name: imp.matchingAccount, Balance: amount}
+		otherAccount := ledger.Account{Name: imp.predictAccount(strings.Fields(payee)), Balance: amount.Neg()}
+
+		trans := &ledger.Transaction{Date: jsonDate, Payee: payee}
+		trans.AccountChanges = []ledger.Account{jsonAccount, otherAccount}
+		setCurrency(trans.AccountChanges, record.Currency)
+		if record.ID != "" {
+			trans.Comments = []string{";" + record.ID}
+		}
+		if record.Comment != "" {
+			trans.Comments = append(trans.Comments, ";"+record.Comment)
+		}
+		imp.emit(trans)
+	}
+}
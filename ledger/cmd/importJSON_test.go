@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/howeyc/ledger"
+)
+
+func Test_importJSON(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "export.json")
+	jsonData := `{"items": [
+  {"id": "t1", "date": "2024-01-02", "amount": "-50.00", "currency": "EUR", "counterparty": {"name": "Grocer"}},
+  {"id": "t2", "date": "2024-01-03", "amount": 1000, "currency": "EUR", "reference": "Salary"},
+  {"date": "2024-01-04", "amount": "-2", "reference": "Fee"}
+]}`
+	if err := os.WriteFile(filename, []byte(jsonData), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mappingFile := filepath.Join(dir, "export.mapping")
+	mappingData := "records items\ndate date\ndate-format 2006-01-02\namount amount\ncurrency currency\npayee counterparty.name | reference\nid id\n"
+	if err := os.WriteFile(mappingFile, []byte(mappingData), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer func(old, currency string) { jsonMappingFile, overrideCurrency = old, currency }(jsonMappingFile, overrideCurrency)
+	jsonMappingFile, overrideCurrency = mappingFile, "USD"
+
+	var buf strings.Builder
+	imp, err := newConverter("Assets:Checking", filename, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer imp.Close()
+	if err := imp.importFile(importFormat(filename)); err != nil {
+		t.Fatal(err)
+	}
+
+	trans, err := ledger.ParseLedger(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("%v in %q", err, buf.String())
+	}
+	if len(trans) != 3 {
+		t.Fatalf("got %d transactions, want 3: %q", len(trans), buf.String())
+	}
+	balances := make(map[string]string)
+	for _, tr := range trans {
+		for _, acc := range tr.AccountChanges {
+			balances[tr.Payee+" "+acc.Name] = acc.Currency + " " + acc.Balance.String()
+		}
+	}
+	want := map[string]string{
+		"Grocer Assets:Checking": "EUR -50",
+		"Salary Assets:Checking": "EUR 1000",
+		"Salary unknown:unknown": "EUR -1000",
+		"Fee Assets:Checking":    "USD -2",
+	}
+	for key, w := range want {
+		if balances[key] != w {
+			t.Errorf("%s = %q, want %q", key, balances[key], w)
+		}
+	}
+	if !strings.Contains(buf.String(), ";t1") {
+		t.Errorf("got %q, want the id as comment", buf.String())
+	}
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/howeyc/ledger"
+)
+
+var importMatchDays int
+var importMatchPayee float64
+var importMatchAmount bool
+
+// existingTransaction returns whether the ledger has the imported
+// transaction, see matchesTransaction.
+func (imp *Importer) existingTransaction(trans *ledger.Transaction) bool {
+	return slices.ContainsFunc(imp.generalLedger, func(existing *ledger.Transaction) bool {
+		return matchesTransaction(existing, trans)
+	})
+}
+
+// matchesTransaction returns whether a transaction of the ledger is the
+// imported one: of the same date and payee, or with --match-days and
+// --match-payee dated at most that many days apart and with a payee at least
+// that similar, see payeeSimilarity. With --match-amount it must also move
+// the same amount.
+func matchesTransaction(existing, trans *ledger.Transaction) bool {
+	if existing.Date.Before(trans.Date.AddDate(0, 0, -importMatchDays)) ||
+		existing.Date.After(trans.Date.AddDate(0, 0, importMatchDays)) {
+		return false
+	}
+	if payeeSimilarity(existing.Payee, trans.Payee) < importMatchPayee {
+		return false
+	}
+	return !importMatchAmount || transactionSize(existing).Equal(transactionSize(trans))
+}
+
+// payeeSimilarity returns how alike two payees are, from 0 to 1 for the same
+// payee: one less the edit distance of their letters, ignoring case, relative
+// to the longer. Digits, spaces and punctuation, such as the store numbers
+// and references banks add, are left out.
+func payeeSimilarity(a, b string) float64 {
+	if strings.TrimSpace(a) == strings.TrimSpace(b) {
+		return 1
+	}
+	letters := func(s string) []rune {
+		var runes []rune
+		for _, r := range strings.ToLower(s) {
+			if unicode.IsLetter(r) {
+				runes = append(runes, r)
+			}
+		}
+		return runes
+	}
+	ra, rb := letters(a), letters(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 0
+	}
+	return 1 - float64(editDistance(ra, rb))/float64(longest)
+}
+
+// editDistance returns the Levenshtein distance of a and b.
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
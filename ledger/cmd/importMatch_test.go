@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/howeyc/ledger"
+)
+
+func Test_payeeSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		min  float64
+		max  float64
+	}{
+		{"Corner Grocer", " Corner Grocer ", 1, 1},
+		{"CORNER GROCER #1234", "Corner Grocer", 1, 1},
+		{"SQ *COFFEE SHOP", "Coffee Shop", 0.8, 0.9},
+		{"Corner Grocer", "Bookshop", 0, 0.3},
+		{"1234", "5678", 0, 0},
+	}
+	for _, tt := range tests {
+		if got := payeeSimilarity(tt.a, tt.b); got < tt.min || got > tt.max {
+			t.Errorf("payeeSimilarity(%q, %q) = %v, want %v to %v", tt.a, tt.b, got, tt.min, tt.max)
+		}
+	}
+}
+
+func Test_importFuzzyMatch(t *testing.T) {
+	defer func(days int, payee float64, amount bool) {
+		importMatchDays, importMatchPayee, importMatchAmount = days, payee, amount
+	}(importMatchDays, importMatchPayee, importMatchAmount)
+
+	generalLedger, err := ledger.ParseLedger(strings.NewReader(`2024/01/02 Coffee Shop
+    Expenses:Coffee    4.50
+    Assets:Checking
+
+2024/01/05 Corner Grocer
+    Expenses:Food    50
+    Assets:Checking
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(t.TempDir(), "bank.csv")
+	// posted a day later, with the payees of the bank
+	csvData := "Date,Description,Amount\n01/03/2024,SQ *COFFEE SHOP 0042,-4.50\n01/06/2024,CORNER GROCER,-12\n01/06/2024,Bookshop,-20\n"
+	if err := os.WriteFile(filename, []byte(csvData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		days   int
+		payee  float64
+		amount bool
+		want   []string
+	}{
+		{0, 1, false, []string{"SQ *COFFEE SHOP 0042", "CORNER GROCER", "Bookshop"}},
+		{1, 1, false, []string{"SQ *COFFEE SHOP 0042", "Bookshop"}},
+		{1, 0.8, false, []string{"Bookshop"}},
+		{1, 0.8, true, []string{"CORNER GROCER", "Bookshop"}},
+	}
+	for _, tt := range tests {
+		importMatchDays, importMatchPayee, importMatchAmount = tt.days, tt.payee, tt.amount
+
+		var buf strings.Builder
+		imp, err := newConverter("Assets:Checking", filename, &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		imp.generalLedger = generalLedger
+		imp.importCSV()
+		imp.Close()
+
+		var payees []string
+		for _, trans := range imp.imported {
+			payees = append(payees, trans.Payee)
+		}
+		if strings.Join(payees, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("days %d, payee %v, amount %v: imported %q, want %q", tt.days, tt.payee, tt.amount, payees, tt.want)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/jbrukh/bayesian"
+)
+
+var importModelFile string
+
+// classifierModel is a trained classifier saved to a model file, with what
+// it was trained on: the matching account and a fingerprint of the ledger.
+// It also keeps the accounts guessed for the transactions imported with it,
+// and the corrections learned from those, see learn.
+type classifierModel struct {
+	Account     string
+	Fingerprint string
+	Classifier  []byte
+	Predictions []modelPrediction
+	Corrections []modelCorrection
+}
+
+// modelPrediction is the account guessed for an imported transaction.
+type modelPrediction struct {
+	Date    time.Time
+	Payee   string
+	Account string
+}
+
+// modelCorrection is the account of a payee the guessed account was
+// corrected to, learned again each time the classifier is trained.
+type modelCorrection struct {
+	Payee   string
+	Account string
+}
+
+// trainingFingerprint returns a hash of what the classifier learns from the
+// ledger for the matching account, so a model can be reused as long as it
+// does not change.
+func (imp *Importer) trainingFingerprint(matchingAccount string) string {
+	h := sha256.New()
+	for _, trans := range imp.generalLedger {
+		h.Write([]byte(trans.Payee))
+		for _, acc := range trans.AccountChanges {
+			h.Write([]byte{0})
+			h.Write([]byte(acc.Name))
+		}
+		h.Write([]byte{'\n'})
+	}
+	h.Write([]byte(matchingAccount))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// readClassifierModel reads the model file.
+func readClassifierModel(path string) (classifierModel, error) {
+	var model classifierModel
+	f, err := os.Open(path)
+	if err != nil {
+		return model, err
+	}
+	defer f.Close()
+	err = gob.NewDecoder(f).Decode(&model)
+	return model, err
+}
+
+// loadClassifierModel returns the classifier of the model file when it was
+// trained for the matching account on a ledger with the fingerprint.
+func loadClassifierModel(path, matchingAccount, fingerprint string) (*bayesian.Classifier, bool) {
+	model, err := readClassifierModel(path)
+	if err != nil {
+		return nil, false
+	}
+	if model.Account != matchingAccount || model.Fingerprint != fingerprint {
+		return nil, false
+	}
+	classifier, err := bayesian.NewClassifierFromReader(bytes.NewReader(model.Classifier))
+	if err != nil {
+		return nil, false
+	}
+	return classifier, true
+}
+
+// saveClassifierModel writes the model with the classifier to the model file.
+func saveClassifierModel(path string, model classifierModel, classifier *bayesian.Classifier) error {
+	var buf bytes.Buffer
+	if err := classifier.WriteTo(&buf); err != nil {
+		return err
+	}
+	model.Classifier = buf.Bytes()
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(f).Encode(model); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// modelClassifier returns the classifier for the matching account, loaded
+// from the model file when it is up to date with the ledger, otherwise
+// trained and, with a model file, saved to it. The predictions and
+// corrections of the model file for the account are kept.
+func (imp *Importer) modelClassifier(matchingAccount string) (*bayesian.Classifier, error) {
+	if importModelFile == "" {
+		return imp.trainClassifier(matchingAccount), nil
+	}
+	fingerprint := imp.trainingFingerprint(matchingAccount)
+	if classifier, ok := loadClassifierModel(importModelFile, matchingAccount, fingerprint); ok {
+		return classifier, nil
+	}
+	model := classifierModel{Account: matchingAccount, Fingerprint: fingerprint}
+	if saved, err := readClassifierModel(importModelFile); err == nil && saved.Account == matchingAccount {
+		model.Predictions, model.Corrections = saved.Predictions, saved.Corrections
+	}
+	classifier := imp.trainModel(matchingAccount, model.Corrections)
+	return classifier, saveClassifierModel(importModelFile, model, classifier)
+}
+
+// trainModel trains the classifier on the ledger, then learns the
+// corrections again, so they weigh more than the guesses they correct.
+func (imp *Importer) trainModel(matchingAccount string, corrections []modelCorrection) *bayesian.Classifier {
+	classifier := imp.trainClassifier(matchingAccount)
+	for _, c := range corrections {
+		bayesClassifier{classifier}.Learn(strings.Fields(c.Payee), c.Account)
+	}
+	return classifier
+}
+
+// predictedAccount returns the other account of an imported transaction with
+// two postings, the account guessed for it, or "".
+func predictedAccount(trans *ledger.Transaction, matchingAccount string) string {
+	if len(trans.AccountChanges) != 2 {
+		return ""
+	}
+	for _, acc := range trans.AccountChanges {
+		if acc.Name != matchingAccount {
+			return acc.Name
+		}
+	}
+	return ""
+}
+
+// recordPredictions adds the accounts guessed for the imported transactions
+// to the model file of the matching account, for learn to compare with the
+// accounts they have in the ledger later.
+func recordPredictions(path, matchingAccount string, imported []*ledger.Transaction) error {
+	model, err := readClassifierModel(path)
+	if err != nil || model.Account != matchingAccount {
+		return err
+	}
+	classifier, err := bayesian.NewClassifierFromReader(bytes.NewReader(model.Classifier))
+	if err != nil {
+		return err
+	}
+	for _, trans := range imported {
+		if account := predictedAccount(trans, matchingAccount); account != "" {
+			model.Predictions = append(model.Predictions, modelPrediction{Date: trans.Date, Payee: trans.Payee, Account: account})
+		}
+	}
+	return saveClassifierModel(path, model, classifier)
+}
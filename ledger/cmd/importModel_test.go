@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/howeyc/ledger"
+)
+
+func Test_modelClassifier(t *testing.T) {
+	generalLedger := []*ledger.Transaction{
+		{Payee: "Corner Grocer", AccountChanges: []ledger.Account{{Name: "Assets:Checking"}, {Name: "Expenses:Food"}}},
+		{Payee: "Gas Station", AccountChanges: []ledger.Account{{Name: "Assets:Checking"}, {Name: "Expenses:Fuel"}}},
+	}
+	imp := &Importer{generalLedger: generalLedger}
+
+	defer func(old string) { importModelFile = old }(importModelFile)
+	importModelFile = filepath.Join(t.TempDir(), "import.model")
+
+	trained, err := imp.modelClassifier("Assets:Checking")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fingerprint := imp.trainingFingerprint("Assets:Checking")
+	loaded, ok := loadClassifierModel(importModelFile, "Assets:Checking", fingerprint)
+	if !ok {
+		t.Fatal("saved model not loaded")
+	}
+	if !slices.Equal(loaded.Classes, trained.Classes) || loaded.Learned() != trained.Learned() {
+		t.Errorf("loaded model differs: %v (%d learned), want %v (%d learned)",
+			loaded.Classes, loaded.Learned(), trained.Classes, trained.Learned())
+	}
+
+	if _, ok := loadClassifierModel(importModelFile, "Assets:Savings", fingerprint); ok {
+		t.Error("model loaded for another account")
+	}
+	imp.generalLedger = append(imp.generalLedger, &ledger.Transaction{
+		Payee:          "Bookshop",
+		AccountChanges: []ledger.Account{{Name: "Assets:Checking"}, {Name: "Expenses:Books"}},
+	})
+	if _, ok := loadClassifierModel(importModelFile, "Assets:Checking", imp.trainingFingerprint("Assets:Checking")); ok {
+		t.Error("model loaded after the ledger changed")
+	}
+}
@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/shopspring/decimal"
+)
+
+var paypalFundingAccount string
+
+// paypalColumns are the columns of a PayPal activity download, -1 for those
+// missing.
+type paypalColumns struct {
+	date, name, kind, status, currency, gross, fee, net, id, ref, title int
+}
+
+// findPayPalColumns returns the columns of a PayPal activity download, and
+// whether the header is of one.
+func findPayPalColumns(header []string) (paypalColumns, bool) {
+	columns := paypalColumns{-1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1}
+	fields := map[string]*int{
+		"date":             &columns.date,
+		"name":             &columns.name,
+		"type":             &columns.kind,
+		"status":           &columns.status,
+		"currency":         &columns.currency,
+		"gross":            &columns.gross,
+		"fee":              &columns.fee,
+		"net":              &columns.net,
+		"transaction id":   &columns.id,
+		"reference txn id": &columns.ref,
+		"item title":       &columns.title,
+	}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if column, ok := fields[name]; ok && *column < 0 {
+			*column = i
+		}
+	}
+	ok := columns.date >= 0 && columns.kind >= 0 && columns.currency >= 0 &&
+		columns.gross >= 0 && columns.net >= 0 && columns.id >= 0
+	return columns, ok
+}
+
+// paypalRow is a row of a PayPal activity download.
+type paypalRow struct {
+	date                                 time.Time
+	name, kind, currency, id, ref, title string
+	gross, fee, net                      decimal.Decimal
+}
+
+// funding returns whether the row moves money from a bank account or card
+// into PayPal, to pay with or to top up the balance.
+func (r paypalRow) funding() bool {
+	return strings.Contains(strings.ToLower(r.kind), "deposit")
+}
+
+// conversion returns whether the row is one side of a currency conversion.
+func (r paypalRow) conversion() bool {
+	return strings.Contains(strings.ToLower(r.kind), "currency conversion")
+}
+
+// paypalSkipped returns whether a row of the type and status is left out:
+// holds and authorizations, which are released again, and payments not
+// completed.
+func paypalSkipped(kind, status string) bool {
+	kind = strings.ToLower(kind)
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "pending", "denied", "removed", "canceled", "cancelled":
+		return true
+	}
+	return strings.Contains(kind, "hold") || strings.Contains(kind, "authorization")
+}
+
+// paypalAmount parses an amount written with thousands separators, such as
+// "1,234.56" or "1.234,56".
+func paypalAmount(s string) (decimal.Decimal, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), " ", "")
+	if s == "" {
+		return decimal.Zero, nil
+	}
+	comma, dot := strings.LastIndex(s, ","), strings.LastIndex(s, ".")
+	switch {
+	case comma > dot && (dot >= 0 || len(s)-comma-1 != 3):
+		// decimal comma
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.Replace(s, ",", ".", 1)
+	default:
+		s = strings.ReplaceAll(s, ",", "")
+	}
+	return decimal.NewFromString(s)
+}
+
+// importPayPal imports a PayPal activity download, see importPayPalRecords.
+func (imp *Importer) importPayPal() {
+	csvRecords, err := imp.readCSV()
+	if err != nil {
+		fmt.Println("CSV parse error:", err.Error())
+		return
+	}
+	if len(csvRecords) == 0 {
+		return
+	}
+	if _, ok := findPayPalColumns(csvRecords[0]); !ok {
+		fmt.Println("Unable to find the columns of a PayPal activity download from the header.")
+		return
+	}
+	imp.importPayPalRecords(csvRecords)
+}
+
+// importPayPalRecords imports the records of a PayPal activity download,
+// header first. The rows of a payment, such as its currency conversion and
+// the deposit from a bank account or card funding it, which refer to it by
+// their reference id, are collapsed into a single transaction: the net amounts go to the matching account, the gross
+// of payments to the predicted account, their fees to Expenses:Fees and the
+// deposits from --paypal-funding, unknown:funding unless set. Holds, and payments that are pending or
+// denied, are left out.
+func (imp *Importer) importPayPalRecords(csvRecords [][]string) {
+	columns, _ := findPayPalColumns(csvRecords[0])
+
+	value := func(record []string, column int) string {
+		if column < 0 || column >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[column])
+	}
+	var rows []paypalRow
+	for _, record := range csvRecords[1:] {
+		if paypalSkipped(value(record, columns.kind), value(record, columns.status)) {
+			continue
+		}
+		date, err := time.Parse(csvDateFormat, value(record, columns.date))
+		if err != nil {
+			fmt.Println("PayPal date parse error:", err.Error())
+			continue
+		}
+		row := paypalRow{
+			date:     date,
+			name:     value(record, columns.name),
+			kind:     value(record, columns.kind),
+			currency: value(record, columns.currency),
+			id:       value(record, columns.id),
+			ref:      value(record, columns.ref),
+			title:    value(record, columns.title),
+		}
+		var grossErr, feeErr, netErr error
+		row.gross, grossErr = paypalAmount(value(record, columns.gross))
+		row.fee, feeErr = paypalAmount(value(record, columns.fee))
+		row.net, netErr = paypalAmount(value(record, columns.net))
+		if err := cmp.Or(grossErr, feeErr, netErr); err != nil {
+			fmt.Println("PayPal amount parse error:", err.Error())
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	// the rows of each payment, by the id of the payment
+	ids := make(map[string]bool)
+	for _, row := range rows {
+		ids[row.id] = true
+	}
+	groups := make(map[string][]paypalRow)
+	var keys []string
+	for _, row := range rows {
+		key := row.id
+		if row.ref != "" && ids[row.ref] {
+			key = row.ref
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], row)
+	}
+	main := func(key string) paypalRow {
+		group := groups[key]
+		if i := slices.IndexFunc(group, func(r paypalRow) bool { return r.id == key }); i >= 0 {
+			return group[i]
+		}
+		return group[0]
+	}
+	// downloads list the newest first
+	slices.SortStableFunc(keys, func(a, b string) int {
+		return main(a).date.Compare(main(b).date)
+	})
+
+	for _, key := range keys {
+		payment := main(key)
+		payee := imp.normalizePayee(cmp.Or(payment.name, payment.kind))
+		if !imp.newTransaction(payment.date, payee, key) {
+			continue
+		}
+
+		// amounts of each account by currency, in the order of the rows
+		type amount struct {
+			account, currency string
+			balance           decimal.Decimal
+		}
+		var amounts []amount
+		add := func(account, currency string, balance decimal.Decimal) {
+			currency = cmp.Or(currency, overrideCurrency)
+			i := slices.IndexFunc(amounts, func(a amount) bool {
+				return a.account == account && a.currency == currency
+			})
+			if i < 0 {
+				amounts = append(amounts, amount{account: account, currency: currency})
+				i = len(amounts) - 1
+			}
+			amounts[i].balance = amounts[i].balance.Add(balance.Mul(imp.decScale))
+		}
+		otherAccount := imp.predictAccount(strings.Fields(payee))
+		for _, row := range groups[key] {
+			add(imp.matchingAccount, row.currency, row.net)
+			switch {
+			case row.funding():
+				add(paypalFundingAccount, row.currency, row.net.Neg())
+			case row.conversion():
+			default:
+				add(otherAccount, row.currency, row.gross.Neg())
+				add(feeAccount, row.currency, row.fee.Neg())
+			}
+		}
+
+		trans := &ledger.Transaction{Date: payment.date, Payee: payee}
+		for _, a := range amounts {
+			if !a.balance.IsZero() {
+				trans.AccountChanges = append(trans.AccountChanges, ledger.Account{Name: a.account, Currency: a.currency, Balance: a.balance})
+			}
+		}
+		if len(trans.AccountChanges) < 2 {
+			continue
+		}
+		trans.Comments = []string{";" + key}
+		if payment.title != "" {
+			trans.Comments = append(trans.Comments, ";"+payment.title)
+		}
+		imp.emit(trans)
+	}
+}
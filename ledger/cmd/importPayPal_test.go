@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/howeyc/ledger"
+)
+
+func Test_importPayPal(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "Download.CSV")
+	csvData := "\ufeff" + `"Date","Time","TimeZone","Name","Type","Status","Currency","Gross","Fee","Net","Transaction ID","Reference Txn ID","Item Title"
+"01/05/2024","10:00:00","PST","Customer Co","Express Checkout Payment","Completed","USD","1,000.00","-29.30","970.70","R1","","Consulting"
+"01/03/2024","09:00:00","PST","Shop EU","Express Checkout Payment","Completed","EUR","-10.00","0.00","-10.00","P1","",""
+"01/03/2024","09:00:00","PST","","General Currency Conversion","Completed","EUR","10.00","0.00","10.00","C1","P1",""
+"01/03/2024","09:00:00","PST","","General Currency Conversion","Completed","USD","-11.00","0.00","-11.00","C2","P1",""
+"01/03/2024","09:00:00","PST","","Bank Deposit to PP Account ","Completed","USD","11.00","0.00","11.00","D1","P1",""
+"01/02/2024","08:00:00","PST","Hotel","General Authorization","Pending","USD","-50.00","0.00","-50.00","A1","",""
+"01/02/2024","08:00:00","PST","","Account Hold for Open Authorization","Completed","USD","-50.00","0.00","-50.00","H1","A1",""
+`
+	if err := os.WriteFile(filename, []byte(csvData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf strings.Builder
+	imp, err := newConverter("Assets:PayPal", filename, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer imp.Close()
+	if err := imp.importFile(importFormat(filename)); err != nil {
+		t.Fatal(err)
+	}
+
+	trans, err := ledger.ParseLedger(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("%v in %q", err, buf.String())
+	}
+	if len(trans) != 2 {
+		t.Fatalf("got %d transactions, want 2: %q", len(trans), buf.String())
+	}
+	if trans[0].Payee != "Shop EU" || trans[1].Payee != "Customer Co" {
+		t.Errorf("got payees %q and %q, want Shop EU and Customer Co", trans[0].Payee, trans[1].Payee)
+	}
+	want := [][]string{
+		{"unknown:funding USD -11", "unknown:unknown EUR 10"},
+		{"Assets:PayPal USD 970.7", "Expenses:Fees USD 29.3", "unknown:unknown USD -1000"},
+	}
+	for i, tr := range trans {
+		var got []string
+		for _, acc := range tr.AccountChanges {
+			got = append(got, acc.Name+" "+acc.Currency+" "+acc.Balance.String())
+		}
+		if strings.Join(got, ", ") != strings.Join(want[i], ", ") {
+			t.Errorf("%s: got postings %q, want %q", tr.Payee, got, want[i])
+		}
+	}
+}
+
+func Test_paypalAmount(t *testing.T) {
+	for s, want := range map[string]string{
+		"1,234.56":  "1234.56",
+		"-1.234,56": "-1234.56",
+		"12,50":     "12.5",
+		"1,000":     "1000",
+		"":          "0",
+	} {
+		got, err := paypalAmount(s)
+		if err != nil {
+			t.Errorf("paypalAmount(%q): %v", s, err)
+		} else if got.String() != want {
+			t.Errorf("paypalAmount(%q) = %s, want %s", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var importPayeeRules string
+
+// payeeRule replaces the matches of a regular expression in a payee.
+type payeeRule struct {
+	regexp      *regexp.Regexp
+	replacement string
+}
+
+// parsePayeeRules reads payee rules, a line each with a regular expression
+// and its replacement separated by two spaces or a tab, matched ignoring case.
+// A line with only a regular expression removes its matches:
+//
+//	^AMZN MKTP.*        Amazon
+//	^(POS|SQ \*)\s*
+//	\s+#?\d{4,}$
+//
+// The replacement may refer to groups, as in $1. Blank lines and lines
+// starting with '#' or ';' are ignored.
+func parsePayeeRules(r io.Reader) ([]payeeRule, error) {
+	var rules []payeeRule
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		pattern, replacement, ok := strings.Cut(line, "\t")
+		if !ok {
+			pattern, replacement, _ = strings.Cut(line, "  ")
+		}
+		re, err := regexp.Compile("(?i)" + strings.TrimSpace(pattern))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		rules = append(rules, payeeRule{regexp: re, replacement: strings.TrimSpace(replacement)})
+	}
+	return rules, scanner.Err()
+}
+
+// loadPayeeRules reads the payee rules file given by --payee-rules, if any.
+func loadPayeeRules() ([]payeeRule, error) {
+	if importPayeeRules == "" {
+		return nil, nil
+	}
+	f, err := os.Open(importPayeeRules)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rules, err := parsePayeeRules(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", importPayeeRules, err)
+	}
+	return rules, nil
+}
+
+// normalizePayee returns the payee of a bank transaction after applying each
+// payee rule in order, with runs of spaces collapsed. A payee the rules empty
+// is kept as it was.
+func (imp *Importer) normalizePayee(payee string) string {
+	if len(imp.payeeRules) == 0 {
+		return payee
+	}
+	normalized := payee
+	for _, rule := range imp.payeeRules {
+		normalized = rule.regexp.ReplaceAllString(normalized, rule.replacement)
+	}
+	normalized = strings.Join(strings.Fields(normalized), " ")
+	if normalized == "" {
+		return payee
+	}
+	return normalized
+}
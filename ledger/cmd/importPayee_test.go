@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_normalizePayee(t *testing.T) {
+	rules, err := parsePayeeRules(strings.NewReader(`# bank payees
+^AMZN MKTP.*		Amazon
+^(POS|SQ \*)\s*
+\s+#?\d{4,}$
+^paypal \*(\w+)  PayPal $1
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	imp := &Importer{payeeRules: rules}
+
+	tests := map[string]string{
+		"AMZN MKTP US*2A4":          "Amazon",
+		"POS CORNER  GROCER 123456": "CORNER GROCER",
+		"SQ *COFFEE BAR":            "COFFEE BAR",
+		"PAYPAL *SPOTIFY":           "PayPal SPOTIFY",
+		"Salary":                    "Salary",
+		"12345":                     "12345",
+	}
+	for payee, want := range tests {
+		if got := imp.normalizePayee(payee); got != want {
+			t.Errorf("normalizePayee(%q) = %q, want %q", payee, got, want)
+		}
+	}
+
+	if _, err := parsePayeeRules(strings.NewReader("(unclosed  x\n")); err == nil {
+		t.Error("parsePayeeRules succeeded on an invalid regular expression")
+	}
+}
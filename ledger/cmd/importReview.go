@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/howeyc/ledger"
+)
+
+var importInteractive bool
+
+// completeAccount returns the accounts that complete the input: the account
+// named input, ignoring case, or else those starting with or containing it.
+func completeAccount(accounts []string, input string) []string {
+	lower := strings.ToLower(input)
+	var prefixed, containing []string
+	for _, acc := range accounts {
+		accLower := strings.ToLower(acc)
+		switch {
+		case accLower == lower:
+			return []string{acc}
+		case strings.HasPrefix(accLower, lower):
+			prefixed = append(prefixed, acc)
+		case strings.Contains(accLower, lower):
+			containing = append(containing, acc)
+		}
+	}
+	if len(prefixed) > 0 {
+		return prefixed
+	}
+	return containing
+}
+
+// reviewPosting returns the posting of the transaction a review edits: the
+// first one not to the matching account.
+func (imp *Importer) reviewPosting(trans *ledger.Transaction) *ledger.Account {
+	for i := range trans.AccountChanges {
+		if trans.AccountChanges[i].Name != imp.matchingAccount {
+			return &trans.AccountChanges[i]
+		}
+	}
+	return &trans.AccountChanges[len(trans.AccountChanges)-1]
+}
+
+// readAccount asks for an account, completing what is entered from the
+// accounts. An entry no account completes is a new account.
+func readAccount(in *bufio.Scanner, out io.Writer, accounts []string) (string, bool) {
+	for {
+		fmt.Fprint(out, "Account: ")
+		if !in.Scan() {
+			fmt.Fprintln(out)
+			return "", false
+		}
+		input := strings.TrimSpace(in.Text())
+		if input == "" {
+			continue
+		}
+		completions := completeAccount(accounts, input)
+		switch len(completions) {
+		case 0:
+			return input, true
+		case 1:
+			return completions[0], true
+		}
+		for _, acc := range completions {
+			fmt.Fprintln(out, "  "+acc)
+		}
+	}
+}
+
+// reviewTransactions returns a review of each imported transaction, asking
+// on out whether to accept it, edit its account or skip it, and reading the
+// answers from in. An edited account is learned by the classifier, so it is
+// predicted for the payees that follow. After quitting, or when in has no
+// more answers, the remaining transactions are skipped.
+func (imp *Importer) reviewTransactions(in *bufio.Scanner, out io.Writer) func(trans *ledger.Transaction) bool {
+	var accounts []string
+	for _, acc := range ledger.GetBalances(imp.generalLedger, nil) {
+		accounts = append(accounts, acc.Name)
+	}
+	quit := false
+
+	return func(trans *ledger.Transaction) bool {
+		if quit {
+			return false
+		}
+		posting := imp.reviewPosting(trans)
+		for {
+			var shown strings.Builder
+			WriteTransaction(&shown, trans, 80)
+			fmt.Fprint(out, shown.String())
+			fmt.Fprint(out, "[a]ccept, [e]dit account, [s]kip, [q]uit? ")
+			if !in.Scan() {
+				fmt.Fprintln(out)
+				quit = true
+				return false
+			}
+			switch strings.ToLower(strings.TrimSpace(in.Text())) {
+			case "a", "accept", "":
+				return true
+			case "s", "skip":
+				return false
+			case "q", "quit":
+				quit = true
+				return false
+			case "e", "edit":
+				account, ok := readAccount(in, out, accounts)
+				if !ok {
+					quit = true
+					return false
+				}
+				posting.Name = account
+				if !slices.Contains(accounts, account) {
+					accounts = append(accounts, account)
+					slices.Sort(accounts)
+				}
+				if imp.classifier != nil {
+					imp.classifier.Learn(strings.Fields(trans.Payee), account)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bufio"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/shopspring/decimal"
+)
+
+func Test_completeAccount(t *testing.T) {
+	accounts := []string{"Assets:Checking", "Expenses:Food", "Expenses:Food:Dining", "Expenses:Fuel"}
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"expenses:food", []string{"Expenses:Food"}},
+		{"Expenses:F", []string{"Expenses:Food", "Expenses:Food:Dining", "Expenses:Fuel"}},
+		{"dining", []string{"Expenses:Food:Dining"}},
+		{"Income", nil},
+	}
+	for _, tt := range tests {
+		if got := completeAccount(accounts, tt.input); !slices.Equal(got, tt.want) {
+			t.Errorf("completeAccount(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func Test_reviewTransactions(t *testing.T) {
+	generalLedger := []*ledger.Transaction{{
+		Payee: "Grocer",
+		AccountChanges: []ledger.Account{
+			{Name: "Assets:Checking", Balance: decimal.NewFromInt(-10)},
+			{Name: "Expenses:Food", Balance: decimal.NewFromInt(10)},
+		},
+	}}
+	imp := &Importer{generalLedger: generalLedger, matchingAccount: "Assets:Checking"}
+	newTrans := func(payee string) *ledger.Transaction {
+		return &ledger.Transaction{
+			Date:  time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC),
+			Payee: payee,
+			AccountChanges: []ledger.Account{
+				{Name: "Assets:Checking", Balance: decimal.NewFromInt(-5)},
+				{Name: "unknown:unknown", Balance: decimal.NewFromInt(5)},
+			},
+		}
+	}
+
+	// accept, edit with an ambiguous then completed account, skip, quit
+	answers := "a\ne\nexp\nfood\na\ns\nq\n"
+	var out strings.Builder
+	review := imp.reviewTransactions(bufio.NewScanner(strings.NewReader(answers)), &out)
+
+	accepted := newTrans("Cafe")
+	if !review(accepted) || accepted.AccountChanges[1].Name != "unknown:unknown" {
+		t.Errorf("accepted transaction changed or skipped: %+v", accepted.AccountChanges)
+	}
+	edited := newTrans("Market")
+	if !review(edited) || edited.AccountChanges[1].Name != "Expenses:Food" {
+		t.Errorf("edited account = %s, want Expenses:Food", edited.AccountChanges[1].Name)
+	}
+	if review(newTrans("Skipped")) {
+		t.Error("skipped transaction accepted")
+	}
+	if review(newTrans("Quit")) || review(newTrans("After quit")) {
+		t.Error("transaction accepted after quitting")
+	}
+	if !strings.Contains(out.String(), "  Expenses:Food\n") {
+		t.Errorf("ambiguous account completions not listed: %q", out.String())
+	}
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// importState is the set of transactions imported before, read from a state
+// file with a line per transaction: the account imported to and the id of the
+// transaction (the FITID of OFX/QFX files, a hash of the record for csv
+// files) separated by a tab.
+type importState struct {
+	path  string
+	seen  map[string]bool
+	added []string
+}
+
+// loadImportState reads the state file, which need not exist yet.
+func loadImportState(path string) (*importState, error) {
+	state := &importState{path: path, seen: make(map[string]bool)}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			state.seen[line] = true
+		}
+	}
+	return state, scanner.Err()
+}
+
+// record returns whether the transaction with the id was not imported to the
+// account before, and marks it imported.
+func (s *importState) record(account, id string) bool {
+	key := account + "\t" + id
+	if s.seen[key] {
+		return false
+	}
+	s.seen[key] = true
+	s.added = append(s.added, key)
+	return true
+}
+
+// save appends the transactions recorded since the state was loaded to the
+// state file.
+func (s *importState) save() error {
+	if len(s.added) == 0 {
+		return nil
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, key := range s.added {
+		w.WriteString(key + newLine)
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	s.added = nil
+	return f.Close()
+}
+
+// recordHashes returns ids for records without one. A record repeated in the
+// same file, such as two equal purchases on a day, gets the count of times it
+// was seen appended to its hash, so each repetition is imported once.
+func recordHashes() func(values ...string) string {
+	counts := make(map[string]int)
+	return func(values ...string) string {
+		sum := sha256.Sum256([]byte(strings.Join(values, "\x1f")))
+		hash := "csv:" + hex.EncodeToString(sum[:16])
+		counts[hash]++
+		if counts[hash] > 1 {
+			hash += "#" + strconv.Itoa(counts[hash])
+		}
+		return hash
+	}
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_importState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "import.state")
+	state, err := loadImportState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.record("Assets:Checking", "1001") || !state.record("Assets:Savings", "1001") {
+		t.Error("first import of an id is not new")
+	}
+	if state.record("Assets:Checking", "1001") {
+		t.Error("id imported twice")
+	}
+	if err := state.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := loadImportState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.record("Assets:Checking", "1001") {
+		t.Error("id imported again after reloading the state")
+	}
+	if !reloaded.record("Assets:Checking", "1002") {
+		t.Error("new id is not new after reloading the state")
+	}
+}
+
+func Test_recordHashes(t *testing.T) {
+	recordHash := recordHashes()
+	a := recordHash("01/02/2024", "Cafe", "4")
+	b := recordHash("01/02/2024", "Cafe", "4")
+	c := recordHash("01/02/2024", "Cafe", "5")
+	if a == b || a == c || b == c {
+		t.Errorf("hashes not unique: %s %s %s", a, b, c)
+	}
+	again := recordHashes()
+	if again("01/02/2024", "Cafe", "4") != a || again("01/02/2024", "Cafe", "4") != b {
+		t.Error("hashes differ between imports of the same file")
+	}
+}
+
+func Test_importCSVState(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "jan.csv")
+	second := filepath.Join(dir, "jan-feb.csv")
+	header := "Date,Description,Amount\n"
+	jan := "01/02/2024,Cafe,4\n01/02/2024,Cafe,4\n"
+	feb := "02/01/2024,Rent,500\n"
+	if err := os.WriteFile(first, []byte(header+jan), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, []byte(header+jan+feb), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	statePath := filepath.Join(dir, "import.state")
+	importFile := func(filename string) string {
+		state, err := loadImportState(statePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf strings.Builder
+		imp, err := newConverter("Assets:Checking", filename, &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer imp.Close()
+		imp.state = state
+		imp.importCSV()
+		if err := state.save(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	if got := importFile(first); strings.Count(got, "Cafe") != 2 {
+		t.Errorf("first import = %q, want both Cafe transactions", got)
+	}
+	got := importFile(second)
+	if strings.Contains(got, "Cafe") || !strings.Contains(got, "Rent") {
+		t.Errorf("overlapping import = %q, want only Rent", got)
+	}
+}
@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/shopspring/decimal"
+)
+
+var stripeRevenueAccount string
+var stripeFeeAccount string
+var stripePayoutAccount string
+
+// stripeColumns are the columns of a Stripe balance report, -1 for those
+// missing. The itemized balance change and payout reconciliation reports name
+// them as in balance_transaction_id, the balance history export of the
+// dashboard as in "Available On (UTC)".
+type stripeColumns struct {
+	id, kind, created, available, currency, gross, net, description, source, payout int
+}
+
+// findStripeColumns returns the columns of a Stripe balance report, and
+// whether the header is of one.
+func findStripeColumns(header []string) (stripeColumns, bool) {
+	columns := stripeColumns{-1, -1, -1, -1, -1, -1, -1, -1, -1, -1}
+	fields := map[string]*int{
+		"balance_transaction_id": &columns.id,
+		"id":                     &columns.id,
+		"reporting_category":     &columns.kind,
+		"type":                   &columns.kind,
+		"created_utc":            &columns.created,
+		"created (utc)":          &columns.created,
+		"available_on_utc":       &columns.available,
+		"available on (utc)":     &columns.available,
+		"currency":               &columns.currency,
+		"gross":                  &columns.gross,
+		"amount":                 &columns.gross,
+		"net":                    &columns.net,
+		"description":            &columns.description,
+		"source_id":              &columns.source,
+		"source":                 &columns.source,
+		"automatic_payout_id":    &columns.payout,
+		"payout_id":              &columns.payout,
+		"transfer":               &columns.payout,
+	}
+	for i, name := range header {
+		if column, ok := fields[strings.ToLower(strings.TrimSpace(name))]; ok && *column < 0 {
+			*column = i
+		}
+	}
+	ok := columns.id >= 0 && columns.kind >= 0 && columns.created >= 0 && columns.available >= 0 &&
+		columns.currency >= 0 && columns.gross >= 0 && columns.net >= 0
+	return columns, ok
+}
+
+// stripeRow is a balance transaction of a Stripe balance report.
+type stripeRow struct {
+	date                            time.Time
+	id, kind, currency, description string
+	gross, net                      decimal.Decimal
+}
+
+// payout returns whether the row pays the balance out to the bank account.
+func (r stripeRow) payout() bool {
+	kind := strings.ToLower(r.kind)
+	return kind == "payout" || kind == "transfer"
+}
+
+// stripeAccount returns the account of the gross amount of the row: the
+// payout account for payouts, the fee account for fees Stripe bills
+// separately, the revenue account for charges and refunds, and else the
+// account predicted from the description.
+func (imp *Importer) stripeAccount(row stripeRow) string {
+	switch kind := strings.ToLower(row.kind); {
+	case row.payout():
+		return stripePayoutAccount
+	case strings.Contains(kind, "fee") || kind == "tax":
+		return stripeFeeAccount
+	case kind == "charge" || kind == "payment" || strings.Contains(kind, "refund"):
+		return stripeRevenueAccount
+	}
+	return imp.predictAccount(strings.Fields(imp.normalizePayee(row.description)))
+}
+
+// importStripe imports a Stripe balance report, see importStripeRecords.
+func (imp *Importer) importStripe() {
+	csvRecords, err := imp.readCSV()
+	if err != nil {
+		fmt.Println("CSV parse error:", err.Error())
+		return
+	}
+	if len(csvRecords) == 0 {
+		return
+	}
+	if _, ok := findStripeColumns(csvRecords[0]); !ok {
+		fmt.Println("Unable to find the columns of a Stripe balance report from the header.")
+		return
+	}
+	imp.importStripeRecords(csvRecords)
+}
+
+// importStripeRecords imports the records of a Stripe balance report, header
+// first. The balance transactions of a payout are collapsed into a single
+// transaction dated when the payout arrives: the payout goes to
+// --stripe-payout, the gross of charges and refunds to --stripe-revenue and
+// the fees Stripe takes from them to --stripe-fees. Whatever does not add up,
+// such as the balance kept back, goes to the matching account. A balance
+// transaction not paid out yet is a transaction of its own, with its net
+// amount posted to the matching account.
+func (imp *Importer) importStripeRecords(csvRecords [][]string) {
+	columns, _ := findStripeColumns(csvRecords[0])
+
+	value := func(record []string, column int) string {
+		if column < 0 || column >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[column])
+	}
+	amount := func(s string) (decimal.Decimal, error) {
+		if s = strings.ReplaceAll(s, ",", ""); s == "" {
+			return decimal.Zero, nil
+		}
+		return decimal.NewFromString(s)
+	}
+
+	// the balance transactions of each payout, by the id of the payout
+	groups := make(map[string][]stripeRow)
+	var keys []string
+	for _, record := range csvRecords[1:] {
+		row := stripeRow{
+			id:          value(record, columns.id),
+			kind:        value(record, columns.kind),
+			currency:    strings.ToUpper(value(record, columns.currency)),
+			description: value(record, columns.description),
+		}
+
+		// payouts are dated when they arrive
+		date := value(record, columns.created)
+		if row.payout() {
+			date = cmp.Or(value(record, columns.available), date)
+		}
+		var err error
+		if row.date, err = time.Parse(time.DateOnly, date[:min(len(date), len(time.DateOnly))]); err != nil {
+			fmt.Println("Stripe date parse error:", err.Error())
+			continue
+		}
+		var grossErr, netErr error
+		row.gross, grossErr = amount(value(record, columns.gross))
+		row.net, netErr = amount(value(record, columns.net))
+		if err := cmp.Or(grossErr, netErr); err != nil {
+			fmt.Println("Stripe amount parse error:", err.Error())
+			continue
+		}
+
+		key := value(record, columns.payout)
+		if key == "" && row.payout() {
+			key = value(record, columns.source)
+		}
+		key = cmp.Or(key, row.id)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], row)
+	}
+
+	// a payout is dated by its own row, else by its latest balance transaction
+	main := func(key string) stripeRow {
+		group := groups[key]
+		if i := slices.IndexFunc(group, stripeRow.payout); i >= 0 {
+			return group[i]
+		}
+		return slices.MaxFunc(group, func(a, b stripeRow) int { return a.date.Compare(b.date) })
+	}
+	slices.SortStableFunc(keys, func(a, b string) int {
+		return main(a).date.Compare(main(b).date)
+	})
+
+	for _, key := range keys {
+		head := main(key)
+		payee := imp.normalizePayee(cmp.Or(head.description, "Stripe "+head.kind))
+		if !imp.newTransaction(head.date, payee, key) {
+			continue
+		}
+
+		// amounts of each account by currency, in the order of the rows
+		type posting struct {
+			account, currency string
+			balance           decimal.Decimal
+		}
+		var postings []posting
+		add := func(account, currency string, balance decimal.Decimal) {
+			currency = cmp.Or(currency, overrideCurrency)
+			i := slices.IndexFunc(postings, func(p posting) bool {
+				return p.account == account && p.currency == currency
+			})
+			if i < 0 {
+				postings = append(postings, posting{account: account, currency: currency})
+				i = len(postings) - 1
+			}
+			postings[i].balance = postings[i].balance.Add(balance.Mul(imp.decScale))
+		}
+		for _, row := range groups[key] {
+			// the balance moves by the net, the other accounts by the
+			// gross and the fee
+			add(imp.matchingAccount, row.currency, row.net)
+			add(imp.stripeAccount(row), row.currency, row.gross.Neg())
+			add(stripeFeeAccount, row.currency, row.gross.Sub(row.net))
+		}
+
+		trans := &ledger.Transaction{Date: head.date, Payee: payee}
+		for _, p := range postings {
+			if !p.balance.IsZero() {
+				trans.AccountChanges = append(trans.AccountChanges, ledger.Account{Name: p.account, Currency: p.currency, Balance: p.balance})
+			}
+		}
+		if len(trans.AccountChanges) < 2 {
+			continue
+		}
+		trans.Comments = []string{";" + key}
+		imp.emit(trans)
+	}
+}
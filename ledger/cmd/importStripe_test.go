@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/howeyc/ledger"
+)
+
+func Test_importStripe(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "payout_reconciliation.csv")
+	csvData := `balance_transaction_id,created_utc,available_on_utc,currency,gross,fee,net,reporting_category,source_id,description,automatic_payout_id
+txn_1,2024-01-02 10:00:00,2024-01-04 00:00:00,usd,100.00,3.20,96.80,charge,ch_1,Order 1,po_1
+txn_2,2024-01-03 11:00:00,2024-01-05 00:00:00,usd,50.00,1.75,48.25,charge,ch_2,Order 2,po_1
+txn_3,2024-01-03 12:00:00,2024-01-05 00:00:00,usd,-20.00,0.00,-20.00,refund,re_1,Refund of Order 1,po_1
+txn_4,2024-01-05 00:00:00,2024-01-07 00:00:00,usd,-125.05,0.00,-125.05,payout,po_1,STRIPE PAYOUT,
+txn_5,2024-01-06 09:00:00,2024-01-08 00:00:00,usd,30.00,1.17,28.83,charge,ch_3,Order 3,
+`
+	if err := os.WriteFile(filename, []byte(csvData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf strings.Builder
+	imp, err := newConverter("Assets:Stripe", filename, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer imp.Close()
+	if err := imp.importFile(importFormat(filename)); err != nil {
+		t.Fatal(err)
+	}
+
+	trans, err := ledger.ParseLedger(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("%v in %q", err, buf.String())
+	}
+	if len(trans) != 2 {
+		t.Fatalf("got %d transactions, want 2: %q", len(trans), buf.String())
+	}
+	want := []string{
+		"2024/01/06 Order 3: Assets:Stripe USD 28.83, Expenses:Fees USD 1.17, Income:Sales USD -30",
+		"2024/01/07 STRIPE PAYOUT: Expenses:Fees USD 4.95, Income:Sales USD -130, unknown:payout USD 125.05",
+	}
+	for i, tr := range trans {
+		var postings []string
+		for _, acc := range tr.AccountChanges {
+			postings = append(postings, acc.Name+" "+acc.Currency+" "+acc.Balance.String())
+		}
+		got := tr.Date.Format(transactionDateFormat) + " " + tr.Payee + ": " + strings.Join(postings, ", ")
+		if got != want[i] {
+			t.Errorf("got %q, want %q", got, want[i])
+		}
+	}
+}
@@ -1,9 +1,16 @@
 package cmd
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/howeyc/ledger"
+	"github.com/howeyc/ledger/ledger/qfx"
+	"github.com/howeyc/ledger/ledger/qif"
+	"github.com/shopspring/decimal"
 )
 
 func Test_findMatchingAccount(t *testing.T) {
@@ -50,3 +57,465 @@ func Test_findMatchingAccount(t *testing.T) {
 		})
 	}
 }
+
+func Test_importCSVRules(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "bank.csv")
+	csvData := "Date;Text;Amount\n2024-01-02;Grocer;-50\n2024-01-03;Salary;1000\n"
+	if err := os.WriteFile(filename, []byte(csvData), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rulesFile := filepath.Join(dir, "bank.rules")
+	rulesData := "skip 1\nseparator ;\nfields date, description, amount\ndate-format 2006-01-02\ncurrency EUR\n\nif grocer\n  account2 Expenses:Food\n"
+	if err := os.WriteFile(rulesFile, []byte(rulesData), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer func(old string) { csvRulesFile = old }(csvRulesFile)
+	csvRulesFile = rulesFile
+
+	var buf strings.Builder
+	imp, err := newConverter("Assets:Checking", filename, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer imp.Close()
+	imp.importCSV()
+
+	trans, err := ledger.ParseLedger(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("%v in %q", err, buf.String())
+	}
+	if len(trans) != 2 {
+		t.Fatalf("got %d transactions, want 2: %q", len(trans), buf.String())
+	}
+	balances := make(map[string]string)
+	for _, tr := range trans {
+		for _, acc := range tr.AccountChanges {
+			balances[tr.Payee+" "+acc.Name] = acc.Currency + " " + acc.Balance.String()
+		}
+	}
+	want := map[string]string{
+		"Grocer Assets:Checking": "EUR -50",
+		"Grocer Expenses:Food":   "EUR 50",
+		"Salary Assets:Checking": "EUR 1000",
+		"Salary unknown:unknown": "EUR -1000",
+	}
+	for key, w := range want {
+		if balances[key] != w {
+			t.Errorf("%s = %q, want %q", key, balances[key], w)
+		}
+	}
+}
+
+func Test_predictAccount(t *testing.T) {
+	var generalLedger []*ledger.Transaction
+	for range 20 {
+		generalLedger = append(generalLedger,
+			&ledger.Transaction{Payee: "Corner Grocer", AccountChanges: []ledger.Account{{Name: "Assets:Checking"}, {Name: "Expenses:Food"}}},
+			&ledger.Transaction{Payee: "Gas Station", AccountChanges: []ledger.Account{{Name: "Assets:Checking"}, {Name: "Expenses:Fuel"}}},
+		)
+	}
+	generalLedger = append(generalLedger,
+		&ledger.Transaction{Payee: "Corner Bookshop", AccountChanges: []ledger.Account{{Name: "Assets:Checking"}, {Name: "Expenses:Books"}}})
+	imp := &Importer{generalLedger: generalLedger}
+	imp.classifier = bayesClassifier{imp.trainClassifier("Assets:Checking")}
+
+	defer func(old float64) { importMinConfidence = old }(importMinConfidence)
+	importMinConfidence = 10
+	if got := imp.predictAccount([]string{"Grocer"}); got != "Expenses:Food" {
+		t.Errorf("predictAccount(Grocer) = %s, want Expenses:Food", got)
+	}
+	if got := imp.predictAccount([]string{"Corner"}); got != "unknown:unknown" {
+		t.Errorf("predictAccount(Corner) = %s, want unknown:unknown", got)
+	}
+	importMinConfidence = 0
+	if got := imp.predictAccount([]string{"Corner"}); got != "Expenses:Food" {
+		t.Errorf("predictAccount(Corner) without minimum confidence = %s, want Expenses:Food", got)
+	}
+
+	want := ";candidates: Expenses:Food, Expenses:Books"
+	if got := imp.candidatesComment([]string{"Corner"}); !strings.HasPrefix(got, want) {
+		t.Errorf("candidatesComment(Corner) = %q, want it to start with %q", got, want)
+	}
+}
+
+func Test_importCSVNoHeader(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "bank.csv")
+	csvData := "01/02/2024,4711,Grocer,50\n01/03/2024,4712,Bakery,5\n01/04/2024\n"
+	if err := os.WriteFile(filename, []byte(csvData), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer func(noHeader bool, fields string) { csvNoHeader, csvFields = noHeader, fields }(csvNoHeader, csvFields)
+	csvNoHeader = true
+
+	importCSV := func() string {
+		var buf strings.Builder
+		imp, err := newConverter("Assets:Checking", filename, &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer imp.Close()
+		imp.importCSV()
+		return buf.String()
+	}
+
+	// without the fields nothing is imported
+	if got := importCSV(); got != "" {
+		t.Errorf("got %q without --fields, want nothing", got)
+	}
+
+	csvFields = "date,,payee,amount"
+	got := importCSV()
+	for _, want := range []string{"2024/01/02 Grocer", "2024/01/03 Bakery"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("got %q, want it to contain %q", got, want)
+		}
+	}
+	if strings.Contains(got, "2024/01/04") {
+		t.Errorf("got %q, want the record missing columns skipped", got)
+	}
+}
+
+func Test_importCSVDebitCredit(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "bank.csv")
+	csvData := "Date,Description,Debit Amount,Credit Amount\n01/02/2024,Grocer,50.00,\n01/03/2024,Salary,,1000\n01/04/2024,Fee,-2,0\n"
+	if err := os.WriteFile(filename, []byte(csvData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf strings.Builder
+	imp, err := newConverter("Assets:Checking", filename, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer imp.Close()
+	imp.importCSV()
+
+	trans, err := ledger.ParseLedger(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("%v in %q", err, buf.String())
+	}
+	want := map[string]string{"Grocer": "-50", "Salary": "1000", "Fee": "-2"}
+	if len(trans) != len(want) {
+		t.Fatalf("got %d transactions, want %d: %q", len(trans), len(want), buf.String())
+	}
+	for _, tr := range trans {
+		if got := tr.AccountChanges[0].Balance.String(); got != want[tr.Payee] {
+			t.Errorf("%s: Assets:Checking = %s, want %s", tr.Payee, got, want[tr.Payee])
+		}
+	}
+}
+
+func Test_importCSVCurrency(t *testing.T) {
+	defer func(currency string) { overrideCurrency = currency }(overrideCurrency)
+	overrideCurrency = "USD"
+
+	filename := filepath.Join(t.TempDir(), "bank.csv")
+	csvData := "Date,Description,Amount,Currency\n01/02/2024,Grocer,50.00,EUR\n01/03/2024,Bakery,5,\n"
+	if err := os.WriteFile(filename, []byte(csvData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf strings.Builder
+	imp, err := newConverter("Assets:Checking", filename, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer imp.Close()
+	imp.importCSV()
+
+	trans, err := ledger.ParseLedger(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("%v in %q", err, buf.String())
+	}
+	// the currency column wins, --override-currency fills in for blanks
+	want := map[string]string{"Grocer": "EUR", "Bakery": "USD"}
+	if len(trans) != len(want) {
+		t.Fatalf("got %d transactions, want %d: %q", len(trans), len(want), buf.String())
+	}
+	for _, tr := range trans {
+		for _, acc := range tr.AccountChanges {
+			if acc.Currency != want[tr.Payee] {
+				t.Errorf("%s: %s currency = %q, want %q", tr.Payee, acc.Name, acc.Currency, want[tr.Payee])
+			}
+		}
+	}
+}
+
+func Test_qifAccountMap(t *testing.T) {
+	m := qifAccountMap{
+		accounts: []string{"Assets:Savings", "Expenses:Food:Groceries", "Expenses:Groceries Extra", "Income:Salary"},
+		categories: map[string]qif.Category{
+			"bonus":  {Name: "Bonus", Income: true},
+			"travel": {Name: "Travel"},
+		},
+	}
+	tests := map[string]string{
+		"":                   "",
+		"groceries":          "Expenses:Food:Groceries",
+		"Food:Groceries/Mom": "Expenses:Food:Groceries",
+		"[Savings]":          "Assets:Savings",
+		"income:salary":      "Income:Salary",
+		"Household:Cleaning": "Household:Cleaning",
+		"Bonus":              "Income:Bonus",
+		"Travel/Work":        "Expenses:Travel",
+		"[Travel]":           "Travel",
+	}
+	for category, want := range tests {
+		if got := m.account(category); got != want {
+			t.Errorf("account(%q) = %q, want %q", category, got, want)
+		}
+	}
+}
+
+func Test_importQIFSplits(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "bank.qif")
+	qifData := "!Type:Bank\nD01/05/2024\nT-120.00\nPSuperstore\nSGroceries\nEweekly shop\n$-80.00\nSHousehold:Cleaning\n$-40.00\nSFee\n$0.00\n^\n"
+	if err := os.WriteFile(filename, []byte(qifData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf strings.Builder
+	imp, err := newConverter("Assets:Checking", filename, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer imp.Close()
+	imp.importQIF()
+
+	trans, err := ledger.ParseLedger(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("%v in %q", err, buf.String())
+	}
+	if len(trans) != 1 {
+		t.Fatalf("got %d transactions, want 1: %q", len(trans), buf.String())
+	}
+	balances := make(map[string]string)
+	for _, acc := range trans[0].AccountChanges {
+		balances[acc.Name] = acc.Balance.String()
+	}
+	want := map[string]string{
+		"Assets:Checking":    "120",
+		"Groceries":          "-80",
+		"Household:Cleaning": "-40",
+	}
+	if len(balances) != len(want) {
+		t.Errorf("got postings %v, want %v", balances, want)
+	}
+	for name, w := range want {
+		if balances[name] != w {
+			t.Errorf("%s = %q, want %q", name, balances[name], w)
+		}
+	}
+	if !strings.Contains(buf.String(), "; weekly shop") {
+		t.Errorf("got %q, want the split memo as comment", buf.String())
+	}
+}
+
+func Test_importQIFAccounts(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "export.qif")
+	qifData := "!Type:Cat\nNBonus\nI\n^\n" +
+		"!Account\nNChecking\nTBank\n^\n!Type:Bank\nD01/05/2024\nT-100.00\nPTransfer\nL[Savings]\n^\nD01/06/2024\nT-10.00\nPBonus\nSBonus\n$-6.00\nS[Savings]\n$-4.00\n^\n" +
+		"!Account\nNSavings\nTBank\n^\n!Type:Bank\nD01/05/2024\nT100.00\nPTransfer\nL[Checking]\n^\n"
+	if err := os.WriteFile(filename, []byte(qifData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf strings.Builder
+	imp, err := newConverter("Assets:Unknown", filename, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer imp.Close()
+	imp.importQIF()
+
+	trans, err := ledger.ParseLedger(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("%v in %q", err, buf.String())
+	}
+	// the transfer is imported once, from the checking account
+	if len(trans) != 2 {
+		t.Fatalf("got %d transactions, want 2: %q", len(trans), buf.String())
+	}
+	balances := make(map[string]string)
+	for _, tr := range trans {
+		for _, acc := range tr.AccountChanges {
+			balances[tr.Payee+" "+acc.Name] = acc.Balance.String()
+		}
+	}
+	want := map[string]string{
+		"Transfer Checking":  "100",
+		"Transfer Savings":   "-100",
+		"Bonus Checking":     "10",
+		"Bonus Income:Bonus": "-6",
+		"Bonus Savings":      "-4",
+	}
+	for key, w := range want {
+		if balances[key] != w {
+			t.Errorf("%s = %q, want %q", key, balances[key], w)
+		}
+	}
+}
+
+func Test_importQFXInvestments(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "broker.qfx")
+	qfxData := `<OFX><INVSTMTMSGSRSV1><INVSTMTTRNRS><INVSTMTRS><CURDEF>USD</CURDEF><INVTRANLIST>
+<BUYSTOCK><INVBUY><INVTRAN><FITID>T1</FITID><DTTRADE>20250106</DTTRADE></INVTRAN><SECID><UNIQUEID>922908769</UNIQUEID></SECID>
+<UNITS>10</UNITS><UNITPRICE>250.25</UNITPRICE><COMMISSION>5</COMMISSION><TOTAL>-2507.50</TOTAL></INVBUY></BUYSTOCK>
+<INCOME><INVTRAN><FITID>T2</FITID><DTTRADE>20250131</DTTRADE></INVTRAN><SECID><UNIQUEID>922908769</UNIQUEID></SECID><INCOMETYPE>DIV</INCOMETYPE><TOTAL>8.12</TOTAL></INCOME>
+<REINVEST><INVTRAN><FITID>T3</FITID><DTTRADE>20250131</DTTRADE></INVTRAN><SECID><UNIQUEID>922908769</UNIQUEID></SECID><INCOMETYPE>DIV</INCOMETYPE><TOTAL>-8.12</TOTAL><UNITS>0.032</UNITS><UNITPRICE>253.75</UNITPRICE></REINVEST>
+</INVTRANLIST></INVSTMTRS></INVSTMTTRNRS></INVSTMTMSGSRSV1>
+<SECLISTMSGSRSV1><SECLIST><STOCKINFO><SECINFO><SECID><UNIQUEID>922908769</UNIQUEID></SECID><SECNAME>Vanguard Total Stock Market ETF</SECNAME><TICKER>VTI</TICKER></SECINFO></STOCKINFO></SECLIST></SECLISTMSGSRSV1></OFX>
+`
+	if err := os.WriteFile(filename, []byte(qfxData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf strings.Builder
+	imp, err := newConverter("Assets:Broker", filename, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer imp.Close()
+	imp.importQFX()
+
+	// parsing checks that each transaction balances
+	trans, err := ledger.ParseLedger(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("%v in %q", err, buf.String())
+	}
+	if len(trans) != 3 {
+		t.Fatalf("got %d transactions, want 3: %q", len(trans), buf.String())
+	}
+	for _, want := range []string{
+		"2025/01/06 Buy VTI",
+		"VTI 10.00 @ 250.25",
+		"Expenses:Commissions",
+		"2025/01/31 Dividend VTI",
+		"2025/01/31 Reinvest VTI",
+		"VTI 0.032 @ 253.75",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("got %q, want it to contain %q", buf.String(), want)
+		}
+	}
+}
+
+func Test_statementBalanceWarning(t *testing.T) {
+	date := func(s string) time.Time {
+		d, _ := time.Parse(time.DateOnly, s)
+		return d
+	}
+	posting := func(name string, amount int64) ledger.Account {
+		return ledger.Account{Name: name, Balance: decimal.NewFromInt(amount)}
+	}
+	imp := &Importer{
+		matchingAccount: "Assets:Checking",
+		decScale:        decimal.NewFromInt(1),
+		generalLedger: []*ledger.Transaction{
+			{Date: date("2025-01-01"), AccountChanges: []ledger.Account{posting("Assets:Checking", 100), posting("Equity", -100)}},
+			{Date: date("2025-03-01"), AccountChanges: []ledger.Account{posting("Assets:Checking", 50), posting("Income", -50)}},
+		},
+		imported: []*ledger.Transaction{
+			{Date: date("2025-01-20"), AccountChanges: []ledger.Account{posting("Assets:Checking", -30), posting("Expenses", 30)}},
+		},
+	}
+
+	if w := imp.statementBalanceWarning(qfx.Bal{BalAmt: "70.00", DtAsOf: "20250131120000"}); w != "" {
+		t.Errorf("got warning %q for agreeing balances", w)
+	}
+	if w := imp.statementBalanceWarning(qfx.Bal{}); w != "" {
+		t.Errorf("got warning %q without a balance", w)
+	}
+	w := imp.statementBalanceWarning(qfx.Bal{BalAmt: "100", DtAsOf: "20250131"})
+	for _, want := range []string{"Assets:Checking", "2025/01/31", "100.00 in the statement", "70.00 in the ledger", "30.00"} {
+		if !strings.Contains(w, want) {
+			t.Errorf("warning %q does not contain %q", w, want)
+		}
+	}
+	// without a date every transaction counts
+	if w := imp.statementBalanceWarning(qfx.Bal{BalAmt: "120"}); w != "" {
+		t.Errorf("got warning %q for agreeing balances", w)
+	}
+}
+
+func Test_importQFXCreditCard(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "card.qfx")
+	qfxData := `<OFX><CREDITCARDMSGSRSV1><CCSTMTTRNRS><CCSTMTRS><CCACCTFROM><ACCTID>4111</ACCTID></CCACCTFROM><BANKTRANLIST>
+<STMTTRN><TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>20250110</DTPOSTED><TRNAMT>-25.40</TRNAMT><FITID>C1</FITID><NAME>CORNER GROCER</NAME></STMTTRN>
+<STMTTRN><TRNTYPE>CREDIT</TRNTYPE><DTPOSTED>20250125</DTPOSTED><TRNAMT>200.00</TRNAMT><FITID>C2</FITID><NAME>PAYMENT</NAME><MEMO>AUTOPAY</MEMO></STMTTRN>
+</BANKTRANLIST><LEDGERBAL><BALAMT>-325.40</BALAMT><DTASOF>20250131</DTASOF></LEDGERBAL></CCSTMTRS></CCSTMTTRNRS></CREDITCARDMSGSRSV1></OFX>
+`
+	if err := os.WriteFile(filename, []byte(qfxData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf strings.Builder
+	imp, err := newConverter("Liabilities:Card", filename, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer imp.Close()
+	imp.importQFX()
+
+	trans, err := ledger.ParseLedger(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("%v in %q", err, buf.String())
+	}
+	balances := make(map[string]string)
+	for _, tr := range trans {
+		for _, acc := range tr.AccountChanges {
+			balances[tr.Payee+" "+acc.Name] = acc.Balance.String()
+		}
+	}
+	// charges add to what is owed, payments reduce it
+	want := map[string]string{
+		"CORNER GROCER Liabilities:Card": "-25.4",
+		"CORNER GROCER unknown:unknown":  "25.4",
+		"PAYMENT Liabilities:Card":       "200",
+		"PAYMENT unknown:unknown":        "-200",
+	}
+	for key, w := range want {
+		if balances[key] != w {
+			t.Errorf("%s = %q, want %q", key, balances[key], w)
+		}
+	}
+	if !strings.Contains(buf.String(), ";AUTOPAY") {
+		t.Errorf("got %q, want the memo as comment", buf.String())
+	}
+}
+
+func Test_importQFXCurrency(t *testing.T) {
+	defer func(currency string) { overrideCurrency = currency }(overrideCurrency)
+	overrideCurrency = "USD"
+
+	filename := filepath.Join(t.TempDir(), "bank.qfx")
+	qfxData := `<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS><CURDEF>CAD</CURDEF><BANKTRANLIST>
+<STMTTRN><TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>20250110</DTPOSTED><TRNAMT>-25.40</TRNAMT><FITID>B1</FITID><NAME>CORNER GROCER</NAME></STMTTRN>
+</BANKTRANLIST></STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>
+`
+	if err := os.WriteFile(filename, []byte(qfxData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf strings.Builder
+	imp, err := newConverter("Assets:Checking", filename, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer imp.Close()
+	imp.importQFX()
+
+	trans, err := ledger.ParseLedger(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("%v in %q", err, buf.String())
+	}
+	if len(trans) != 1 {
+		t.Fatalf("got %d transactions, want 1: %q", len(trans), buf.String())
+	}
+	for _, acc := range trans[0].AccountChanges {
+		if acc.Currency != "CAD" {
+			t.Errorf("%s currency = %q, want CURDEF CAD", acc.Name, acc.Currency)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/howeyc/ledger"
+	"github.com/spf13/cobra"
+)
+
+// learnResult is what learn found comparing the accounts guessed for imported
+// transactions with their accounts in the ledger.
+type learnResult struct {
+	corrections []learnCorrection
+	confirmed   int
+	pending     int // not in the ledger (yet)
+}
+
+// learnCorrection is an imported transaction whose guessed account was
+// changed in the ledger.
+type learnCorrection struct {
+	prediction modelPrediction
+	account    string
+}
+
+// learnCorrections compares the predictions of the model with the ledger
+// transactions of the same date and payee posting to the matching account,
+// adds a correction to the model for each transaction whose other account
+// changed and removes the predictions found. Predictions of transactions not
+// in the ledger are kept, in case they are added later.
+func (imp *Importer) learnCorrections(model *classifierModel) learnResult {
+	var result learnResult
+	var pending []modelPrediction
+	for _, prediction := range model.Predictions {
+		var found *ledger.Transaction
+		for _, trans := range imp.generalLedger {
+			if trans.Date.Equal(prediction.Date) && strings.TrimSpace(trans.Payee) == strings.TrimSpace(prediction.Payee) &&
+				predictedAccount(trans, imp.matchingAccount) != "" {
+				found = trans
+				break
+			}
+		}
+		if found == nil {
+			pending = append(pending, prediction)
+			continue
+		}
+		account := predictedAccount(found, imp.matchingAccount)
+		if account == prediction.Account {
+			result.confirmed++
+			continue
+		}
+		result.corrections = append(result.corrections, learnCorrection{prediction: prediction, account: account})
+		model.Corrections = append(model.Corrections, modelCorrection{Payee: prediction.Payee, Account: account})
+	}
+	model.Predictions = pending
+	result.pending = len(pending)
+	return result
+}
+
+// PrintLearned prints each correction learned and a count of the predictions
+// confirmed and not yet in the ledger.
+func PrintLearned(w io.Writer, result learnResult) {
+	buf := bufio.NewWriter(w)
+	for _, c := range result.corrections {
+		fmt.Fprintf(buf, "%s %s: %s -> %s%s", c.prediction.Date.Format(transactionDateFormat),
+			c.prediction.Payee, c.prediction.Account, c.account, newLine)
+	}
+	fmt.Fprintf(buf, "%d corrected, %d confirmed, %d not in the ledger%s",
+		len(result.corrections), result.confirmed, result.pending, newLine)
+	buf.Flush()
+}
+
+// learnCmd represents the learn command
+var learnCmd = &cobra.Command{
+	Use:   "learn <account-substring>",
+	Args:  cobra.ExactArgs(1),
+	Short: "Update the import model from corrected accounts",
+	Long: `Compare the accounts import --model guessed for the transactions it imported
+to the matching account with their accounts in the ledger now, and teach the
+model the ones corrected by hand. Corrections are kept in the model file and
+learned again whenever import retrains the model, so they weigh more than the
+guesses they correct.`,
+	Run: func(_ *cobra.Command, args []string) {
+		if importModelFile == "" {
+			fatalln("learn needs --model")
+		}
+		if ledgerFilePath == "" {
+			fatalln("learn needs a ledger file")
+		}
+		generalLedger, err := ledger.ParseLedgerFile(ledgerFilePath)
+		if err != nil {
+			fatalln(fmt.Sprintf("%s:%s", ledgerFilePath, err.Error()))
+		}
+		imp := &Importer{generalLedger: generalLedger}
+		if imp.matchingAccount, err = imp.findMatchingAccount(args[0]); err != nil {
+			fatalln(err)
+		}
+
+		model, err := readClassifierModel(importModelFile)
+		if err != nil {
+			fatalln(err)
+		}
+		if model.Account != imp.matchingAccount {
+			fatalln(fmt.Sprintf("%s: model of %s, not %s", importModelFile, model.Account, imp.matchingAccount))
+		}
+		result := imp.learnCorrections(&model)
+
+		model.Fingerprint = imp.trainingFingerprint(imp.matchingAccount)
+		classifier := imp.trainModel(imp.matchingAccount, model.Corrections)
+		if err := saveClassifierModel(importModelFile, model, classifier); err != nil {
+			fatalln(err)
+		}
+		PrintLearned(cliOutput, result)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(learnCmd)
+
+	learnCmd.Flags().StringVar(&importModelFile, "model", "", "Model file of import to update.")
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/howeyc/ledger"
+)
+
+func Test_learnCorrections(t *testing.T) {
+	generalLedger, err := ledger.ParseLedger(strings.NewReader(`2024/01/02 Corner Grocer
+    Expenses:Food    12
+    Assets:Checking
+
+2024/01/03 Bookshop
+    Expenses:Books    20
+    Assets:Checking
+
+2024/01/04 Gas Station
+    Expenses:Fuel    40
+    Assets:Checking
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	imp := &Importer{generalLedger: generalLedger, matchingAccount: "Assets:Checking"}
+
+	defer func(old string) { importModelFile = old }(importModelFile)
+	importModelFile = filepath.Join(t.TempDir(), "import.model")
+	if _, err := imp.modelClassifier(imp.matchingAccount); err != nil {
+		t.Fatal(err)
+	}
+
+	// import guessed these, the grocer and the gas station were corrected
+	imported := []*ledger.Transaction{
+		{Date: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), Payee: "Corner Grocer", AccountChanges: []ledger.Account{{Name: "Assets:Checking"}, {Name: "unknown:unknown"}}},
+		{Date: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), Payee: "Bookshop", AccountChanges: []ledger.Account{{Name: "Assets:Checking"}, {Name: "Expenses:Books"}}},
+		{Date: time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC), Payee: "Gas Station", AccountChanges: []ledger.Account{{Name: "Assets:Checking"}, {Name: "Expenses:Food"}}},
+		{Date: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), Payee: "Cinema", AccountChanges: []ledger.Account{{Name: "Assets:Checking"}, {Name: "unknown:unknown"}}},
+	}
+	if err := recordPredictions(importModelFile, imp.matchingAccount, imported); err != nil {
+		t.Fatal(err)
+	}
+	model, err := readClassifierModel(importModelFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.Predictions) != 4 {
+		t.Fatalf("got %d predictions, want 4", len(model.Predictions))
+	}
+
+	result := imp.learnCorrections(&model)
+	var buf strings.Builder
+	PrintLearned(&buf, result)
+	want := "2024/01/02 Corner Grocer: unknown:unknown -> Expenses:Food\n" +
+		"2024/01/04 Gas Station: Expenses:Food -> Expenses:Fuel\n" +
+		"2 corrected, 1 confirmed, 1 not in the ledger\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+	if len(model.Predictions) != 1 || model.Predictions[0].Payee != "Cinema" {
+		t.Errorf("predictions left = %+v, want Cinema", model.Predictions)
+	}
+	if len(model.Corrections) != 2 {
+		t.Errorf("corrections = %+v, want 2", model.Corrections)
+	}
+
+	// retraining keeps the corrections and learns them again
+	if err := saveClassifierModel(importModelFile, model, imp.trainModel(imp.matchingAccount, model.Corrections)); err != nil {
+		t.Fatal(err)
+	}
+	imp.generalLedger = append(generalLedger, &ledger.Transaction{
+		Payee:          "Bakery",
+		AccountChanges: []ledger.Account{{Name: "Expenses:Food"}, {Name: "Assets:Checking"}},
+	})
+	classifier, err := imp.modelClassifier(imp.matchingAccount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := classifier.Learned(); n != 6 {
+		t.Errorf("retrained on %d payees, want 4 of the ledger and 2 corrections", n)
+	}
+	if saved, err := readClassifierModel(importModelFile); err != nil || len(saved.Corrections) != 2 || len(saved.Predictions) != 1 {
+		t.Errorf("retrained model = %+v, %v, want the corrections and predictions kept", saved, err)
+	}
+}
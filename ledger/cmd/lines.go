@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxLineLength is the longest line, in bytes, of the ledger files commands
+// read line by line, set by the max_line_length configuration. The parser
+// itself reads lines of any length.
+var maxLineLength = 64 << 20
+
+// newLineScanner returns a scanner of the lines of r, of up to maxLineLength
+// bytes.
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, min(64*1024, maxLineLength)), maxLineLength)
+	return scanner
+}
+
+// scanError returns the error of a scanner of newLineScanner that scanned
+// lineNum lines, locating a line too long at the line after them.
+func scanError(err error, lineNum int) error {
+	if errors.Is(err, bufio.ErrTooLong) {
+		return fmt.Errorf("line %d: line too long, longer than %d bytes (see max_line_length)", lineNum+1, maxLineLength)
+	}
+	return err
+}
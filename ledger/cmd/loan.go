@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"bufio"
+	"time"
+
+	"github.com/howeyc/ledger"
+	date "github.com/joyt/godate"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+)
+
+var loanPrincipal float64
+var loanRate float64
+var loanYears int
+var loanStart string
+var loanPayee string
+var loanAccount string
+var loanInterestAccount string
+var loanPaymentAccount string
+
+// loanAccounts are the accounts of the postings of a loan payment.
+type loanAccounts struct {
+	Loan     string // principal paid off
+	Interest string // interest paid
+	Payment  string // where the payment comes from
+}
+
+// loanPayment returns the monthly payment, rounded to cents, that pays off
+// principal in the number of months at the annual rate, in percent.
+func loanPayment(principal, annualRate decimal.Decimal, months int) decimal.Decimal {
+	if annualRate.IsZero() {
+		return principal.Div(decimal.NewFromInt(int64(months))).Round(2)
+	}
+	rate := annualRate.Div(decimal.NewFromInt(1200))
+	growth := rate.Add(decimal.NewFromInt(1)).Pow(decimal.NewFromInt(int64(months)))
+	return principal.Mul(rate).Mul(growth).Div(growth.Sub(decimal.NewFromInt(1))).Round(2)
+}
+
+// loanPaymentDate returns the date of the payment the number of months after
+// start, on the same day of the month, or the last day of shorter months.
+func loanPaymentDate(start time.Time, months int) time.Time {
+	first := time.Date(start.Year(), start.Month()+time.Month(months), 1, 0, 0, 0, 0, start.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(start.Day(), lastDay)-1)
+}
+
+// loanSchedule returns the monthly payments of a loan of principal at the
+// annual rate, in percent, over the number of months, starting on start. Each
+// payment splits into the principal and the interest for the month, the last
+// one pays off what is left of the principal.
+func loanSchedule(principal, annualRate decimal.Decimal, months int, start time.Time, payee string, accounts loanAccounts) []*ledger.Transaction {
+	payment := loanPayment(principal, annualRate, months)
+	rate := annualRate.Div(decimal.NewFromInt(1200))
+
+	schedule := make([]*ledger.Transaction, 0, months)
+	balance := principal
+	for month := range months {
+		interest := balance.Mul(rate).Round(2)
+		paidOff := payment.Sub(interest)
+		if month == months-1 || paidOff.GreaterThan(balance) {
+			paidOff = balance
+		}
+		balance = balance.Sub(paidOff)
+
+		schedule = append(schedule, &ledger.Transaction{
+			Date:  loanPaymentDate(start, month),
+			Payee: payee,
+			AccountChanges: []ledger.Account{
+				{Name: accounts.Loan, Balance: paidOff},
+				{Name: accounts.Interest, Balance: interest},
+				{Name: accounts.Payment, Balance: paidOff.Add(interest).Neg()},
+			},
+		})
+		if balance.IsZero() {
+			break
+		}
+	}
+	return schedule
+}
+
+// loanCmd represents the loan command
+var loanCmd = &cobra.Command{
+	Use:   "loan",
+	Short: "Print the payment transactions of a loan",
+	Long: `Print the monthly payments of a loan as transactions in ledger format, to paste
+into the ledger file or a forecast. Each payment is split into the principal
+paid off, posted to --account, and the interest for the month, posted to
+--interest-account, and comes from --payment-account.`,
+	Args: cobra.NoArgs,
+	Run: func(_ *cobra.Command, _ []string) {
+		if loanPrincipal <= 0 || loanRate < 0 || loanYears <= 0 {
+			fatalln("--principal and --years must be positive and --rate not negative")
+		}
+		now := time.Now()
+		start := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.Local)
+		if loanStart != "" {
+			var err error
+			if start, err = date.Parse(loanStart); err != nil {
+				fatalln("unable to parse start date:", err)
+			}
+		}
+
+		schedule := loanSchedule(decimal.NewFromFloat(loanPrincipal), decimal.NewFromFloat(loanRate),
+			12*loanYears, start, loanPayee,
+			loanAccounts{Loan: loanAccount, Interest: loanInterestAccount, Payment: loanPaymentAccount})
+
+		buf := bufio.NewWriter(cliOutput)
+		for _, trans := range schedule {
+			WriteTransaction(buf, trans, columnWidth)
+		}
+		buf.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(loanCmd)
+
+	loanCmd.Flags().IntVar(&columnWidth, "columns", 80, "Set a column width for output.")
+
+	loanCmd.Flags().Float64Var(&loanPrincipal, "principal", 0, "Amount borrowed.")
+	loanCmd.Flags().Float64Var(&loanRate, "rate", 0, "Annual interest rate, in percent.")
+	loanCmd.Flags().IntVar(&loanYears, "years", 0, "Term of the loan in years.")
+	loanCmd.Flags().StringVar(&loanStart, "start", "", "Date of the first payment (default is the first of next month).")
+	loanCmd.Flags().StringVar(&loanPayee, "payee", "Loan Payment", "Payee of the payments.")
+	loanCmd.Flags().StringVar(&loanAccount, "account", "Liabilities:Loan", "Account of the loan.")
+	loanCmd.Flags().StringVar(&loanInterestAccount, "interest-account", "Expenses:Interest", "Account of the interest.")
+	loanCmd.Flags().StringVar(&loanPaymentAccount, "payment-account", "Assets:Checking", "Account the payments come from.")
+}
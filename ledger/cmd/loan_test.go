@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func Test_loanPayment(t *testing.T) {
+	tests := []struct {
+		principal, rate string
+		months          int
+		want            string
+	}{
+		{"250000", "4.1", 300, "1333.44"},
+		{"10000", "6", 12, "860.66"},
+		{"1200", "0", 12, "100"},
+	}
+	for _, tt := range tests {
+		got := loanPayment(decimal.RequireFromString(tt.principal), decimal.RequireFromString(tt.rate), tt.months)
+		if !got.Equal(decimal.RequireFromString(tt.want)) {
+			t.Errorf("loanPayment(%s, %s, %d) = %s, want %s", tt.principal, tt.rate, tt.months, got, tt.want)
+		}
+	}
+}
+
+func Test_loanSchedule(t *testing.T) {
+	start := time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC)
+	accounts := loanAccounts{Loan: "Liabilities:Loan", Interest: "Expenses:Interest", Payment: "Assets:Checking"}
+	schedule := loanSchedule(decimal.NewFromInt(10000), decimal.NewFromInt(6), 12, start, "Loan", accounts)
+	if len(schedule) != 12 {
+		t.Fatalf("got %d payments, want 12", len(schedule))
+	}
+
+	first := schedule[0].AccountChanges
+	if !first[0].Balance.Equal(decimal.RequireFromString("810.66")) || !first[1].Balance.Equal(decimal.RequireFromString("50")) {
+		t.Errorf("first payment = %s principal, %s interest, want 810.66, 50", first[0].Balance, first[1].Balance)
+	}
+
+	var paidOff decimal.Decimal
+	for _, trans := range schedule {
+		sum := decimal.Zero
+		for _, acc := range trans.AccountChanges {
+			sum = sum.Add(acc.Balance)
+		}
+		if !sum.IsZero() {
+			t.Errorf("payment of %s does not balance: %s", trans.Date.Format("2006-01-02"), sum)
+		}
+		paidOff = paidOff.Add(trans.AccountChanges[0].Balance)
+	}
+	if !paidOff.Equal(decimal.NewFromInt(10000)) {
+		t.Errorf("paid off %s, want 10000", paidOff)
+	}
+	if got := schedule[1].Date; !got.Equal(time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("second payment on %s, want 2024-02-29", got.Format("2006-01-02"))
+	}
+	if got := schedule[11].Date; !got.Equal(time.Date(2024, time.December, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("last payment on %s, want 2024-12-31", got.Format("2006-01-02"))
+	}
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var mergeConflicts string
+
+// mergeChoice is how a conflict between two versions of a transaction is
+// resolved.
+type mergeChoice int
+
+const (
+	mergeKeepBoth mergeChoice = iota
+	mergeKeepOld
+	mergeKeepNew
+)
+
+// mergeContent returns the paragraph with spacing normalized, so paragraphs
+// that only differ in alignment are duplicates.
+func mergeContent(p ledgerParagraph) string {
+	lines := make([]string, len(p.lines))
+	for i, line := range p.lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.Join(lines, newLine)
+}
+
+// mergeKey returns the date and payee of the transaction in the paragraph,
+// without its status marker. Paragraphs with the same key but different
+// content conflict.
+func mergeKey(p ledgerParagraph) string {
+	for _, line := range p.lines {
+		if line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		content, _ := splitComment(line)
+		before, payee, _ := strings.Cut(content, " ")
+		if before == "" || before == "include" {
+			continue
+		}
+		payee = strings.TrimLeft(payee, " \t")
+		if len(payee) > 0 && (payee[0] == '*' || payee[0] == '!') {
+			payee = payee[1:]
+		}
+		return p.date.Format("2006-01-02") + "\x00" + strings.ToLower(strings.Join(strings.Fields(payee), " "))
+	}
+	return ""
+}
+
+// mergeLedgers combines the paragraphs of ledgers, see readParagraphs. The
+// paragraphs without a transaction come first, each once, followed by the
+// transactions in date order.
+//
+// A transaction of a later ledger that is the same as one of an earlier
+// ledger is left out. When it has the same date and payee as one of an
+// earlier ledger but differs, resolve chooses which to keep. Transactions
+// repeated within a single ledger are all kept.
+func mergeLedgers(ledgers [][]ledgerParagraph, resolve func(old, new ledgerParagraph) mergeChoice) []ledgerParagraph {
+	var others, transactions []ledgerParagraph
+	seenOthers := make(map[string]bool)
+	for lIdx, paragraphs := range ledgers {
+		// the transactions of earlier ledgers, each matched at most once
+		earlier := len(transactions)
+		matched := make([]bool, earlier)
+		for _, p := range paragraphs {
+			content := mergeContent(p)
+			if !p.transaction {
+				if !seenOthers[content] {
+					seenOthers[content] = true
+					others = append(others, p)
+				}
+				continue
+			}
+			if lIdx == 0 {
+				transactions = append(transactions, p)
+				continue
+			}
+
+			duplicate, conflict := -1, -1
+			for i, e := range transactions[:earlier] {
+				if matched[i] {
+					continue
+				}
+				if mergeContent(e) == content {
+					duplicate = i
+					break
+				}
+				if conflict < 0 && mergeKey(e) == mergeKey(p) {
+					conflict = i
+				}
+			}
+			switch {
+			case duplicate >= 0:
+				matched[duplicate] = true
+			case conflict >= 0:
+				matched[conflict] = true
+				switch resolve(transactions[conflict], p) {
+				case mergeKeepNew:
+					transactions[conflict] = p
+				case mergeKeepBoth:
+					transactions = append(transactions, p)
+				}
+			default:
+				transactions = append(transactions, p)
+			}
+		}
+	}
+
+	slices.SortStableFunc(transactions, func(a, b ledgerParagraph) int {
+		return a.date.Compare(b.date)
+	})
+	return append(others, transactions...)
+}
+
+// askMergeConflict shows both versions of a transaction on out and reads
+// which to keep from in. Both are kept when in has no answer.
+func askMergeConflict(in *bufio.Scanner, out io.Writer) func(old, new ledgerParagraph) mergeChoice {
+	return func(old, new ledgerParagraph) mergeChoice {
+		fmt.Fprintln(out, "Conflicting transactions:")
+		for _, version := range []struct {
+			label string
+			p     ledgerParagraph
+		}{{"old", old}, {"new", new}} {
+			fmt.Fprintln(out, version.label+":")
+			for _, line := range version.p.lines {
+				fmt.Fprintln(out, "  "+line)
+			}
+		}
+		for {
+			fmt.Fprint(out, "Keep [o]ld, [n]ew or [b]oth? ")
+			if !in.Scan() {
+				fmt.Fprintln(out)
+				return mergeKeepBoth
+			}
+			switch strings.ToLower(strings.TrimSpace(in.Text())) {
+			case "o", "old":
+				return mergeKeepOld
+			case "n", "new":
+				return mergeKeepNew
+			case "b", "both":
+				return mergeKeepBoth
+			}
+		}
+	}
+}
+
+// mergeCmd represents the merge command
+var mergeCmd = &cobra.Command{
+	Use:   "merge <file> <file>...",
+	Short: "Combine ledger files, removing duplicate transactions",
+	Long: `Combine ledger files into one, written to standard output (or --output), with
+the transactions in date order.
+
+A transaction of a later file that is the same as one of an earlier file, apart
+from spacing, is left out. A transaction with the same date and payee as one
+of an earlier file that differs is a conflict: by default you are asked which
+to keep, --conflicts first, last or both resolves them all the same way.
+Comments, directives and other paragraphs without a transaction are kept once,
+before the transactions.`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(_ *cobra.Command, args []string) {
+		var resolve func(old, new ledgerParagraph) mergeChoice
+		switch mergeConflicts {
+		case "ask":
+			resolve = askMergeConflict(bufio.NewScanner(os.Stdin), os.Stderr)
+		case "first":
+			resolve = func(_, _ ledgerParagraph) mergeChoice { return mergeKeepOld }
+		case "last":
+			resolve = func(_, _ ledgerParagraph) mergeChoice { return mergeKeepNew }
+		case "both":
+			resolve = func(_, _ ledgerParagraph) mergeChoice { return mergeKeepBoth }
+		default:
+			fatalln("unknown conflict resolution:", mergeConflicts)
+		}
+
+		var ledgers [][]ledgerParagraph
+		for _, filename := range args {
+			f, err := os.Open(filename)
+			if err != nil {
+				fatalln(err)
+			}
+			paragraphs, err := readParagraphs(f)
+			f.Close()
+			if err != nil {
+				fatalln(fmt.Errorf("%s: %w", filename, err))
+			}
+			ledgers = append(ledgers, paragraphs)
+		}
+
+		buf := bufio.NewWriter(cliOutput)
+		writeParagraphs(buf, mergeLedgers(ledgers, resolve))
+		buf.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mergeCmd)
+
+	mergeCmd.Flags().StringVar(&mergeConflicts, "conflicts", "ask", "Resolve conflicting transactions: ask, first, last or both.")
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+)
+
+func Test_mergeLedgers(t *testing.T) {
+	a := `; laptop
+
+2024/01/02 Grocer
+    Expenses:Food    50
+    Assets:Checking
+
+2024/01/09 Cafe
+    Expenses:Food    4
+    Assets:Checking
+
+2024/01/09 Cafe
+    Expenses:Food    4
+    Assets:Checking
+`
+	b := `; laptop
+
+2024/01/01 Rent
+    Expenses:Rent    500
+    Assets:Checking
+
+2024/01/02 Grocer
+    Expenses:Food      50
+    Assets:Checking
+
+2024/01/09 * Cafe
+    Expenses:Food    4.50
+    Assets:Checking
+`
+	read := func(s string) []ledgerParagraph {
+		paragraphs, err := readParagraphs(strings.NewReader(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return paragraphs
+	}
+
+	tests := []struct {
+		choice mergeChoice
+		want   []string
+	}{
+		{mergeKeepOld, []string{"; laptop", "2024/01/01 Rent", "2024/01/02 Grocer", "2024/01/09 Cafe", "2024/01/09 Cafe"}},
+		{mergeKeepNew, []string{"; laptop", "2024/01/01 Rent", "2024/01/02 Grocer", "2024/01/09 * Cafe", "2024/01/09 Cafe"}},
+		{mergeKeepBoth, []string{"; laptop", "2024/01/01 Rent", "2024/01/02 Grocer", "2024/01/09 Cafe", "2024/01/09 Cafe", "2024/01/09 * Cafe"}},
+	}
+	for _, tt := range tests {
+		conflicts := 0
+		merged := mergeLedgers([][]ledgerParagraph{read(a), read(b)}, func(_, _ ledgerParagraph) mergeChoice {
+			conflicts++
+			return tt.choice
+		})
+		var got []string
+		for _, p := range merged {
+			got = append(got, p.lines[0])
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("choice %d: got %q, want %q", tt.choice, got, tt.want)
+		}
+		if conflicts != 1 {
+			t.Errorf("choice %d: %d conflicts, want 1", tt.choice, conflicts)
+		}
+	}
+}
+
+func Test_askMergeConflict(t *testing.T) {
+	resolve := askMergeConflict(bufio.NewScanner(strings.NewReader("x\nn\n")), io.Discard)
+	if got := resolve(ledgerParagraph{lines: []string{"a"}}, ledgerParagraph{lines: []string{"b"}}); got != mergeKeepNew {
+		t.Errorf("got %d, want %d", got, mergeKeepNew)
+	}
+	if got := resolve(ledgerParagraph{lines: []string{"a"}}, ledgerParagraph{lines: []string{"b"}}); got != mergeKeepBoth {
+		t.Errorf("at end of input got %d, want %d", got, mergeKeepBoth)
+	}
+}
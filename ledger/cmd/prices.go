@@ -0,0 +1,390 @@
+package cmd
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+)
+
+var pricesFrom string
+var pricesBase string
+var pricesCoins []string
+var pricesCurrency string
+
+// ecbURL is where the euro exchange rates of the last 90 days of the
+// European Central Bank are fetched from.
+var ecbURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-hist-90d.xml"
+
+// coingeckoURL is where the prices of crypto currencies are fetched from.
+var coingeckoURL = "https://api.coingecko.com/api/v3/simple/price"
+
+// parseRates reads the prices of a csv file. A file with columns named as in
+// date, commodity and price, and currency if any, has a price per line; the
+// commodity may also be named symbol or from, the currency to or quote, and
+// the price rate or close. Otherwise the first column is the date and every
+// other column a currency, with the prices of one unit of base in it, as the
+// exchange rates of the European Central Bank.
+func parseRates(r io.Reader, base string) ([]*ledger.Price, error) {
+	csvReader := csv.NewReader(skipBOM(r))
+	csvReader.FieldsPerRecord = -1
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	date, commodity, currency, price := -1, -1, -1, -1
+	fields := map[string]*int{
+		"date":      &date,
+		"commodity": &commodity,
+		"symbol":    &commodity,
+		"from":      &commodity,
+		"currency":  &currency,
+		"to":        &currency,
+		"quote":     &currency,
+		"price":     &price,
+		"rate":      &price,
+		"close":     &price,
+	}
+	for i, name := range header {
+		if column, ok := fields[strings.ToLower(strings.TrimSpace(name))]; ok && *column < 0 {
+			*column = i
+		}
+	}
+	long := date >= 0 && commodity >= 0 && price >= 0
+	if !long && base == "" {
+		return nil, errors.New("no date, commodity and price columns, and no base currency of the rates")
+	}
+
+	var prices []*ledger.Price
+	for line, record := range records[1:] {
+		if len(record) == 0 || strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		dateColumn := 0
+		if long {
+			dateColumn = date
+		}
+		if dateColumn >= len(record) {
+			continue
+		}
+		priceDate, err := ratesDate(record[dateColumn])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line+2, err)
+		}
+		add := func(commodity, currency, value string) error {
+			value = strings.TrimSpace(value)
+			// rates missing on a day, as in "N/A"
+			if value == "" || strings.EqualFold(value, "N/A") {
+				return nil
+			}
+			p, err := decimal.NewFromString(value)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", line+2, err)
+			}
+			prices = append(prices, &ledger.Price{Date: priceDate, Commodity: strings.TrimSpace(commodity),
+				Currency: strings.TrimSpace(currency), Price: p})
+			return nil
+		}
+
+		if long {
+			if max(commodity, price, currency) >= len(record) {
+				continue
+			}
+			cur := ""
+			if currency >= 0 {
+				cur = record[currency]
+			}
+			if err := add(record[commodity], cur, record[price]); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		for i := 1; i < min(len(header), len(record)); i++ {
+			if strings.TrimSpace(header[i]) == "" {
+				continue
+			}
+			if err := add(base, header[i], record[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return prices, nil
+}
+
+// ratesDate parses the date of a price, such as "2024-01-02" or "2024/01/02".
+func ratesDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if date, err := time.Parse(transactionDateFormat, s); err == nil {
+		return date, nil
+	}
+	return time.Parse(time.DateOnly, s[:min(len(s), len(time.DateOnly))])
+}
+
+// getURL returns the body of the response to a GET request of the url.
+func getURL(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return body, nil
+}
+
+// fetchECB returns the euro exchange rates of the European Central Bank at
+// the url, the price of one euro in each currency.
+func fetchECB(ctx context.Context, client *http.Client, url string) ([]*ledger.Price, error) {
+	body, err := getURL(ctx, client, url)
+	if err != nil {
+		return nil, err
+	}
+	var envelope struct {
+		Days []struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string `xml:"currency,attr"`
+				Rate     string `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube>Cube"`
+	}
+	if err := xml.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("ECB: %w", err)
+	}
+	var prices []*ledger.Price
+	for _, day := range envelope.Days {
+		date, err := time.Parse(time.DateOnly, day.Time)
+		if err != nil {
+			return nil, fmt.Errorf("ECB: %w", err)
+		}
+		for _, rate := range day.Rates {
+			p, err := decimal.NewFromString(rate.Rate)
+			if err != nil {
+				return nil, fmt.Errorf("ECB: %w", err)
+			}
+			prices = append(prices, &ledger.Price{Date: date, Commodity: "EUR", Currency: rate.Currency, Price: p})
+		}
+	}
+	return prices, nil
+}
+
+// fetchCoinGecko returns the prices on the date of the coins in the
+// currency from the CoinGecko API at the url. Coins maps the ids of CoinGecko,
+// such as bitcoin, to commodities, such as BTC.
+func fetchCoinGecko(ctx context.Context, client *http.Client, apiURL string, coins map[string]string, currency string, date time.Time) ([]*ledger.Price, error) {
+	ids := make([]string, 0, len(coins))
+	for id := range coins {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	query := url.Values{"ids": {strings.Join(ids, ",")}, "vs_currencies": {strings.ToLower(currency)}}
+	body, err := getURL(ctx, client, apiURL+"?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	var quotes map[string]map[string]decimal.Decimal
+	if err := json.Unmarshal(body, &quotes); err != nil {
+		return nil, fmt.Errorf("CoinGecko: %w", err)
+	}
+	var prices []*ledger.Price
+	for _, id := range ids {
+		p, ok := quotes[id][strings.ToLower(currency)]
+		if !ok {
+			fmt.Fprintln(os.Stderr, "WARNING: no CoinGecko price of", id)
+			continue
+		}
+		prices = append(prices, &ledger.Price{Date: date, Commodity: coins[id], Currency: strings.ToUpper(currency), Price: p})
+	}
+	return prices, nil
+}
+
+// parseCoins returns the CoinGecko ids of coins given as id=COMMODITY, or
+// only the id for its upper case as the commodity.
+func parseCoins(coins []string) map[string]string {
+	m := make(map[string]string, len(coins))
+	for _, coin := range coins {
+		id, commodity, ok := strings.Cut(coin, "=")
+		if !ok {
+			commodity = strings.ToUpper(id)
+		}
+		m[strings.TrimSpace(id)] = strings.TrimSpace(commodity)
+	}
+	return m
+}
+
+// newPrices returns the prices without a price of the same date, commodity
+// and currency in existing or earlier in prices, by date and commodity.
+func newPrices(prices, existing []*ledger.Price) []*ledger.Price {
+	key := func(p *ledger.Price) string {
+		return p.Date.Format(time.DateOnly) + " " + p.Commodity + " " + p.Currency
+	}
+	seen := make(map[string]bool)
+	for _, p := range existing {
+		seen[key(p)] = true
+	}
+	var added []*ledger.Price
+	for _, p := range prices {
+		if !seen[key(p)] {
+			seen[key(p)] = true
+			added = append(added, p)
+		}
+	}
+	slices.SortStableFunc(added, func(a, b *ledger.Price) int {
+		return cmp.Or(a.Date.Compare(b.Date), strings.Compare(a.Commodity, b.Commodity), strings.Compare(a.Currency, b.Currency))
+	})
+	return added
+}
+
+// writePrices writes a price directive for each price.
+func writePrices(w io.Writer, prices []*ledger.Price) error {
+	buf := bufio.NewWriter(w)
+	for _, p := range prices {
+		buf.WriteString("P " + p.Date.Format(transactionDateFormat) + " " + p.Commodity)
+		if p.Currency != "" {
+			buf.WriteString(" " + p.Currency)
+		}
+		buf.WriteString(" " + p.Price.String() + newLine)
+	}
+	return buf.Flush()
+}
+
+// appendPrices appends the price directives of the prices to the file, on a
+// line of their own.
+func appendPrices(filename string, prices []*ledger.Price) error {
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if size > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, size-1); err != nil {
+			return err
+		}
+		if last[0] != '\n' {
+			if _, err := f.WriteString(newLine); err != nil {
+				return err
+			}
+		}
+	}
+	if err := writePrices(f, prices); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// pricesCmd represents the prices command
+var pricesCmd = &cobra.Command{
+	Use:   "prices",
+	Short: "Manage market prices",
+}
+
+// pricesImportCmd represents the prices import command
+var pricesImportCmd = &cobra.Command{
+	Use:   "import [rates.csv]",
+	Args:  cobra.MaximumNArgs(1),
+	Short: "Add market prices from a csv file, the ECB or CoinGecko",
+	Long: `Add the prices of a csv file, or fetched with --from, as P directives to the
+end of the price database file (--price-db), or else the ledger file. Prices
+the file already has for the same date, commodity and currency are left out.
+
+--from ecb fetches the euro exchange rates of the European Central Bank of the
+last 90 days, and --from coingecko today's prices in --currency of the --coins.`,
+	Run: func(_ *cobra.Command, args []string) {
+		if (len(args) == 0) == (pricesFrom == "") {
+			fatalln("prices import needs a csv file or --from, not both")
+		}
+		filename := cmp.Or(priceDBPath, ledgerFilePath)
+		if filename == "" || filename == "-" {
+			fatalln("prices import needs --price-db or a ledger file")
+		}
+
+		var prices []*ledger.Price
+		var err error
+		ctx := context.Background()
+		client := &http.Client{Timeout: time.Minute}
+		switch pricesFrom {
+		case "":
+			f, ferr := os.Open(args[0])
+			if ferr != nil {
+				fatalln(ferr)
+			}
+			prices, err = parseRates(f, pricesBase)
+			f.Close()
+			if err != nil {
+				err = fmt.Errorf("%s:%w", args[0], err)
+			}
+		case "ecb":
+			prices, err = fetchECB(ctx, client, ecbURL)
+		case "coingecko":
+			if len(pricesCoins) == 0 {
+				fatalln("prices import --from coingecko needs --coins")
+			}
+			prices, err = fetchCoinGecko(ctx, client, coingeckoURL, parseCoins(pricesCoins), pricesCurrency, time.Now())
+		default:
+			fatalln("unknown price source:", pricesFrom)
+		}
+		if err != nil {
+			fatalln(err)
+		}
+
+		var existing []*ledger.Price
+		if _, serr := os.Stat(filename); serr == nil {
+			if existing, err = ledger.ParsePrices(filename); err != nil {
+				fatalln(err)
+			}
+		}
+		added := newPrices(prices, existing)
+		if len(added) > 0 {
+			if err := appendPrices(filename, added); err != nil {
+				fatalln(err)
+			}
+		}
+		fmt.Fprintf(cliOutput, "%d prices added to %s, %d already there%s", len(added), filename, len(prices)-len(added), newLine)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pricesCmd)
+	pricesCmd.AddCommand(pricesImportCmd)
+
+	pricesImportCmd.Flags().StringVar(&priceDBPath, "price-db", "", "File to add the prices to (default is the ledger file).")
+	pricesImportCmd.Flags().StringVar(&pricesFrom, "from", "", "Fetch the prices from ecb or coingecko instead of a csv file.")
+	pricesImportCmd.Flags().StringVar(&pricesBase, "base", "", "Currency priced by the columns of a csv file of a column per currency, such as EUR.")
+	pricesImportCmd.Flags().StringSliceVar(&pricesCoins, "coins", nil, "CoinGecko ids of the coins to price, as bitcoin=BTC,ethereum=ETH.")
+	pricesImportCmd.Flags().StringVar(&pricesCurrency, "currency", "USD", "Currency of the CoinGecko prices.")
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/howeyc/ledger"
+)
+
+// priceLines returns the price directives of the prices.
+func priceLines(t *testing.T, prices []*ledger.Price) string {
+	t.Helper()
+	var buf strings.Builder
+	if err := writePrices(&buf, prices); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func Test_parseRates(t *testing.T) {
+	prices, err := parseRates(strings.NewReader("Date,Symbol,Quote,Close\n2024-01-02,AAPL,USD,185.64\n2024-01-03,AAPL,USD,184.25\n"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "P 2024/01/02 AAPL USD 185.64\nP 2024/01/03 AAPL USD 184.25\n"
+	if got := priceLines(t, prices); got != want {
+		t.Errorf("long rates: got %q, want %q", got, want)
+	}
+
+	prices, err = parseRates(strings.NewReader("Date, USD, JPY, \n2024-01-02, 1.0956, N/A, \n"), "EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "P 2024/01/02 EUR USD 1.0956\n"
+	if got := priceLines(t, prices); got != want {
+		t.Errorf("wide rates: got %q, want %q", got, want)
+	}
+
+	if _, err := parseRates(strings.NewReader("Date,USD\n2024-01-02,1.09\n"), ""); err == nil {
+		t.Error("parseRates succeeded on rates per currency without a base")
+	}
+}
+
+func Test_fetchPrices(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ecb":
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2024-01-03"><Cube currency="USD" rate="1.0919"/><Cube currency="JPY" rate="155.52"/></Cube>
+		<Cube time="2024-01-02"><Cube currency="USD" rate="1.0956"/></Cube>
+	</Cube>
+</gesmes:Envelope>`))
+		case "/coingecko":
+			if got := r.URL.Query().Get("ids"); got != "bitcoin,ethereum" {
+				t.Errorf("ids = %q", got)
+			}
+			w.Write([]byte(`{"bitcoin":{"usd":42123.5},"ethereum":{"usd":2250.12}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	prices, err := fetchECB(ctx, server.Client(), server.URL+"/ecb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "P 2024/01/03 EUR USD 1.0919\nP 2024/01/03 EUR JPY 155.52\nP 2024/01/02 EUR USD 1.0956\n"
+	if got := priceLines(t, prices); got != want {
+		t.Errorf("ECB: got %q, want %q", got, want)
+	}
+
+	date := time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC)
+	prices, err = fetchCoinGecko(ctx, server.Client(), server.URL+"/coingecko", parseCoins([]string{"bitcoin=BTC", "ethereum"}), "USD", date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "P 2024/01/04 BTC USD 42123.5\nP 2024/01/04 ETHEREUM USD 2250.12\n"
+	if got := priceLines(t, prices); got != want {
+		t.Errorf("CoinGecko: got %q, want %q", got, want)
+	}
+
+	if _, err := fetchECB(ctx, server.Client(), server.URL+"/missing"); err == nil {
+		t.Error("fetchECB succeeded on a missing page")
+	}
+}
+
+func Test_appendPrices(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "prices.ledger")
+	if err := os.WriteFile(filename, []byte("P 2024/01/02 EUR USD 1.0956"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	existing, err := ledger.ParsePrices(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prices, err := parseRates(strings.NewReader("date,commodity,currency,rate\n2024-01-03,EUR,USD,1.0919\n2024-01-02,EUR,USD,1.0956\n2024-01-03,EUR,USD,1.0919\n"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	added := newPrices(prices, existing)
+	if len(added) != 1 {
+		t.Fatalf("got %d new prices, want 1", len(added))
+	}
+	if err := appendPrices(filename, added); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "P 2024/01/02 EUR USD 1.0956\nP 2024/01/03 EUR USD 1.0919\n"; string(data) != want {
+		t.Errorf("got %q, want %q", data, want)
+	}
+	if all, err := ledger.ParsePrices(filename); err != nil || len(all) != 2 {
+		t.Errorf("parsed %d prices, %v, want 2", len(all), err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"regexp"
 	"slices"
 	"strings"
 	"time"
@@ -21,20 +22,81 @@ import (
 	"golang.org/x/term"
 )
 
-const (
-	transactionDateFormat = "2006/01/02"
-	newLine               = "\n"
-)
+const newLine = "\n"
+
+// transactionDateFormat is the layout of dates in reports, set by the
+// date_format configuration.
+var transactionDateFormat = "2006/01/02"
+
+// commodityFormats holds the formats of amounts in reports, set by the
+// commodity configuration, in commodityConfigs, and inferred from the ledger.
+var commodityFormats ledger.CommodityFormats
+var commodityConfigs map[string]commodityConfig
+
+// localeName is the locale of amounts in reports, set by --locale or the
+// locale configuration, and reportLocale the locale of the name.
+var localeName string
+var reportLocale *ledger.Locale
 
 var startString, endString string
 var columnWidth, transactionDepth int
 var showEmptyAccounts bool
+var balanceTree bool
+var balanceSortBy string
 var columnWide bool
+var statusCleared, statusPending, statusUncleared bool
+var invertAmounts bool
+var marketValue bool
+var exchangeCurrency string
+var priceDBPath string
+
+// fatalln prints the error and exits. The repl replaces it to end only the
+// command that failed.
+var fatalln = log.Fatalln
+
+// cliOutput is where reports are written, set by the --output flag.
+var cliOutput io.Writer = os.Stdout
 var period string
 var payeeFilter string
+var transactionSortBy string
+var headCount, tailCount int
 var spaceStr string
 
 func cliTransactions() ([]*ledger.Transaction, error) {
+	setWideColumns()
+	parsedStartDate, parsedEndDate, err := cliDateRange()
+	if err != nil {
+		return nil, err
+	}
+
+	var generalLedger []*ledger.Transaction
+	var parseError error
+	switch {
+	case replLedger != nil && replLedgerPath == ledgerFilePath:
+		// parsed once by the repl, copied as reports sort it
+		generalLedger = slices.Clone(replLedger)
+	case ledgerFilePath == "-":
+		generalLedger, parseError = ledger.ParseLedger(os.Stdin)
+	default:
+		// transactions of included files of other dates are not parsed
+		generalLedger, parseError = ledger.ParseLedgerFileRange(ledgerFilePath, parsedStartDate, parsedEndDate)
+	}
+	if parseError != nil {
+		return nil, parseError
+	}
+
+	slices.SortStableFunc(generalLedger, func(a, b *ledger.Transaction) int {
+		return a.Date.Compare(b.Date)
+	})
+	// of the transactions parsed, so the places shown can depend on the dates
+	setCommodityFormats(ledger.InferCommodityFormats(generalLedger))
+
+	return ledger.FilterTransactions(generalLedger, cliFilters(parsedStartDate, parsedEndDate)...), nil
+}
+
+// setWideColumns sets the width of reports to the width of the terminal, or
+// 132 columns, with the wide option.
+func setWideColumns() {
 	if columnWidth == 80 && columnWide {
 		columnWidth = 132
 		fd := int(os.Stdout.Fd())
@@ -45,61 +107,309 @@ func cliTransactions() ([]*ledger.Transaction, error) {
 			}
 		}
 	}
+}
 
-	parsedStartDate, tstartErr := date.Parse(startString)
-	parsedEndDate, tendErr := date.Parse(endString)
+// cliDateRange returns the dates of the begin and end date options, the end
+// date moved past its transactions.
+func cliDateRange() (start, end time.Time, err error) {
+	start, tstartErr := date.Parse(startString)
+	end, tendErr := date.Parse(endString)
 
 	if tstartErr != nil || tendErr != nil {
-		return nil, errors.New("unable to parse start or end date string argument")
+		return start, end, errors.New("unable to parse start or end date string argument")
 	}
 
 	// include end dates' transactions too
-	parsedEndDate = parsedEndDate.Add(time.Second)
+	return start, end.Add(time.Second), nil
+}
 
-	var generalLedger []*ledger.Transaction
-	var parseError error
-	if ledgerFilePath == "-" {
-		generalLedger, parseError = ledger.ParseLedger(os.Stdin)
-	} else {
-		generalLedger, parseError = ledger.ParseLedgerFile(ledgerFilePath)
+// cliFilters returns the filters of transactions in reports: the date range
+// and the payee option.
+func cliFilters(start, end time.Time) []ledger.TransactionFilter {
+	return []ledger.TransactionFilter{
+		ledger.ByDateRange(start, end),
+		ledger.ByPayeeRegex(regexp.MustCompile(regexp.QuoteMeta(payeeFilter))),
 	}
-	if parseError != nil {
-		return nil, parseError
+}
+
+// setCommodityFormats sets the formats of amounts in reports: those of the
+// commodity configuration, with the decimal places inferred unless
+// configured, and the inferred formats of the other commodities, in the
+// locale of the report.
+func setCommodityFormats(inferred ledger.CommodityFormats) {
+	formats := make(ledger.CommodityFormats, len(inferred)+len(commodityConfigs))
+	for name, f := range inferred {
+		formats[name] = localized(name, f)
+	}
+	for name, c := range commodityConfigs {
+		formats[name] = c.format(inferred.Lookup(name).Places)
+	}
+	commodityFormats = formats
+}
+
+// localized returns the format of the commodity in the locale of the report,
+// if any.
+func localized(name string, f ledger.CommodityFormat) ledger.CommodityFormat {
+	if reportLocale != nil {
+		return reportLocale.Apply(name, f)
+	}
+	return f
+}
+
+// formatAmount returns the amount of the commodity formatted for reports.
+func formatAmount(name string, amount decimal.Decimal) string {
+	f, ok := commodityFormats[name]
+	if !ok {
+		f = localized(name, ledger.DefaultCommodityFormat)
+	}
+	return f.Format(name, amount)
+}
+
+// queryQuote quotes a value for use in a query expression. Quoted parts next
+// to each other are one value, so a value with both quotes has its double
+// quotes single quoted.
+func queryQuote(s string) string {
+	switch {
+	case !strings.Contains(s, `"`):
+		return `"` + s + `"`
+	case !strings.Contains(s, "'"):
+		return "'" + s + "'"
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `"'"'"`) + `"`
+}
+
+// transactionSize is the sum of the positive amounts of a transaction, the
+// amount moved by it.
+func transactionSize(trans *ledger.Transaction) decimal.Decimal {
+	var size decimal.Decimal
+	for _, acc := range trans.AccountChanges {
+		if acc.Balance.Sign() > 0 {
+			size = size.Add(acc.Balance)
+		}
 	}
+	return size
+}
 
+// limitTransactions keeps the first head transactions, then the last tail of
+// those. A limit of zero keeps all transactions.
+func limitTransactions(generalLedger []*ledger.Transaction, head, tail int) []*ledger.Transaction {
+	if head > 0 && head < len(generalLedger) {
+		generalLedger = generalLedger[:head]
+	}
+	if tail > 0 && tail < len(generalLedger) {
+		generalLedger = generalLedger[len(generalLedger)-tail:]
+	}
+	return generalLedger
+}
+
+// sortTransactions orders transactions by a comma separated list of keys
+// (date, payee, amount), each descending when prefixed with "-". The sort is
+// stable, so transactions equal on every key keep their order.
+func sortTransactions(generalLedger []*ledger.Transaction, keys string) error {
+	var cmps []func(a, b *ledger.Transaction) int
+	for _, key := range strings.Split(keys, ",") {
+		key = strings.TrimSpace(key)
+		desc := false
+		if after, ok := strings.CutPrefix(key, "-"); ok {
+			key, desc = after, true
+		}
+		var cmp func(a, b *ledger.Transaction) int
+		switch key {
+		case "date":
+			cmp = func(a, b *ledger.Transaction) int { return a.Date.Compare(b.Date) }
+		case "payee":
+			cmp = func(a, b *ledger.Transaction) int { return strings.Compare(a.Payee, b.Payee) }
+		case "amount":
+			cmp = func(a, b *ledger.Transaction) int { return transactionSize(a).Cmp(transactionSize(b)) }
+		default:
+			return fmt.Errorf("unknown sort key: %q", key)
+		}
+		if desc {
+			asc := cmp
+			cmp = func(a, b *ledger.Transaction) int { return asc(b, a) }
+		}
+		cmps = append(cmps, cmp)
+	}
 	slices.SortStableFunc(generalLedger, func(a, b *ledger.Transaction) int {
-		return a.Date.Compare(b.Date)
+		for _, cmp := range cmps {
+			if c := cmp(a, b); c != 0 {
+				return c
+			}
+		}
+		return 0
 	})
+	return nil
+}
 
-	generalLedger = ledger.TransactionsInDateRange(generalLedger, parsedStartDate, parsedEndDate)
+// cliQuery parses the command line arguments as a query, see ledger.Query,
+// limited to the postings with the statuses selected by flags.
+func cliQuery(args []string) *ledger.Query {
+	expr := strings.Join(args, " ")
+	var statuses []string
+	if statusCleared {
+		statuses = append(statuses, "status:cleared")
+	}
+	if statusPending {
+		statuses = append(statuses, "status:pending")
+	}
+	if statusUncleared {
+		statuses = append(statuses, "status:uncleared")
+	}
+	if len(statuses) > 0 {
+		if expr != "" {
+			expr = "(" + expr + ") and "
+		}
+		expr += "(" + strings.Join(statuses, " or ") + ")"
+	}
+
+	query, err := ledger.ParseQuery(expr)
+	if err != nil {
+		fatalln(err)
+	}
+	return query
+}
+
+// invertTransactions returns copies of the transactions with the sign of
+// every amount flipped.
+func invertTransactions(generalLedger []*ledger.Transaction) []*ledger.Transaction {
+	inverted := make([]*ledger.Transaction, 0, len(generalLedger))
+	for _, trans := range generalLedger {
+		t := *trans
+		t.AccountChanges = make([]ledger.Account, len(trans.AccountChanges))
+		for i, acc := range trans.AccountChanges {
+			acc.Balance = acc.Balance.Neg()
+			if acc.Converted != nil {
+				converted := acc.Converted.Neg()
+				acc.Converted = &converted
+			}
+			t.AccountChanges[i] = acc
+		}
+		inverted = append(inverted, &t)
+	}
+	return inverted
+}
 
-	origLedger := generalLedger
-	generalLedger = make([]*ledger.Transaction, 0)
-	for _, trans := range origLedger {
-		if strings.Contains(trans.Payee, payeeFilter) {
-			generalLedger = append(generalLedger, trans)
+// cliPriceDB returns the prices read from the price database file or the
+// ledger file.
+func cliPriceDB() (*ledger.PriceDB, error) {
+	filename := priceDBPath
+	if filename == "" {
+		filename = ledgerFilePath
+	}
+	if filename == "-" {
+		return nil, errors.New("prices can not be read from stdin, use --price-db")
+	}
+	prices, err := ledger.ParsePrices(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ledger.NewPriceDB(prices), nil
+}
+
+// cliValueDate returns the end of the date range, or today for an open-ended
+// range.
+func cliValueDate() time.Time {
+	valueDate := time.Now()
+	if parsedEndDate, err := date.Parse(endString); err == nil && parsedEndDate.Before(valueDate) {
+		valueDate = parsedEndDate
+	}
+	return valueDate
+}
+
+// valueTransactions returns copies of the transactions with every amount of a
+// commodity that has a price on or before at converted to the currency of the
+// latest one.
+func valueTransactions(generalLedger []*ledger.Transaction, db *ledger.PriceDB, at time.Time) []*ledger.Transaction {
+	valued := make([]*ledger.Transaction, 0, len(generalLedger))
+	for _, trans := range generalLedger {
+		t := *trans
+		t.AccountChanges = make([]ledger.Account, len(trans.AccountChanges))
+		for i, acc := range trans.AccountChanges {
+			if p, ok := db.Latest(acc.Currency, at); ok {
+				acc.Currency, acc.Balance = p.Currency, acc.Balance.Mul(p.Price)
+				acc.Converted, acc.ConversionFactor = nil, nil
+			}
+			t.AccountChanges[i] = acc
 		}
+		valued = append(valued, &t)
+	}
+	return valued
+}
+
+// exchangeTransactions returns copies of the transactions with every amount
+// converted to currency, at the rate on the date of each transaction, or on at
+// when it is not zero. Amounts that can not be converted keep their currency.
+func exchangeTransactions(generalLedger []*ledger.Transaction, db *ledger.PriceDB, currency string, at time.Time) []*ledger.Transaction {
+	type rateKey struct {
+		from string
+		date time.Time
+	}
+	type rate struct {
+		rate decimal.Decimal
+		ok   bool
 	}
+	rates := make(map[rateKey]rate)
 
-	return generalLedger, nil
+	exchanged := make([]*ledger.Transaction, 0, len(generalLedger))
+	for _, trans := range generalLedger {
+		rateDate := at
+		if rateDate.IsZero() {
+			rateDate = trans.Date
+		}
+		t := *trans
+		t.AccountChanges = make([]ledger.Account, len(trans.AccountChanges))
+		for i, acc := range trans.AccountChanges {
+			key := rateKey{acc.Currency, rateDate}
+			r, ok := rates[key]
+			if !ok {
+				r.rate, r.ok = db.ExchangeRate(acc.Currency, currency, rateDate)
+				rates[key] = r
+			}
+			if r.ok && acc.Currency != currency {
+				acc.Currency, acc.Balance = currency, acc.Balance.Mul(r.rate)
+				acc.Converted, acc.ConversionFactor = nil, nil
+			}
+			t.AccountChanges[i] = acc
+		}
+		exchanged = append(exchanged, &t)
+	}
+	return exchanged
 }
 
 // printCmd represents the print command
 var printCmd = &cobra.Command{
-	Use:   "print [account-substring-filter]...",
+	Use:   "print [query]...",
 	Short: "Print transactions in ledger file format",
 	Run: func(_ *cobra.Command, args []string) {
 		generalLedger, err := cliTransactions()
 		if err != nil {
-			log.Fatalln(err)
+			fatalln(err)
 		}
 
-		PrintLedger(generalLedger, args, columnWidth)
+		if err := sortTransactions(generalLedger, transactionSortBy); err != nil {
+			fatalln(err)
+		}
+		if headCount < 0 || tailCount < 0 {
+			fatalln("--head and --tail must not be negative")
+		}
+		query := cliQuery(args)
+		var matched []*ledger.Transaction
+		for _, trans := range generalLedger {
+			if query.MatchTransaction(trans) {
+				matched = append(matched, trans)
+			}
+		}
+		generalLedger = limitTransactions(matched, headCount, tailCount)
+		if printAnonymize {
+			generalLedger, query = anonymizeTransactions(generalLedger), nil
+		}
+		PrintLedger(cliOutput, generalLedger, query, columnWidth)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(printCmd)
+	watchCommand(printCmd)
 
 	var startDate, endDate time.Time
 	startDate = time.Date(1970, 1, 1, 0, 0, 0, 0, time.Local)
@@ -107,13 +417,21 @@ func init() {
 	printCmd.Flags().StringVarP(&startString, "begin-date", "b", startDate.Format(transactionDateFormat), "Begin date of transaction processing.")
 	printCmd.Flags().StringVarP(&endString, "end-date", "e", endDate.Format(transactionDateFormat), "End date of transaction processing.")
 	printCmd.Flags().StringVar(&payeeFilter, "payee", "", "Filter output to payees that contain this string.")
+	completeLedgerCommand(printCmd)
 	printCmd.Flags().IntVar(&columnWidth, "columns", 80, "Set a column width for output.")
 	printCmd.Flags().BoolVar(&columnWide, "wide", false, "Wide output (use terminal width).")
+	printCmd.Flags().StringVar(&transactionSortBy, "sort", "date", "Sort transactions by date, payee and/or amount, comma separated, descending with a - prefix.")
+	printCmd.Flags().IntVar(&headCount, "head", 0, "Only show the first N transactions.")
+	printCmd.Flags().IntVar(&tailCount, "tail", 0, "Only show the last N transactions.")
+	printCmd.Flags().BoolVar(&printAnonymize, "anonymize", false, "Hash payees, rename accounts and scale amounts, to share the ledger without exposing it.")
 }
 
 // PrintBalances prints out account balances formatted to a window set to a width of columns.
 // Only shows accounts with names less than or equal to the given depth.
-func PrintBalances(accountList []*ledger.Account, printZeroBalances bool, depth, columns int) {
+//
+// In tree view each account is shown by its last name segment, indented by
+// depth, and parents of any shown account are always shown.
+func PrintBalances(w io.Writer, accountList []*ledger.Account, printZeroBalances bool, depth, columns int, tree bool) {
 	// Calculate widths: 10 columns for balance, rest for accountname
 	if columns < 12 {
 		columns = 12
@@ -121,119 +439,224 @@ func PrintBalances(accountList []*ledger.Account, printZeroBalances bool, depth,
 	}
 	accWidth := columns - 11
 
-	colorNeg := fastcolor.FgRed
-	colorAccount := fastcolor.FgBlue
+	colorNeg := fastcolor.CurrentTheme.Negative
+	colorAccount := fastcolor.CurrentTheme.Account
 	colorReset := fastcolor.Reset
 
-	buf := bufio.NewWriter(os.Stdout)
-	overallBalance := decimal.Zero
+	// parents needed to keep the tree connected
+	parents := make(map[string]bool)
+	if tree {
+		for _, account := range accountList {
+			accDepth := strings.Count(account.Name, ":") + 1
+			if (printZeroBalances || account.Balance.Sign() != 0) && (depth < 0 || accDepth <= depth) {
+				for pName := account.Name; strings.Contains(pName, ":"); {
+					pName = pName[:strings.LastIndex(pName, ":")]
+					parents[pName] = true
+				}
+			}
+		}
+	}
+
+	buf := bufio.NewWriter(w)
+	overallBalance := make(map[string]decimal.Decimal)
+	var prevName string
 	for _, account := range accountList {
 		accDepth := strings.Count(account.Name, ":") + 1
 		if accDepth == 1 {
-			overallBalance = overallBalance.Add(account.Balance)
+			overallBalance[account.Currency] = overallBalance[account.Currency].Add(account.Balance)
 		}
-		if (printZeroBalances || account.Balance.Sign() != 0) && (depth < 0 || accDepth <= depth) {
-			outBalanceString := account.Currency + " " + account.Balance.StringFixedBank(2)
+		show := (printZeroBalances || account.Balance.Sign() != 0) && (depth < 0 || accDepth <= depth)
+		if !show && parents[account.Name] && account.Name != prevName {
+			show = true
+		}
+		if show {
+			accName := account.Name
+			if tree {
+				if accName == prevName {
+					accName = ""
+				} else {
+					accName = strings.Repeat("  ", accDepth-1) + accName[strings.LastIndex(accName, ":")+1:]
+				}
+			}
+			prevName = account.Name
+
+			outBalanceString := formatAmount(account.Currency, account.Balance)
 			amtColor := colorReset
 			if account.Balance.Sign() < 0 {
 				amtColor = colorNeg
 			}
-			colorAccount.WriteStringFixed(buf, account.Name, accWidth, false)
+			colorAccount.WriteStringFixed(buf, accName, accWidth, false)
 			buf.WriteString(" ")
 			amtColor.WriteStringFixed(buf, outBalanceString, 10, true)
 			buf.WriteString(newLine)
 		}
 	}
 	fmt.Fprintln(buf, strings.Repeat("-", columns))
-	outBalanceString := overallBalance.StringFixedBank(2)
-	amtColor := colorReset
-	if overallBalance.Sign() < 0 {
-		amtColor = colorNeg
-	}
-	colorAccount.WriteStringFixed(buf, "", accWidth, false)
-	buf.WriteString(" ")
-	amtColor.WriteStringFixed(buf, outBalanceString, 10, true)
-	buf.WriteString(newLine)
+	currencies := sortedCurrencies(overallBalance)
+	if len(currencies) == 0 {
+		currencies = []string{""}
+	}
+	for _, cur := range currencies {
+		outBalanceString := formatAmount(cur, overallBalance[cur])
+		amtColor := colorReset
+		if overallBalance[cur].Sign() < 0 {
+			amtColor = colorNeg
+		}
+		colorAccount.WriteStringFixed(buf, "", accWidth, false)
+		buf.WriteString(" ")
+		amtColor.WriteStringFixed(buf, outBalanceString, 10, true)
+		buf.WriteString(newLine)
+	}
 	buf.Flush()
 }
 
-// WriteTransaction writes a transaction formatted to fit in specified column width.
-func WriteTransaction(w io.StringWriter, trans *ledger.Transaction, columns int) {
-	if len(spaceStr) < columns {
-		spaceStr = strings.Repeat(" ", columns)
+// sortBalances returns the accounts of the balance tree for display. Sorting
+// by "amount" orders accounts by descending absolute balance, largest first;
+// in tree view only siblings are compared so every account still follows its
+// parent. Rows of the same account (one per currency) stay together. Sorting
+// by "name" keeps the order of the tree, as GetBalances returns them.
+func sortBalances(root *ledger.BalanceNode, sortBy string, tree bool) []*ledger.Account {
+	if sortBy != "amount" {
+		return root.Accounts()
 	}
 
-	for _, c := range trans.Comments {
-		w.WriteString(c)
-		w.WriteString(newLine)
-	}
-
-	// Print accounts sorted by name
-	slices.SortFunc(trans.AccountChanges, func(a, b ledger.Account) int {
-		return strings.Compare(a.Name, b.Name)
-	})
-
-	w.WriteString(trans.Date.Format(transactionDateFormat))
-	w.WriteString(spaceStr[:1])
-	w.WriteString(trans.Payee)
-	if len(trans.PayeeComment) > 0 {
-		spaceCount := columns - 10 - utf8.RuneCountInString(trans.Payee)
-		if spaceCount < 1 {
-			spaceCount = 1
+	size := func(n *ledger.BalanceNode) (s decimal.Decimal) {
+		for _, acc := range n.Total {
+			if accSize := acc.Balance.Abs(); accSize.GreaterThan(s) {
+				s = accSize
+			}
 		}
-		w.WriteString(spaceStr[:spaceCount])
-		w.WriteString(trans.PayeeComment)
+		return
 	}
-	w.WriteString(newLine)
-	for _, accChange := range trans.AccountChanges {
-		outBalanceString := accChange.Balance.StringFixedBank(2)
-		if accChange.Currency != "" {
-			outBalanceString = accChange.Currency + " " + outBalanceString
+	cmpNode := func(a, b *ledger.BalanceNode) int {
+		if c := size(b).Cmp(size(a)); c != 0 {
+			return c
 		}
-		// Show converted amount (@@) or conversion factor (@) similar to hledger
-		if accChange.Converted != nil {
-			outBalanceString = outBalanceString + " @@ " + accChange.Converted.StringFixedBank(2)
-		} else if accChange.ConversionFactor != nil {
-			outBalanceString = outBalanceString + " @ " + accChange.ConversionFactor.String()
+		return strings.Compare(a.Name, b.Name)
+	}
+	rows := func(n *ledger.BalanceNode) []*ledger.Account {
+		accs := make([]*ledger.Account, len(n.Total))
+		for i := range n.Total {
+			accs[i] = &n.Total[i]
 		}
-		spaceCount := columns - 4 - utf8.RuneCountInString(accChange.Name) - utf8.RuneCountInString(outBalanceString)
-		if spaceCount < 1 {
-			spaceCount = 1
+		return accs
+	}
+
+	var sorted []*ledger.Account
+	if !tree {
+		nodes := slices.SortedFunc(root.All(), cmpNode)
+		for _, n := range nodes {
+			sorted = append(sorted, rows(n)...)
 		}
-		w.WriteString(spaceStr[:4])
-		w.WriteString(accChange.Name)
-		w.WriteString(spaceStr[:spaceCount])
-		w.WriteString(outBalanceString)
-		if len(accChange.Comment) > 0 {
-			w.WriteString(spaceStr[:1])
-			w.WriteString(accChange.Comment)
+		return sorted
+	}
+
+	var walk func(level []*ledger.BalanceNode)
+	walk = func(level []*ledger.BalanceNode) {
+		level = slices.SortedFunc(slices.Values(level), cmpNode)
+		for _, n := range level {
+			sorted = append(sorted, rows(n)...)
+			walk(n.Children)
 		}
-		w.WriteString(newLine)
 	}
-	w.WriteString(newLine)
+	walk(root.Children)
+	return sorted
+}
+
+// statusMarker returns the marker written before a payee or account name for
+// the status.
+func statusMarker(status ledger.Status) string {
+	switch status {
+	case ledger.StatusCleared:
+		return "* "
+	case ledger.StatusPending:
+		return "! "
+	}
+	return ""
+}
+
+// WriteTransaction writes a transaction formatted to fit in specified column width.
+func WriteTransaction(w io.StringWriter, trans *ledger.Transaction, columns int) {
+	transactionFormat(columns).Write(w, trans)
+}
+
+// transactionFormat returns the format of transactions written in specified
+// column width, with the configured date and commodity formats.
+func transactionFormat(columns int) ledger.TransactionFormat {
+	return ledger.TransactionFormat{DateFormat: transactionDateFormat, Columns: columns, Commodities: commodityFormats, LineEnding: newLine}
 }
 
 // PrintLedger prints all transactions as a formatted ledger file.
-func PrintLedger(generalLedger []*ledger.Transaction, filterArr []string, columns int) {
-	buf := bufio.NewWriter(os.Stdout)
+func PrintLedger(w io.Writer, generalLedger []*ledger.Transaction, query *ledger.Query, columns int) {
+	// amounts are in the locale, if any, which cannot be parsed back
+	format := transactionFormat(columns)
+	format.FormatAmounts = reportLocale != nil
+	buf := bufio.NewWriter(w)
+	for _, trans := range generalLedger {
+		if query.MatchTransaction(trans) {
+			format.Write(buf, trans)
+		}
+	}
+	buf.Flush()
+}
+
+// PrintRegister prints each posting that matches the given query. With
+// average, the running average amount per posting is printed after the
+// running total.
+func PrintRegister(w io.Writer, generalLedger []*ledger.Transaction, query *ledger.Query, columns int, average bool) {
+	buf := bufio.NewWriter(w)
+	printPosting := registerPrinter(buf, columns, average)
 	for _, trans := range generalLedger {
-		inFilter := len(filterArr) == 0
 		for _, accChange := range trans.AccountChanges {
-			for _, filter := range filterArr {
-				if strings.Contains(accChange.Name, filter) {
-					inFilter = true
-				}
+			if query.Match(trans, &accChange) {
+				printPosting(trans, &accChange)
 			}
 		}
-		if inFilter {
-			WriteTransaction(buf, trans, columns)
+	}
+	buf.Flush()
+}
+
+// PrintRegisterAsync prints each posting that matches the given query, as
+// PrintRegister, of the transactions received from the channels of
+// ledger.ParseLedgerAsync as they are parsed, keeping only the running
+// totals. After the first error, the transactions are received but not
+// printed, and the error is returned.
+func PrintRegisterAsync(w io.Writer, transactions <-chan *ledger.Transaction, errs <-chan error, filters []ledger.TransactionFilter, query *ledger.Query, columns int, average bool) (err error) {
+	buf := bufio.NewWriter(w)
+	printPosting := registerPrinter(buf, columns, average)
+	for transactions != nil || errs != nil {
+		select {
+		case trans, ok := <-transactions:
+			if !ok {
+				transactions = nil
+				continue
+			}
+			if err != nil || slices.ContainsFunc(filters, func(filter ledger.TransactionFilter) bool { return !filter(trans) }) {
+				continue
+			}
+			for _, accChange := range trans.AccountChanges {
+				if query.Match(trans, &accChange) {
+					printPosting(trans, &accChange)
+				}
+			}
+		case perr, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			if err == nil {
+				err = perr
+			}
 		}
 	}
 	buf.Flush()
+	return err
 }
 
-// PrintRegister prints each transaction that matches the given filters.
-func PrintRegister(generalLedger []*ledger.Transaction, filterArr []string, columns int) {
+// registerPrinter returns a function printing a posting of a transaction as
+// a line of the register, keeping the running totals of the postings
+// printed.
+func registerPrinter(buf *bufio.Writer, columns int, average bool) func(trans *ledger.Transaction, accChange *ledger.Account) {
 	// Calculate widths for variable-length part of output
 	// 3 10-width columns (date, account-change, running-total)
 	// 4 spaces
@@ -242,156 +665,223 @@ func PrintRegister(generalLedger []*ledger.Transaction, filterArr []string, colu
 		fmt.Fprintf(os.Stderr, "warning: `columns` too small, setting to %d\n", columns)
 	}
 	remainingWidth := columns - (10 * 3) - (4 * 1)
+	if average {
+		// another 10-width column and space
+		remainingWidth -= 11
+	}
 	col1width := remainingWidth / 3
 	col2width := remainingWidth - col1width
 
-	colorNeg := fastcolor.FgRed
-	colorPayee := fastcolor.Bold
-	colorAccount := fastcolor.FgBlue
+	colorNeg := fastcolor.CurrentTheme.Negative
+	colorPayee := fastcolor.CurrentTheme.Payee
+	colorAccount := fastcolor.CurrentTheme.Account
 	colorReset := fastcolor.Reset
 
-	buf := bufio.NewWriter(os.Stdout)
 	// runningBalance keeps the total per currency
 	runningBalance := make(map[string]decimal.Decimal)
+	// postingCount keeps the number of postings per currency
+	postingCount := make(map[string]int64)
+
+	return func(trans *ledger.Transaction, accChange *ledger.Account) {
+		// Update running totals per currency
+		cur := accChange.Currency
+		if cur == "" {
+			cur = "_" // treat empty currency as its own bucket
+		}
+		runningBalance[cur] = runningBalance[cur].Add(accChange.Balance)
+		postingCount[cur]++
 
-	for _, trans := range generalLedger {
-		for _, accChange := range trans.AccountChanges {
-			inFilter := len(filterArr) == 0
-			for _, filter := range filterArr {
-				if strings.Contains(accChange.Name, filter) {
-					inFilter = true
-				}
+		// Current posting amount string
+		outBalanceString := formatAmount(accChange.Currency, accChange.Balance)
+
+		// Build primary running total string (first currency: the one for this posting)
+		type curTotal struct {
+			currency string
+			amount   decimal.Decimal
+		}
+		totals := make([]curTotal, 0, len(runningBalance))
+		for k, v := range runningBalance {
+			totals = append(totals, curTotal{currency: k, amount: v})
+		}
+		// Sort for deterministic output: primary currency first, then by name
+		slices.SortFunc(totals, func(a, b curTotal) int {
+			// primary currency first
+			if a.currency == cur && b.currency != cur {
+				return -1
 			}
-			if !inFilter {
-				continue
+			if b.currency == cur && a.currency != cur {
+				return 1
 			}
-
-			// Update running totals per currency
-			cur := accChange.Currency
-			if cur == "" {
-				cur = "_" // treat empty currency as its own bucket
+			// "_" (no currency) should sort last
+			if a.currency == "_" && b.currency != "_" {
+				return 1
 			}
-			runningBalance[cur] = runningBalance[cur].Add(accChange.Balance)
-
-			// Current posting amount string
-			outBalanceString := accChange.Balance.StringFixedBank(2)
-			if accChange.Currency != "" {
-				outBalanceString = accChange.Currency + " " + outBalanceString
+			if b.currency == "_" && a.currency != "_" {
+				return -1
 			}
+			return strings.Compare(a.currency, b.currency)
+		})
 
-			// Build primary running total string (first currency: the one for this posting)
-			type curTotal struct {
-				currency string
-				amount   decimal.Decimal
+		formatTotal := func(ct curTotal) string {
+			if ct.currency == "_" {
+				return formatAmount("", ct.amount)
 			}
-			totals := make([]curTotal, 0, len(runningBalance))
-			for k, v := range runningBalance {
-				totals = append(totals, curTotal{currency: k, amount: v})
+			return formatAmount(ct.currency, ct.amount)
+		}
+		writeAverage := func(ct curTotal) {
+			if !average {
+				return
 			}
-			// Sort for deterministic output: primary currency first, then by name
-			slices.SortFunc(totals, func(a, b curTotal) int {
-				// primary currency first
-				if a.currency == cur && b.currency != cur {
-					return -1
-				}
-				if b.currency == cur && a.currency != cur {
-					return 1
-				}
-				// "_" (no currency) should sort last
-				if a.currency == "_" && b.currency != "_" {
-					return 1
-				}
-				if b.currency == "_" && a.currency != "_" {
-					return -1
-				}
-				return strings.Compare(a.currency, b.currency)
-			})
+			avg := curTotal{currency: ct.currency, amount: ct.amount.Div(decimal.NewFromInt(postingCount[ct.currency]))}
+			avgColor := colorReset
+			if avg.amount.Sign() < 0 {
+				avgColor = colorNeg
+			}
+			buf.WriteString(" ")
+			avgColor.WriteStringFixed(buf, formatTotal(avg), 10, true)
+		}
+
+		primaryTotal := formatTotal(totals[0])
+
+		// Colors
+		balamtColor := colorReset
+		if accChange.Balance.Sign() < 0 {
+			balamtColor = colorNeg
+		}
+		runamtColor := colorReset
+		if totals[0].amount.Sign() < 0 {
+			runamtColor = colorNeg
+		}
 
-			formatTotal := func(ct curTotal) string {
-				amtStr := ct.amount.StringFixedBank(2)
-				if ct.currency == "_" {
-					return amtStr
+		// First line with primary total
+		buf.WriteString(trans.Date.Format(transactionDateFormat))
+		buf.WriteString(" ")
+		colorPayee.WriteStringFixed(buf, trans.Payee, col1width, false)
+		buf.WriteString(" ")
+		colorAccount.WriteStringFixed(buf, accChange.Name, col2width, false)
+		buf.WriteString(" ")
+		balamtColor.WriteStringFixed(buf, outBalanceString, 10, true)
+		buf.WriteString(" ")
+		runamtColor.WriteStringFixed(buf, primaryTotal, 10, true)
+		writeAverage(totals[0])
+		buf.WriteString(newLine)
+
+		// Additional lines for other currencies in running total
+		if len(totals) > 1 {
+			for _, ct := range totals[1:] {
+				otherTotal := formatTotal(ct)
+				otherColor := colorReset
+				if ct.amount.Sign() < 0 {
+					otherColor = colorNeg
 				}
-				return ct.currency + " " + amtStr
+
+				// Empty date/payee/account/amount columns, only total column
+				buf.WriteString(strings.Repeat(" ", 10)) // date
+				buf.WriteString(" ")
+				colorPayee.WriteStringFixed(buf, "", col1width, false)
+				buf.WriteString(" ")
+				colorAccount.WriteStringFixed(buf, "", col2width, false)
+				buf.WriteString(" ")
+				balamtColor.WriteStringFixed(buf, "", 10, true)
+				buf.WriteString(" ")
+				otherColor.WriteStringFixed(buf, otherTotal, 10, true)
+				writeAverage(ct)
+				buf.WriteString(newLine)
 			}
+		}
+	}
+}
 
-			primaryTotal := formatTotal(totals[0])
+// postingTotals sums the postings that match the given query per currency.
+// Postings with no currency are summed under the empty string.
+func postingTotals(generalLedger []*ledger.Transaction, query *ledger.Query) map[string]decimal.Decimal {
+	totals := make(map[string]decimal.Decimal)
+	for p := range ledger.Postings(generalLedger) {
+		if query.Match(p.Transaction, p.Posting) {
+			totals[p.Posting.Currency] = totals[p.Posting.Currency].Add(p.Posting.Balance)
+		}
+	}
+	return totals
+}
 
-			// Colors
-			balamtColor := colorReset
-			if accChange.Balance.Sign() < 0 {
-				balamtColor = colorNeg
-			}
-			runamtColor := colorReset
-			if totals[0].amount.Sign() < 0 {
-				runamtColor = colorNeg
-			}
+// sortedCurrencies returns the currencies of totals sorted by name, with the
+// empty (no currency) bucket last.
+func sortedCurrencies(totals map[string]decimal.Decimal) []string {
+	currencies := make([]string, 0, len(totals))
+	for cur := range totals {
+		currencies = append(currencies, cur)
+	}
+	slices.SortFunc(currencies, func(a, b string) int {
+		if a == "" && b != "" {
+			return 1
+		}
+		if b == "" && a != "" {
+			return -1
+		}
+		return strings.Compare(a, b)
+	})
+	return currencies
+}
 
-			// First line with primary total
-			buf.WriteString(trans.Date.Format(transactionDateFormat))
-			buf.WriteString(" ")
-			colorPayee.WriteStringFixed(buf, trans.Payee, col1width, false)
-			buf.WriteString(" ")
-			colorAccount.WriteStringFixed(buf, accChange.Name, col2width, false)
-			buf.WriteString(" ")
-			balamtColor.WriteStringFixed(buf, outBalanceString, 10, true)
-			buf.WriteString(" ")
-			runamtColor.WriteStringFixed(buf, primaryTotal, 10, true)
-			buf.WriteString(newLine)
+// PrintRegisterTotal prints the total, per currency, of the postings that match
+// the given query. The label is shown in the payee column and the total in
+// the running-total column, so it lines up under PrintRegister output.
+func PrintRegisterTotal(w io.Writer, generalLedger []*ledger.Transaction, query *ledger.Query, label string, columns int) {
+	if columns < 35 {
+		columns = 35
+	}
+	remainingWidth := columns - (10 * 3) - (4 * 1)
+	col1width := remainingWidth / 3
+	col2width := remainingWidth - col1width
 
-			// Additional lines for other currencies in running total
-			if len(totals) > 1 {
-				for _, ct := range totals[1:] {
-					otherTotal := formatTotal(ct)
-					otherColor := colorReset
-					if ct.amount.Sign() < 0 {
-						otherColor = colorNeg
-					}
-
-					// Empty date/payee/account/amount columns, only total column
-					buf.WriteString(strings.Repeat(" ", 10)) // date
-					buf.WriteString(" ")
-					colorPayee.WriteStringFixed(buf, "", col1width, false)
-					buf.WriteString(" ")
-					colorAccount.WriteStringFixed(buf, "", col2width, false)
-					buf.WriteString(" ")
-					balamtColor.WriteStringFixed(buf, "", 10, true)
-					buf.WriteString(" ")
-					otherColor.WriteStringFixed(buf, otherTotal, 10, true)
-					buf.WriteString(newLine)
-				}
-			}
+	colorNeg := fastcolor.CurrentTheme.Negative
+	colorPayee := fastcolor.CurrentTheme.Payee
+	colorReset := fastcolor.Reset
+
+	buf := bufio.NewWriter(w)
+	totals := postingTotals(generalLedger, query)
+	currencies := sortedCurrencies(totals)
+	if len(currencies) == 0 {
+		currencies = []string{""}
+	}
+	for cIdx, cur := range currencies {
+		outTotalString := formatAmount(cur, totals[cur])
+		totColor := colorReset
+		if totals[cur].Sign() < 0 {
+			totColor = colorNeg
 		}
+		if cIdx > 0 {
+			label = ""
+		}
+
+		buf.WriteString(strings.Repeat(" ", 10))
+		buf.WriteString(" ")
+		colorPayee.WriteStringFixed(buf, label, col1width, false)
+		buf.WriteString(" ")
+		colorReset.WriteStringFixed(buf, "", col2width, false)
+		buf.WriteString(" ")
+		colorReset.WriteStringFixed(buf, "", 10, true)
+		buf.WriteString(" ")
+		totColor.WriteStringFixed(buf, outTotalString, 10, true)
+		buf.WriteString(newLine)
 	}
 	buf.Flush()
 }
 
-// PrintCSV prints each transaction that matches the given filters in CSV format
-func PrintCSV(generalLedger []*ledger.Transaction, filterArr []string) {
-	csvWriter := csv.NewWriter(os.Stdout)
+// PrintCSV prints each posting that matches the given query in CSV format
+func PrintCSV(w io.Writer, generalLedger []*ledger.Transaction, query *ledger.Query) {
+	csvWriter := csv.NewWriter(w)
 	csvWriter.Comma, _ = utf8.DecodeRuneInString(fieldDelimiter)
 
 	runningBalance := decimal.Zero
 	for _, trans := range generalLedger {
 		for _, accChange := range trans.AccountChanges {
-			inFilter := len(filterArr) == 0
-			for _, filter := range filterArr {
-				if strings.Contains(accChange.Name, filter) {
-					inFilter = true
-				}
-			}
-			if inFilter {
+			if query.Match(trans, &accChange) {
 				runningBalance = runningBalance.Add(accChange.Balance)
-				outBalanceString := accChange.Balance.StringFixedBank(2)
 				record := []string{trans.Date.Format(transactionDateFormat),
 					trans.Payee,
 					accChange.Name,
-					func() string {
-						if accChange.Currency != "" {
-							return accChange.Currency + " " + outBalanceString
-						}
-						return outBalanceString
-					}(),
+					csvAmount(accChange.Currency, accChange.Balance),
 				}
 				if err := csvWriter.Write(record); err != nil {
 					fmt.Fprintf(os.Stderr, "error writing record to CSV: %s", err)
@@ -408,3 +898,36 @@ func PrintCSV(generalLedger []*ledger.Transaction, filterArr []string) {
 		return
 	}
 }
+
+// csvAmount returns the amount of the commodity for CSV records: the name and
+// the number, with the decimal places of the commodity in reports.
+func csvAmount(name string, amount decimal.Decimal) string {
+	s := amount.StringFixedBank(commodityFormats.Lookup(name).Places)
+	if name != "" {
+		s = name + " " + s
+	}
+	return s
+}
+
+// PrintCSVTotal prints one record per currency holding the total of the
+// postings that match the given query. The record is dated with date and
+// uses label in place of the payee.
+func PrintCSVTotal(w io.Writer, generalLedger []*ledger.Transaction, query *ledger.Query, label string, date time.Time) {
+	csvWriter := csv.NewWriter(w)
+	csvWriter.Comma, _ = utf8.DecodeRuneInString(fieldDelimiter)
+
+	totals := postingTotals(generalLedger, query)
+	for _, cur := range sortedCurrencies(totals) {
+		record := []string{date.Format(transactionDateFormat), label, "", csvAmount(cur, totals[cur])}
+		if err := csvWriter.Write(record); err != nil {
+			fmt.Fprintf(os.Stderr, "error writing record to CSV: %s", err)
+			return
+		}
+	}
+
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		fmt.Fprintf(os.Stderr, "error flushing CSV buffer: %s", err)
+		return
+	}
+}
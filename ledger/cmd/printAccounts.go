@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"log"
 	"strings"
 	"time"
 
@@ -20,11 +19,11 @@ var accountsCmd = &cobra.Command{
 	Run: func(_ *cobra.Command, args []string) {
 		generalLedger, err := cliTransactions()
 		if err != nil {
-			log.Fatalln(err)
+			fatalln(err)
 		}
 
 		if accountMatchDepth && len(args) != 1 {
-			log.Fatalln("account depth matches with one filter")
+			fatalln("account depth matches with one filter")
 		}
 
 		var filterDepth int
@@ -50,7 +49,7 @@ var accountsCmd = &cobra.Command{
 				match = false
 			}
 			if match {
-				fmt.Println(acc.Name)
+				fmt.Fprintln(cliOutput, acc.Name)
 			}
 		}
 	},
@@ -58,6 +57,7 @@ var accountsCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(accountsCmd)
+	watchCommand(accountsCmd)
 
 	var startDate, endDate time.Time
 	startDate = time.Date(1970, 1, 1, 0, 0, 0, 0, time.Local)
@@ -2,49 +2,140 @@ package cmd
 
 import (
 	"fmt"
-	"log"
 	"strings"
 	"time"
 
 	"github.com/howeyc/ledger"
+	date "github.com/joyt/godate"
 	"github.com/spf13/cobra"
 )
 
+var balanceBudget bool
+
 // balanceCmd represents the balance command
 var balanceCmd = &cobra.Command{
 	Aliases: []string{"bal"},
-	Use:     "balance [account-substring-filter]...",
+	Use:     "balance [query]...",
 	Short:   "Print account balances",
-	Run: func(_ *cobra.Command, args []string) {
+	Run: func(cmd *cobra.Command, args []string) {
 		generalLedger, err := cliTransactions()
 		if err != nil {
-			log.Fatalln(err)
+			fatalln(err)
+		}
+		if balanceSortBy != "name" && balanceSortBy != "amount" {
+			fatalln("unknown sort key:", balanceSortBy)
+		}
+		if reportFormat != "" && period != "" {
+			fatalln("--format can not be used with --period")
+		}
+		if reportFormat != "" && balanceBudget {
+			fatalln("--format can not be used with --budget")
+		}
+		var periodic []*ledger.PeriodicTransaction
+		if balanceBudget {
+			if budgetFilePath == "" {
+				budgetFilePath = ledgerFilePath
+			}
+			if periodic, err = ledger.ParsePeriodicTransactions(budgetFilePath); err != nil {
+				fatalln(err)
+			}
+		}
+		query := cliQuery(args)
+		generalLedger = query.Filter(generalLedger)
+		if invertAmounts {
+			generalLedger = invertTransactions(generalLedger)
 		}
-		if period == "" {
-			PrintBalances(ledger.GetBalances(generalLedger, args), showEmptyAccounts, transactionDepth, columnWidth)
-		} else {
+		if marketValue || exchangeCurrency != "" {
+			db, perr := cliPriceDB()
+			if perr != nil {
+				fatalln(perr)
+			}
+			if exchangeCurrency != "" {
+				generalLedger = exchangeTransactions(generalLedger, db, exchangeCurrency, cliValueDate())
+			} else {
+				generalLedger = valueTransactions(generalLedger, db, cliValueDate())
+			}
+		}
+		switch {
+		case balanceBudget && period == "":
+			start, end, rerr := budgetDateRange(cmd, generalLedger)
+			if rerr != nil {
+				fatalln(rerr)
+			}
+			budget := &ledger.Budget{Periodic: periodic, Query: query}
+			PrintBudgetRemaining(cliOutput, budget.EvaluateRange(generalLedger, start, end), columnWidth)
+		case reportFormat != "":
+			tmpl, terr := parseReportFormat(reportFormat)
+			if terr != nil {
+				fatalln(terr)
+			}
+			balances := sortBalances(ledger.GetBalanceTree(generalLedger, nil), balanceSortBy, false)
+			if ferr := FormatBalances(cliOutput, balances, showEmptyAccounts, transactionDepth, tmpl); ferr != nil {
+				fatalln(ferr)
+			}
+		case period == "":
+			balances := sortBalances(ledger.GetBalanceTree(generalLedger, nil), balanceSortBy, balanceTree)
+			PrintBalances(cliOutput, balances, showEmptyAccounts, transactionDepth, columnWidth, balanceTree)
+		default:
 			lperiod := ledger.Period(strings.Title(period))
 			rtrans := ledger.TransactionsByPeriod(generalLedger, lperiod)
 			for rIdx, rt := range rtrans {
-				balances := ledger.GetBalances(rt.Transactions, args)
-				if len(balances) < 1 {
-					continue
+				var rows []ledger.BudgetLine
+				var balances []*ledger.Account
+				if balanceBudget {
+					budget := &ledger.Budget{Periodic: periodic, Query: query}
+					rows = budget.EvaluateRange(rt.Transactions, rt.Start, rt.End.AddDate(0, 0, 1))
+					if len(rows) < 1 {
+						continue
+					}
+				} else {
+					balances = sortBalances(ledger.GetBalanceTree(rt.Transactions, nil), balanceSortBy, balanceTree)
+					if len(balances) < 1 {
+						continue
+					}
 				}
 
 				if rIdx > 0 {
-					fmt.Println("")
-					fmt.Println(strings.Repeat("=", columnWidth))
+					fmt.Fprintln(cliOutput, "")
+					fmt.Fprintln(cliOutput, strings.Repeat("=", columnWidth))
+				}
+				fmt.Fprintln(cliOutput, rt.Start.Format(transactionDateFormat), "-", rt.End.Format(transactionDateFormat))
+				fmt.Fprintln(cliOutput, strings.Repeat("=", columnWidth))
+				if balanceBudget {
+					PrintBudgetRemaining(cliOutput, rows, columnWidth)
+				} else {
+					PrintBalances(cliOutput, balances, showEmptyAccounts, transactionDepth, columnWidth, balanceTree)
 				}
-				fmt.Println(rt.Start.Format(transactionDateFormat), "-", rt.End.Format(transactionDateFormat))
-				fmt.Println(strings.Repeat("=", columnWidth))
-				PrintBalances(balances, showEmptyAccounts, transactionDepth, columnWidth)
 			}
 		}
 	},
 }
 
+// budgetDateRange returns the start (inclusive) and end (exclusive) of the
+// budget compared by balance --budget: the begin and end dates when given,
+// otherwise the dates of the first and last transaction.
+func budgetDateRange(cmd *cobra.Command, generalLedger []*ledger.Transaction) (start, end time.Time, err error) {
+	if len(generalLedger) > 0 {
+		start = generalLedger[0].Date
+		end = generalLedger[len(generalLedger)-1].Date.AddDate(0, 0, 1)
+	}
+	if cmd.Flag("begin-date").Changed {
+		if start, err = date.Parse(startString); err != nil {
+			return start, end, err
+		}
+	}
+	if cmd.Flag("end-date").Changed {
+		if end, err = date.Parse(endString); err != nil {
+			return start, end, err
+		}
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end, nil
+}
+
 func init() {
 	rootCmd.AddCommand(balanceCmd)
+	watchCommand(balanceCmd)
 
 	var startDate, endDate time.Time
 	startDate = time.Date(1970, 1, 1, 0, 0, 0, 0, time.Local)
@@ -52,10 +143,23 @@ func init() {
 	balanceCmd.Flags().StringVarP(&startString, "begin-date", "b", startDate.Format(transactionDateFormat), "Begin date of transaction processing.")
 	balanceCmd.Flags().StringVarP(&endString, "end-date", "e", endDate.Format(transactionDateFormat), "End date of transaction processing.")
 	balanceCmd.Flags().StringVar(&payeeFilter, "payee", "", "Filter output to payees that contain this string.")
+	completeLedgerCommand(balanceCmd)
 	balanceCmd.Flags().IntVar(&columnWidth, "columns", 80, "Set a column width for output.")
 	balanceCmd.Flags().BoolVar(&columnWide, "wide", false, "Wide output (use terminal width).")
 
 	balanceCmd.Flags().StringVar(&period, "period", "", "Split output into periods (Monthly,Quarterly,SemiYearly,Yearly).")
 	balanceCmd.Flags().BoolVar(&showEmptyAccounts, "empty", false, "Show empty (zero balance) accounts.")
 	balanceCmd.Flags().IntVar(&transactionDepth, "depth", -1, "Depth of transaction output (balance).")
+	balanceCmd.Flags().BoolVar(&balanceTree, "tree", false, "Show accounts as an indented tree.")
+	balanceCmd.Flags().StringVar(&balanceSortBy, "sort", "name", "Sort accounts by name or amount (largest first).")
+	balanceCmd.Flags().StringVar(&reportFormat, "format", "", "Write each account with this Go template, such as '{{.Account}}\t{{.Amount}}'.")
+	balanceCmd.Flags().BoolVar(&balanceBudget, "budget", false, "Show budgeted, actual and remaining amounts of budgeted accounts.")
+	balanceCmd.Flags().StringVar(&budgetFilePath, "budget-file", "", "File with periodic transactions (default is the ledger file).")
+	balanceCmd.Flags().BoolVar(&invertAmounts, "invert", false, "Flip the sign of amounts.")
+	balanceCmd.Flags().StringVarP(&exchangeCurrency, "exchange", "X", "", "Convert amounts to this currency at market prices.")
+	balanceCmd.Flags().BoolVarP(&marketValue, "market", "V", false, "Show commodity amounts at their market value.")
+	balanceCmd.Flags().StringVar(&priceDBPath, "price-db", "", "File with market prices (default is the ledger file).")
+	balanceCmd.Flags().BoolVarP(&statusCleared, "cleared", "C", false, "Only include cleared postings.")
+	balanceCmd.Flags().BoolVarP(&statusPending, "pending", "P", false, "Only include pending postings.")
+	balanceCmd.Flags().BoolVarP(&statusUncleared, "uncleared", "U", false, "Only include uncleared postings.")
 }
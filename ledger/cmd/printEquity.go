@@ -1,83 +1,105 @@
 package cmd
 
 import (
-	"log"
-	"os"
+	"bufio"
 	"slices"
 	"strings"
 	"time"
 
 	"github.com/howeyc/ledger"
+	date "github.com/joyt/godate"
 	"github.com/shopspring/decimal"
 	"github.com/spf13/cobra"
 )
 
+var equityAccount string
+
 // equityCmd represents the equity command
 var equityCmd = &cobra.Command{
-	Use:   "equity [account-substring-filter]...",
+	Use:   "equity [query]...",
 	Short: "Print account equity as transaction",
-	Run: func(_ *cobra.Command, args []string) {
+	Run: func(cmd *cobra.Command, args []string) {
 		generalLedger, err := cliTransactions()
 		if err != nil {
-			log.Fatalln(err)
+			fatalln(err)
 		}
 
-		var trans ledger.Transaction
-		trans.Payee = "Opening Balances"
-		trans.Date = time.Now()
-		if len(generalLedger) > 0 {
-			trans.Date = generalLedger[len(generalLedger)-1].Date
+		trans := equityTransaction(generalLedger, cliQuery(args), equityAccount)
+		if cmd.Flags().Changed("end-date") {
+			trans.Date, _ = date.Parse(endString)
 		}
 
-		filterArr := args
-		balances := make(map[string]decimal.Decimal)
-		for _, trans := range generalLedger {
-			for _, accChange := range trans.AccountChanges {
-				inFilter := len(filterArr) == 0
-				for _, filter := range filterArr {
-					if strings.Contains(accChange.Name, filter) {
-						inFilter = true
-					}
-				}
-				if inFilter {
-					if decNum, ok := balances[accChange.Name]; !ok {
-						balances[accChange.Name] = accChange.Balance
-					} else {
-						balances[accChange.Name] = decNum.Add(accChange.Balance)
-					}
-				}
+		buf := bufio.NewWriter(cliOutput)
+		WriteTransaction(buf, trans, 80)
+		buf.Flush()
+	},
+}
+
+// equityTransaction builds a single transaction that opens every account
+// matching the query with its balance, per currency, at the end of
+// generalLedger. The balancing amount is placed in eqAccount.
+func equityTransaction(generalLedger []*ledger.Transaction, query *ledger.Query, eqAccount string) *ledger.Transaction {
+	var trans ledger.Transaction
+	trans.Payee = "Opening Balances"
+	trans.Date = time.Now()
+	if len(generalLedger) > 0 {
+		trans.Date = generalLedger[len(generalLedger)-1].Date
+	}
+
+	type accountKey struct {
+		name     string
+		currency string
+	}
+	balances := make(map[accountKey]decimal.Decimal)
+	for _, trans := range generalLedger {
+		for _, accChange := range trans.AccountChanges {
+			if query.Match(trans, &accChange) {
+				key := accountKey{accChange.Name, accChange.Currency}
+				balances[key] = balances[key].Add(accChange.Balance)
 			}
 		}
+	}
 
-		eqBal := decimal.Zero
-		for name, bal := range balances {
-			if !bal.IsZero() {
-				trans.AccountChanges = append(trans.AccountChanges, ledger.Account{
-					Name:    name,
-					Balance: bal,
-				})
-			}
-			eqBal = eqBal.Add(bal)
+	eqBal := make(map[string]decimal.Decimal)
+	for key, bal := range balances {
+		if !bal.IsZero() {
+			trans.AccountChanges = append(trans.AccountChanges, ledger.Account{
+				Name:     key.name,
+				Currency: key.currency,
+				Balance:  bal,
+			})
+		}
+		eqBal[key.currency] = eqBal[key.currency].Add(bal)
+	}
+	for _, currency := range sortedCurrencies(eqBal) {
+		if eqBal[currency].IsZero() {
+			continue
 		}
 		trans.AccountChanges = append(trans.AccountChanges, ledger.Account{
-			Name:    "Equity",
-			Balance: eqBal.Neg(),
+			Name:     eqAccount,
+			Currency: currency,
+			Balance:  eqBal[currency].Neg(),
 		})
+	}
 
-		slices.SortFunc(trans.AccountChanges, func(a, b ledger.Account) int {
-			return strings.Compare(a.Name, b.Name)
-		})
+	slices.SortFunc(trans.AccountChanges, func(a, b ledger.Account) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Currency, b.Currency)
+	})
 
-		WriteTransaction(os.Stdout, &trans, 80)
-	},
+	return &trans
 }
 
 func init() {
 	rootCmd.AddCommand(equityCmd)
+	watchCommand(equityCmd)
 
 	var startDate, endDate time.Time
 	startDate = time.Date(1970, 1, 1, 0, 0, 0, 0, time.Local)
 	endDate = time.Now().Add(1<<63 - 1)
 	equityCmd.Flags().StringVarP(&startString, "begin-date", "b", startDate.Format(transactionDateFormat), "Begin date of transaction processing.")
 	equityCmd.Flags().StringVarP(&endString, "end-date", "e", endDate.Format(transactionDateFormat), "End date of transaction processing.")
+	equityCmd.Flags().StringVar(&equityAccount, "account", "Equity:Opening Balances", "Account receiving the balancing amount.")
 }
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/shopspring/decimal"
+)
+
+func Test_equityTransaction(t *testing.T) {
+	generalLedger := []*ledger.Transaction{
+		{
+			Date: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+			AccountChanges: []ledger.Account{
+				{Name: "Assets:Checking", Balance: decimal.NewFromInt(100)},
+				{Name: "Income:Salary", Balance: decimal.NewFromInt(-100)},
+			},
+		},
+		{
+			Date: time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC),
+			AccountChanges: []ledger.Account{
+				{Name: "Assets:Wise", Currency: "EUR", Balance: decimal.NewFromInt(50)},
+				{Name: "Income:Salary", Currency: "EUR", Balance: decimal.NewFromInt(-50)},
+			},
+		},
+	}
+
+	trans := equityTransaction(generalLedger, mustParseQuery(t, "Assets"), "Equity:Opening Balances")
+	if !trans.Date.Equal(generalLedger[1].Date) {
+		t.Errorf("date = %v, want %v", trans.Date, generalLedger[1].Date)
+	}
+	if err := trans.IsBalanced(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []ledger.Account{
+		{Name: "Assets:Checking", Balance: decimal.NewFromInt(100)},
+		{Name: "Assets:Wise", Currency: "EUR", Balance: decimal.NewFromInt(50)},
+		{Name: "Equity:Opening Balances", Balance: decimal.NewFromInt(-100)},
+		{Name: "Equity:Opening Balances", Currency: "EUR", Balance: decimal.NewFromInt(-50)},
+	}
+	if len(trans.AccountChanges) != len(want) {
+		t.Fatalf("got %d postings, want %d", len(trans.AccountChanges), len(want))
+	}
+	for i, acc := range trans.AccountChanges {
+		if acc.Name != want[i].Name || acc.Currency != want[i].Currency || !acc.Balance.Equal(want[i].Balance) {
+			t.Errorf("posting %d = %s %s %s, want %s %s %s", i,
+				acc.Name, acc.Currency, acc.Balance, want[i].Name, want[i].Currency, want[i].Balance)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"fmt"
-	"log"
+	"maps"
+	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -10,19 +12,173 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var registerRelated bool
+var registerAverage bool
+var registerGroupBy string
+var registerStream bool
+
+// registerGroup holds copies of the transactions with postings in a group of
+// register --group-by, each holding only the postings of the group.
+type registerGroup struct {
+	Name         string
+	Transactions []*ledger.Transaction
+}
+
+// groupPostings groups the postings that match the query by payee, account
+// or tag, ordered by group name. A posting with several tags is in the group
+// of each tag, and postings without tags are in the "(untagged)" group.
+func groupPostings(generalLedger []*ledger.Transaction, query *ledger.Query, groupBy string) ([]registerGroup, error) {
+	var names func(trans *ledger.Transaction, acc *ledger.Account) []string
+	switch groupBy {
+	case "payee":
+		names = func(trans *ledger.Transaction, _ *ledger.Account) []string { return []string{trans.Payee} }
+	case "account":
+		names = func(_ *ledger.Transaction, acc *ledger.Account) []string { return []string{acc.Name} }
+	case "tag":
+		names = func(trans *ledger.Transaction, acc *ledger.Account) []string {
+			tags := slices.Sorted(maps.Keys(trans.PostingTags(acc)))
+			if len(tags) == 0 {
+				return []string{"(untagged)"}
+			}
+			return tags
+		}
+	default:
+		return nil, fmt.Errorf("unknown group: %s", groupBy)
+	}
+
+	groups := make(map[string][]*ledger.Transaction)
+	for _, trans := range generalLedger {
+		postings := make(map[string][]ledger.Account)
+		var order []string
+		for _, accChange := range trans.AccountChanges {
+			if !query.Match(trans, &accChange) {
+				continue
+			}
+			for _, name := range names(trans, &accChange) {
+				if _, ok := postings[name]; !ok {
+					order = append(order, name)
+				}
+				postings[name] = append(postings[name], accChange)
+			}
+		}
+		for _, name := range order {
+			t := *trans
+			t.AccountChanges = postings[name]
+			groups[name] = append(groups[name], &t)
+		}
+	}
+
+	result := make([]registerGroup, 0, len(groups))
+	for _, name := range slices.Sorted(maps.Keys(groups)) {
+		result = append(result, registerGroup{Name: name, Transactions: groups[name]})
+	}
+	return result, nil
+}
+
+// streamRegister prints the register of the ledger file as it is parsed,
+// in the order of the file, without keeping its transactions.
+func streamRegister(cmd *cobra.Command, args []string) error {
+	for _, name := range []string{"sort", "period", "group-by", "format", "head", "tail", "related", "invert", "market", "exchange"} {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("--stream can not be used with --%s", name)
+		}
+	}
+	setWideColumns()
+	start, end, err := cliDateRange()
+	if err != nil {
+		return err
+	}
+
+	var transactions chan *ledger.Transaction
+	var errs chan error
+	if ledgerFilePath == "-" {
+		transactions, errs = ledger.ParseLedgerAsync(os.Stdin)
+	} else {
+		transactions, errs = ledger.ParseLedgerFileAsync(ledgerFilePath)
+	}
+	return PrintRegisterAsync(cliOutput, transactions, errs, cliFilters(start, end), cliQuery(args), columnWidth, registerAverage)
+}
+
 // registerCmd represents the register command
 var registerCmd = &cobra.Command{
 	Aliases: []string{"reg"},
-	Use:     "register [account-substring-filter]...",
+	Use:     "register [query]...",
 	Short:   "Print register of transactions",
-	Run: func(_ *cobra.Command, args []string) {
+	Run: func(cmd *cobra.Command, args []string) {
+		if registerStream {
+			if err := streamRegister(cmd, args); err != nil {
+				fatalln(err)
+			}
+			return
+		}
 		generalLedger, err := cliTransactions()
 		if err != nil {
-			log.Fatalln(err)
+			fatalln(err)
+		}
+		if reportFormat != "" && period != "" {
+			fatalln("--format can not be used with --period")
+		}
+		if registerGroupBy != "" && (reportFormat != "" || period != "") {
+			fatalln("--group-by can not be used with --format or --period")
+		}
+		if headCount < 0 || tailCount < 0 {
+			fatalln("--head and --tail must not be negative")
 		}
-		if period == "" {
-			PrintRegister(generalLedger, args, columnWidth)
-		} else {
+		if err := sortTransactions(generalLedger, transactionSortBy); err != nil {
+			fatalln(err)
+		}
+		query := cliQuery(args)
+		if registerRelated {
+			generalLedger, query = query.Related(generalLedger), nil
+		}
+		if invertAmounts {
+			generalLedger, query = invertTransactions(query.Filter(generalLedger)), nil
+		}
+		if marketValue || exchangeCurrency != "" {
+			db, perr := cliPriceDB()
+			if perr != nil {
+				fatalln(perr)
+			}
+			generalLedger = query.Filter(generalLedger)
+			if exchangeCurrency != "" {
+				// each transaction at the rate of its date
+				generalLedger = exchangeTransactions(generalLedger, db, exchangeCurrency, time.Time{})
+			} else {
+				generalLedger = valueTransactions(generalLedger, db, cliValueDate())
+			}
+			query = nil
+		}
+		if headCount > 0 || tailCount > 0 {
+			generalLedger, query = limitTransactions(query.Filter(generalLedger), headCount, tailCount), nil
+		}
+		switch {
+		case registerGroupBy != "":
+			groups, gerr := groupPostings(generalLedger, query, registerGroupBy)
+			if gerr != nil {
+				fatalln(gerr)
+			}
+			for gIdx, group := range groups {
+				if gIdx > 0 {
+					fmt.Fprintln(cliOutput, strings.Repeat("=", columnWidth))
+				}
+				fmt.Fprintln(cliOutput, group.Name)
+				fmt.Fprintln(cliOutput, strings.Repeat("=", columnWidth))
+				PrintRegister(cliOutput, group.Transactions, nil, columnWidth, registerAverage)
+				PrintRegisterTotal(cliOutput, group.Transactions, nil, "Subtotal", columnWidth)
+			}
+			fmt.Fprintln(cliOutput, strings.Repeat("=", columnWidth))
+			PrintRegisterTotal(cliOutput, generalLedger, query, "Total", columnWidth)
+		case reportFormat != "":
+			tmpl, terr := parseReportFormat(reportFormat)
+			if terr != nil {
+				fatalln(terr)
+			}
+			if ferr := FormatRegister(cliOutput, generalLedger, query, tmpl); ferr != nil {
+				fatalln(ferr)
+			}
+		case period == "":
+			PrintRegister(cliOutput, generalLedger, query, columnWidth, registerAverage)
+		default:
 			lperiod := ledger.Period(strings.Title(period))
 			rtrans := ledger.TransactionsByPeriod(generalLedger, lperiod)
 			for rIdx, rt := range rtrans {
@@ -31,18 +187,22 @@ var registerCmd = &cobra.Command{
 				}
 
 				if rIdx > 0 {
-					fmt.Println(strings.Repeat("=", columnWidth))
+					fmt.Fprintln(cliOutput, strings.Repeat("=", columnWidth))
 				}
-				fmt.Println(rt.Start.Format(transactionDateFormat), "-", rt.End.Format(transactionDateFormat))
-				fmt.Println(strings.Repeat("=", columnWidth))
-				PrintRegister(rt.Transactions, args, columnWidth)
+				fmt.Fprintln(cliOutput, rt.Start.Format(transactionDateFormat), "-", rt.End.Format(transactionDateFormat))
+				fmt.Fprintln(cliOutput, strings.Repeat("=", columnWidth))
+				PrintRegister(cliOutput, rt.Transactions, query, columnWidth, registerAverage)
+				PrintRegisterTotal(cliOutput, rt.Transactions, query, "Subtotal", columnWidth)
 			}
+			fmt.Fprintln(cliOutput, strings.Repeat("=", columnWidth))
+			PrintRegisterTotal(cliOutput, generalLedger, query, "Total", columnWidth)
 		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(registerCmd)
+	watchCommand(registerCmd)
 
 	var startDate, endDate time.Time
 	startDate = time.Date(1970, 1, 1, 0, 0, 0, 0, time.Local)
@@ -50,8 +210,24 @@ func init() {
 	registerCmd.Flags().StringVarP(&startString, "begin-date", "b", startDate.Format(transactionDateFormat), "Begin date of transaction processing.")
 	registerCmd.Flags().StringVarP(&endString, "end-date", "e", endDate.Format(transactionDateFormat), "End date of transaction processing.")
 	registerCmd.Flags().StringVar(&payeeFilter, "payee", "", "Filter output to payees that contain this string.")
+	completeLedgerCommand(registerCmd)
 	registerCmd.Flags().IntVar(&columnWidth, "columns", 80, "Set a column width for output.")
 	registerCmd.Flags().BoolVar(&columnWide, "wide", false, "Wide output (use terminal width).")
 
-	registerCmd.Flags().StringVar(&period, "period", "", "Split output into periods (Monthly,Quarterly,SemiYearly,Yearly).")
+	registerCmd.Flags().BoolVarP(&statusCleared, "cleared", "C", false, "Only include cleared postings.")
+	registerCmd.Flags().BoolVarP(&statusPending, "pending", "P", false, "Only include pending postings.")
+	registerCmd.Flags().BoolVarP(&statusUncleared, "uncleared", "U", false, "Only include uncleared postings.")
+	registerCmd.Flags().BoolVar(&registerAverage, "average", false, "Show the running average amount after the running total.")
+	registerCmd.Flags().BoolVar(&invertAmounts, "invert", false, "Flip the sign of amounts.")
+	registerCmd.Flags().StringVarP(&exchangeCurrency, "exchange", "X", "", "Convert amounts to this currency at market prices on the transaction date.")
+	registerCmd.Flags().BoolVarP(&marketValue, "market", "V", false, "Show commodity amounts at their market value.")
+	registerCmd.Flags().StringVar(&priceDBPath, "price-db", "", "File with market prices (default is the ledger file).")
+	registerCmd.Flags().BoolVar(&registerRelated, "related", false, "Show the other postings of the transactions matching the query.")
+	registerCmd.Flags().StringVar(&period, "period", "", "Split output into periods (Weekly,Monthly,Quarterly,SemiYearly,Yearly) with subtotals.")
+	registerCmd.Flags().StringVar(&transactionSortBy, "sort", "date", "Sort transactions by date, payee and/or amount, comma separated, descending with a - prefix.")
+	registerCmd.Flags().IntVar(&headCount, "head", 0, "Only show the first N transactions.")
+	registerCmd.Flags().IntVar(&tailCount, "tail", 0, "Only show the last N transactions.")
+	registerCmd.Flags().StringVar(&registerGroupBy, "group-by", "", "Group postings by payee, account or tag with subtotals.")
+	registerCmd.Flags().BoolVar(&registerStream, "stream", false, "Print postings as the ledger is parsed, in file order, keeping only running totals in memory.")
+	registerCmd.Flags().StringVar(&reportFormat, "format", "", "Write each posting with this Go template, such as '{{.Date}} {{.Payee}} {{.Amount}}'.")
 }
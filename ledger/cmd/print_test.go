@@ -0,0 +1,415 @@
+package cmd
+
+import (
+	"bytes"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/howeyc/ledger/ledger/internal/fastcolor"
+	"github.com/shopspring/decimal"
+)
+
+func Test_sortBalances(t *testing.T) {
+	posting := func(name string, amount int64) ledger.Account {
+		return ledger.Account{Name: name, Balance: decimal.NewFromInt(amount)}
+	}
+	root := ledger.GetBalanceTree([]*ledger.Transaction{{AccountChanges: []ledger.Account{
+		posting("Expenses:Books", 20),
+		posting("Expenses:Food:Groceries", 30),
+		posting("Expenses:Food:TakeOut", 80),
+		posting("Income", -200),
+		posting("Assets", 70),
+	}}}, []string{"Expenses", "Income"})
+
+	tests := []struct {
+		name   string
+		sortBy string
+		tree   bool
+		want   []string
+	}{
+		{
+			"name",
+			"name",
+			false,
+			[]string{"Expenses", "Expenses:Books", "Expenses:Food", "Expenses:Food:Groceries", "Expenses:Food:TakeOut", "Income"},
+		},
+		{
+			"amount flat",
+			"amount",
+			false,
+			[]string{"Income", "Expenses", "Expenses:Food", "Expenses:Food:TakeOut", "Expenses:Food:Groceries", "Expenses:Books"},
+		},
+		{
+			"amount tree",
+			"amount",
+			true,
+			[]string{"Income", "Expenses", "Expenses:Food", "Expenses:Food:TakeOut", "Expenses:Food:Groceries", "Expenses:Books"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, acc := range sortBalances(root, tt.sortBy, tt.tree) {
+				got = append(got, acc.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("sortBalances() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_sortTransactions(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.Local) }
+	trans := func(d int, payee string, amount int64) *ledger.Transaction {
+		return &ledger.Transaction{Date: day(d), Payee: payee, AccountChanges: []ledger.Account{
+			{Name: "Expenses", Balance: decimal.NewFromInt(amount)},
+			{Name: "Assets", Balance: decimal.NewFromInt(-amount)},
+		}}
+	}
+	payees := func(generalLedger []*ledger.Transaction) (s []string) {
+		for _, t := range generalLedger {
+			s = append(s, t.Payee)
+		}
+		return
+	}
+
+	tests := []struct {
+		keys string
+		want []string
+	}{
+		{"date", []string{"b", "a", "c", "d"}},
+		{"-date", []string{"d", "c", "b", "a"}},
+		{"payee", []string{"a", "b", "c", "d"}},
+		{"-amount,payee", []string{"c", "a", "d", "b"}},
+		{"amount, -date", []string{"b", "d", "a", "c"}},
+	}
+	for _, tc := range tests {
+		generalLedger := []*ledger.Transaction{trans(1, "b", 5), trans(1, "a", 10), trans(2, "c", 20), trans(3, "d", 10)}
+		if err := sortTransactions(generalLedger, tc.keys); err != nil {
+			t.Fatal(err)
+		}
+		if got := payees(generalLedger); !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.keys, got, tc.want)
+		}
+	}
+	if err := sortTransactions(nil, "date,size"); err == nil {
+		t.Error("expected error for unknown key")
+	}
+}
+
+func Test_limitTransactions(t *testing.T) {
+	var generalLedger []*ledger.Transaction
+	for _, payee := range []string{"a", "b", "c", "d", "e"} {
+		generalLedger = append(generalLedger, &ledger.Transaction{Payee: payee})
+	}
+	tests := []struct {
+		head, tail int
+		want       string
+	}{
+		{0, 0, "abcde"},
+		{2, 0, "ab"},
+		{0, 2, "de"},
+		{4, 2, "cd"},
+		{9, 9, "abcde"},
+	}
+	for _, tc := range tests {
+		var got string
+		for _, trans := range limitTransactions(generalLedger, tc.head, tc.tail) {
+			got += trans.Payee
+		}
+		if got != tc.want {
+			t.Errorf("head %d tail %d: got %q, want %q", tc.head, tc.tail, got, tc.want)
+		}
+	}
+}
+
+func Test_cliQuery(t *testing.T) {
+	defer func() { statusCleared, statusPending, statusUncleared = false, false, false }()
+	trans := []*ledger.Transaction{
+		{Status: ledger.StatusCleared, AccountChanges: []ledger.Account{{Name: "Assets:Cleared"}}},
+		{Status: ledger.StatusPending, AccountChanges: []ledger.Account{{Name: "Assets:Pending"}}},
+		{AccountChanges: []ledger.Account{
+			{Name: "Assets:Uncleared"},
+			{Name: "Expenses:Cleared", Status: ledger.StatusCleared},
+		}},
+	}
+
+	tests := []struct {
+		args                        []string
+		cleared, pending, uncleared bool
+		want                        []string
+	}{
+		{nil, false, false, false, []string{"Assets:Cleared", "Assets:Pending", "Assets:Uncleared", "Expenses:Cleared"}},
+		{nil, true, false, false, []string{"Assets:Cleared", "Expenses:Cleared"}},
+		{nil, false, true, false, []string{"Assets:Pending"}},
+		{nil, false, false, true, []string{"Assets:Uncleared"}},
+		{nil, true, true, false, []string{"Assets:Cleared", "Assets:Pending", "Expenses:Cleared"}},
+		{[]string{"Assets"}, true, false, false, []string{"Assets:Cleared"}},
+		{[]string{"Assets", "or", "Expenses"}, false, false, true, []string{"Assets:Uncleared"}},
+	}
+	for _, tt := range tests {
+		statusCleared, statusPending, statusUncleared = tt.cleared, tt.pending, tt.uncleared
+		query := cliQuery(tt.args)
+		var got []string
+		for p := range ledger.Postings(trans) {
+			if query.Match(p.Transaction, p.Posting) {
+				got = append(got, p.Posting.Name)
+			}
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%v cleared=%v pending=%v uncleared=%v: got %v, want %v",
+				tt.args, tt.cleared, tt.pending, tt.uncleared, got, tt.want)
+		}
+	}
+}
+
+func Test_queryQuote(t *testing.T) {
+	for _, payee := range []string{"Grocer", "Dining Out", `The "Best" Cafe`, "Joe's Diner", `Joe's "Best" Diner`, `"'`} {
+		query, err := ledger.ParseQuery("payee:" + queryQuote(payee))
+		if err != nil {
+			t.Errorf("%s: %v", payee, err)
+			continue
+		}
+		if !query.MatchTransaction(&ledger.Transaction{Payee: payee, AccountChanges: []ledger.Account{{Name: "Expenses"}}}) ||
+			query.MatchTransaction(&ledger.Transaction{Payee: "Other", AccountChanges: []ledger.Account{{Name: "Expenses"}}}) {
+			t.Errorf("%s: quoted as %s, got query %s", payee, queryQuote(payee), query)
+		}
+	}
+}
+
+func Test_PrintCSV(t *testing.T) {
+	defer func(formats ledger.CommodityFormats) { commodityFormats = formats }(commodityFormats)
+	generalLedger := []*ledger.Transaction{
+		{Date: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), Payee: "Exchange", AccountChanges: []ledger.Account{
+			{Name: "Assets:Crypto", Currency: "BTC", Balance: decimal.RequireFromString("0.12345678")},
+			{Name: "Assets:Crypto", Currency: "BTC", Balance: decimal.RequireFromString("0.00000001")},
+			{Name: "Equity", Currency: "BTC", Balance: decimal.RequireFromString("-0.12345679")},
+		}},
+	}
+	setCommodityFormats(ledger.InferCommodityFormats(generalLedger))
+
+	var out bytes.Buffer
+	PrintCSV(&out, generalLedger, mustParseQuery(t, "Assets"))
+	PrintCSVTotal(&out, generalLedger, mustParseQuery(t, "Assets"), "Total", generalLedger[0].Date)
+	want := "2024/01/05,Exchange,Assets:Crypto,BTC 0.12345678\n" +
+		"2024/01/05,Exchange,Assets:Crypto,BTC 0.00000001\n" +
+		"2024/01/05,Total,,BTC 0.12345679\n"
+	if out.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func Test_invertTransactions(t *testing.T) {
+	converted := decimal.NewFromInt(-90)
+	trans := &ledger.Transaction{
+		Payee: "Salary",
+		AccountChanges: []ledger.Account{
+			{Name: "Income:Salary", Balance: decimal.NewFromInt(-100), Currency: "EUR", Converted: &converted},
+			{Name: "Assets:Checking", Balance: decimal.NewFromInt(90)},
+		},
+	}
+	inverted := invertTransactions([]*ledger.Transaction{trans})
+	if len(inverted) != 1 || inverted[0].Payee != "Salary" {
+		t.Fatalf("unexpected result: %v", inverted)
+	}
+	if got := inverted[0].AccountChanges[0]; !got.Balance.Equal(decimal.NewFromInt(100)) || !got.Converted.Equal(decimal.NewFromInt(90)) {
+		t.Errorf("unexpected inverted posting: %v", got)
+	}
+	if !inverted[0].AccountChanges[1].Balance.Equal(decimal.NewFromInt(-90)) {
+		t.Errorf("unexpected inverted posting: %v", inverted[0].AccountChanges[1])
+	}
+	if !trans.AccountChanges[0].Balance.Equal(decimal.NewFromInt(-100)) || !converted.Equal(decimal.NewFromInt(-90)) {
+		t.Error("original transaction modified")
+	}
+}
+
+func mustParseQuery(t *testing.T, s string) *ledger.Query {
+	t.Helper()
+	query, err := ledger.ParseQuery(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return query
+}
+
+func Test_valueTransactions(t *testing.T) {
+	trans := &ledger.Transaction{
+		Payee: "Buy",
+		AccountChanges: []ledger.Account{
+			{Name: "Assets:Broker", Balance: decimal.NewFromInt(10), Currency: "AAPL"},
+			{Name: "Assets:Checking", Balance: decimal.NewFromInt(-1500), Currency: "USD"},
+		},
+	}
+	db := ledger.NewPriceDB([]*ledger.Price{
+		{Commodity: "AAPL", Currency: "USD", Price: decimal.NewFromInt(180)},
+	})
+	valued := valueTransactions([]*ledger.Transaction{trans}, db, time.Now())
+	if got := valued[0].AccountChanges[0]; got.Currency != "USD" || !got.Balance.Equal(decimal.NewFromInt(1800)) {
+		t.Errorf("unexpected valued posting: %v", got)
+	}
+	if got := valued[0].AccountChanges[1]; got.Currency != "USD" || !got.Balance.Equal(decimal.NewFromInt(-1500)) {
+		t.Errorf("unexpected valued posting: %v", got)
+	}
+	if trans.AccountChanges[0].Currency != "AAPL" {
+		t.Error("original transaction modified")
+	}
+}
+
+func Test_exchangeTransactions(t *testing.T) {
+	day := func(month int) time.Time {
+		return time.Date(2024, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	}
+	prices := []*ledger.Price{
+		{Date: day(1), Commodity: "EUR", Currency: "USD", Price: decimal.NewFromInt(2)},
+		{Date: day(3), Commodity: "EUR", Currency: "USD", Price: decimal.NewFromInt(4)},
+	}
+	generalLedger := []*ledger.Transaction{
+		{Date: day(2), AccountChanges: []ledger.Account{
+			{Name: "Assets:Checking", Balance: decimal.NewFromInt(10), Currency: "EUR"},
+			{Name: "Assets:Savings", Balance: decimal.NewFromInt(-20), Currency: "USD"},
+			{Name: "Assets:Broker", Balance: decimal.NewFromInt(1), Currency: "AAPL"},
+		}},
+		{Date: day(4), AccountChanges: []ledger.Account{
+			{Name: "Assets:Checking", Balance: decimal.NewFromInt(10), Currency: "EUR"},
+		}},
+	}
+
+	// at the rate of each transaction date
+	exchanged := exchangeTransactions(generalLedger, ledger.NewPriceDB(prices), "USD", time.Time{})
+	want := []string{"USD 20", "USD -20", "AAPL 1", "USD 40"}
+	var got []string
+	for _, trans := range exchanged {
+		for _, acc := range trans.AccountChanges {
+			got = append(got, acc.Currency+" "+acc.Balance.String())
+		}
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// at a single rate
+	exchanged = exchangeTransactions(generalLedger, ledger.NewPriceDB(prices), "USD", day(12))
+	if got := exchanged[0].AccountChanges[0]; got.Currency != "USD" || !got.Balance.Equal(decimal.NewFromInt(40)) {
+		t.Errorf("unexpected exchanged posting: %v", got)
+	}
+}
+
+func TestPrintRegister(t *testing.T) {
+	fastcolor.NoColor = true
+	generalLedger := []*ledger.Transaction{
+		{Date: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), Payee: "Grocery Store", AccountChanges: []ledger.Account{
+			{Name: "Expenses:Food", Balance: decimal.NewFromInt(40)},
+			{Name: "Assets:Checking", Balance: decimal.NewFromInt(-40)},
+		}},
+		{Date: time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC), Payee: "Bakery", AccountChanges: []ledger.Account{
+			{Name: "Expenses:Food", Balance: decimal.NewFromInt(5)},
+			{Name: "Assets:Checking", Balance: decimal.NewFromInt(-5)},
+		}},
+	}
+
+	var buf bytes.Buffer
+	PrintRegister(&buf, generalLedger, mustParseQuery(t, "Food"), 60, false)
+	want := "" +
+		"2024/01/05 Grocery  Expenses:Food           40.00      40.00\n" +
+		"2024/01/09 Bakery   Expenses:Food            5.00      45.00\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestPrintRegisterAsync(t *testing.T) {
+	fastcolor.NoColor = true
+	transactions, errs := ledger.ParseLedgerAsync(strings.NewReader(`2024/01/05 Grocery Store
+	Expenses:Food  40
+	Assets:Checking
+
+2024/01/07 Cinema
+	Expenses:Movies  12
+	Assets:Checking
+
+2024/01/09 Bakery
+	Expenses:Food  5
+	Assets:Checking
+`))
+	start := time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC)
+	filters := []ledger.TransactionFilter{ledger.ByDateRange(start, start.AddDate(0, 1, 0))}
+
+	var buf bytes.Buffer
+	if err := PrintRegisterAsync(&buf, transactions, errs, filters, mustParseQuery(t, "Expenses"), 60, false); err != nil {
+		t.Fatal(err)
+	}
+	want := "" +
+		"2024/01/07 Cinema   Expenses:Movies         12.00      12.00\n" +
+		"2024/01/09 Bakery   Expenses:Food            5.00      17.00\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	transactions, errs = ledger.ParseLedgerAsync(strings.NewReader("2024/01/05 Grocery Store\n\tExpenses:Food  40\n"))
+	if err := PrintRegisterAsync(&buf, transactions, errs, nil, nil, 60, false); err == nil {
+		t.Error("expected the parse error")
+	}
+}
+
+func Test_groupPostings(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	generalLedger := []*ledger.Transaction{
+		{Date: day(1), Payee: "Market", PayeeComment: "; :food:", AccountChanges: []ledger.Account{
+			{Name: "Expenses:Food", Balance: decimal.NewFromInt(30)},
+			{Name: "Expenses:Home", Balance: decimal.NewFromInt(10), Comment: "; :home:"},
+			{Name: "Assets:Cash", Balance: decimal.NewFromInt(-40)},
+		}},
+		{Date: day(2), Payee: "Bakery", AccountChanges: []ledger.Account{
+			{Name: "Expenses:Food", Balance: decimal.NewFromInt(5)},
+			{Name: "Assets:Cash", Balance: decimal.NewFromInt(-5)},
+		}},
+		{Date: day(3), Payee: "Market", AccountChanges: []ledger.Account{
+			{Name: "Expenses:Food", Balance: decimal.NewFromInt(20)},
+			{Name: "Assets:Cash", Balance: decimal.NewFromInt(-20)},
+		}},
+	}
+
+	tests := []struct {
+		groupBy string
+		want    map[string]string // group: amounts of its postings
+	}{
+		{"payee", map[string]string{"Bakery": "5", "Market": "30 10 20"}},
+		{"account", map[string]string{"Expenses:Food": "30 5 20", "Expenses:Home": "10"}},
+		{"tag", map[string]string{"(untagged)": "5 20", "food": "30 10", "home": "10"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.groupBy, func(t *testing.T) {
+			groups, err := groupPostings(generalLedger, mustParseQuery(t, "Expenses"), tt.groupBy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string]string)
+			var names []string
+			for _, g := range groups {
+				names = append(names, g.Name)
+				var amounts []string
+				for _, trans := range g.Transactions {
+					for _, acc := range trans.AccountChanges {
+						amounts = append(amounts, acc.Balance.String())
+					}
+				}
+				got[g.Name] = strings.Join(amounts, " ")
+			}
+			if !slices.IsSorted(names) {
+				t.Errorf("groups not sorted: %v", names)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := groupPostings(generalLedger, nil, "week"); err == nil {
+		t.Error("expected error for unknown group")
+	}
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/howeyc/ledger"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+)
+
+var reconcileTarget string
+
+// reconcileItem is an uncleared transaction posting to the account being
+// reconciled, with the total of its uncleared postings to the account.
+type reconcileItem struct {
+	trans  *ledger.Transaction
+	amount decimal.Decimal
+}
+
+// reconcileItems returns the cleared balance of account (including its
+// sub-accounts) and the transactions with uncleared postings to it.
+func reconcileItems(generalLedger []*ledger.Transaction, account string) (cleared decimal.Decimal, items []reconcileItem) {
+	for _, trans := range generalLedger {
+		var uncleared decimal.Decimal
+		found := false
+		for i := range trans.AccountChanges {
+			acc := &trans.AccountChanges[i]
+			if acc.Name != account && !strings.HasPrefix(acc.Name, account+":") {
+				continue
+			}
+			if trans.PostingStatus(acc) == ledger.StatusCleared {
+				cleared = cleared.Add(acc.Balance)
+			} else {
+				uncleared = uncleared.Add(acc.Balance)
+				found = true
+			}
+		}
+		if found {
+			items = append(items, reconcileItem{trans: trans, amount: uncleared})
+		}
+	}
+	return
+}
+
+// reconcile asks, for each item in turn, whether it is cleared until the
+// cleared total reaches target. It returns the transactions marked cleared and
+// whether the target was reached.
+func reconcile(items []reconcileItem, cleared, target decimal.Decimal, in io.Reader, out io.Writer) (marked []*ledger.Transaction, balanced bool) {
+	if cleared.Equal(target) {
+		return nil, true
+	}
+
+	scanner := bufio.NewScanner(in)
+	for _, item := range items {
+		fmt.Fprintf(out, "%s %s %s  (cleared %s, difference %s) Cleared? [y/n/q] ",
+			item.trans.Date.Format(transactionDateFormat), item.trans.Payee, item.amount.StringFixedBank(2),
+			cleared.StringFixedBank(2), target.Sub(cleared).StringFixedBank(2))
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return marked, false
+		}
+		switch strings.ToLower(strings.TrimSpace(scanner.Text())) {
+		case "y", "yes":
+			marked = append(marked, item.trans)
+			cleared = cleared.Add(item.amount)
+			if cleared.Equal(target) {
+				return marked, true
+			}
+		case "q", "quit":
+			return marked, false
+		}
+	}
+	return marked, false
+}
+
+// clearedHeader returns the transaction header line with its status marker
+// set to cleared.
+func clearedHeader(line string) string {
+	dateString, rest, _ := strings.Cut(line, " ")
+	rest = strings.TrimLeft(rest, " \t")
+	if len(rest) > 0 && (rest[0] == '*' || rest[0] == '!') && (len(rest) == 1 || rest[1] == ' ' || rest[1] == '\t') {
+		// replace the existing marker
+		rest = strings.TrimLeft(rest[1:], " \t")
+	}
+	return dateString + " * " + rest
+}
+
+// markCleared marks the transactions cleared in the files they were parsed
+// from.
+func markCleared(transactions []*ledger.Transaction) error {
+	lines := make(map[string][]int)
+	for _, trans := range transactions {
+		lines[trans.Filename] = append(lines[trans.Filename], trans.Line)
+	}
+
+	for filename, lineNums := range lines {
+		fi, err := os.Stat(filename)
+		if err != nil {
+			return err
+		}
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		fileLines := strings.Split(string(src), "\n")
+		slices.Sort(lineNums)
+		for _, lineNum := range slices.Compact(lineNums) {
+			if lineNum < 1 || lineNum > len(fileLines) {
+				return fmt.Errorf("%s:%d: line not found", filename, lineNum)
+			}
+			header, cr := strings.CutSuffix(fileLines[lineNum-1], "\r")
+			header = clearedHeader(header)
+			if cr {
+				header += "\r"
+			}
+			fileLines[lineNum-1] = header
+		}
+		if err := os.WriteFile(filename, []byte(strings.Join(fileLines, "\n")), fi.Mode()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcileCmd represents the reconcile command
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile <account> --target AMOUNT",
+	Short: "Interactively mark transactions cleared against a statement balance",
+	Long: `Step through the uncleared transactions of an account (and its sub-accounts)
+in date order, asking whether each one is cleared. Once the cleared balance
+matches the statement balance given by --target, the transactions marked are
+written back to the ledger file with a "*" cleared status marker.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		target, err := decimal.NewFromString(reconcileTarget)
+		if err != nil {
+			log.Fatalln(fmt.Errorf("invalid target(%s): %w", reconcileTarget, err))
+		}
+
+		generalLedger, lerr := ledger.ParseLedgerFile(ledgerFilePath)
+		if lerr != nil {
+			log.Fatalln(lerr)
+		}
+		slices.SortStableFunc(generalLedger, func(a, b *ledger.Transaction) int {
+			return a.Date.Compare(b.Date)
+		})
+
+		cleared, items := reconcileItems(generalLedger, args[0])
+		marked, balanced := reconcile(items, cleared, target, os.Stdin, os.Stdout)
+		if !balanced {
+			fmt.Println("Cleared balance does not match target, no changes written.")
+			os.Exit(1)
+		}
+		if len(marked) == 0 {
+			fmt.Println("Cleared balance matches target.")
+			return
+		}
+		if err := markCleared(marked); err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Printf("Cleared balance matches target, marked %d transactions cleared.\n", len(marked))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reconcileCmd)
+
+	reconcileCmd.Flags().StringVar(&reconcileTarget, "target", "", "Statement balance to reconcile to.")
+	reconcileCmd.MarkFlagRequired("target")
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/howeyc/ledger"
+	"github.com/shopspring/decimal"
+)
+
+func Test_reconcile(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "ledger.dat")
+	data := `2024/01/01 * Opening
+    Assets:Checking    100
+    Equity:Opening Balances
+
+2024/01/05 Grocery Store
+    Expenses:Food    40
+    Assets:Checking
+
+2024/01/06 ! Coffee
+    Expenses:Food    5
+    Assets:Checking
+
+2024/01/07 Employer
+    Assets:Checking    500
+    Income:Salary
+`
+	if err := os.WriteFile(filename, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	generalLedger, err := ledger.ParseLedgerFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cleared, items := reconcileItems(generalLedger, "Assets")
+	if !cleared.Equal(decimal.NewFromInt(100)) || len(items) != 3 {
+		t.Fatalf("unexpected cleared balance %s, %d items", cleared, len(items))
+	}
+
+	// skip the coffee, target is reached before the paycheck
+	var out bytes.Buffer
+	marked, balanced := reconcile(items, cleared, decimal.NewFromInt(560), strings.NewReader("y\nn\ny\n"), &out)
+	if !balanced || len(marked) != 2 || marked[0].Payee != "Grocery Store" || marked[1].Payee != "Employer" {
+		t.Fatalf("unexpected reconcile result: %v %v", balanced, marked)
+	}
+
+	// running out of input does not reach the target
+	if _, balanced := reconcile(items, cleared, decimal.NewFromInt(1), strings.NewReader("y\n"), &out); balanced {
+		t.Error("expected target not to be reached")
+	}
+
+	if err := markCleared(marked); err != nil {
+		t.Fatal(err)
+	}
+	src, _ := os.ReadFile(filename)
+	expected := strings.Replace(strings.Replace(data, "2024/01/05 Grocery", "2024/01/05 * Grocery", 1), "2024/01/07 Employer", "2024/01/07 * Employer", 1)
+	if string(src) != expected {
+		t.Errorf("unexpected file contents:\n%s", src)
+	}
+}
+
+func Test_clearedHeader(t *testing.T) {
+	tests := map[string]string{
+		"2024/01/05 Payee":         "2024/01/05 * Payee",
+		"2024/01/05 ! Payee":       "2024/01/05 * Payee",
+		"2024/01/05   * Payee":     "2024/01/05 * Payee",
+		"2024/01/05 !Important Co": "2024/01/05 * !Important Co",
+	}
+	for line, expected := range tests {
+		if got := clearedHeader(line); got != expected {
+			t.Errorf("clearedHeader(%q) = %q, expected %q", line, got, expected)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/howeyc/ledger"
+	"github.com/howeyc/ledger/ledger/internal/fastcolor"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
+)
+
+// replLedger is the ledger parsed once by the repl, read by cliTransactions
+// instead of parsing the ledger file again.
+var replLedger []*ledger.Transaction
+var replLedgerPath string
+
+// replDefaultCommand runs a line that does not start with a command name.
+const replDefaultCommand = "balance"
+
+// replError ends a command run in the repl in place of exiting.
+type replError string
+
+// splitArgs splits a line into arguments on spaces. An argument that starts
+// with a quote runs to the matching quote, without the quotes; quotes inside
+// an argument are kept, so queries such as acct:"Dining Out" keep working.
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	var inArg, keepQuotes bool
+	var quote rune
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+				if !keepQuotes {
+					continue
+				}
+			}
+			current.WriteRune(r)
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			keepQuotes = inArg
+			inArg = true
+			if keepQuotes {
+				current.WriteRune(r)
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// replCommand returns the report command named by the first argument, or nil.
+// Report commands are the commands that can be watched.
+func replCommand(name string) *cobra.Command {
+	for _, cmd := range rootCmd.Commands() {
+		if (cmd.Name() == name || cmd.HasAlias(name)) && cmd.Flags().Lookup("watch") != nil {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// resetFlags sets the flags of the command, and the --output flag, back to
+// their defaults, as flag values would otherwise carry over from the previous
+// line.
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		f.Value.Set(f.DefValue)
+		f.Changed = false
+	}
+	cmd.LocalNonPersistentFlags().VisitAll(reset)
+	reset(rootCmd.PersistentFlags().Lookup("output"))
+}
+
+// runReplLine runs a single repl line as a report command.
+func runReplLine(line string) (err error) {
+	args, err := splitArgs(line)
+	if err != nil || len(args) == 0 {
+		return err
+	}
+	cmd := replCommand(args[0])
+	if cmd == nil {
+		// a query for the default report
+		cmd = replCommand(replDefaultCommand)
+		args = append([]string{replDefaultCommand}, args...)
+	}
+	if slices.ContainsFunc(args, func(arg string) bool { return arg == "--watch" || strings.HasPrefix(arg, "--watch=") }) {
+		return errors.New("--watch is not available in the repl")
+	}
+
+	noColor := fastcolor.NoColor
+	defer func() {
+		// undo --output
+		cliOutput, fastcolor.NoColor = os.Stdout, noColor
+		if r := recover(); r != nil {
+			msg, ok := r.(replError)
+			if !ok {
+				panic(r)
+			}
+			err = errors.New(string(msg))
+		}
+	}()
+	resetFlags(cmd)
+	rootCmd.SetArgs(args)
+	return rootCmd.Execute()
+}
+
+// replHelp lists the report commands available in the repl.
+func replHelp(w io.Writer) {
+	fmt.Fprintln(w, "Report commands, each taking its usual flags and query:")
+	for _, cmd := range rootCmd.Commands() {
+		if cmd.Flags().Lookup("watch") != nil {
+			fmt.Fprintf(w, "  %-16s %s\n", cmd.Name(), cmd.Short)
+		}
+	}
+	fmt.Fprintf(w, "A line not starting with a command is a query for %q.\n", replDefaultCommand)
+	fmt.Fprintln(w, "Other commands: reload (parse the ledger file again), help, quit")
+}
+
+// replCmd represents the repl command
+var replCmd = &cobra.Command{
+	Use:   "repl",
+	Short: "Run report commands interactively on a ledger parsed once",
+	Long: `Parse the ledger file once, then read report commands (such as
+"register Expenses --period Monthly") or queries for the balance report,
+one per line, and print their reports.`,
+	Args: cobra.NoArgs,
+	Run: func(_ *cobra.Command, _ []string) {
+		if ledgerFilePath == "-" {
+			fatalln("the repl reads commands from stdin, use a ledger file")
+		}
+		load := func() error {
+			generalLedger, err := ledger.ParseLedgerFile(ledgerFilePath)
+			if err != nil {
+				return err
+			}
+			replLedger, replLedgerPath = generalLedger, ledgerFilePath
+			return nil
+		}
+		if err := load(); err != nil {
+			fatalln(err)
+		}
+
+		// commands end with an error instead of exiting
+		fatalln = func(v ...any) {
+			panic(replError(strings.TrimSuffix(fmt.Sprintln(v...), "\n")))
+		}
+		rootCmd.SilenceUsage = true
+
+		interactive := term.IsTerminal(int(os.Stdin.Fd()))
+		scanner := bufio.NewScanner(os.Stdin)
+		for {
+			if interactive {
+				fmt.Print("ledger> ")
+			}
+			if !scanner.Scan() {
+				break
+			}
+			line := strings.TrimSpace(scanner.Text())
+			var err error
+			switch line {
+			case "":
+			case "quit", "exit":
+				return
+			case "help":
+				replHelp(os.Stdout)
+			case "reload":
+				err = load()
+			default:
+				err = runReplLine(line)
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error:", err)
+			}
+		}
+		if interactive {
+			fmt.Println()
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(replCmd)
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+)
+
+func Test_splitArgs(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+		err  bool
+	}{
+		{"register  Expenses --period Monthly", []string{"register", "Expenses", "--period", "Monthly"}, false},
+		{`balance --payee "Whole Foods" Food`, []string{"balance", "--payee", "Whole Foods", "Food"}, false},
+		{`reg acct:"Dining Out" or payee:'Cafe Bar'`, []string{"reg", `acct:"Dining Out"`, "or", "payee:'Cafe Bar'"}, false},
+		{`reg ""`, []string{"reg", ""}, false},
+		{`reg "Food`, nil, true},
+	}
+	for _, tc := range tests {
+		got, err := splitArgs(tc.line)
+		if (err != nil) != tc.err || !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got %q (%v), want %q", tc.line, got, err, tc.want)
+		}
+	}
+}
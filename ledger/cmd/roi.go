@@ -0,0 +1,374 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/howeyc/ledger/ledger/internal/fastcolor"
+	date "github.com/joyt/godate"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+)
+
+var roiStartString, roiEndString string
+var roiIncome string
+
+// roiPosting is a posting to an investment account, with the cost of the
+// posting in the currency it was paid with.
+type roiPosting struct {
+	date      time.Time
+	account   string
+	commodity string
+	quantity  decimal.Decimal
+	cost      decimal.Decimal
+	// flow is set for money moved into or out of the investment. Postings
+	// paid for from income (dividends, interest) are returns, not flows.
+	flow bool
+}
+
+type roiPrice struct {
+	date  time.Time
+	price decimal.Decimal
+}
+
+// roiPrices holds, per commodity, the prices paid in date order.
+type roiPrices map[string][]roiPrice
+
+// at returns the last price of the commodity on or before d. Commodities
+// without a price (the currency paid with) are worth one.
+func (p roiPrices) at(commodity string, d time.Time) decimal.Decimal {
+	price := decimal.NewFromInt(1)
+	for _, rp := range p[commodity] {
+		if rp.date.After(d) {
+			break
+		}
+		price = rp.price
+	}
+	return price
+}
+
+// postingCost returns the cost of the posting at index idx of trans: the
+// amount at its "@" price, or its share of the postings in other currencies.
+// A posting without other currencies in the transaction costs its own
+// amount.
+func postingCost(trans *ledger.Transaction, idx int) (cost decimal.Decimal, priced bool) {
+	acc := &trans.AccountChanges[idx]
+	if acc.ConversionFactor != nil {
+		return acc.Balance.Mul(*acc.ConversionFactor), true
+	}
+
+	var same, other decimal.Decimal
+	for _, a := range trans.AccountChanges {
+		if a.Currency == acc.Currency {
+			same = same.Add(a.Balance)
+		} else {
+			other = other.Add(a.Balance)
+		}
+	}
+	if other.IsZero() || same.IsZero() {
+		return acc.Balance, false
+	}
+	return other.Neg().Mul(acc.Balance).Div(same), true
+}
+
+// roiPostings returns the postings matching the query, with the prices of
+// their commodities. A posting is a flow unless its transaction has a
+// posting to an account starting with incomePrefix.
+func roiPostings(generalLedger []*ledger.Transaction, query *ledger.Query, incomePrefix string) ([]roiPosting, roiPrices) {
+	var postings []roiPosting
+	prices := make(roiPrices)
+	for _, trans := range generalLedger {
+		income := incomePrefix != "" && slices.ContainsFunc(trans.AccountChanges, func(a ledger.Account) bool {
+			return strings.HasPrefix(a.Name, incomePrefix)
+		})
+		for i := range trans.AccountChanges {
+			acc := &trans.AccountChanges[i]
+			if !query.Match(trans, acc) {
+				continue
+			}
+			cost, priced := postingCost(trans, i)
+			// a posting of no quantity only has the price it is given
+			switch {
+			case priced && !acc.Balance.IsZero():
+				prices[acc.Currency] = append(prices[acc.Currency], roiPrice{date: trans.Date, price: cost.Div(acc.Balance)})
+			case acc.ConversionFactor != nil:
+				prices[acc.Currency] = append(prices[acc.Currency], roiPrice{date: trans.Date, price: *acc.ConversionFactor})
+			}
+			postings = append(postings, roiPosting{
+				date:      trans.Date,
+				account:   acc.Name,
+				commodity: acc.Currency,
+				quantity:  acc.Balance,
+				cost:      cost,
+				flow:      !income,
+			})
+		}
+	}
+	for _, history := range prices {
+		slices.SortStableFunc(history, func(a, b roiPrice) int {
+			return a.date.Compare(b.date)
+		})
+	}
+	return postings, prices
+}
+
+type roiResult struct {
+	Name                        string
+	StartValue, Flows, EndValue decimal.Decimal
+	// TWR is the time-weighted return over the whole range.
+	TWR float64
+	// MWR is the money-weighted return (XIRR), annualized. MWROK is false
+	// when it could not be computed.
+	MWR   float64
+	MWROK bool
+}
+
+// roiReturns computes the returns of the postings, sorted by date, from the
+// start of start to the end of end. Holdings are valued at the last price on
+// or before the valuation date.
+func roiReturns(name string, postings []roiPosting, prices roiPrices, start, end time.Time) roiResult {
+	type holding struct{ account, commodity string }
+	holdings := make(map[holding]decimal.Decimal)
+	value := func(d time.Time) decimal.Decimal {
+		var total decimal.Decimal
+		for h, qty := range holdings {
+			total = total.Add(qty.Mul(prices.at(h.commodity, d)))
+		}
+		return total
+	}
+
+	idx := 0
+	for ; idx < len(postings) && postings[idx].date.Before(start); idx++ {
+		p := postings[idx]
+		holdings[holding{p.account, p.commodity}] = holdings[holding{p.account, p.commodity}].Add(p.quantity)
+	}
+
+	result := roiResult{Name: name, StartValue: value(start)}
+	var cfDates []time.Time
+	var cfAmounts []float64
+	if !result.StartValue.IsZero() {
+		cfDates = append(cfDates, start)
+		cfAmounts = append(cfAmounts, -result.StartValue.InexactFloat64())
+	}
+
+	growth := 1.0
+	prev := result.StartValue
+	for idx < len(postings) && !postings[idx].date.After(end) {
+		d := postings[idx].date
+		before := value(d)
+		var flow decimal.Decimal
+		for ; idx < len(postings) && postings[idx].date.Equal(d); idx++ {
+			p := postings[idx]
+			holdings[holding{p.account, p.commodity}] = holdings[holding{p.account, p.commodity}].Add(p.quantity)
+			if p.flow {
+				flow = flow.Add(p.cost)
+			}
+		}
+		if flow.IsZero() {
+			continue
+		}
+		if !prev.IsZero() {
+			growth *= before.Div(prev).InexactFloat64()
+		}
+		prev = value(d)
+		result.Flows = result.Flows.Add(flow)
+		cfDates = append(cfDates, d)
+		cfAmounts = append(cfAmounts, -flow.InexactFloat64())
+	}
+
+	result.EndValue = value(end)
+	if !prev.IsZero() {
+		growth *= result.EndValue.Div(prev).InexactFloat64()
+	}
+	result.TWR = growth - 1
+	cfDates = append(cfDates, end)
+	cfAmounts = append(cfAmounts, result.EndValue.InexactFloat64())
+	result.MWR, result.MWROK = xirr(cfDates, cfAmounts)
+	return result
+}
+
+// xirr returns the annual rate at which the net present value of the cash
+// flows is zero. It needs both positive and negative cash flows.
+func xirr(dates []time.Time, amounts []float64) (float64, bool) {
+	if !slices.ContainsFunc(amounts, func(a float64) bool { return a > 0 }) ||
+		!slices.ContainsFunc(amounts, func(a float64) bool { return a < 0 }) {
+		return 0, false
+	}
+
+	npv := func(rate float64) float64 {
+		var total float64
+		for i, amount := range amounts {
+			years := dates[i].Sub(dates[0]).Hours() / 24 / 365
+			total += amount / math.Pow(1+rate, years)
+		}
+		return total
+	}
+
+	// bisect between a rate near -100% and a rate large enough to change
+	// the sign of the net present value
+	low, high := -0.999999, 1.0
+	for npv(low)*npv(high) > 0 {
+		high *= 2
+		if high > 1e9 {
+			return 0, false
+		}
+	}
+	for range 200 {
+		mid := (low + high) / 2
+		if npv(low)*npv(mid) <= 0 {
+			high = mid
+		} else {
+			low = mid
+		}
+	}
+	return (low + high) / 2, true
+}
+
+// roiReport computes the returns of each account and each commodity of the
+// postings.
+func roiReport(postings []roiPosting, prices roiPrices, start, end time.Time) (accounts, commodities []roiResult) {
+	group := func(key func(p roiPosting) string) []roiResult {
+		groups := make(map[string][]roiPosting)
+		for _, p := range postings {
+			groups[key(p)] = append(groups[key(p)], p)
+		}
+		var results []roiResult
+		for name, grouped := range groups {
+			results = append(results, roiReturns(name, grouped, prices, start, end))
+		}
+		slices.SortFunc(results, func(a, b roiResult) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+		return results
+	}
+	accounts = group(func(p roiPosting) string { return p.account })
+	commodities = group(func(p roiPosting) string { return p.commodity })
+	return
+}
+
+// PrintROI prints the returns formatted to a window set to a width of
+// columns, with a heading for the name column.
+func PrintROI(w io.Writer, title string, results []roiResult, columns int) {
+	// 4 10-width amount columns, 2 8-width percentage columns, each with a
+	// leading space
+	if columns < 10+4*11+2*9 {
+		columns = 10 + 4*11 + 2*9
+		fmt.Fprintf(os.Stderr, "warning: `columns` too small, setting to %d\n", columns)
+	}
+	nameWidth := columns - 4*11 - 2*9
+
+	colorNeg := fastcolor.CurrentTheme.Negative
+	colorAccount := fastcolor.CurrentTheme.Account
+	colorHeader := fastcolor.CurrentTheme.Header
+	colorReset := fastcolor.Reset
+
+	writeColumn := func(buf *bufio.Writer, s string, negative bool, width int) {
+		amtColor := colorReset
+		if negative {
+			amtColor = colorNeg
+		}
+		buf.WriteString(" ")
+		amtColor.WriteStringFixed(buf, s, width, true)
+	}
+	percent := func(rate float64) string {
+		return fmt.Sprintf("%.2f%%", rate*100)
+	}
+
+	buf := bufio.NewWriter(w)
+	colorHeader.WriteStringFixed(buf, title, nameWidth, false)
+	for _, heading := range []string{"Start", "Flows", "End", "Gain"} {
+		buf.WriteString(" ")
+		colorHeader.WriteStringFixed(buf, heading, 10, true)
+	}
+	for _, heading := range []string{"TWR", "XIRR"} {
+		buf.WriteString(" ")
+		colorHeader.WriteStringFixed(buf, heading, 8, true)
+	}
+	buf.WriteString(newLine)
+
+	for _, r := range results {
+		gain := r.EndValue.Sub(r.StartValue).Sub(r.Flows)
+		name := r.Name
+		if name == "" {
+			name = "(no currency)"
+		}
+		colorAccount.WriteStringFixed(buf, name, nameWidth, false)
+		for _, amount := range []decimal.Decimal{r.StartValue, r.Flows, r.EndValue, gain} {
+			writeColumn(buf, amount.StringFixedBank(2), amount.Sign() < 0, 10)
+		}
+		writeColumn(buf, percent(r.TWR), r.TWR < 0, 8)
+		if r.MWROK {
+			writeColumn(buf, percent(r.MWR), r.MWR < 0, 8)
+		} else {
+			writeColumn(buf, "-", false, 8)
+		}
+		buf.WriteString(newLine)
+	}
+	buf.Flush()
+}
+
+// roiCmd represents the roi command
+var roiCmd = &cobra.Command{
+	Use:   "roi <query>...",
+	Short: "Print investment returns per account and commodity",
+	Long: `Print the time-weighted and money-weighted (XIRR) returns of the investment
+accounts matching the query, per account and per commodity.
+
+Holdings are valued at the last price paid for them, from "@" prices or the
+other currency of the transaction they were bought or sold in. Postings in a
+transaction with an income account are counted as returns, other postings as
+money moved into or out of the investment.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		start, serr := date.Parse(roiStartString)
+		end, eerr := date.Parse(roiEndString)
+		if serr != nil || eerr != nil {
+			fatalln("unable to parse start or end date string argument")
+		}
+
+		// holdings before the start date count towards the starting value
+		generalLedger, err := cliTransactions()
+		if err != nil {
+			fatalln(err)
+		}
+		postings, prices := roiPostings(generalLedger, cliQuery(args), roiIncome)
+		if len(postings) < 1 {
+			return
+		}
+		if first := postings[0].date; start.Before(first) {
+			start = first
+		}
+		if last := generalLedger[len(generalLedger)-1].Date; end.After(last) {
+			end = last
+		}
+
+		accounts, commodities := roiReport(postings, prices, start, end)
+		fmt.Fprintln(cliOutput, start.Format(transactionDateFormat), "-", end.Format(transactionDateFormat))
+		fmt.Fprintln(cliOutput, strings.Repeat("=", columnWidth))
+		PrintROI(cliOutput, "Account", accounts, columnWidth)
+		fmt.Fprintln(cliOutput, "")
+		PrintROI(cliOutput, "Commodity", commodities, columnWidth)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(roiCmd)
+	watchCommand(roiCmd)
+
+	var startDate, endDate time.Time
+	startDate = time.Date(1970, 1, 1, 0, 0, 0, 0, time.Local)
+	endDate = time.Now().Add(1<<63 - 1)
+	roiCmd.Flags().StringVarP(&roiStartString, "begin-date", "b", startDate.Format(transactionDateFormat), "Begin date of the returns.")
+	roiCmd.Flags().StringVarP(&roiEndString, "end-date", "e", endDate.Format(transactionDateFormat), "End date of the returns.")
+	roiCmd.Flags().IntVar(&columnWidth, "columns", 80, "Set a column width for output.")
+	roiCmd.Flags().BoolVar(&columnWide, "wide", false, "Wide output (use terminal width).")
+
+	roiCmd.Flags().StringVar(&roiIncome, "income", "Income", "Account prefix of investment income (dividends, interest).")
+}
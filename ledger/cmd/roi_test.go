@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/shopspring/decimal"
+)
+
+func Test_xirr(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	rate, ok := xirr([]time.Time{start, start.AddDate(0, 0, 365)}, []float64{-1000, 1100})
+	if !ok || math.Abs(rate-0.10) > 1e-6 {
+		t.Errorf("got %v (%v), want 0.10", rate, ok)
+	}
+
+	if _, ok := xirr([]time.Time{start, start.AddDate(1, 0, 0)}, []float64{1000, 1100}); ok {
+		t.Error("expected no rate without a negative cash flow")
+	}
+}
+
+func Test_roiReturns(t *testing.T) {
+	day := func(month, d int) time.Time {
+		return time.Date(2024, time.Month(month), d, 0, 0, 0, 0, time.UTC)
+	}
+	price := func(p int64) *decimal.Decimal {
+		d := decimal.NewFromInt(p)
+		return &d
+	}
+	generalLedger := []*ledger.Transaction{
+		{Date: day(1, 1), AccountChanges: []ledger.Account{
+			{Name: "Assets:Broker", Currency: "AAPL", Balance: decimal.NewFromInt(10), ConversionFactor: price(150)},
+			{Name: "Assets:Cash", Balance: decimal.NewFromInt(-1500)},
+		}},
+		{Date: day(2, 1), AccountChanges: []ledger.Account{
+			{Name: "Assets:Broker", Currency: "AAPL", Balance: decimal.NewFromInt(5)},
+			{Name: "Assets:Cash", Balance: decimal.NewFromInt(-800)},
+		}},
+		{Date: day(3, 1), AccountChanges: []ledger.Account{
+			{Name: "Assets:Broker", Currency: "AAPL", Balance: decimal.NewFromInt(-5), ConversionFactor: price(180)},
+			{Name: "Assets:Cash", Balance: decimal.NewFromInt(900)},
+		}},
+		{Date: day(6, 1), AccountChanges: []ledger.Account{
+			{Name: "Assets:Broker:Cash", Balance: decimal.NewFromInt(20)},
+			{Name: "Income:Dividends", Balance: decimal.NewFromInt(-20)},
+		}},
+		{Date: day(12, 31), AccountChanges: []ledger.Account{
+			{Name: "Assets:Broker", Currency: "AAPL", ConversionFactor: price(200)},
+			{Name: "Assets:Cash"},
+		}},
+	}
+
+	postings, prices := roiPostings(generalLedger, mustParseQuery(t, "Broker"), "Income")
+	if got := prices.at("AAPL", day(2, 15)); !got.Equal(decimal.NewFromInt(160)) {
+		t.Errorf("price on 2024/02/15: got %s, want 160", got)
+	}
+
+	r := roiReturns("Assets", postings, prices, day(1, 1), day(12, 31))
+	if !r.Flows.Equal(decimal.NewFromInt(1400)) || !r.EndValue.Equal(decimal.NewFromInt(2020)) {
+		t.Errorf("got flows %s, end value %s, want 1400, 2020", r.Flows, r.EndValue)
+	}
+	// 1600/1500 * 2700/2400 * 2020/1800
+	if want := 1600.0 / 1500 * 2700 / 2400 * 2020 / 1800; math.Abs(r.TWR-(want-1)) > 1e-9 {
+		t.Errorf("TWR: got %v, want %v", r.TWR, want-1)
+	}
+	if !r.MWROK || r.MWR <= 0 {
+		t.Errorf("MWR: got %v (%v), want a positive rate", r.MWR, r.MWROK)
+	}
+
+	// holdings before the start are the starting value
+	r = roiReturns("Assets", postings, prices, day(2, 15), day(12, 31))
+	if !r.StartValue.Equal(decimal.NewFromInt(2400)) || !r.Flows.Equal(decimal.NewFromInt(-900)) {
+		t.Errorf("got start value %s, flows %s, want 2400, -900", r.StartValue, r.Flows)
+	}
+}
+
+func Test_roiPostingsZeroQuantity(t *testing.T) {
+	generalLedger, err := ledger.ParseLedger(strings.NewReader(`2024/01/01 Buy
+    Assets:Broker    AAPL 10
+    Assets:Broker    AAPL 0
+    Assets:Cash    USD -1500
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	postings, prices := roiPostings(generalLedger, mustParseQuery(t, "Broker"), "Income")
+	if len(postings) != 2 {
+		t.Fatalf("got %d postings, want 2", len(postings))
+	}
+	if got := prices.at("AAPL", generalLedger[0].Date); !got.Equal(decimal.NewFromInt(150)) {
+		t.Errorf("price: got %s, want 150", got)
+	}
+}
@@ -5,18 +5,35 @@ import (
 	"os"
 	"runtime/pprof"
 
+	"github.com/howeyc/ledger/ledger/internal/fastcolor"
 	cc "github.com/ivanpirog/coloredcobra"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var cpuprofile string
 var cpuf *os.File
+var outputPath string
+var outputFile *os.File
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "ledger",
 	Short: "Plain text accounting",
-	PersistentPreRun: func(_ *cobra.Command, _ []string) {
+	PersistentPreRun: func(cmd *cobra.Command, _ []string) {
+		applyConfig(cmd, cliConfig)
+		if localeName != "" && reportLocale == nil {
+			log.Fatal("unknown locale: ", localeName)
+		}
+		if outputPath != "" && outputPath != "-" {
+			var err error
+			outputFile, err = os.Create(outputPath)
+			if err != nil {
+				log.Fatal("could not create output file: ", err)
+			}
+			cliOutput = outputFile
+			fastcolor.NoColor = true
+		}
 		if cpuprofile != "" {
 			var err error
 			cpuf, err = os.Create(cpuprofile)
@@ -33,6 +50,12 @@ var rootCmd = &cobra.Command{
 			pprof.StopCPUProfile()
 			cpuf.Close()
 		}
+		if outputFile != nil {
+			if err := outputFile.Close(); err != nil {
+				log.Fatal("could not write output file: ", err)
+			}
+			outputFile = nil
+		}
 	},
 }
 
@@ -63,9 +86,27 @@ func init() {
 	ledgerFilePath = os.Getenv("LEDGER_FILE")
 
 	rootCmd.PersistentFlags().StringVarP(&ledgerFilePath, "file", "f", ledgerFilePath, "ledger file (default is $LEDGER_FILE)")
+	rootCmd.PersistentFlags().StringVarP(&outputPath, "output", "o", "", "write reports to `file` instead of standard output")
+	rootCmd.PersistentFlags().StringVar(&localeName, "locale", "", "write amounts in reports as in the `locale`, such as de_DE")
 	rootCmd.PersistentFlags().StringVarP(&cpuprofile, "prof", "", "", "write cpu profile to `file`")
+
+	// accept --begin and --end as short forms of the date range flags
+	rootCmd.SetGlobalNormalizationFunc(func(_ *pflag.FlagSet, name string) pflag.NormalizedName {
+		switch name {
+		case "begin":
+			name = "begin-date"
+		case "end":
+			name = "end-date"
+		}
+		return pflag.NormalizedName(name)
+	})
 }
 
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
+	var err error
+	cliConfig, err = loadConfig(configFiles())
+	if err != nil {
+		log.Fatal("could not read config file: ", err)
+	}
 }
@@ -0,0 +1,353 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/howeyc/ledger"
+	"github.com/howeyc/ledger/ledger/cmd/internal/httpcompress"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+)
+
+var serveAPI bool
+
+// apiServer serves the JSON API from the ledger held in memory. The ledger is
+// replaced whenever the ledger files change and parse.
+type apiServer struct {
+	mu sync.RWMutex
+	j  *ledger.Journal
+}
+
+// load parses the ledger file, keeping the previous ledger on error.
+func (s *apiServer) load(filename string) error {
+	j := &ledger.Journal{}
+	if err := j.Load(filename); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.j = j
+	s.mu.Unlock()
+	return nil
+}
+
+// journal returns the current ledger. The journal is shared between requests
+// and must not be modified.
+func (s *apiServer) journal() *ledger.Journal {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.j
+}
+
+type apiAmount struct {
+	Currency string          `json:"currency"`
+	Amount   decimal.Decimal `json:"amount"`
+}
+
+type apiBalance struct {
+	Account  string          `json:"account"`
+	Currency string          `json:"currency"`
+	Balance  decimal.Decimal `json:"balance"`
+}
+
+type apiPosting struct {
+	Account  string          `json:"account"`
+	Status   string          `json:"status,omitempty"`
+	Currency string          `json:"currency"`
+	Amount   decimal.Decimal `json:"amount"`
+	Comment  string          `json:"comment,omitempty"`
+}
+
+type apiTransaction struct {
+	ID       int          `json:"id"`
+	Date     string       `json:"date"`
+	Status   string       `json:"status,omitempty"`
+	Payee    string       `json:"payee"`
+	Comment  string       `json:"comment,omitempty"`
+	Postings []apiPosting `json:"postings"`
+	Comments []string     `json:"comments,omitempty"`
+	File     string       `json:"file,omitempty"`
+	Line     int          `json:"line,omitempty"`
+}
+
+type apiRegisterRow struct {
+	Transaction int             `json:"transaction"`
+	Date        string          `json:"date"`
+	Payee       string          `json:"payee"`
+	Account     string          `json:"account"`
+	Currency    string          `json:"currency"`
+	Amount      decimal.Decimal `json:"amount"`
+	Balance     decimal.Decimal `json:"balance"`
+}
+
+type apiRegisterPeriod struct {
+	Start  string           `json:"start,omitempty"`
+	End    string           `json:"end,omitempty"`
+	Rows   []apiRegisterRow `json:"rows"`
+	Totals []apiAmount      `json:"totals"`
+}
+
+func apiStatus(status ledger.Status) string {
+	switch status {
+	case ledger.StatusCleared:
+		return "cleared"
+	case ledger.StatusPending:
+		return "pending"
+	}
+	return ""
+}
+
+func newAPITransaction(id int, trans *ledger.Transaction) apiTransaction {
+	t := apiTransaction{
+		ID:       id,
+		Date:     trans.Date.Format("2006-01-02"),
+		Status:   apiStatus(trans.Status),
+		Payee:    trans.Payee,
+		Comment:  trans.PayeeComment,
+		Comments: trans.Comments,
+		File:     trans.Filename,
+		Line:     trans.Line,
+		Postings: make([]apiPosting, len(trans.AccountChanges)),
+	}
+	for i, acc := range trans.AccountChanges {
+		t.Postings[i] = apiPosting{
+			Account:  acc.Name,
+			Status:   apiStatus(acc.Status),
+			Currency: acc.Currency,
+			Amount:   acc.Balance,
+			Comment:  acc.Comment,
+		}
+	}
+	return t
+}
+
+// apiAmounts returns the totals ordered by currency.
+func apiAmounts(totals map[string]decimal.Decimal) []apiAmount {
+	amounts := []apiAmount{}
+	for _, cur := range sortedCurrencies(totals) {
+		amounts = append(amounts, apiAmount{Currency: cur, Amount: totals[cur]})
+	}
+	return amounts
+}
+
+// apiQuery builds the query of a request from its q (a query expression),
+// acct, payee, begin and end parameters.
+func apiQuery(r *http.Request) (*ledger.Query, error) {
+	var terms []string
+	if q := r.FormValue("q"); q != "" {
+		terms = append(terms, "("+q+")")
+	}
+	if acct := r.FormValue("acct"); acct != "" {
+		terms = append(terms, "acct:"+queryQuote(acct))
+	}
+	if payee := r.FormValue("payee"); payee != "" {
+		terms = append(terms, "payee:"+queryQuote(payee))
+	}
+	if begin := r.FormValue("begin"); begin != "" {
+		terms = append(terms, "date>="+queryQuote(begin))
+	}
+	if end := r.FormValue("end"); end != "" {
+		terms = append(terms, "date<="+queryQuote(end))
+	}
+	return ledger.ParseQuery(strings.Join(terms, " and "))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func (s *apiServer) accountsHandler(w http.ResponseWriter, _ *http.Request) {
+	names := []string{}
+	for _, acc := range ledger.GetBalances(s.journal().Transactions, nil) {
+		if len(names) == 0 || names[len(names)-1] != acc.Name {
+			names = append(names, acc.Name)
+		}
+	}
+	writeJSON(w, http.StatusOK, names)
+}
+
+func (s *apiServer) balancesHandler(w http.ResponseWriter, r *http.Request) {
+	query, err := apiQuery(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	depth := -1
+	if d := r.FormValue("depth"); d != "" {
+		if depth, err = strconv.Atoi(d); err != nil || depth < 1 {
+			writeJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid depth %q", d))
+			return
+		}
+	}
+
+	balances := []apiBalance{}
+	for _, acc := range ledger.GetBalances(s.journal().Filter(query), nil) {
+		if depth > 0 && strings.Count(acc.Name, ":")+1 > depth {
+			continue
+		}
+		balances = append(balances, apiBalance{Account: acc.Name, Currency: acc.Currency, Balance: acc.Balance})
+	}
+	writeJSON(w, http.StatusOK, balances)
+}
+
+func (s *apiServer) registerHandler(w http.ResponseWriter, r *http.Request) {
+	query, err := apiQuery(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ids := make(map[*ledger.Transaction]int)
+	var matched []*ledger.Transaction
+	for id, t := range s.journal().Matching(query) {
+		ids[t] = id
+		matched = append(matched, t)
+	}
+
+	registerPeriod := func(trans []*ledger.Transaction) apiRegisterPeriod {
+		period := apiRegisterPeriod{Rows: []apiRegisterRow{}}
+		totals := make(map[string]decimal.Decimal)
+		for _, e := range (&ledger.Journal{Transactions: trans}).Register(query) {
+			totals[e.Posting.Currency] = e.Balance
+			period.Rows = append(period.Rows, apiRegisterRow{
+				Transaction: ids[e.Transaction],
+				Date:        e.Transaction.Date.Format("2006-01-02"),
+				Payee:       e.Transaction.Payee,
+				Account:     e.Posting.Name,
+				Currency:    e.Posting.Currency,
+				Amount:      e.Posting.Balance,
+				Balance:     e.Balance,
+			})
+		}
+		period.Totals = apiAmounts(totals)
+		return period
+	}
+
+	periods := []apiRegisterPeriod{}
+	if p := r.FormValue("period"); p == "" {
+		periods = append(periods, registerPeriod(matched))
+	} else {
+		lperiod, ok := ledger.ParsePeriod(p)
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, fmt.Errorf("unknown period %q", p))
+			return
+		}
+		if len(matched) > 0 {
+			for _, rt := range ledger.TransactionsByPeriod(matched, lperiod) {
+				period := registerPeriod(rt.Transactions)
+				period.Start, period.End = rt.Start.Format("2006-01-02"), rt.End.Format("2006-01-02")
+				periods = append(periods, period)
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, periods)
+}
+
+func (s *apiServer) transactionsHandler(w http.ResponseWriter, r *http.Request) {
+	query, err := apiQuery(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	transactions := []apiTransaction{}
+	for id, t := range s.journal().Matching(query) {
+		transactions = append(transactions, newAPITransaction(id, t))
+	}
+	writeJSON(w, http.StatusOK, transactions)
+}
+
+func (s *apiServer) transactionHandler(w http.ResponseWriter, r *http.Request) {
+	trans := s.journal().Transactions
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 0 || id >= len(trans) {
+		writeJSONError(w, http.StatusNotFound, fmt.Errorf("no transaction %q", r.PathValue("id")))
+		return
+	}
+	writeJSON(w, http.StatusOK, newAPITransaction(id, trans[id]))
+}
+
+// handler returns the routes of the API. Transaction ids are the positions of
+// the transactions in the ledger sorted by date, so they change when earlier
+// transactions are added.
+func (s *apiServer) handler() http.Handler {
+	m := http.NewServeMux()
+	m.HandleFunc("GET /accounts", httpcompress.Middleware(s.accountsHandler, false))
+	m.HandleFunc("GET /balances", httpcompress.Middleware(s.balancesHandler, false))
+	m.HandleFunc("GET /register", httpcompress.Middleware(s.registerHandler, false))
+	m.HandleFunc("GET /transactions", httpcompress.Middleware(s.transactionsHandler, false))
+	m.HandleFunc("GET /transactions/{id}", httpcompress.Middleware(s.transactionHandler, false))
+	m.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSONError(w, http.StatusNotFound, fmt.Errorf("no endpoint %s", r.URL.Path))
+	})
+	return m
+}
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the ledger over HTTP",
+	Long: `Serve the ledger over HTTP. With --api, serve a read-only JSON API:
+
+  /accounts            account names
+  /balances            balances, filtered by depth and the query parameters
+  /register            postings with running totals, split by period
+  /transactions        transactions matching the query parameters
+  /transactions/{id}   a single transaction
+
+Query parameters are q (a query expression), acct, payee, begin and end. The
+ledger is parsed again whenever the ledger file or a file it includes changes.
+With users in the auth configuration, requests must be of one of them.
+For the web pages, use the web command.`,
+	Args: cobra.NoArgs,
+	Run: func(_ *cobra.Command, _ []string) {
+		if !serveAPI {
+			fatalln("nothing to serve: use --api, or the web command for web pages")
+		}
+		if ledgerFilePath == "-" {
+			fatalln("can not serve stdin")
+		}
+
+		var server apiServer
+		if err := server.load(ledgerFilePath); err != nil {
+			fatalln(err)
+		}
+		go func() {
+			err := watchLedgerFiles(ledgerFilePath, func() {
+				if err := server.load(ledgerFilePath); err != nil {
+					log.Println("reload:", err)
+				}
+			})
+			log.Println(err)
+		}()
+
+		log.Println("Listening on port", serverPort)
+		var listenAddress string
+		if localhost {
+			listenAddress = fmt.Sprintf("127.0.0.1:%d", serverPort)
+		} else {
+			listenAddress = fmt.Sprintf(":%d", serverPort)
+		}
+		log.Fatalln(http.ListenAndServe(listenAddress, newWebAuth(webAuthConfig).handler(server.handler())))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().BoolVar(&serveAPI, "api", false, "Serve the JSON API.")
+	serveCmd.Flags().IntVar(&serverPort, "port", 8056, "Port to listen on.")
+	serveCmd.Flags().BoolVar(&localhost, "localhost", false, "Listen on localhost only.")
+}
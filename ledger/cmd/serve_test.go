@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_apiServer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.dat")
+	content := `2024/02/01 * Grocery Store
+    Expenses:Food:Groceries    30
+    Assets:Cash
+
+2024/01/01 Grocery Store
+    Expenses:Food:Groceries    10
+    Assets:Cash
+
+2024/01/15 Gas Station
+    Expenses:Auto:Fuel    20
+    Assets:Cash
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var server apiServer
+	if err := server.load(path); err != nil {
+		t.Fatal(err)
+	}
+	handler := server.handler()
+
+	get := func(url string, wantStatus int, v any) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+		if rec.Code != wantStatus {
+			t.Fatalf("%s: status %d, want %d: %s", url, rec.Code, wantStatus, rec.Body)
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("%s: %v", url, err)
+		}
+	}
+
+	var balances []apiBalance
+	get("/balances?depth=2&acct=Expenses", http.StatusOK, &balances)
+	got := make(map[string]string)
+	for _, b := range balances {
+		got[b.Account] = b.Balance.String()
+	}
+	if len(got) != 3 || got["Expenses"] != "60" || got["Expenses:Food"] != "40" || got["Expenses:Auto"] != "20" {
+		t.Errorf("balances: got %v", got)
+	}
+
+	var periods []apiRegisterPeriod
+	get("/register?acct=Food&period=monthly", http.StatusOK, &periods)
+	if len(periods) != 2 || len(periods[0].Rows) != 1 || periods[1].Start != "2024-02-01" ||
+		periods[1].Rows[0].Transaction != 2 || periods[1].Totals[0].Amount.String() != "30" {
+		t.Errorf("register: got %+v", periods)
+	}
+
+	var trans apiTransaction
+	get("/transactions/2", http.StatusOK, &trans)
+	if trans.Payee != "Grocery Store" || trans.Status != "cleared" || trans.Date != "2024-02-01" || len(trans.Postings) != 2 {
+		t.Errorf("transaction: got %+v", trans)
+	}
+
+	var list []apiTransaction
+	get("/transactions?payee=Gas", http.StatusOK, &list)
+	if len(list) != 1 || list[0].ID != 1 {
+		t.Errorf("transactions: got %+v", list)
+	}
+
+	var apiErr map[string]string
+	get("/transactions/3", http.StatusNotFound, &apiErr)
+	get("/balances?depth=x", http.StatusBadRequest, &apiErr)
+	get("/register?period=Fortnightly", http.StatusBadRequest, &apiErr)
+}
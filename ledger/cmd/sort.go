@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"bufio"
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// ledgerParagraph is a run of non-blank lines of a ledger file. A paragraph
+// holding a transaction carries its date, the comments and directives
+// directly above the transaction move with it.
+type ledgerParagraph struct {
+	lines       []string
+	transaction bool
+	date        time.Time
+}
+
+// readParagraphs splits the ledger read from r into paragraphs separated by
+// blank lines, noting which of them hold a transaction.
+func readParagraphs(r io.Reader) ([]ledgerParagraph, error) {
+	var paragraphs []ledgerParagraph
+	err := scanParagraphs(r, func(p ledgerParagraph) error {
+		paragraphs = append(paragraphs, p)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return paragraphs, nil
+}
+
+// scanParagraphs calls fn with each paragraph of the ledger read from r in
+// turn, as readParagraphs returns them, stopping at the first error.
+func scanParagraphs(r io.Reader, fn func(p ledgerParagraph) error) error {
+	var dp dateParser
+	var current ledgerParagraph
+	emit := func() error {
+		p := current
+		current = ledgerParagraph{}
+		p.date, p.transaction = p.transactionDate(&dp)
+		return fn(p)
+	}
+	scanner := newLineScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if len(line) == 0 {
+			if len(current.lines) > 0 {
+				if err := emit(); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		current.lines = append(current.lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return scanError(err, lineNum)
+	}
+	if len(current.lines) > 0 {
+		return emit()
+	}
+	return nil
+}
+
+// transactionDate returns the date of the transaction in the paragraph, if
+// there is one before any periodic transaction or account declaration.
+func (p *ledgerParagraph) transactionDate(dp *dateParser) (time.Time, bool) {
+	for _, line := range p.lines {
+		if line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		content, _ := splitComment(line)
+		before, _, _ := strings.Cut(content, " ")
+		switch before {
+		case "", "include":
+			continue
+		case "account", "~":
+			return time.Time{}, false
+		}
+		transDate, err := dp.parseDate(before)
+		return transDate, err == nil
+	}
+	return time.Time{}, false
+}
+
+// sortLedger writes the ledger read from r to w with its transactions in
+// chronological order. Paragraphs without a transaction (such as periodic
+// transactions or a file header) keep their position, and paragraphs are
+// separated by a single blank line.
+func sortLedger(w io.Writer, r io.Reader) error {
+	paragraphs, err := readParagraphs(r)
+	if err != nil {
+		return err
+	}
+
+	var transactions []ledgerParagraph
+	for _, p := range paragraphs {
+		if p.transaction {
+			transactions = append(transactions, p)
+		}
+	}
+	slices.SortStableFunc(transactions, func(a, b ledgerParagraph) int {
+		return a.date.Compare(b.date)
+	})
+
+	buf := bufio.NewWriter(w)
+	for i, p := range paragraphs {
+		if p.transaction {
+			p, transactions = transactions[0], transactions[1:]
+		}
+		if i > 0 {
+			buf.WriteString(newLine)
+		}
+		for _, line := range p.lines {
+			buf.WriteString(line)
+			buf.WriteString(newLine)
+		}
+	}
+	return buf.Flush()
+}
+
+// sortCmd represents the sort command
+var sortCmd = &cobra.Command{
+	Use:   "sort [file]...",
+	Short: "Rewrite ledger files with transactions in date order",
+	Long: `Rewrite ledger files with transactions in chronological order.
+
+Comments and directives directly above a transaction move with it, other
+paragraphs (periodic transactions, account declarations, file headers) keep
+their place. Sorts the ledger file if no files are given.
+
+With --memory, ledgers too large to sort in memory are sorted in runs spilled
+to temporary files, which are then merged.`,
+	Run: func(_ *cobra.Command, args []string) {
+		if len(args) == 0 {
+			args = []string{ledgerFilePath}
+		}
+
+		if sortMemory < 0 {
+			log.Fatalln("--memory must not be negative")
+		}
+		for _, filename := range args {
+			if sortMemory > 0 {
+				err := rewriteLedgerFileStreaming(filename, func(w io.Writer, r io.Reader) error {
+					return sortLedgerExternal(w, r, sortMemory<<20, "")
+				})
+				if err != nil {
+					log.Fatalln(err)
+				}
+				continue
+			}
+			if _, err := rewriteLedgerFile(filename, false, sortLedger); err != nil {
+				log.Fatalln(err)
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sortCmd)
+
+	sortCmd.Flags().IntVar(&sortMemory, "memory", 0, "Sort in runs of at most this many megabytes of transactions spilled to temporary files, for ledgers too large for memory.")
+}
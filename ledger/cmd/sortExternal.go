@@ -0,0 +1,339 @@
+package cmd
+
+import (
+	"bufio"
+	"container/heap"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// sortMemory is the size, in megabytes, of the runs of transactions sort
+// holds in memory before spilling them to temporary files, 0 to sort in
+// memory.
+var sortMemory int
+
+// maxSortRuns is the most runs sort merges at a time, and so the most
+// temporary files it holds open. More runs are merged in passes.
+var maxSortRuns = 64
+
+// placedParagraph is a paragraph without a transaction, kept after the
+// number of transactions before it.
+type placedParagraph struct {
+	paragraph ledgerParagraph
+	after     int
+}
+
+// sortRun is a run of sorted transaction paragraphs spilled to a temporary
+// file, each written as its date followed by its lines and a blank line.
+// The file is open only while the run is merged.
+type sortRun struct {
+	name    string
+	index   int // among the runs merged, ordering transactions of a date
+	file    *os.File
+	scanner *bufio.Scanner
+	lineNum int
+	next    ledgerParagraph
+	done    bool
+}
+
+// runWriter writes the paragraphs of a run to a new temporary file in dir.
+type runWriter struct {
+	file *os.File
+	buf  *bufio.Writer
+}
+
+func newRunWriter(dir string) (*runWriter, error) {
+	f, err := os.CreateTemp(dir, "ledger-sort-*")
+	if err != nil {
+		return nil, err
+	}
+	return &runWriter{file: f, buf: bufio.NewWriter(f)}, nil
+}
+
+func (rw *runWriter) write(p ledgerParagraph) error {
+	rw.buf.WriteString(p.date.Format(time.RFC3339Nano))
+	rw.buf.WriteString(newLine)
+	for _, line := range p.lines {
+		rw.buf.WriteString(line)
+		rw.buf.WriteString(newLine)
+	}
+	_, err := rw.buf.WriteString(newLine)
+	return err
+}
+
+// close closes the file, returning the run written to it.
+func (rw *runWriter) close() (*sortRun, error) {
+	run := &sortRun{name: rw.file.Name()}
+	err := rw.buf.Flush()
+	if cerr := rw.file.Close(); err == nil {
+		err = cerr
+	}
+	return run, err
+}
+
+// writeSortRun writes the paragraphs of a run, sorted by date, to a new
+// temporary file in dir.
+func writeSortRun(dir string, paragraphs []ledgerParagraph) (*sortRun, error) {
+	slices.SortStableFunc(paragraphs, func(a, b ledgerParagraph) int {
+		return a.date.Compare(b.date)
+	})
+	rw, err := newRunWriter(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range paragraphs {
+		if err := rw.write(p); err != nil {
+			break
+		}
+	}
+	return rw.close()
+}
+
+// open opens the file of the run and reads its first paragraph.
+func (run *sortRun) open() error {
+	f, err := os.Open(run.name)
+	if err != nil {
+		return err
+	}
+	run.file = f
+	run.scanner = newLineScanner(f)
+	return run.advance()
+}
+
+// advance reads the next paragraph of the run, setting done at its end.
+func (run *sortRun) advance() error {
+	if !run.scanner.Scan() {
+		run.done = true
+		return scanError(run.scanner.Err(), run.lineNum)
+	}
+	run.lineNum++
+	date, err := time.Parse(time.RFC3339Nano, run.scanner.Text())
+	if err != nil {
+		return fmt.Errorf("corrupt sort run %s: %w", run.name, err)
+	}
+	run.next = ledgerParagraph{transaction: true, date: date}
+	for run.scanner.Scan() {
+		run.lineNum++
+		if run.scanner.Text() == "" {
+			break
+		}
+		run.next.lines = append(run.next.lines, run.scanner.Text())
+	}
+	return scanError(run.scanner.Err(), run.lineNum)
+}
+
+// close closes and removes the file of the run.
+func (run *sortRun) close() {
+	if run.file != nil {
+		run.file.Close()
+		run.file = nil
+	}
+	os.Remove(run.name)
+}
+
+// runHeap is a heap of open runs by their next date, then by their index,
+// so transactions of the same date keep their order.
+type runHeap []*sortRun
+
+func (h runHeap) Len() int { return len(h) }
+func (h runHeap) Less(i, j int) bool {
+	if c := h[i].next.date.Compare(h[j].next.date); c != 0 {
+		return c < 0
+	}
+	return h[i].index < h[j].index
+}
+func (h runHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *runHeap) Push(x any)   { *h = append(*h, x.(*sortRun)) }
+func (h *runHeap) Pop() any {
+	old := *h
+	run := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return run
+}
+
+// mergeRuns calls fn with the paragraphs of the runs, at most maxSortRuns of
+// them, in order of date, closing each run at its end.
+func mergeRuns(runs []*sortRun, fn func(p ledgerParagraph) error) error {
+	h := make(runHeap, 0, len(runs))
+	for i, run := range runs {
+		run.index = i
+		if err := run.open(); err != nil {
+			return err
+		}
+		if run.done {
+			run.close()
+			continue
+		}
+		h = append(h, run)
+	}
+	heap.Init(&h)
+	for len(h) > 0 {
+		run := h[0]
+		if err := fn(run.next); err != nil {
+			return err
+		}
+		if err := run.advance(); err != nil {
+			return err
+		}
+		if run.done {
+			heap.Pop(&h)
+			run.close()
+		} else {
+			heap.Fix(&h, 0)
+		}
+	}
+	return nil
+}
+
+// mergePasses merges consecutive runs, maxSortRuns at a time, until at most
+// maxSortRuns of them are left, returning those left.
+func mergePasses(dir string, runs []*sortRun) ([]*sortRun, error) {
+	for len(runs) > maxSortRuns {
+		var merged []*sortRun
+		for i := 0; i < len(runs); i += maxSortRuns {
+			group := runs[i:min(i+maxSortRuns, len(runs))]
+			if len(group) == 1 {
+				merged = append(merged, group[0])
+				continue
+			}
+			rw, err := newRunWriter(dir)
+			if err != nil {
+				return append(merged, runs[i:]...), err
+			}
+			err = mergeRuns(group, rw.write)
+			run, cerr := rw.close()
+			merged = append(merged, run)
+			if err == nil {
+				err = cerr
+			}
+			if err != nil {
+				// the runs left are closed by the caller
+				return append(merged, runs[i:]...), err
+			}
+		}
+		runs = merged
+	}
+	return runs, nil
+}
+
+// sortLedgerExternal writes the ledger read from r to w as sortLedger, holding
+// about runSize bytes of transactions in memory at a time: runs of that size
+// are sorted and spilled to temporary files in dir, or the default directory
+// for temporary files if empty, and then merged. Paragraphs without a
+// transaction are held in memory throughout.
+func sortLedgerExternal(w io.Writer, r io.Reader, runSize int, dir string) (err error) {
+	var runs []*sortRun
+	defer func() {
+		for _, run := range runs {
+			run.close()
+		}
+	}()
+
+	var others []placedParagraph
+	var pending []ledgerParagraph
+	pendingSize, count := 0, 0
+	spill := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		run, err := writeSortRun(dir, pending)
+		if run != nil {
+			runs = append(runs, run)
+		}
+		pending, pendingSize = nil, 0
+		return err
+	}
+	err = scanParagraphs(r, func(p ledgerParagraph) error {
+		if !p.transaction {
+			others = append(others, placedParagraph{paragraph: p, after: count})
+			return nil
+		}
+		pending = append(pending, p)
+		count++
+		for _, line := range p.lines {
+			pendingSize += len(line) + len(newLine)
+		}
+		if pendingSize >= runSize {
+			return spill()
+		}
+		return nil
+	})
+	if err == nil {
+		err = spill()
+	}
+	if err == nil {
+		runs, err = mergePasses(dir, runs)
+	}
+	if err != nil {
+		return err
+	}
+
+	buf := bufio.NewWriter(w)
+	first := true
+	write := func(p ledgerParagraph) {
+		if !first {
+			buf.WriteString(newLine)
+		}
+		first = false
+		for _, line := range p.lines {
+			buf.WriteString(line)
+			buf.WriteString(newLine)
+		}
+	}
+	written := 0
+	err = mergeRuns(runs, func(p ledgerParagraph) error {
+		for len(others) > 0 && others[0].after == written {
+			write(others[0].paragraph)
+			others = others[1:]
+		}
+		write(p)
+		written++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, other := range others {
+		write(other.paragraph)
+	}
+	return buf.Flush()
+}
+
+// rewriteLedgerFileStreaming replaces the ledger file with what rewrite writes
+// from it, through a temporary file next to it, so neither is held in
+// memory. The file "-" is rewritten from standard input to standard output.
+func rewriteLedgerFileStreaming(filename string, rewrite func(w io.Writer, r io.Reader) error) error {
+	if filename == "-" {
+		return rewrite(os.Stdout, os.Stdin)
+	}
+
+	src, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	fi, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+	if err := rewrite(out, src); err != nil {
+		out.Close()
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(out.Name(), fi.Mode()); err != nil {
+		return err
+	}
+	return os.Rename(out.Name(), filename)
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_sortLedger(t *testing.T) {
+	input := `; journal header
+
+~ Monthly  Rent
+    Expenses:Rent  1000
+    Assets:Checking
+
+; paid late
+2024/03/01 Rent
+    Expenses:Rent  1000
+    Assets:Checking
+
+
+include other.ledger
+2024/01/15 Employer
+    Assets:Checking  2000
+    Income:Salary
+
+2024/03/01 Grocery Store
+    Expenses:Food  42
+    Assets:Checking
+
+2024/02/01 Rent
+    Expenses:Rent  1000
+    Assets:Checking
+`
+	expected := `; journal header
+
+~ Monthly  Rent
+    Expenses:Rent  1000
+    Assets:Checking
+
+include other.ledger
+2024/01/15 Employer
+    Assets:Checking  2000
+    Income:Salary
+
+2024/02/01 Rent
+    Expenses:Rent  1000
+    Assets:Checking
+
+; paid late
+2024/03/01 Rent
+    Expenses:Rent  1000
+    Assets:Checking
+
+2024/03/01 Grocery Store
+    Expenses:Food  42
+    Assets:Checking
+`
+
+	var out bytes.Buffer
+	if err := sortLedger(&out, strings.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != expected {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+}
+
+func Test_sortLedgerExternal(t *testing.T) {
+	var input strings.Builder
+	input.WriteString("; journal header\n\n")
+	for i := range 200 {
+		if i%50 == 25 {
+			input.WriteString("~ Monthly  Rent\n    Expenses:Rent  1000\n    Assets:Checking\n\n")
+		}
+		// dates out of order, with ties
+		fmt.Fprintf(&input, "; note %d\n2024/%02d/%02d Payee %d\n    Expenses:Food  %d\n    Assets:Checking\n\n", i, i*7%12+1, i*13%5+1, i, i)
+	}
+
+	var want bytes.Buffer
+	if err := sortLedger(&want, strings.NewReader(input.String())); err != nil {
+		t.Fatal(err)
+	}
+	defer func(runs int) { maxSortRuns = runs }(maxSortRuns)
+	for _, runs := range []int{2, 3, 64} {
+		maxSortRuns = runs
+		for _, runSize := range []int{1, 1000, 1 << 20} {
+			dir := t.TempDir()
+			var out bytes.Buffer
+			if err := sortLedgerExternal(&out, strings.NewReader(input.String()), runSize, dir); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != want.String() {
+				t.Errorf("run size %d, %d runs merged: output differs from sortLedger", runSize, runs)
+			}
+			if left, _ := os.ReadDir(dir); len(left) > 0 {
+				t.Errorf("run size %d, %d runs merged: temporary files left: %v", runSize, runs, left)
+			}
+		}
+	}
+}
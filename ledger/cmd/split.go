@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/spf13/cobra"
+)
+
+var splitBy string
+var splitDir string
+var splitMaster string
+var splitAccounts string
+
+// splitJournal splits the paragraphs of the ledger read from r into the
+// transactions of each year and the rest of the ledger, see readParagraphs.
+func splitJournal(r io.Reader) (rest []ledgerParagraph, years map[int][]ledgerParagraph, err error) {
+	paragraphs, err := readParagraphs(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	years = make(map[int][]ledgerParagraph)
+	for _, p := range paragraphs {
+		if p.transaction {
+			years[p.date.Year()] = append(years[p.date.Year()], p)
+		} else {
+			rest = append(rest, p)
+		}
+	}
+	return rest, years, nil
+}
+
+// writeParagraphs writes the paragraphs separated by a blank line.
+func writeParagraphs(w io.StringWriter, paragraphs []ledgerParagraph) {
+	for i, p := range paragraphs {
+		if i > 0 {
+			w.WriteString(newLine)
+		}
+		for _, line := range p.lines {
+			w.WriteString(line)
+			w.WriteString(newLine)
+		}
+	}
+}
+
+// balanceSheetTransactions returns copies of the transactions holding only
+// the postings to the accounts with the prefixes, or their sub-accounts,
+// other than eqAccount, which balances the opening entries.
+func balanceSheetTransactions(generalLedger []*ledger.Transaction, prefixes []string, eqAccount string) []*ledger.Transaction {
+	section := statementSection{Prefixes: prefixes}
+	var filtered []*ledger.Transaction
+	for _, trans := range generalLedger {
+		var postings []ledger.Account
+		for _, acc := range trans.AccountChanges {
+			if section.matches(acc.Name) && acc.Name != eqAccount {
+				postings = append(postings, acc)
+			}
+		}
+		if len(postings) > 0 {
+			t := *trans
+			t.AccountChanges = postings
+			filtered = append(filtered, &t)
+		}
+	}
+	return filtered
+}
+
+// splitYearFiles returns the contents of the file of each year. Each year but
+// the first opens with the balances of the accounts with the prefixes at the
+// end of the year before, and each year but the last closes them, so a year
+// file can be used on its own while the years included together still add up.
+func splitYearFiles(years map[int][]ledgerParagraph, generalLedger []*ledger.Transaction, prefixes []string, eqAccount string) map[int]string {
+	order := slices.Sorted(maps.Keys(years))
+
+	balanceSheet := balanceSheetTransactions(generalLedger, prefixes, eqAccount)
+	balancesBefore := func(year int) *ledger.Transaction {
+		var before []*ledger.Transaction
+		for _, trans := range balanceSheet {
+			if trans.Date.Year() < year {
+				before = append(before, trans)
+			}
+		}
+		return equityTransaction(before, nil, eqAccount)
+	}
+
+	files := make(map[int]string, len(years))
+	for yIdx, year := range order {
+		var buf strings.Builder
+		if yIdx > 0 {
+			opening := balancesBefore(year)
+			opening.Date = time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
+			if len(opening.AccountChanges) > 0 {
+				WriteTransaction(&buf, opening, 80)
+			}
+		}
+		writeParagraphs(&buf, years[year])
+		if yIdx < len(order)-1 {
+			closing := balancesBefore(year + 1)
+			closing.Date = time.Date(year, time.December, 31, 0, 0, 0, 0, time.Local)
+			closing.Payee = "Closing Balances"
+			for i := range closing.AccountChanges {
+				closing.AccountChanges[i].Balance = closing.AccountChanges[i].Balance.Neg()
+			}
+			if len(closing.AccountChanges) > 0 {
+				buf.WriteString(newLine)
+				WriteTransaction(&buf, closing, 80)
+			}
+		}
+		files[year] = strings.TrimRight(buf.String(), newLine) + newLine
+	}
+	return files
+}
+
+// splitCmd represents the split command
+var splitCmd = &cobra.Command{
+	Use:   "split",
+	Short: "Split the ledger file into a file per year",
+	Long: `Split the ledger file into a file per year in a directory, and write a master
+file with the rest of the ledger file (comments, directives, prices) and an
+include of each year file.
+
+Each year file opens with the balances of the asset, liability and equity
+accounts at the end of the year before, and each year but the last closes with
+the opposite entry, so a year file can be used on its own while the master file
+still adds up to the same balances.`,
+	Args: cobra.NoArgs,
+	Run: func(_ *cobra.Command, _ []string) {
+		if splitBy != "year" {
+			fatalln("unknown split:", splitBy)
+		}
+		if ledgerFilePath == "-" {
+			fatalln("can not split stdin")
+		}
+		if splitMaster == "" {
+			splitMaster = ledgerFilePath
+		}
+
+		ledgerFile, err := os.Open(ledgerFilePath)
+		if err != nil {
+			fatalln(err)
+		}
+		rest, years, err := splitJournal(ledgerFile)
+		ledgerFile.Close()
+		if err != nil {
+			fatalln(fmt.Errorf("%s: %w", ledgerFilePath, err))
+		}
+
+		parsed, err := ledger.ParseLedgerFile(ledgerFilePath)
+		if err != nil {
+			fatalln(err)
+		}
+		// transactions of included files stay where they are
+		var generalLedger []*ledger.Transaction
+		for _, trans := range parsed {
+			if trans.Filename == ledgerFilePath {
+				generalLedger = append(generalLedger, trans)
+			}
+		}
+		slices.SortStableFunc(generalLedger, func(a, b *ledger.Transaction) int {
+			return a.Date.Compare(b.Date)
+		})
+
+		files := splitYearFiles(years, generalLedger, splitPrefixes(splitAccounts), equityAccount)
+
+		ext := filepath.Ext(ledgerFilePath)
+		if ext == "" {
+			ext = ".ledger"
+		}
+		yearPaths := make(map[int]string, len(files))
+		for year := range files {
+			yearPaths[year] = filepath.Join(splitDir, fmt.Sprintf("%d%s", year, ext))
+			if _, serr := os.Stat(yearPaths[year]); !errors.Is(serr, os.ErrNotExist) {
+				fatalln("will not overwrite", yearPaths[year])
+			}
+		}
+
+		if err := os.MkdirAll(splitDir, 0755); err != nil {
+			fatalln(err)
+		}
+		var includes []string
+		for _, year := range slices.Sorted(maps.Keys(files)) {
+			if err := os.WriteFile(yearPaths[year], []byte(files[year]), 0644); err != nil {
+				fatalln(err)
+			}
+			rel, rerr := filepath.Rel(filepath.Dir(splitMaster), yearPaths[year])
+			if rerr != nil {
+				rel = yearPaths[year]
+			}
+			includes = append(includes, "include "+filepath.ToSlash(rel))
+		}
+
+		var master strings.Builder
+		writeParagraphs(&master, append(rest, ledgerParagraph{lines: includes}))
+		if err := os.WriteFile(splitMaster, []byte(master.String()), 0644); err != nil {
+			fatalln(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(splitCmd)
+
+	splitCmd.Flags().StringVar(&splitBy, "by", "year", "Split into a file per year.")
+	splitCmd.Flags().StringVar(&splitDir, "dir", ".", "Directory of the year files.")
+	splitCmd.Flags().StringVar(&splitMaster, "master", "", "Master file to write (default is the ledger file, which is replaced).")
+	splitCmd.Flags().StringVar(&splitAccounts, "accounts", "Assets,Liabilities,Equity", "Comma separated account prefixes carried from year to year.")
+	splitCmd.Flags().StringVar(&equityAccount, "account", "Equity:Opening Balances", "Account receiving the balancing amount.")
+}
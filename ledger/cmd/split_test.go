@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/shopspring/decimal"
+)
+
+func Test_splitYearFiles(t *testing.T) {
+	input := `; header
+
+; opening
+2023/12/01 Opening
+    Assets:Checking    1000
+    Equity:Opening Balances
+
+2024/01/05 Grocer
+    Expenses:Food    50
+    Assets:Checking
+`
+	rest, years, err := splitJournal(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 1 || rest[0].lines[0] != "; header" {
+		t.Errorf("rest = %v", rest)
+	}
+
+	day := func(y, m, d int) time.Time { return time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.Local) }
+	generalLedger := []*ledger.Transaction{
+		{Date: day(2023, 12, 1), Payee: "Opening", AccountChanges: []ledger.Account{
+			{Name: "Assets:Checking", Balance: decimal.NewFromInt(1000)},
+			{Name: "Equity:Opening Balances", Balance: decimal.NewFromInt(-1000)},
+		}},
+		{Date: day(2024, 1, 5), Payee: "Grocer", AccountChanges: []ledger.Account{
+			{Name: "Expenses:Food", Balance: decimal.NewFromInt(50)},
+			{Name: "Assets:Checking", Balance: decimal.NewFromInt(-50)},
+		}},
+	}
+	files := splitYearFiles(years, generalLedger, []string{"Assets", "Liabilities", "Equity"}, "Equity:Opening Balances")
+
+	want2023 := `; opening
+2023/12/01 Opening
+    Assets:Checking    1000
+    Equity:Opening Balances
+
+2023/12/31 Closing Balances
+    Assets:Checking                                                     -1000.00
+    Equity:Opening Balances                                              1000.00
+`
+	want2024 := `2024/01/01 Opening Balances
+    Assets:Checking                                                      1000.00
+    Equity:Opening Balances                                             -1000.00
+
+2024/01/05 Grocer
+    Expenses:Food    50
+    Assets:Checking
+`
+	if files[2023] != want2023 {
+		t.Errorf("2023 got\n%s\nwant\n%s", files[2023], want2023)
+	}
+	if files[2024] != want2024 {
+		t.Errorf("2024 got\n%s\nwant\n%s", files[2024], want2024)
+	}
+}
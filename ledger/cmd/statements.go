@@ -0,0 +1,352 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/howeyc/ledger/ledger/internal/fastcolor"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+)
+
+var statementPeriod string
+var statementAssets, statementLiabilities, statementEquity string
+var statementIncome, statementExpenses string
+var statementCash string
+
+// statementSection is a group of accounts in a financial statement, selected
+// by account name prefix.
+type statementSection struct {
+	Title    string
+	Prefixes []string
+	// Negate flips the sign of amounts so that credit balances (income,
+	// liabilities, equity) show as positive.
+	Negate bool
+	// Net includes the section in the net total of the statement.
+	Net bool
+}
+
+// matches reports whether the account is one of the section prefixes or a
+// sub-account of one.
+func (s *statementSection) matches(name string) bool {
+	for _, prefix := range s.Prefixes {
+		if name == prefix || strings.HasPrefix(name, prefix+":") {
+			return true
+		}
+	}
+	return false
+}
+
+type statementRow struct {
+	Account  string
+	Currency string
+	Amounts  []decimal.Decimal
+}
+
+// statementSectionReport holds the rows of a section, one per account and
+// currency, and the section totals per currency, with an amount per period.
+type statementSectionReport struct {
+	statementSection
+	Rows   []statementRow
+	Totals map[string][]decimal.Decimal
+}
+
+// statementReport totals the postings of each period per account for the
+// sections. Each posting is counted in the first section it matches. When
+// cumulative is set, each period holds the balance at its end rather than
+// the change within it.
+//
+// The net total per currency is the sum of the sections marked Net, negated
+// when netNegate is set.
+func statementReport(periods []*ledger.RangeTransactions, sections []statementSection, cumulative, netNegate bool) (reports []statementSectionReport, net map[string][]decimal.Decimal) {
+	type rowKey struct {
+		name     string
+		currency string
+	}
+	rowAmounts := make([]map[rowKey][]decimal.Decimal, len(sections))
+	for i := range sections {
+		rowAmounts[i] = make(map[rowKey][]decimal.Decimal)
+	}
+	net = make(map[string][]decimal.Decimal)
+
+	for col, rt := range periods {
+		for _, trans := range rt.Transactions {
+			for _, acc := range trans.AccountChanges {
+				idx := slices.IndexFunc(sections, func(s statementSection) bool {
+					return s.matches(acc.Name)
+				})
+				if idx < 0 {
+					continue
+				}
+				key := rowKey{acc.Name, acc.Currency}
+				if _, ok := rowAmounts[idx][key]; !ok {
+					rowAmounts[idx][key] = make([]decimal.Decimal, len(periods))
+				}
+				if _, ok := net[acc.Currency]; !ok {
+					net[acc.Currency] = make([]decimal.Decimal, len(periods))
+				}
+
+				amount := acc.Balance
+				if sections[idx].Net {
+					if netNegate {
+						net[acc.Currency][col] = net[acc.Currency][col].Sub(amount)
+					} else {
+						net[acc.Currency][col] = net[acc.Currency][col].Add(amount)
+					}
+				}
+				if sections[idx].Negate {
+					amount = amount.Neg()
+				}
+				rowAmounts[idx][key][col] = rowAmounts[idx][key][col].Add(amount)
+			}
+		}
+	}
+
+	accumulate := func(amounts []decimal.Decimal) {
+		if cumulative {
+			for col := 1; col < len(amounts); col++ {
+				amounts[col] = amounts[col].Add(amounts[col-1])
+			}
+		}
+	}
+	for _, amounts := range net {
+		accumulate(amounts)
+	}
+
+	for i, section := range sections {
+		report := statementSectionReport{statementSection: section, Totals: make(map[string][]decimal.Decimal)}
+		for key, amounts := range rowAmounts[i] {
+			accumulate(amounts)
+			report.Rows = append(report.Rows, statementRow{Account: key.name, Currency: key.currency, Amounts: amounts})
+
+			if _, ok := report.Totals[key.currency]; !ok {
+				report.Totals[key.currency] = make([]decimal.Decimal, len(periods))
+			}
+			for col, amount := range amounts {
+				report.Totals[key.currency][col] = report.Totals[key.currency][col].Add(amount)
+			}
+		}
+		slices.SortFunc(report.Rows, func(a, b statementRow) int {
+			if c := strings.Compare(a.Account, b.Account); c != 0 {
+				return c
+			}
+			return strings.Compare(a.Currency, b.Currency)
+		})
+		reports = append(reports, report)
+	}
+	return reports, net
+}
+
+// PrintStatement prints a financial statement with a column per period label,
+// the sections with their accounts and totals, and the net total, formatted
+// to a window set to a width of columns.
+func PrintStatement(w io.Writer, labels []string, reports []statementSectionReport, netTitle string, net map[string][]decimal.Decimal, columns int) {
+	// a 12-width column for each period, each with a leading space
+	minColumns := 13*len(labels) + 12
+	if columns < minColumns {
+		columns = minColumns
+		fmt.Fprintf(os.Stderr, "warning: `columns` too small, setting to %d\n", columns)
+	}
+	accWidth := columns - 13*len(labels)
+
+	colorNeg := fastcolor.CurrentTheme.Negative
+	colorAccount := fastcolor.CurrentTheme.Account
+	colorHeader := fastcolor.CurrentTheme.Header
+	colorReset := fastcolor.Reset
+
+	buf := bufio.NewWriter(w)
+	writeAmounts := func(currency string, amounts []decimal.Decimal) {
+		for _, amount := range amounts {
+			outBalanceString := amount.StringFixedBank(2)
+			if currency != "" {
+				outBalanceString = currency + " " + outBalanceString
+			}
+			amtColor := colorReset
+			if amount.Sign() < 0 {
+				amtColor = colorNeg
+			}
+			buf.WriteString(" ")
+			amtColor.WriteStringFixed(buf, outBalanceString, 12, true)
+		}
+		buf.WriteString(newLine)
+	}
+	writeTotals := func(title string, totals map[string][]decimal.Decimal) {
+		currencies := make(map[string]decimal.Decimal, len(totals))
+		for cur := range totals {
+			currencies[cur] = decimal.Zero
+		}
+		sorted := sortedCurrencies(currencies)
+		if len(sorted) == 0 {
+			sorted = []string{""}
+			totals = map[string][]decimal.Decimal{"": make([]decimal.Decimal, len(labels))}
+		}
+		for i, cur := range sorted {
+			if i > 0 {
+				title = ""
+			}
+			colorHeader.WriteStringFixed(buf, title, accWidth, false)
+			writeAmounts(cur, totals[cur])
+		}
+	}
+
+	colorHeader.WriteStringFixed(buf, "Account", accWidth, false)
+	for _, label := range labels {
+		buf.WriteString(" ")
+		colorHeader.WriteStringFixed(buf, label, 12, true)
+	}
+	buf.WriteString(newLine)
+
+	for _, report := range reports {
+		colorHeader.WriteStringFixed(buf, report.Title, len(report.Title), false)
+		buf.WriteString(newLine)
+		for _, row := range report.Rows {
+			colorAccount.WriteStringFixed(buf, "  "+row.Account, accWidth, false)
+			writeAmounts(row.Currency, row.Amounts)
+		}
+		fmt.Fprintln(buf, strings.Repeat("-", columns))
+		writeTotals("Total "+report.Title, report.Totals)
+		buf.WriteString(newLine)
+	}
+	fmt.Fprintln(buf, strings.Repeat("=", columns))
+	writeTotals(netTitle, net)
+	buf.Flush()
+}
+
+// splitPrefixes splits a comma separated list of account prefixes.
+func splitPrefixes(s string) []string {
+	var prefixes []string
+	for _, prefix := range strings.Split(s, ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+// runStatement prints a financial statement of the sections for the
+// transactions selected on the command line, in a single column or a column
+// per period. Balance statements (cumulative) label columns by the end of
+// each period, flow statements by the start.
+func runStatement(args []string, sections []statementSection, cumulative, netNegate bool, netTitle string) {
+	generalLedger, err := cliTransactions()
+	if err != nil {
+		fatalln(err)
+	}
+	generalLedger = cliQuery(args).Filter(generalLedger)
+	if len(generalLedger) < 1 {
+		return
+	}
+
+	var periods []*ledger.RangeTransactions
+	if statementPeriod == "" {
+		periods = []*ledger.RangeTransactions{{
+			Start:        generalLedger[0].Date,
+			End:          generalLedger[len(generalLedger)-1].Date,
+			Transactions: generalLedger,
+		}}
+	} else {
+		lperiod, ok := ledger.ParsePeriod(statementPeriod)
+		if !ok {
+			fatalln("unknown period:", statementPeriod)
+		}
+		periods = ledger.TransactionsByPeriod(generalLedger, lperiod)
+	}
+
+	labels := make([]string, len(periods))
+	for i, rt := range periods {
+		labelDate := rt.Start
+		if cumulative {
+			labelDate = rt.End
+		}
+		labels[i] = labelDate.Format(transactionDateFormat)
+	}
+
+	reports, net := statementReport(periods, sections, cumulative, netNegate)
+	PrintStatement(cliOutput, labels, reports, netTitle, net, columnWidth)
+}
+
+// incomeStatementCmd represents the incomestatement command
+var incomeStatementCmd = &cobra.Command{
+	Aliases: []string{"is"},
+	Use:     "incomestatement [query]...",
+	Short:   "Print income and expenses with net income",
+	Long: `Print the income and expenses of each period, and the net income (income
+less expenses). Income is shown as positive.`,
+	Run: func(_ *cobra.Command, args []string) {
+		sections := []statementSection{
+			{Title: "Income", Prefixes: splitPrefixes(statementIncome), Negate: true, Net: true},
+			{Title: "Expenses", Prefixes: splitPrefixes(statementExpenses), Net: true},
+		}
+		runStatement(args, sections, false, true, "Net Income")
+	},
+}
+
+// balanceSheetCmd represents the balancesheet command
+var balanceSheetCmd = &cobra.Command{
+	Aliases: []string{"bs"},
+	Use:     "balancesheet [query]...",
+	Short:   "Print assets, liabilities and equity with net assets",
+	Long: `Print the balances of assets, liabilities and equity at the end of each
+period, and the net assets (assets less liabilities). Liabilities and equity
+are shown as positive. Balances include only the transactions within the date
+range.`,
+	Run: func(_ *cobra.Command, args []string) {
+		sections := []statementSection{
+			{Title: "Assets", Prefixes: splitPrefixes(statementAssets), Net: true},
+			{Title: "Liabilities", Prefixes: splitPrefixes(statementLiabilities), Negate: true, Net: true},
+			{Title: "Equity", Prefixes: splitPrefixes(statementEquity), Negate: true},
+		}
+		runStatement(args, sections, true, false, "Net Assets")
+	},
+}
+
+// cashFlowCmd represents the cashflow command
+var cashFlowCmd = &cobra.Command{
+	Aliases: []string{"cf"},
+	Use:     "cashflow [query]...",
+	Short:   "Print changes in cash accounts with net cash flow",
+	Long: `Print the changes in cash accounts (by default all assets) over each period,
+and the net cash flow.`,
+	Run: func(_ *cobra.Command, args []string) {
+		sections := []statementSection{
+			{Title: "Cash", Prefixes: splitPrefixes(statementCash), Net: true},
+		}
+		runStatement(args, sections, false, false, "Net Cash Flow")
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{incomeStatementCmd, balanceSheetCmd, cashFlowCmd} {
+		rootCmd.AddCommand(cmd)
+		watchCommand(cmd)
+
+		var startDate, endDate time.Time
+		startDate = time.Date(1970, 1, 1, 0, 0, 0, 0, time.Local)
+		endDate = time.Now().Add(1<<63 - 1)
+		cmd.Flags().StringVarP(&startString, "begin-date", "b", startDate.Format(transactionDateFormat), "Begin date of transaction processing.")
+		cmd.Flags().StringVarP(&endString, "end-date", "e", endDate.Format(transactionDateFormat), "End date of transaction processing.")
+		cmd.Flags().StringVar(&payeeFilter, "payee", "", "Filter output to payees that contain this string.")
+		cmd.Flags().IntVar(&columnWidth, "columns", 80, "Set a column width for output.")
+		cmd.Flags().BoolVar(&columnWide, "wide", false, "Wide output (use terminal width).")
+
+		cmd.Flags().StringVar(&statementPeriod, "period", "", "Split output into period columns (Monthly,Quarterly,SemiYearly,Yearly).")
+		cmd.Flags().BoolVarP(&statusCleared, "cleared", "C", false, "Only include cleared postings.")
+		cmd.Flags().BoolVarP(&statusPending, "pending", "P", false, "Only include pending postings.")
+		cmd.Flags().BoolVarP(&statusUncleared, "uncleared", "U", false, "Only include uncleared postings.")
+	}
+
+	incomeStatementCmd.Flags().StringVar(&statementIncome, "income", "Income", "Comma separated account prefixes of income.")
+	incomeStatementCmd.Flags().StringVar(&statementExpenses, "expenses", "Expenses", "Comma separated account prefixes of expenses.")
+
+	balanceSheetCmd.Flags().StringVar(&statementAssets, "assets", "Assets", "Comma separated account prefixes of assets.")
+	balanceSheetCmd.Flags().StringVar(&statementLiabilities, "liabilities", "Liabilities", "Comma separated account prefixes of liabilities.")
+	balanceSheetCmd.Flags().StringVar(&statementEquity, "equity", "Equity", "Comma separated account prefixes of equity.")
+
+	cashFlowCmd.Flags().StringVar(&statementCash, "cash", "Assets", "Comma separated account prefixes of cash accounts.")
+}
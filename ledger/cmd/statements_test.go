@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/shopspring/decimal"
+)
+
+func Test_statementReport(t *testing.T) {
+	jan := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	periods := []*ledger.RangeTransactions{
+		{Start: jan, End: feb.AddDate(0, 0, -1), Transactions: []*ledger.Transaction{
+			{Date: jan, AccountChanges: []ledger.Account{
+				{Name: "Assets:Checking", Balance: decimal.NewFromInt(1000)},
+				{Name: "Income:Salary", Balance: decimal.NewFromInt(-1000)},
+			}},
+			{Date: jan, AccountChanges: []ledger.Account{
+				{Name: "Expenses:Food", Balance: decimal.NewFromInt(300)},
+				{Name: "Assets:Checking", Balance: decimal.NewFromInt(-300)},
+			}},
+		}},
+		{Start: feb, End: feb.AddDate(0, 1, -1), Transactions: []*ledger.Transaction{
+			{Date: feb, AccountChanges: []ledger.Account{
+				{Name: "Expenses:Food", Balance: decimal.NewFromInt(200)},
+				{Name: "Assets:Checking", Balance: decimal.NewFromInt(-200)},
+			}},
+		}},
+	}
+
+	check := func(name string, got []decimal.Decimal, want ...int64) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Fatalf("%s: got %v, want %v", name, got, want)
+		}
+		for i := range want {
+			if !got[i].Equal(decimal.NewFromInt(want[i])) {
+				t.Errorf("%s[%d]: got %s, want %d", name, i, got[i], want[i])
+			}
+		}
+	}
+
+	income := []statementSection{
+		{Title: "Income", Prefixes: []string{"Income"}, Negate: true, Net: true},
+		{Title: "Expenses", Prefixes: []string{"Expenses"}, Net: true},
+	}
+	reports, net := statementReport(periods, income, false, true)
+	if len(reports) != 2 || len(reports[0].Rows) != 1 || len(reports[1].Rows) != 1 {
+		t.Fatalf("unexpected reports: %v", reports)
+	}
+	check("income", reports[0].Totals[""], 1000, 0)
+	check("expenses", reports[1].Totals[""], 300, 200)
+	check("net income", net[""], 700, -200)
+
+	assets := []statementSection{
+		{Title: "Assets", Prefixes: []string{"Assets"}, Net: true},
+	}
+	reports, net = statementReport(periods, assets, true, false)
+	if len(reports[0].Rows) != 1 || reports[0].Rows[0].Account != "Assets:Checking" {
+		t.Fatalf("unexpected rows: %v", reports[0].Rows)
+	}
+	check("assets", reports[0].Rows[0].Amounts, 700, 500)
+	check("net assets", net[""], 700, 500)
+}
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"log"
 	"math"
 	"strings"
 	"time"
@@ -19,7 +18,7 @@ var statsCmd = &cobra.Command{
 	Run: func(_ *cobra.Command, _ []string) {
 		transactions, terr := cliTransactions()
 		if terr != nil {
-			log.Fatalln(terr)
+			fatalln(terr)
 		}
 		printStats(transactions)
 	},
@@ -27,7 +26,7 @@ var statsCmd = &cobra.Command{
 
 func printStats(generalLedger []*ledger.Transaction) {
 	if len(generalLedger) < 1 {
-		fmt.Println("Empty ledger.")
+		fmt.Fprintln(cliOutput, "Empty ledger.")
 		return
 	}
 
@@ -52,14 +51,15 @@ func printStats(generalLedger []*ledger.Transaction) {
 
 	days := math.Floor(endDate.Sub(startDate).Hours() / 24)
 
-	fmt.Printf("%-25s : %s to %s (%s)\n", "Time period", startDate.Format(time.DateOnly), endDate.Format(time.DateOnly), durafmt.Parse(endDate.Sub(startDate)).String())
-	fmt.Printf("%-25s : %d\n", "Unique payees", len(cipayees))
-	fmt.Printf("%-25s : %d\n", "Unique accounts", len(accounts))
-	fmt.Printf("%-25s : %d (%.1f per day)\n", "Number of transactions", len(generalLedger), float64(len(generalLedger))/days)
-	fmt.Printf("%-25s : %d (%.1f per day)\n", "Number of postings", postings, float64(postings)/days)
-	fmt.Printf("%-25s : %s\n", "Time since last post", durafmt.ParseShort(time.Since(endDate)).String())
+	fmt.Fprintf(cliOutput, "%-25s : %s to %s (%s)\n", "Time period", startDate.Format(time.DateOnly), endDate.Format(time.DateOnly), durafmt.Parse(endDate.Sub(startDate)).String())
+	fmt.Fprintf(cliOutput, "%-25s : %d\n", "Unique payees", len(cipayees))
+	fmt.Fprintf(cliOutput, "%-25s : %d\n", "Unique accounts", len(accounts))
+	fmt.Fprintf(cliOutput, "%-25s : %d (%.1f per day)\n", "Number of transactions", len(generalLedger), float64(len(generalLedger))/days)
+	fmt.Fprintf(cliOutput, "%-25s : %d (%.1f per day)\n", "Number of postings", postings, float64(postings)/days)
+	fmt.Fprintf(cliOutput, "%-25s : %s\n", "Time since last post", durafmt.ParseShort(time.Since(endDate)).String())
 }
 
 func init() {
 	rootCmd.AddCommand(statsCmd)
+	watchCommand(statsCmd)
 }
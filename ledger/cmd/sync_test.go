@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/howeyc/ledger"
+	"github.com/howeyc/ledger/ledger/simplefin"
+)
+
+func Test_loadSyncConfig(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "sync.toml")
+	data := "access_url = \"https://user:pw@bridge.example/simplefin\"\ndays = 14\n\n[[account]]\nid = \"ACT-1\"\naccount = \"Checking\"\n"
+	if err := os.WriteFile(filename, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("SIMPLEFIN_ACCESS_URL", "")
+	config, err := loadSyncConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.AccessURL != "https://user:pw@bridge.example/simplefin" || config.Days != 14 ||
+		len(config.Accounts) != 1 || config.Accounts[0] != (syncAccountConfig{ID: "ACT-1", Account: "Checking"}) {
+		t.Errorf("unexpected config: %+v", config)
+	}
+
+	t.Setenv("SIMPLEFIN_ACCESS_URL", "https://other@bridge.example/simplefin")
+	if config, err = loadSyncConfig(filename); err != nil || config.AccessURL != "https://other@bridge.example/simplefin" {
+		t.Errorf("access URL = %q, %v, want the one of the environment", config.AccessURL, err)
+	}
+}
+
+func Test_importSimpleFIN(t *testing.T) {
+	imp, err := newSyncImporter("Assets:Checking", nil, &importState{seen: map[string]bool{"Assets:Checking\tT0": true}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf strings.Builder
+	imp.output = &buf
+	imp.importSimpleFIN(simplefin.Account{
+		ID:       "ACT-1",
+		Currency: "CAD",
+		Transactions: []simplefin.Transaction{
+			{ID: "T2", Posted: 1704240000, Amount: "1000.00", Description: "PAYROLL DEPOSIT", Payee: "Employer"},
+			{ID: "T1", Posted: 1704153600, Amount: "-50.00", Description: "CORNER GROCER"},
+			{ID: "T0", Posted: 1704067200, Amount: "-5.00", Description: "ALREADY IMPORTED"},
+		},
+	})
+
+	trans, err := ledger.ParseLedger(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("%v in %q", err, buf.String())
+	}
+	if len(trans) != 2 {
+		t.Fatalf("got %d transactions, want 2: %q", len(trans), buf.String())
+	}
+	// in date order, the state file skips T0
+	if trans[0].Payee != "CORNER GROCER" || trans[1].Payee != "Employer" {
+		t.Errorf("payees = %q, %q", trans[0].Payee, trans[1].Payee)
+	}
+	acc := trans[1].AccountChanges[0]
+	if acc.Name != "Assets:Checking" || acc.Currency != "CAD" || acc.Balance.String() != "1000" {
+		t.Errorf("posting = %+v", acc)
+	}
+	if !strings.Contains(buf.String(), ";PAYROLL DEPOSIT") {
+		t.Errorf("got %q, want the description as comment", buf.String())
+	}
+}
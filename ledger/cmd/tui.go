@@ -0,0 +1,353 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// tuiKey is a key read from the terminal.
+type tuiKey int
+
+const (
+	keyNone tuiKey = iota
+	keyUp
+	keyDown
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+	keyEnter
+	keyBack
+	keyEscape
+	keyRune
+)
+
+// decodeKey decodes the bytes of a single read from the terminal in raw mode.
+// For keyRune the rune typed is returned as well.
+func decodeKey(b []byte) (tuiKey, rune) {
+	switch s := string(b); s {
+	case "\x1b[A", "\x1bOA":
+		return keyUp, 0
+	case "\x1b[B", "\x1bOB":
+		return keyDown, 0
+	case "\x1b[5~":
+		return keyPageUp, 0
+	case "\x1b[6~", " ":
+		return keyPageDown, 0
+	case "\x1b[H", "\x1b[1~", "\x1bOH":
+		return keyHome, 0
+	case "\x1b[F", "\x1b[4~", "\x1bOF":
+		return keyEnd, 0
+	case "\r", "\n":
+		return keyEnter, 0
+	case "\x7f", "\b":
+		return keyBack, 0
+	case "\x1b":
+		return keyEscape, 0
+	default:
+		if strings.HasPrefix(s, "\x1b") || len(s) == 0 {
+			return keyNone, 0
+		}
+		r := []rune(s)[0]
+		if r < ' ' {
+			return keyNone, 0
+		}
+		return keyRune, r
+	}
+}
+
+// tuiView is a screen of report lines, built by one of the report functions
+// for the terminal width. Lines with a key (an account name) can be opened.
+type tuiView struct {
+	title string
+	build func(width int) (lines, keys []string)
+	open  func(key string) *tuiView
+
+	width          int
+	lines, keys    []string
+	cursor, offset int
+}
+
+// layout builds the lines again when the width changed.
+func (v *tuiView) layout(width int) {
+	if width == v.width && v.lines != nil {
+		return
+	}
+	v.lines, v.keys = v.build(width)
+	v.width = width
+	v.move(0, 1)
+}
+
+// move moves the cursor by delta lines, scrolling to keep it within the
+// height lines shown.
+func (v *tuiView) move(delta, height int) {
+	v.cursor = max(0, min(v.cursor+delta, len(v.lines)-1))
+	if v.cursor < v.offset {
+		v.offset = v.cursor
+	}
+	if v.cursor >= v.offset+height {
+		v.offset = v.cursor - height + 1
+	}
+}
+
+// key returns the key of the line at the cursor.
+func (v *tuiView) key() string {
+	if v.cursor < len(v.keys) {
+		return v.keys[v.cursor]
+	}
+	return ""
+}
+
+// reportLines splits the output of a report function into lines.
+func reportLines(print func(w io.Writer)) []string {
+	var buf bytes.Buffer
+	print(&buf)
+	return strings.Split(strings.TrimSuffix(buf.String(), newLine), newLine)
+}
+
+// accountTransactions returns copies of the transactions with postings to
+// account or its sub-accounts, holding only those postings.
+func accountTransactions(generalLedger []*ledger.Transaction, account string) []*ledger.Transaction {
+	var filtered []*ledger.Transaction
+	for _, trans := range generalLedger {
+		var postings []ledger.Account
+		for _, acc := range trans.AccountChanges {
+			if acc.Name == account || strings.HasPrefix(acc.Name, account+":") {
+				postings = append(postings, acc)
+			}
+		}
+		if len(postings) > 0 {
+			t := *trans
+			t.AccountChanges = postings
+			filtered = append(filtered, &t)
+		}
+	}
+	return filtered
+}
+
+func registerView(title string, generalLedger []*ledger.Transaction, query *ledger.Query) *tuiView {
+	return &tuiView{
+		title: title,
+		build: func(width int) ([]string, []string) {
+			return reportLines(func(w io.Writer) {
+				PrintRegister(w, generalLedger, query, width, false)
+				PrintRegisterTotal(w, generalLedger, query, "Total", width)
+			}), nil
+		},
+	}
+}
+
+func accountsView(generalLedger []*ledger.Transaction) *tuiView {
+	accounts := ledger.GetBalances(generalLedger, nil)
+	return &tuiView{
+		title: "Accounts",
+		build: func(width int) ([]string, []string) {
+			lines := reportLines(func(w io.Writer) {
+				PrintBalances(w, accounts, true, -1, width, true)
+			})
+			// with zero balances shown there is a line per account
+			keys := make([]string, len(accounts))
+			for i, acc := range accounts {
+				keys[i] = acc.Name
+			}
+			return lines, keys
+		},
+		open: func(account string) *tuiView {
+			return registerView("Register: "+account, accountTransactions(generalLedger, account), nil)
+		},
+	}
+}
+
+// runTUI runs the browser until the user quits.
+func runTUI(generalLedger []*ledger.Transaction, in *os.File, out io.Writer) error {
+	fd := int(in.Fd())
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, oldState)
+
+	screen := bufio.NewWriter(out)
+	// alternate screen, hidden cursor
+	screen.WriteString("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		screen.WriteString("\x1b[?25h\x1b[?1049l")
+		screen.Flush()
+	}()
+
+	stack := []*tuiView{accountsView(generalLedger)}
+	var prompt *strings.Builder
+	var status string
+	input := make([]byte, 16)
+	for {
+		width, height, err := term.GetSize(fd)
+		if err != nil {
+			return err
+		}
+		// title and status lines, a column for the cursor
+		bodyHeight := max(1, height-2)
+		view := stack[len(stack)-1]
+		view.layout(max(40, width-2))
+		view.move(0, bodyHeight)
+
+		screen.WriteString("\x1b[H\x1b[7m")
+		writePadded(screen, " "+view.title, width)
+		screen.WriteString("\x1b[0m\r\n")
+		for row := range bodyHeight {
+			idx := view.offset + row
+			if idx < len(view.lines) {
+				if idx == view.cursor {
+					screen.WriteString("\x1b[7m>\x1b[0m ")
+				} else {
+					screen.WriteString("  ")
+				}
+				screen.WriteString(view.lines[idx])
+			}
+			screen.WriteString("\x1b[0m\x1b[K\r\n")
+		}
+		switch {
+		case prompt != nil:
+			screen.WriteString("Search payee: " + prompt.String())
+		case status != "":
+			screen.WriteString(status)
+		default:
+			screen.WriteString("up/down move  enter open  / search payees  esc back  q quit")
+		}
+		screen.WriteString("\x1b[K")
+		screen.Flush()
+		status = ""
+
+		n, err := in.Read(input)
+		if err != nil {
+			return err
+		}
+		key, r := decodeKey(input[:n])
+
+		if prompt != nil {
+			switch key {
+			case keyRune:
+				prompt.WriteRune(r)
+			case keyBack:
+				s := []rune(prompt.String())
+				if len(s) > 0 {
+					prompt.Reset()
+					prompt.WriteString(string(s[:len(s)-1]))
+				}
+			case keyEscape:
+				prompt = nil
+			case keyEnter:
+				payee := prompt.String()
+				prompt = nil
+				if payee == "" {
+					break
+				}
+				query, qerr := ledger.ParseQuery("payee:" + queryQuote(payee))
+				if qerr != nil {
+					status = qerr.Error()
+					break
+				}
+				stack = append(stack, registerView("Payee: "+payee, query.Filter(generalLedger), nil))
+			}
+			continue
+		}
+
+		switch key {
+		case keyUp:
+			view.move(-1, bodyHeight)
+		case keyDown:
+			view.move(1, bodyHeight)
+		case keyPageUp:
+			view.move(-bodyHeight, bodyHeight)
+		case keyPageDown:
+			view.move(bodyHeight, bodyHeight)
+		case keyHome:
+			view.move(-len(view.lines), bodyHeight)
+		case keyEnd:
+			view.move(len(view.lines), bodyHeight)
+		case keyEnter:
+			if view.open != nil && view.key() != "" {
+				stack = append(stack, view.open(view.key()))
+			}
+		case keyEscape, keyBack:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case keyRune:
+			switch r {
+			case 'q':
+				return nil
+			case 'j':
+				view.move(1, bodyHeight)
+			case 'k':
+				view.move(-1, bodyHeight)
+			case 'g':
+				view.move(-len(view.lines), bodyHeight)
+			case 'G':
+				view.move(len(view.lines), bodyHeight)
+			case 'h':
+				if len(stack) > 1 {
+					stack = stack[:len(stack)-1]
+				}
+			case 'l':
+				if view.open != nil && view.key() != "" {
+					stack = append(stack, view.open(view.key()))
+				}
+			case '/':
+				prompt = new(strings.Builder)
+			}
+		}
+	}
+}
+
+// writePadded writes s cut or padded with spaces to width.
+func writePadded(w *bufio.Writer, s string, width int) {
+	r := []rune(s)
+	if len(r) > width {
+		r = r[:width]
+	}
+	w.WriteString(string(r))
+	w.WriteString(strings.Repeat(" ", width-len(r)))
+}
+
+// tuiCmd represents the tui command
+var tuiCmd = &cobra.Command{
+	Use:   "tui [query]...",
+	Short: "Browse accounts and registers in the terminal",
+	Long: `Browse the account balances in the terminal. Open an account to see its
+register, or search for a payee with / to see the register of its transactions.`,
+	Run: func(_ *cobra.Command, args []string) {
+		if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+			fatalln("tui needs a terminal")
+		}
+		if ledgerFilePath == "-" {
+			fatalln("tui reads keys from stdin, use a ledger file")
+		}
+		generalLedger, err := cliTransactions()
+		if err != nil {
+			fatalln(err)
+		}
+		generalLedger = cliQuery(args).Filter(generalLedger)
+		if err := runTUI(generalLedger, os.Stdin, os.Stdout); err != nil {
+			fatalln(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tuiCmd)
+
+	var startDate, endDate time.Time
+	startDate = time.Date(1970, 1, 1, 0, 0, 0, 0, time.Local)
+	endDate = time.Now().Add(1<<63 - 1)
+	tuiCmd.Flags().StringVarP(&startString, "begin-date", "b", startDate.Format(transactionDateFormat), "Begin date of transaction processing.")
+	tuiCmd.Flags().StringVarP(&endString, "end-date", "e", endDate.Format(transactionDateFormat), "End date of transaction processing.")
+	tuiCmd.Flags().StringVar(&payeeFilter, "payee", "", "Filter output to payees that contain this string.")
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/howeyc/ledger"
+	"github.com/shopspring/decimal"
+)
+
+func Test_decodeKey(t *testing.T) {
+	tests := []struct {
+		in   string
+		key  tuiKey
+		rune rune
+	}{
+		{"\x1b[A", keyUp, 0},
+		{"\x1bOB", keyDown, 0},
+		{"\x1b[6~", keyPageDown, 0},
+		{"\r", keyEnter, 0},
+		{"\x1b", keyEscape, 0},
+		{"\x7f", keyBack, 0},
+		{"q", keyRune, 'q'},
+		{"é", keyRune, 'é'},
+		{"\x1b[15~", keyNone, 0},
+		{"\x03", keyNone, 0},
+	}
+	for _, tc := range tests {
+		key, r := decodeKey([]byte(tc.in))
+		if key != tc.key || r != tc.rune {
+			t.Errorf("%q: got %v %q, want %v %q", tc.in, key, r, tc.key, tc.rune)
+		}
+	}
+}
+
+func Test_tuiViewMove(t *testing.T) {
+	v := &tuiView{lines: make([]string, 10)}
+	v.move(4, 3)
+	if v.cursor != 4 || v.offset != 2 {
+		t.Errorf("down: cursor %d offset %d", v.cursor, v.offset)
+	}
+	v.move(20, 3)
+	if v.cursor != 9 || v.offset != 7 {
+		t.Errorf("end: cursor %d offset %d", v.cursor, v.offset)
+	}
+	v.move(-8, 3)
+	if v.cursor != 1 || v.offset != 1 {
+		t.Errorf("up: cursor %d offset %d", v.cursor, v.offset)
+	}
+}
+
+func Test_accountsView(t *testing.T) {
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	generalLedger := []*ledger.Transaction{
+		{Date: date, Payee: "Store", AccountChanges: []ledger.Account{
+			{Name: "Expenses:Food", Balance: decimal.NewFromInt(10)},
+			{Name: "Assets:Cash", Balance: decimal.NewFromInt(-10)},
+		}},
+		{Date: date, Payee: "Garage", AccountChanges: []ledger.Account{
+			{Name: "Expenses:Auto", Balance: decimal.NewFromInt(5)},
+			{Name: "Assets:Cashback", Balance: decimal.NewFromInt(-5)},
+		}},
+	}
+
+	v := accountsView(generalLedger)
+	v.layout(60)
+	for i, key := range v.keys {
+		name := key[strings.LastIndex(key, ":")+1:]
+		if !strings.Contains(v.lines[i], name) {
+			t.Errorf("line %d %q is not account %s", i, v.lines[i], key)
+		}
+	}
+
+	v.cursor = 1 // Assets:Cash
+	reg := v.open(v.key())
+	reg.layout(60)
+	if len(reg.lines) != 2 || !strings.Contains(reg.lines[0], "Store") {
+		t.Errorf("register of %s: got %q", v.key(), reg.lines)
+	}
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+)
+
+var watchLedger bool
+
+// watchCommand adds the --watch flag to a report command. When watching, the
+// report is printed again (by running the command without --watch) whenever
+// the ledger file or a file it includes changes. Running the report in its
+// own process keeps a parse error while editing from ending the watch.
+func watchCommand(cmd *cobra.Command) {
+	run := cmd.Run
+	cmd.Flags().BoolVar(&watchLedger, "watch", false, "Print the report again whenever the ledger file changes.")
+	cmd.Run = func(c *cobra.Command, args []string) {
+		if !watchLedger {
+			run(c, args)
+			return
+		}
+		if ledgerFilePath == "-" {
+			log.Fatalln("can not watch stdin")
+		}
+		executable, err := os.Executable()
+		if err != nil {
+			log.Fatalln(err)
+		}
+		childArgs := watchArgs(os.Args[1:])
+		err = watchLedgerFiles(ledgerFilePath, func() {
+			// clear the screen
+			fmt.Print("\x1b[H\x1b[2J")
+			child := exec.Command(executable, childArgs...)
+			child.Stdin, child.Stdout, child.Stderr = os.Stdin, os.Stdout, os.Stderr
+			child.Run()
+		})
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
+}
+
+// watchArgs returns the command line arguments without the --watch flag.
+func watchArgs(args []string) []string {
+	var childArgs []string
+	for _, arg := range args {
+		if arg == "--watch" || strings.HasPrefix(arg, "--watch=") {
+			continue
+		}
+		childArgs = append(childArgs, arg)
+	}
+	return childArgs
+}
+
+// ledgerFiles returns the absolute paths of the ledger file and of the files
+// it includes, following includes recursively. Files that can not be read
+// are still returned, so they are watched for being created.
+func ledgerFiles(filename string) []string {
+	var files []string
+	seen := make(map[string]bool)
+	var walk func(name string)
+	walk = func(name string) {
+		absName, err := filepath.Abs(name)
+		if err != nil || seen[absName] {
+			return
+		}
+		seen[absName] = true
+		files = append(files, absName)
+
+		ifile, err := os.Open(absName)
+		if err != nil {
+			return
+		}
+		defer ifile.Close()
+		scanner := newLineScanner(ifile)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if commentIdx := strings.Index(line, ";"); commentIdx >= 0 {
+				line = strings.TrimSpace(line[:commentIdx])
+			}
+			if after, ok := strings.CutPrefix(line, "include "); ok {
+				paths, _ := filepath.Glob(filepath.Join(filepath.Dir(absName), strings.TrimSpace(after)))
+				for _, incpath := range paths {
+					walk(incpath)
+				}
+			}
+		}
+	}
+	walk(filename)
+	return files
+}
+
+// watchLedgerFiles calls onChange once, and then again every time the ledger
+// file or a file it includes changes. Directories are watched rather than the
+// files themselves, as editors often save by replacing the file. It only
+// returns on a watch error.
+func watchLedgerFiles(filename string, onChange func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	files := make(map[string]bool)
+	update := func() error {
+		clear(files)
+		for _, name := range ledgerFiles(filename) {
+			files[name] = true
+			// adding a watched directory again does nothing
+			if err := watcher.Add(filepath.Dir(name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := update(); err != nil {
+		return err
+	}
+	onChange()
+
+	// wait for a burst of events (an editor saving) to settle
+	var settle <-chan time.Time
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if files[event.Name] && event.Op != fsnotify.Chmod {
+				settle = time.After(100 * time.Millisecond)
+			}
+		case werr, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return fmt.Errorf("watching ledger files: %w", werr)
+		case <-settle:
+			settle = nil
+			if err := update(); err != nil {
+				return err
+			}
+			onChange()
+		}
+	}
+}
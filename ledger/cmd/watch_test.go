@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func Test_ledgerFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.dat":        "include sub/*.dat ; monthly files\n\n2024/01/01 A\n    Expenses:Food    10\n    Assets:Cash\n",
+		"sub/jan.dat":     "include ../main.dat\n",
+		"sub/feb.dat":     "",
+		"sub/ignored.txt": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := ledgerFiles(filepath.Join(dir, "main.dat"))
+	want := []string{
+		filepath.Join(dir, "main.dat"),
+		filepath.Join(dir, "sub", "feb.dat"),
+		filepath.Join(dir, "sub", "jan.dat"),
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func Test_watchArgs(t *testing.T) {
+	got := watchArgs([]string{"-f", "ledger.dat", "bal", "--watch", "Expenses", "--watch=true", "--wide"})
+	want := []string{"-f", "ledger.dat", "bal", "Expenses", "--wide"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
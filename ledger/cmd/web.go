@@ -1,17 +1,21 @@
 package cmd
 
 import (
+	"bufio"
 	"embed"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/howeyc/ledger/ledger/cmd/internal/httpcompress"
 
 	"github.com/howeyc/ledger"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 var reportConfigFileName string
@@ -21,6 +25,7 @@ var quickviewConfigFileName string
 var serverPort int
 var localhost bool
 var webReadOnly bool
+var webHashPassword bool
 
 //go:embed static/*
 var contentStatic embed.FS
@@ -39,11 +44,44 @@ func getTransactions() ([]*ledger.Transaction, error) {
 	return trans, nil
 }
 
+// readPassword reads a password from stdin, without echoing it from a
+// terminal.
+func readPassword() (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, "Password: ")
+		password, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		return string(password), err
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
 // webCmd represents the web command
 var webCmd = &cobra.Command{
 	Use:   "web",
 	Short: "Web service",
 	Run: func(_ *cobra.Command, _ []string) {
+		if webHashPassword {
+			password, err := readPassword()
+			if err != nil {
+				log.Fatalln(err)
+			}
+			if password == "" {
+				log.Fatalln("empty password")
+			}
+			hash, err := hashPassword(password)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			fmt.Println(hash)
+			return
+		}
+
 		configLoaders(time.Minute * 5)
 
 		// initialize cache
@@ -62,9 +100,9 @@ var webCmd = &cobra.Command{
 		})
 
 		if !webReadOnly {
-			m.HandleFunc("GET /addtrans", httpcompress.Middleware(addTransactionHandler, false))
-			m.HandleFunc("GET /addtrans/{accountName}", httpcompress.Middleware(addQuickTransactionHandler, false))
-			m.HandleFunc("POST /addtrans", httpcompress.Middleware(addTransactionPostHandler, false))
+			m.HandleFunc("GET /addtrans", requireEdit(httpcompress.Middleware(addTransactionHandler, false)))
+			m.HandleFunc("GET /addtrans/{accountName}", requireEdit(httpcompress.Middleware(addQuickTransactionHandler, false)))
+			m.HandleFunc("POST /addtrans", requireEdit(httpcompress.Middleware(addTransactionPostHandler, false)))
 		}
 
 		m.HandleFunc("GET /ledger", httpcompress.Middleware(ledgerHandler, false))
@@ -85,7 +123,7 @@ var webCmd = &cobra.Command{
 		} else {
 			listenAddress = fmt.Sprintf(":%d", serverPort)
 		}
-		log.Fatalln(http.ListenAndServe(listenAddress, m))
+		log.Fatalln(http.ListenAndServe(listenAddress, newWebAuth(webAuthConfig).handler(m)))
 	},
 }
 
@@ -98,4 +136,5 @@ func init() {
 	webCmd.Flags().IntVar(&serverPort, "port", 8056, "Port to listen on.")
 	webCmd.Flags().BoolVar(&localhost, "localhost", false, "Listen on localhost only.")
 	webCmd.Flags().BoolVar(&webReadOnly, "read-only", false, "Disable adding transactions through web.")
+	webCmd.Flags().BoolVar(&webHashPassword, "hash-password", false, "Print the password_hash of a password read from stdin, and exit.")
 }
@@ -0,0 +1,566 @@
+package cmd
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// authConfig holds the users of the web and serve commands, who log in with
+// a password by basic authentication, or by their email through an OpenID
+// Connect provider. Without users, anyone reaching the server may use it.
+type authConfig struct {
+	Users map[string]authUserConfig `toml:"users"`
+	OIDC  oidcConfig                `toml:"oidc"`
+}
+
+// authUserConfig holds a user: their role, read, or edit to also add
+// transactions, and for basic authentication the hash of their password, as
+// hashPassword returns. Users without one log in through OIDC only, named by
+// email.
+type authUserConfig struct {
+	Role         string `toml:"role"`
+	PasswordHash string `toml:"password_hash"`
+}
+
+// oidcConfig holds the OpenID Connect provider users log in through, if
+// Issuer is set. RedirectURL is the /auth/callback page of the server as
+// browsers reach it.
+type oidcConfig struct {
+	Issuer       string `toml:"issuer"`
+	ClientID     string `toml:"client_id"`
+	ClientSecret string `toml:"client_secret"`
+	RedirectURL  string `toml:"redirect_url"`
+}
+
+// roles of users, read being the default
+const (
+	roleRead = "read"
+	roleEdit = "edit"
+)
+
+// sessionDuration is how long users stay logged in after logging in through
+// the OIDC provider.
+const sessionDuration = 24 * time.Hour
+
+// passwordIterations is the number of PBKDF2-SHA256 iterations of the hashes
+// of passwords hashPassword returns, and minPasswordIterations the fewest
+// accepted.
+const (
+	passwordIterations    = 600_000
+	minPasswordIterations = 100_000
+)
+
+// failedLoginDelay slows down guessing passwords.
+var failedLoginDelay = time.Second
+
+const (
+	sessionCookie = "ledger_session"
+	loginCookie   = "ledger_login"
+)
+
+var webAuthConfig authConfig
+
+// check returns an error if the configuration is invalid.
+func (c authConfig) check() error {
+	for name, user := range c.Users {
+		switch user.Role {
+		case "", roleRead, roleEdit:
+		default:
+			return fmt.Errorf("users: %s: role must be read or edit, not %q", name, user.Role)
+		}
+		if user.PasswordHash != "" {
+			if _, _, _, err := parsePasswordHash(user.PasswordHash); err != nil {
+				return fmt.Errorf("users: %s: password_hash: %w", name, err)
+			}
+		}
+	}
+
+	o := c.OIDC
+	if o == (oidcConfig{}) {
+		return nil
+	}
+	if !strings.HasPrefix(o.Issuer, "https://") {
+		return errors.New("oidc: issuer must be an https URL")
+	}
+	if o.ClientID == "" {
+		return errors.New("oidc: client_id is required")
+	}
+	if u, err := url.Parse(o.RedirectURL); err != nil || !u.IsAbs() || u.Path != "/auth/callback" {
+		return errors.New("oidc: redirect_url must be the URL of /auth/callback of the server")
+	}
+	if len(c.Users) == 0 {
+		return errors.New("oidc: users are required")
+	}
+	return nil
+}
+
+// webAuth authenticates the requests of the web and serve commands.
+type webAuth struct {
+	config authConfig
+	// key signs the cookies of sessions and logins, so sessions end when
+	// the server restarts
+	key    []byte
+	client *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+
+	// verified holds the HMACs of the users and passwords of the basic
+	// authentications checked, so passwords are hashed once
+	verified sync.Map
+}
+
+// hashPassword returns the hash of the password for the password_hash of a
+// user: "pbkdf2$ITERATIONS$SALT$HASH", of PBKDF2-SHA256 with a random salt,
+// the salt and hash in base64.
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, 16)
+	rand.Read(salt)
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordIterations, sha256.Size)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("pbkdf2$%d$%s$%s", passwordIterations,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// parsePasswordHash returns the iterations, salt and key of a password hash.
+func parsePasswordHash(hash string) (iterations int, salt, key []byte, err error) {
+	fields := strings.Split(hash, "$")
+	if len(fields) != 4 || fields[0] != "pbkdf2" {
+		return 0, nil, nil, errors.New("must be pbkdf2$ITERATIONS$SALT$HASH, see web --hash-password")
+	}
+	iterations, err = strconv.Atoi(fields[1])
+	if err != nil || iterations < minPasswordIterations {
+		return 0, nil, nil, fmt.Errorf("iterations must be at least %d", minPasswordIterations)
+	}
+	salt, serr := base64.RawStdEncoding.DecodeString(fields[2])
+	key, kerr := base64.RawStdEncoding.DecodeString(fields[3])
+	if serr != nil || kerr != nil || len(salt) < 8 || len(key) != sha256.Size {
+		return 0, nil, nil, errors.New("invalid salt or hash")
+	}
+	return iterations, salt, key, nil
+}
+
+// checkPassword returns whether the password is that of the hash.
+func checkPassword(hash, password string) bool {
+	iterations, salt, want, err := parsePasswordHash(hash)
+	if err != nil {
+		return false
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(want))
+	return err == nil && subtle.ConstantTimeCompare(key, want) == 1
+}
+
+// newWebAuth returns the authentication of the configuration, nil if it has
+// no users.
+func newWebAuth(config authConfig) *webAuth {
+	if len(config.Users) == 0 {
+		return nil
+	}
+	key := make([]byte, 32)
+	rand.Read(key)
+	return &webAuth{config: config, key: key, client: http.DefaultClient}
+}
+
+type roleKey struct{}
+
+// requestRole returns the role of the user of the request, edit if the
+// server has no users.
+func requestRole(r *http.Request) string {
+	if role, ok := r.Context().Value(roleKey{}).(string); ok {
+		return role
+	}
+	return roleEdit
+}
+
+// requireEdit refuses the requests to next of users who may only read, and
+// those changing the ledger from pages of other sites, to which browsers
+// would attach the credentials of the user.
+func requireEdit(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if requestRole(r) != roleEdit {
+			http.Error(w, "read-only user", http.StatusForbidden)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			if !sameOrigin(r) {
+				http.Error(w, "cross-site request", http.StatusForbidden)
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// sameOrigin returns whether the request is from a page of the server, by its
+// Sec-Fetch-Site header, or else its Origin header. Requests with neither are
+// not sent by browsers from pages.
+func sameOrigin(r *http.Request) bool {
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "same-origin", "none":
+		return true
+	case "":
+	default:
+		return false
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+// handler returns next for users only, with the pages of logging in and out
+// added. Without users, it returns next.
+func (a *webAuth) handler(next http.Handler) http.Handler {
+	if a == nil {
+		return next
+	}
+	m := http.NewServeMux()
+	if a.config.OIDC.Issuer != "" {
+		m.HandleFunc("GET /auth/login", a.loginHandler)
+		m.HandleFunc("GET /auth/callback", a.callbackHandler)
+	}
+	m.HandleFunc("GET /auth/logout", a.logoutHandler)
+	m.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		name, ok := a.authenticate(r)
+		if !ok {
+			a.unauthorized(w, r)
+			return
+		}
+		role := a.config.Users[name].Role
+		if role == "" {
+			role = roleRead
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), roleKey{}, role)))
+	})
+	return m
+}
+
+// authenticate returns the user of the session cookie, or of the basic
+// authentication, of the request.
+func (a *webAuth) authenticate(r *http.Request) (string, bool) {
+	if c, err := r.Cookie(sessionCookie); err == nil {
+		if fields, ok := a.verify("session", c.Value); ok && len(fields) == 1 {
+			if _, ok := a.config.Users[fields[0]]; ok {
+				return fields[0], true
+			}
+		}
+	}
+	if name, password, ok := r.BasicAuth(); ok {
+		if a.checkBasic(name, password) {
+			return name, true
+		}
+		time.Sleep(failedLoginDelay)
+	}
+	return "", false
+}
+
+// checkBasic returns whether the password is that of the user, remembering
+// those that are.
+func (a *webAuth) checkBasic(name, password string) bool {
+	user, found := a.config.Users[name]
+	if !found || user.PasswordHash == "" {
+		return false
+	}
+	mac := hmac.New(sha256.New, a.key)
+	fmt.Fprintf(mac, "%d:%s%s", len(name), name, password)
+	memo := string(mac.Sum(nil))
+	if _, ok := a.verified.Load(memo); ok {
+		return true
+	}
+	if !checkPassword(user.PasswordHash, password) {
+		return false
+	}
+	a.verified.Store(memo, struct{}{})
+	return true
+}
+
+// unauthorized sends browsers to log in through the OIDC provider, if any,
+// and asks others for basic authentication.
+func (a *webAuth) unauthorized(w http.ResponseWriter, r *http.Request) {
+	if a.config.OIDC.Issuer != "" && r.Method == http.MethodGet && strings.Contains(r.Header.Get("Accept"), "text/html") {
+		http.Redirect(w, r, "/auth/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusFound)
+		return
+	}
+	w.Header().Set("WWW-Authenticate", `Basic realm="ledger", charset="UTF-8"`)
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+}
+
+// sign returns a token of the fields, valid for purpose until expiry.
+func (a *webAuth) sign(purpose string, expiry time.Time, fields ...string) string {
+	payload := strconv.FormatInt(expiry.Unix(), 10) + "\n" + strings.Join(fields, "\n")
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(purpose + "\n" + payload))
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verify returns the fields of a token signed for purpose, unless it has
+// expired.
+func (a *webAuth) verify(purpose, token string) ([]string, bool) {
+	strPayload, strSum, _ := strings.Cut(token, ".")
+	payload, perr := base64.RawURLEncoding.DecodeString(strPayload)
+	sum, serr := base64.RawURLEncoding.DecodeString(strSum)
+	if perr != nil || serr != nil {
+		return nil, false
+	}
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(purpose + "\n"))
+	mac.Write(payload)
+	if !hmac.Equal(sum, mac.Sum(nil)) {
+		return nil, false
+	}
+	strExpiry, rest, _ := strings.Cut(string(payload), "\n")
+	expiry, err := strconv.ParseInt(strExpiry, 10, 64)
+	if err != nil || time.Now().Unix() >= expiry {
+		return nil, false
+	}
+	return strings.Split(rest, "\n"), true
+}
+
+// secure returns whether cookies are only sent over https, as the server is
+// reached through its redirect URL.
+func (a *webAuth) secure() bool {
+	return strings.HasPrefix(a.config.OIDC.RedirectURL, "https://")
+}
+
+// oidcDiscovery holds the endpoints of the OIDC provider from its discovery
+// document.
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+}
+
+// discover returns the endpoints of the OIDC provider, fetched once.
+func (a *webAuth) discover() (*oidcDiscovery, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.discovery != nil {
+		return a.discovery, nil
+	}
+
+	issuer := strings.TrimSuffix(a.config.OIDC.Issuer, "/")
+	resp, err := a.client.Get(issuer + "/.well-known/openid-configuration")
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc discovery: %s", resp.Status)
+	}
+	var d oidcDiscovery
+	if err := json.NewDecoder(resp.Body).Decode(&d); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if strings.TrimSuffix(d.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("oidc discovery: issuer %q, want %q", d.Issuer, a.config.OIDC.Issuer)
+	}
+	if d.AuthorizationEndpoint == "" || !strings.HasPrefix(d.TokenEndpoint, "https://") {
+		return nil, errors.New("oidc discovery: missing authorization or https token endpoint")
+	}
+	a.discovery = &d
+	return a.discovery, nil
+}
+
+// localPath returns the path of the server to go to after logging in, "/" if
+// next is not one.
+func localPath(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.ContainsAny(next, "\\\r\n") {
+		return "/"
+	}
+	return next
+}
+
+// loginHandler sends the browser to log in at the OIDC provider, keeping the
+// state and nonce of the login in a cookie to check them on its return.
+func (a *webAuth) loginHandler(w http.ResponseWriter, r *http.Request) {
+	d, err := a.discover()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	authURL, err := url.Parse(d.AuthorizationEndpoint)
+	if err != nil {
+		http.Error(w, "oidc discovery: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	state, nonce := rand.Text(), rand.Text()
+	next := localPath(r.URL.Query().Get("next"))
+	http.SetCookie(w, &http.Cookie{
+		Name:     loginCookie,
+		Value:    a.sign("login", time.Now().Add(10*time.Minute), state, nonce, next),
+		Path:     "/auth/",
+		MaxAge:   600,
+		HttpOnly: true,
+		Secure:   a.secure(),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	q := authURL.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", a.config.OIDC.ClientID)
+	q.Set("redirect_uri", a.config.OIDC.RedirectURL)
+	q.Set("scope", "openid email")
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	authURL.RawQuery = q.Encode()
+	http.Redirect(w, r, authURL.String(), http.StatusFound)
+}
+
+// callbackHandler logs in the user the OIDC provider returns the browser
+// for, if they are one of the users.
+func (a *webAuth) callbackHandler(w http.ResponseWriter, r *http.Request) {
+	var fields []string
+	c, err := r.Cookie(loginCookie)
+	ok := err == nil
+	if ok {
+		fields, ok = a.verify("login", c.Value)
+	}
+	if !ok || len(fields) != 3 || subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("state")), []byte(fields[0])) != 1 {
+		http.Error(w, "invalid or expired login, log in again", http.StatusBadRequest)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: loginCookie, Path: "/auth/", MaxAge: -1})
+	if e := r.URL.Query().Get("error"); e != "" {
+		http.Error(w, "login failed: "+e, http.StatusForbidden)
+		return
+	}
+
+	email, err := a.exchange(r.Context(), r.URL.Query().Get("code"), fields[1])
+	if err != nil {
+		http.Error(w, "login failed: "+err.Error(), http.StatusForbidden)
+		return
+	}
+	if _, ok := a.config.Users[email]; !ok {
+		http.Error(w, fmt.Sprintf("login failed: %s is not a user", email), http.StatusForbidden)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    a.sign("session", time.Now().Add(sessionDuration), email),
+		Path:     "/",
+		MaxAge:   int(sessionDuration.Seconds()),
+		HttpOnly: true,
+		Secure:   a.secure(),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, fields[2], http.StatusFound)
+}
+
+// logoutHandler ends the session of the browser.
+func (a *webAuth) logoutHandler(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Path: "/", MaxAge: -1})
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// oidcAudience is the aud claim of an ID token, a string or a list of them.
+type oidcAudience []string
+
+func (aud *oidcAudience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*aud = oidcAudience{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(aud))
+}
+
+// idTokenClaims holds the claims of an ID token checked on logging in.
+type idTokenClaims struct {
+	Issuer          string       `json:"iss"`
+	Audience        oidcAudience `json:"aud"`
+	AuthorizedParty string       `json:"azp"`
+	Expiry          int64        `json:"exp"`
+	Nonce           string       `json:"nonce"`
+	Email           string       `json:"email"`
+	EmailVerified   bool         `json:"email_verified"`
+}
+
+// exchange redeems the code of a login at the token endpoint, returning the
+// email of the ID token, which the provider must have verified. The ID token
+// comes straight from the provider over TLS, so its signature is not checked,
+// as OIDC Core allows (section 3.1.3.7), but its issuer, audience, expiry and
+// nonce are.
+func (a *webAuth) exchange(ctx context.Context, code, nonce string) (string, error) {
+	d, err := a.discover()
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {a.config.OIDC.RedirectURL},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(a.config.OIDC.ClientID), url.QueryEscape(a.config.OIDC.ClientSecret))
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var token struct {
+		IDToken string `json:"id_token"`
+		Error   string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("token endpoint: %s: %w", resp.Status, err)
+	}
+	if resp.StatusCode != http.StatusOK || token.IDToken == "" {
+		return "", fmt.Errorf("token endpoint: %s %s", resp.Status, token.Error)
+	}
+
+	parts := strings.Split(token.IDToken, ".")
+	if len(parts) != 3 {
+		return "", errors.New("malformed id token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("malformed id token: %w", err)
+	}
+	var claims idTokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", fmt.Errorf("malformed id token: %w", err)
+	}
+
+	clientID := a.config.OIDC.ClientID
+	switch {
+	case claims.Issuer != d.Issuer:
+		return "", fmt.Errorf("id token of issuer %q", claims.Issuer)
+	case !slices.Contains(claims.Audience, clientID), claims.AuthorizedParty != "" && claims.AuthorizedParty != clientID:
+		return "", errors.New("id token for another client")
+	case time.Now().Unix() >= claims.Expiry:
+		return "", errors.New("id token expired")
+	case subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1:
+		return "", errors.New("id token of another login")
+	case claims.Email == "", !claims.EmailVerified:
+		return "", errors.New("no verified email")
+	}
+	return claims.Email, nil
+}
@@ -0,0 +1,279 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_webAuthBasic(t *testing.T) {
+	defer func(delay time.Duration) { failedLoginDelay = delay }(failedLoginDelay)
+	failedLoginDelay = 0
+	hash, err := hashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := newWebAuth(authConfig{Users: map[string]authUserConfig{
+		"alice": {Role: roleEdit, PasswordHash: hash},
+		"bob":   {PasswordHash: hash},
+		"carol": {Role: roleEdit},
+	}})
+	m := http.NewServeMux()
+	m.HandleFunc("GET /role", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, requestRole(r))
+	})
+	m.HandleFunc("POST /addtrans", requireEdit(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, "added")
+	}))
+	handler := auth.handler(m)
+
+	tests := []struct {
+		method, path   string
+		user, password string
+		wantStatus     int
+		wantBody       string
+	}{
+		{"GET", "/role", "", "", http.StatusUnauthorized, ""},
+		{"GET", "/role", "alice", "wrong", http.StatusUnauthorized, ""},
+		{"GET", "/role", "carol", "", http.StatusUnauthorized, ""},
+		{"GET", "/role", "alice", "secret", http.StatusOK, roleEdit},
+		{"GET", "/role", "alice", "secret", http.StatusOK, roleEdit},
+		{"GET", "/role", "bob", "secret", http.StatusOK, roleRead},
+		{"POST", "/addtrans", "alice", "secret", http.StatusOK, "added"},
+		{"POST", "/addtrans", "bob", "secret", http.StatusForbidden, ""},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		if tc.user != "" {
+			req.SetBasicAuth(tc.user, tc.password)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tc.wantStatus {
+			t.Errorf("%s %s as %s: status %d, want %d", tc.method, tc.path, tc.user, rec.Code, tc.wantStatus)
+			continue
+		}
+		if tc.wantBody != "" && rec.Body.String() != tc.wantBody {
+			t.Errorf("%s %s as %s: got %q, want %q", tc.method, tc.path, tc.user, rec.Body, tc.wantBody)
+		}
+		if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s %s as %s: no WWW-Authenticate", tc.method, tc.path, tc.user)
+		}
+	}
+
+	if role := requestRole(httptest.NewRequest("GET", "/", nil)); role != roleEdit {
+		t.Errorf("without users: got role %q, want %q", role, roleEdit)
+	}
+	if newWebAuth(authConfig{}).handler(m) != m {
+		t.Error("without users: expected the handler unchanged")
+	}
+}
+
+func Test_hashPassword(t *testing.T) {
+	hash, err := hashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other, _ := hashPassword("secret"); other == hash {
+		t.Error("expected hashes of random salts")
+	}
+	if !checkPassword(hash, "secret") || checkPassword(hash, "Secret") || checkPassword(hash, "") {
+		t.Errorf("checkPassword of %s", hash)
+	}
+	if err := (authConfig{Users: map[string]authUserConfig{"alice": {PasswordHash: hash}}}).check(); err != nil {
+		t.Error(err)
+	}
+
+	for _, bad := range []string{
+		"2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b",
+		"pbkdf2$1000$c2FsdHNhbHQ$" + strings.Repeat("A", 43),
+		"pbkdf2$600000$c2FsdHNhbHQ$c2hvcnQ",
+		"scrypt$600000$c2FsdHNhbHQ$" + strings.Repeat("A", 43),
+	} {
+		if _, _, _, err := parsePasswordHash(bad); err == nil {
+			t.Errorf("%s: expected error", bad)
+		}
+		if checkPassword(bad, "secret") {
+			t.Errorf("%s: expected no password", bad)
+		}
+	}
+}
+
+func Test_requireEdit(t *testing.T) {
+	handler := requireEdit(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, "added")
+	})
+	tests := []struct {
+		method     string
+		header     map[string]string
+		wantStatus int
+	}{
+		{"POST", nil, http.StatusOK},
+		{"POST", map[string]string{"Sec-Fetch-Site": "same-origin", "Origin": "http://ledger.example.com"}, http.StatusOK},
+		{"POST", map[string]string{"Origin": "http://ledger.example.com"}, http.StatusOK},
+		{"POST", map[string]string{"Sec-Fetch-Site": "cross-site", "Origin": "https://evil.example.com"}, http.StatusForbidden},
+		{"POST", map[string]string{"Sec-Fetch-Site": "same-site"}, http.StatusForbidden},
+		{"POST", map[string]string{"Origin": "https://evil.example.com"}, http.StatusForbidden},
+		{"POST", map[string]string{"Origin": "null"}, http.StatusForbidden},
+		{"GET", map[string]string{"Sec-Fetch-Site": "cross-site"}, http.StatusOK},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(tc.method, "http://ledger.example.com/addtrans", nil)
+		for k, v := range tc.header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		if rec.Code != tc.wantStatus {
+			t.Errorf("%s %v: status %d, want %d", tc.method, tc.header, rec.Code, tc.wantStatus)
+		}
+	}
+}
+
+func Test_webAuthOIDC(t *testing.T) {
+	var nonce, email string
+	verified := true
+	var provider *httptest.Server
+	provider = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(oidcDiscovery{
+				Issuer:                provider.URL,
+				AuthorizationEndpoint: provider.URL + "/authorize",
+				TokenEndpoint:         provider.URL + "/token",
+			})
+		case "/token":
+			if id, secret, _ := r.BasicAuth(); id != "ledger" || secret != "s3cret" || r.FormValue("code") != "good" {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+				return
+			}
+			claims := map[string]any{
+				"iss":   provider.URL,
+				"aud":   []string{"ledger"},
+				"exp":   time.Now().Add(time.Minute).Unix(),
+				"nonce": nonce,
+				"email": email,
+			}
+			if verified {
+				claims["email_verified"] = true
+			}
+			payload, _ := json.Marshal(claims)
+			json.NewEncoder(w).Encode(map[string]string{
+				"id_token": "e30." + base64.RawURLEncoding.EncodeToString(payload) + ".c2ln",
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer provider.Close()
+
+	auth := newWebAuth(authConfig{
+		Users: map[string]authUserConfig{"carol@example.com": {Role: roleRead}},
+		OIDC: oidcConfig{
+			Issuer:       provider.URL,
+			ClientID:     "ledger",
+			ClientSecret: "s3cret",
+			RedirectURL:  "https://ledger.example.com/auth/callback",
+		},
+	})
+	auth.client = provider.Client()
+	handler := auth.handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, requestRole(r))
+	}))
+	get := func(path string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Accept", "text/html")
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	cookie := func(rec *httptest.ResponseRecorder, name string) *http.Cookie {
+		for _, c := range rec.Result().Cookies() {
+			if c.Name == name {
+				return c
+			}
+		}
+		t.Fatalf("no cookie %s", name)
+		return nil
+	}
+	login := func() (state string, loginCookie *http.Cookie) {
+		rec := get("/ledger")
+		if loc := rec.Header().Get("Location"); rec.Code != http.StatusFound || loc != "/auth/login?next=%2Fledger" {
+			t.Fatalf("unauthenticated: status %d to %q", rec.Code, loc)
+		}
+		rec = get("/auth/login?next=%2Fledger")
+		authURL, err := url.Parse(rec.Header().Get("Location"))
+		if err != nil || rec.Code != http.StatusFound || authURL.Path != "/authorize" {
+			t.Fatalf("login: status %d to %q", rec.Code, rec.Header().Get("Location"))
+		}
+		q := authURL.Query()
+		if q.Get("client_id") != "ledger" || q.Get("redirect_uri") != "https://ledger.example.com/auth/callback" {
+			t.Errorf("login: authorization request %v", q)
+		}
+		nonce = q.Get("nonce")
+		return q.Get("state"), cookie(rec, "ledger_login")
+	}
+
+	email = "carol@example.com"
+	state, loginCookie := login()
+	if rec := get("/auth/callback?code=good&state=other", loginCookie); rec.Code != http.StatusBadRequest {
+		t.Errorf("callback of another state: status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	rec := get("/auth/callback?code=good&state="+state, loginCookie)
+	if loc := rec.Header().Get("Location"); rec.Code != http.StatusFound || loc != "/ledger" {
+		t.Fatalf("callback: status %d to %q: %s", rec.Code, loc, rec.Body)
+	}
+	session := cookie(rec, "ledger_session")
+	if rec := get("/ledger", session); rec.Code != http.StatusOK || rec.Body.String() != roleRead {
+		t.Errorf("logged in: status %d, got %q", rec.Code, rec.Body)
+	}
+	tampered := *session
+	tampered.Value = base64.RawURLEncoding.EncodeToString([]byte("9999999999\nmallory@example.com")) + tampered.Value[len(tampered.Value)-44:]
+	if rec := get("/ledger", &tampered); rec.Code != http.StatusFound {
+		t.Errorf("tampered session: status %d, want %d", rec.Code, http.StatusFound)
+	}
+
+	state, loginCookie = login()
+	nonce = "replayed"
+	if rec := get("/auth/callback?code=good&state="+state, loginCookie); rec.Code != http.StatusForbidden {
+		t.Errorf("id token of another nonce: status %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	verified = false
+	state, loginCookie = login()
+	if rec := get("/auth/callback?code=good&state="+state, loginCookie); rec.Code != http.StatusForbidden {
+		t.Errorf("unverified email: status %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	verified = true
+	email = "mallory@example.com"
+	state, loginCookie = login()
+	if rec := get("/auth/callback?code=good&state="+state, loginCookie); rec.Code != http.StatusForbidden {
+		t.Errorf("unknown user: status %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func Test_localPath(t *testing.T) {
+	tests := map[string]string{
+		"/ledger?begin=2024-01-01": "/ledger?begin=2024-01-01",
+		"":                         "/",
+		"https://example.com/":     "/",
+		"//example.com/":           "/",
+		"/\\example.com/":          "/",
+	}
+	for next, want := range tests {
+		if got := localPath(next); got != want {
+			t.Errorf("localPath(%q) = %q, want %q", next, got, want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"log"
+	"net/http"
 	"os"
 	"time"
 
@@ -109,11 +110,13 @@ type pageData struct {
 	Stocks       []stockInfo
 	Portfolios   []portfolioStruct
 	AccountNames []string
+	Payees       []string
 	ReadOnly     bool
+	Filter       webFilter
 }
 
-func (p *pageData) Init() {
-	p.ReadOnly = webReadOnly
+func (p *pageData) Init(r *http.Request) {
+	p.ReadOnly = webReadOnly || requestRole(r) != roleEdit
 	p.Reports = reportConfigData.Reports
 	p.Portfolios = portfolioConfigData.Portfolios
 }
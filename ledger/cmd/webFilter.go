@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/howeyc/ledger"
+)
+
+// webFilter is the date range and account filter of the web pages, read from
+// the begin, end and account query parameters. Dates are in the yyyy-mm-dd
+// form of date inputs and both are inclusive.
+type webFilter struct {
+	Begin   string
+	End     string
+	Account string
+
+	begin, end time.Time
+}
+
+func parseWebFilter(r *http.Request) (webFilter, error) {
+	f := webFilter{
+		Begin:   r.FormValue("begin"),
+		End:     r.FormValue("end"),
+		Account: strings.TrimSpace(r.FormValue("account")),
+	}
+	var err error
+	if f.Begin != "" {
+		if f.begin, err = time.ParseInLocation(time.DateOnly, f.Begin, time.Local); err != nil {
+			return f, fmt.Errorf("invalid begin date %q", f.Begin)
+		}
+	}
+	if f.End != "" {
+		if f.end, err = time.ParseInLocation(time.DateOnly, f.End, time.Local); err != nil {
+			return f, fmt.Errorf("invalid end date %q", f.End)
+		}
+	}
+	return f, nil
+}
+
+// Active reports whether any filter is set.
+func (f webFilter) Active() bool {
+	return f.Begin != "" || f.End != "" || f.Account != ""
+}
+
+// dates returns the transactions within the date range.
+func (f webFilter) dates(trans []*ledger.Transaction) []*ledger.Transaction {
+	if f.begin.IsZero() && f.end.IsZero() {
+		return trans
+	}
+	var filtered []*ledger.Transaction
+	for _, t := range trans {
+		if !f.begin.IsZero() && t.Date.Before(f.begin) {
+			continue
+		}
+		if !f.end.IsZero() && !t.Date.Before(f.end.AddDate(0, 0, 1)) {
+			continue
+		}
+		filtered = append(filtered, t)
+	}
+	return filtered
+}
+
+// apply returns the transactions within the date range that have a posting
+// to an account containing the account filter.
+func (f webFilter) apply(trans []*ledger.Transaction) []*ledger.Transaction {
+	trans = f.dates(trans)
+	if f.Account == "" {
+		return trans
+	}
+	var filtered []*ledger.Transaction
+	for _, t := range trans {
+		for _, accChange := range t.AccountChanges {
+			if strings.Contains(accChange.Name, f.Account) {
+				filtered = append(filtered, t)
+				break
+			}
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/howeyc/ledger"
+)
+
+func Test_webFilter(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.Local) }
+	trans := []*ledger.Transaction{
+		{Payee: "a", Date: day(1), AccountChanges: []ledger.Account{{Name: "Expenses:Food"}, {Name: "Assets:Cash"}}},
+		{Payee: "b", Date: day(2), AccountChanges: []ledger.Account{{Name: "Expenses:Auto"}, {Name: "Assets:Cash"}}},
+		{Payee: "c", Date: day(3), AccountChanges: []ledger.Account{{Name: "Expenses:Food"}, {Name: "Assets:Cash"}}},
+	}
+	payees := func(trans []*ledger.Transaction) (s string) {
+		for _, t := range trans {
+			s += t.Payee
+		}
+		return
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "abc"},
+		{"begin=2024-01-02", "bc"},
+		{"end=2024-01-02", "ab"},
+		{"begin=2024-01-02&end=2024-01-02", "b"},
+		{"account=Food", "ac"},
+		{"account=Food&end=2024-01-02", "a"},
+	}
+	for _, tc := range tests {
+		f, err := parseWebFilter(httptest.NewRequest("GET", "/ledger?"+tc.query, nil))
+		if err != nil {
+			t.Errorf("%s: %v", tc.query, err)
+			continue
+		}
+		if got := payees(f.apply(trans)); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.query, got, tc.want)
+		}
+	}
+
+	if _, err := parseWebFilter(httptest.NewRequest("GET", "/ledger?begin=2024/01/02", nil)); err == nil {
+		t.Error("expected error for invalid date")
+	}
+}
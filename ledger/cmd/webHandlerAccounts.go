@@ -2,9 +2,10 @@ package cmd
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"net/http"
-	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -30,7 +31,7 @@ func quickviewHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var pData pageData
-	pData.Init()
+	pData.Init(r)
 	pData.Transactions = trans
 
 	includeNames := make(map[string]bool)
@@ -51,45 +52,69 @@ func quickviewHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func addTransactionPostHandler(w http.ResponseWriter, r *http.Request) {
-	strDate := r.FormValue("transactionDate")
-	strPayee := r.FormValue("transactionPayee")
-
-	var accountLines []string
-	for i := 1; i < 20; i++ {
-		strAcc := r.FormValue(fmt.Sprintf("transactionAccount%d", i))
-		strAmt := r.FormValue(fmt.Sprintf("transactionAmount%d", i))
-		accountLines = append(accountLines, strings.Trim(fmt.Sprintf("%s          %s", strAcc, strAmt), " \t"))
+// parseWebTransaction returns the transaction of the add transaction form: a
+// date, a payee and two or more postings, numbered from 1, of an account and
+// an amount, which may be left empty on one posting to balance the others.
+// Postings without an account are skipped.
+func parseWebTransaction(r *http.Request) (*ledger.Transaction, error) {
+	var ferr error
+	formValue := func(key string) string {
+		value := strings.TrimSpace(r.FormValue(key))
+		if strings.ContainsAny(value, "\r\n") && ferr == nil {
+			ferr = fmt.Errorf("line break in %s", key)
+		}
+		return value
 	}
 
-	date, _ := time.Parse(time.DateOnly, strDate)
+	strDate := formValue("transactionDate")
+	date, derr := time.Parse(time.DateOnly, strDate)
+	if derr != nil {
+		return nil, fmt.Errorf("invalid date: %q", strDate)
+	}
+	strPayee := formValue("transactionPayee")
+	if strPayee == "" {
+		return nil, errors.New("missing payee")
+	}
 
 	var tbuf bytes.Buffer
 	fmt.Fprintln(&tbuf, date.Format("2006/01/02"), strPayee)
-	for _, accLine := range accountLines {
-		if len(accLine) > 0 {
-			fmt.Fprintf(&tbuf, "    %s", accLine)
-			fmt.Fprintln(&tbuf, "")
+	for i := 1; r.Form.Has(fmt.Sprintf("transactionAccount%d", i)); i++ {
+		strAcc := formValue(fmt.Sprintf("transactionAccount%d", i))
+		strAmt := formValue(fmt.Sprintf("transactionAmount%d", i))
+		if strAcc == "" {
+			if strAmt != "" {
+				return nil, fmt.Errorf("amount %s without an account", strAmt)
+			}
+			continue
 		}
+		fmt.Fprintf(&tbuf, "    %s          %s\n", strAcc, strAmt)
+	}
+	if ferr != nil {
+		return nil, ferr
 	}
-	fmt.Fprintln(&tbuf, "")
 
 	/* Check valid transaction is created */
 	trans, perr := ledger.ParseLedger(&tbuf)
 	if perr != nil {
-		http.Error(w, perr.Error(), 500)
+		return nil, perr
+	}
+	if err := trans[0].IsBalanced(); err != nil {
+		return nil, err
+	}
+	return trans[0], nil
+}
+
+func addTransactionPostHandler(w http.ResponseWriter, r *http.Request) {
+	trans, terr := parseWebTransaction(r)
+	if terr != nil {
+		http.Error(w, terr.Error(), http.StatusBadRequest)
 		return
 	}
 
-	f, err := os.OpenFile(ledgerFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
-	if err != nil {
+	if err := transactionFormat(80).AppendToFile(ledgerFilePath, trans); err != nil {
 		http.Error(w, err.Error(), 500)
 		return
 	}
-	for _, t := range trans {
-		WriteTransaction(f, t, 80)
-	}
-	f.Close()
 
 	if _, err := getTransactions(); err != nil {
 		http.Error(w, err.Error(), 500)
@@ -98,6 +123,17 @@ func addTransactionPostHandler(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "Transaction added!")
 }
 
+// webPayees returns the payees of the transactions, sorted, to complete the
+// payee of the add transaction form.
+func webPayees(trans []*ledger.Transaction) []string {
+	payees := make([]string, 0, len(trans))
+	for _, t := range trans {
+		payees = append(payees, t.Payee)
+	}
+	slices.Sort(payees)
+	return slices.Compact(payees)
+}
+
 func addQuickTransactionHandler(w http.ResponseWriter, r *http.Request) {
 	accountName := r.PathValue("accountName")
 
@@ -146,10 +182,11 @@ func addQuickTransactionHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var pData pageData
-	pData.Init()
+	pData.Init(r)
 	pData.Accounts = abals
 	pData.Transactions = atrans
 	pData.AccountNames = []string{accountName}
+	pData.Payees = webPayees(atrans)
 
 	err = t.Execute(w, pData)
 	if err != nil {
@@ -157,7 +194,7 @@ func addQuickTransactionHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func addTransactionHandler(w http.ResponseWriter, _ *http.Request) {
+func addTransactionHandler(w http.ResponseWriter, r *http.Request) {
 	t, err := loadTemplates("templates/template.addtransaction.html")
 	if err != nil {
 		http.Error(w, err.Error(), 500)
@@ -172,9 +209,10 @@ func addTransactionHandler(w http.ResponseWriter, _ *http.Request) {
 	balances := ledger.GetBalances(trans, []string{})
 
 	var pData pageData
-	pData.Init()
+	pData.Init(r)
 	pData.Accounts = balances
 	pData.Transactions = trans
+	pData.Payees = webPayees(trans)
 
 	err = t.Execute(w, pData)
 	if err != nil {
@@ -182,7 +220,7 @@ func addTransactionHandler(w http.ResponseWriter, _ *http.Request) {
 	}
 }
 
-func accountsHandler(w http.ResponseWriter, _ *http.Request) {
+func accountsHandler(w http.ResponseWriter, r *http.Request) {
 	t, err := loadTemplates("templates/template.accounts.html")
 	if err != nil {
 		http.Error(w, err.Error(), 500)
@@ -195,12 +233,23 @@ func accountsHandler(w http.ResponseWriter, _ *http.Request) {
 		return
 	}
 
-	balances := ledger.GetBalances(trans, []string{})
+	filter, ferr := parseWebFilter(r)
+	if ferr != nil {
+		http.Error(w, ferr.Error(), http.StatusBadRequest)
+		return
+	}
+	trans = filter.dates(trans)
+	var filterArr []string
+	if filter.Account != "" {
+		filterArr = []string{filter.Account}
+	}
+	balances := ledger.GetBalances(trans, filterArr)
 
 	var pData pageData
-	pData.Init()
+	pData.Init(r)
 	pData.Accounts = balances
 	pData.Transactions = trans
+	pData.Filter = filter
 
 	err = t.Execute(w, pData)
 	if err != nil {
@@ -223,8 +272,16 @@ func accountHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	filter, ferr := parseWebFilter(r)
+	if ferr != nil {
+		http.Error(w, ferr.Error(), http.StatusBadRequest)
+		return
+	}
+	// the account is given by the page
+	filter.Account = ""
+
 	var pageTrans []*ledger.Transaction
-	for _, tran := range trans {
+	for _, tran := range filter.dates(trans) {
 		for _, accChange := range tran.AccountChanges {
 			if strings.Contains(accChange.Name, accountName) {
 				pageTrans = append(pageTrans, &ledger.Transaction{
@@ -237,9 +294,10 @@ func accountHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var pData pageData
-	pData.Init()
+	pData.Init(r)
 	pData.Transactions = pageTrans
 	pData.AccountNames = []string{accountName}
+	pData.Filter = filter
 
 	err = t.Execute(w, pData)
 	if err != nil {
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_parseWebTransaction(t *testing.T) {
+	tests := []struct {
+		form    string
+		want    string
+		wantErr bool
+	}{
+		{"transactionDate=2024-01-05&transactionPayee=Grocery&transactionAccount1=Expenses:Food&transactionAmount1=12.50&transactionAccount2=Assets:Cash&transactionAmount2=",
+			"Grocery Expenses:Food=12.5 Assets:Cash=-12.5", false},
+		{"transactionDate=2024-01-05&transactionPayee=Split&transactionAccount1=Expenses:Food&transactionAmount1=10&transactionAccount2=&transactionAmount2=&transactionAccount3=Expenses:Auto&transactionAmount3=5&transactionAccount4=Assets:Cash&transactionAmount4=-15",
+			"Split Expenses:Food=10 Expenses:Auto=5 Assets:Cash=-15", false},
+		{"transactionDate=2024-01-05&transactionPayee=Grocery&transactionAccount1=Expenses:Food&transactionAmount1=10&transactionAccount2=Assets:Cash&transactionAmount2=-5", "", true},
+		{"transactionDate=2024-01-05&transactionPayee=Grocery&transactionAccount1=Expenses:Food&transactionAmount1=10", "", true},
+		{"transactionDate=2024-01-05&transactionPayee=Grocery&transactionAccount1=Expenses:Food&transactionAmount1=10&transactionAccount2=&transactionAmount2=-10", "", true},
+		{"transactionDate=01/05/2024&transactionPayee=Grocery&transactionAccount1=Expenses:Food&transactionAmount1=10&transactionAccount2=Assets:Cash", "", true},
+		{"transactionDate=2024-01-05&transactionPayee=&transactionAccount1=Expenses:Food&transactionAmount1=10&transactionAccount2=Assets:Cash", "", true},
+		{"transactionDate=2024-01-05&transactionPayee=A%0A2024/01/06+B&transactionAccount1=Expenses:Food&transactionAmount1=10&transactionAccount2=Assets:Cash", "", true},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest("POST", "/addtrans", strings.NewReader(tc.form))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		trans, err := parseWebTransaction(req)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected error", tc.form)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tc.form, err)
+			continue
+		}
+		got := trans.Payee
+		for _, acc := range trans.AccountChanges {
+			got += " " + acc.Name + "=" + acc.Balance.String()
+		}
+		if got != tc.want || trans.Date.Format("2006-01-02") != "2024-01-05" {
+			t.Errorf("%s: got %s %q, want %q", tc.form, trans.Date, got, tc.want)
+		}
+	}
+}
+
+func Test_addTransactionPostHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.dat")
+	content := "2024/01/01 Opening\n    Assets:Cash    100\n    Equity:Opening\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	saved := ledgerFilePath
+	defer func() { ledgerFilePath = saved }()
+	ledgerFilePath = path
+
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/addtrans", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		addTransactionPostHandler(rec, req)
+		return rec
+	}
+
+	form := url.Values{
+		"transactionDate":     {"2024-01-05"},
+		"transactionPayee":    {"Grocery"},
+		"transactionAccount1": {"Expenses:Food"},
+		"transactionAmount1":  {"10"},
+		"transactionAccount2": {"Assets:Cash"},
+		"transactionAmount2":  {"-5"},
+	}
+	if rec := post(form); rec.Code != http.StatusBadRequest {
+		t.Errorf("unbalanced: status %d, want %d: %s", rec.Code, http.StatusBadRequest, rec.Body)
+	}
+	if data, _ := os.ReadFile(path); string(data) != content {
+		t.Errorf("unbalanced transaction written:\n%s", data)
+	}
+
+	form.Set("transactionAmount2", "")
+	if rec := post(form); rec.Code != http.StatusOK {
+		t.Fatalf("status %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "2024/01/05 Grocery\n") || !strings.Contains(string(data), "Expenses:Food") {
+		t.Errorf("transaction not appended:\n%s", data)
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"net/http"
 )
 
-func ledgerHandler(w http.ResponseWriter, _ *http.Request) {
+func ledgerHandler(w http.ResponseWriter, r *http.Request) {
 	t, err := loadTemplates("templates/template.ledger.html")
 	if err != nil {
 		http.Error(w, err.Error(), 500)
@@ -17,9 +17,16 @@ func ledgerHandler(w http.ResponseWriter, _ *http.Request) {
 		return
 	}
 
+	filter, ferr := parseWebFilter(r)
+	if ferr != nil {
+		http.Error(w, ferr.Error(), http.StatusBadRequest)
+		return
+	}
+
 	var pData pageData
-	pData.Init()
-	pData.Transactions = trans
+	pData.Init(r)
+	pData.Transactions = filter.apply(trans)
+	pData.Filter = filter
 
 	err = t.Execute(w, pData)
 	if err != nil {
@@ -40,7 +40,7 @@ func portfolioHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var pData portPageData
-	pData.Init()
+	pData.Init(r)
 	pData.Transactions = trans
 	pData.PortfolioName = portfolioName
 	pData.ShowDividends = portfolio.ShowDividends
@@ -269,7 +269,7 @@ func reportHandler(w http.ResponseWriter, r *http.Request) {
 		}
 
 		var pData lbPageData
-		pData.Init()
+		pData.Init(r)
 		pData.Transactions = vtrans
 		pData.ChartType = "Leaderboard"
 		pData.ChartAccounts = values
@@ -321,7 +321,7 @@ func reportHandler(w http.ResponseWriter, r *http.Request) {
 		}
 
 		var pData piePageData
-		pData.Init()
+		pData.Init(r)
 		pData.Transactions = vtrans
 		pData.ChartAccounts = values
 		pData.RangeStart = rStart
@@ -367,7 +367,7 @@ func reportHandler(w http.ResponseWriter, r *http.Request) {
 			DataSets             []lineData
 		}
 		var lData linePageData
-		lData.Init()
+		lData.Init(r)
 		lData.ReportName = reportName
 
 		for colorIdx, repAccount := range reportSummaryAccounts {
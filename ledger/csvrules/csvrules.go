@@ -0,0 +1,362 @@
+// Package csvrules reads rules files that describe how the records of a CSV
+// file of an institution become transactions, in the style of hledger rules
+// files.
+//
+// A rules file has a directive per line; blank lines and lines starting with
+// '#' or ';' are ignored:
+//
+//	skip 1
+//	separator ;
+//	fields date, description, , amount, note
+//	date-format 02.01.2006
+//	amount-sign negate
+//	account1 Assets:Checking
+//	comment %note
+//
+//	if grocer|supermarket
+//	  account2 Expenses:Food
+//
+//	if %description ^ATM
+//	  account2 Assets:Cash
+//	  description Cash withdrawal
+//
+// fields names the columns of each record; empty names skip a column. The
+// values of a record are assigned to the fields of a transaction (date,
+// description, amount, comment, account1, account2 and currency) of the same
+// name, where files with separate columns for money coming in and going out
+// name them amount-in and amount-out instead of amount, or by an assignment such as "comment %note", a template in which
+// %name (or %N, for the Nth column) is replaced by the value of the column.
+//
+// An if block assigns fields of the records it matches. Its matchers are the
+// regular expression on the if line and on each following unindented line,
+// any of which may match, and are matched, ignoring case, against the whole
+// record with its values joined by commas or, when starting with %name,
+// against that column. The indented lines that follow are the assignments;
+// "skip" leaves the matched records out. Later blocks override earlier ones.
+package csvrules
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Record is a transaction read from a CSV record. Date and Amount are as
+// written in the record, see Rules.DateFormat and Rules.NegateAmount.
+type Record struct {
+	Date        string
+	Description string
+	Amount      string
+	Comment     string
+	Account1    string // account of the CSV file, which receives Amount
+	Account2    string // other account, empty for unknown
+	Currency    string
+}
+
+// Rules are the rules read from a rules file.
+type Rules struct {
+	// Skip is the number of records, such as a header, before the
+	// transactions.
+	Skip int
+	// Separator separates the values of a record, ',' by default.
+	Separator rune
+	// Fields are the names of the columns of a record.
+	Fields []string
+	// DateFormat is the layout of the dates, in Go time format, empty when
+	// not set.
+	DateFormat string
+	// NegateAmount is set by "amount-sign negate", for files where money
+	// going out of account1 is positive.
+	NegateAmount bool
+
+	assignments  map[string]string
+	conditionals []*conditional
+}
+
+// conditional is an if block.
+type conditional struct {
+	matchers    []matcher
+	assignments map[string]string
+	skip        bool
+}
+
+type matcher struct {
+	field  string // empty for the whole record
+	regexp *regexp.Regexp
+}
+
+// assignable are the fields of a Record rules may assign.
+var assignable = map[string]bool{
+	"date":        true,
+	"description": true,
+	"amount":      true,
+	"amount-in":   true,
+	"amount-out":  true,
+	"comment":     true,
+	"account1":    true,
+	"account2":    true,
+	"currency":    true,
+}
+
+// Parse reads the rules from r.
+func Parse(r io.Reader) (*Rules, error) {
+	rules := &Rules{
+		Separator:   ',',
+		assignments: make(map[string]string),
+	}
+
+	var current *conditional
+	inAssignments := false
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed[0] == '#' || trimmed[0] == ';' {
+			continue
+		}
+		indented := line[0] == ' ' || line[0] == '\t'
+		directive, value, _ := strings.Cut(trimmed, " ")
+		value = strings.TrimSpace(value)
+
+		// matchers and assignments of an if block
+		if current != nil {
+			if indented {
+				inAssignments = true
+				if directive == "skip" {
+					current.skip = true
+				} else if assignable[directive] {
+					current.assignments[directive] = value
+				} else {
+					return nil, fmt.Errorf("line %d: can not assign %q", lineNum, directive)
+				}
+				continue
+			}
+			if !inAssignments && directive != "if" {
+				m, err := parseMatcher(trimmed)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", lineNum, err)
+				}
+				current.matchers = append(current.matchers, m)
+				continue
+			}
+			current = nil
+		}
+		if indented {
+			return nil, fmt.Errorf("line %d: unexpected indented line", lineNum)
+		}
+
+		switch {
+		case directive == "if":
+			current = &conditional{assignments: make(map[string]string)}
+			inAssignments = false
+			if value != "" {
+				m, err := parseMatcher(value)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", lineNum, err)
+				}
+				current.matchers = append(current.matchers, m)
+			}
+			rules.conditionals = append(rules.conditionals, current)
+		case directive == "skip":
+			skip := 1
+			if value != "" {
+				var err error
+				if skip, err = strconv.Atoi(value); err != nil {
+					return nil, fmt.Errorf("line %d: skip: %w", lineNum, err)
+				}
+			}
+			rules.Skip = skip
+		case directive == "separator":
+			switch value {
+			case "TAB", `\t`:
+				rules.Separator = '\t'
+			case "SEMICOLON":
+				rules.Separator = ';'
+			case "SPACE":
+				rules.Separator = ' '
+			default:
+				sep, size := utf8.DecodeRuneInString(value)
+				if size == 0 || size != len(value) {
+					return nil, fmt.Errorf("line %d: separator must be a single character", lineNum)
+				}
+				rules.Separator = sep
+			}
+		case directive == "fields":
+			rules.Fields = nil
+			for field := range strings.SplitSeq(value, ",") {
+				rules.Fields = append(rules.Fields, strings.ToLower(strings.TrimSpace(field)))
+			}
+		case directive == "date-format":
+			rules.DateFormat = value
+		case directive == "amount-sign":
+			switch value {
+			case "negate":
+				rules.NegateAmount = true
+			case "normal":
+				rules.NegateAmount = false
+			default:
+				return nil, fmt.Errorf("line %d: amount-sign must be normal or negate", lineNum)
+			}
+		case assignable[directive]:
+			rules.assignments[directive] = value
+		default:
+			return nil, fmt.Errorf("line %d: unknown directive %q", lineNum, directive)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for _, c := range rules.conditionals {
+		if len(c.matchers) == 0 {
+			return nil, fmt.Errorf("if without a pattern")
+		}
+	}
+	return rules, nil
+}
+
+// parseMatcher parses "[%field] REGEX".
+func parseMatcher(s string) (matcher, error) {
+	var m matcher
+	if strings.HasPrefix(s, "%") {
+		field, pattern, ok := strings.Cut(s[1:], " ")
+		if !ok {
+			return m, fmt.Errorf("missing pattern after %%%s", field)
+		}
+		m.field = strings.ToLower(field)
+		s = strings.TrimSpace(pattern)
+	}
+	re, err := regexp.Compile("(?i)" + s)
+	if err != nil {
+		return m, err
+	}
+	m.regexp = re
+	return m, nil
+}
+
+// value returns the value of the named column of the record, or of the Nth
+// column when name is a number.
+func (r *Rules) value(record []string, name string) (string, bool) {
+	if n, err := strconv.Atoi(name); err == nil {
+		if n >= 1 && n <= len(record) {
+			return record[n-1], true
+		}
+		return "", false
+	}
+	for i, field := range r.Fields {
+		if field == name && field != "" && i < len(record) {
+			return record[i], true
+		}
+	}
+	return "", false
+}
+
+var templateField = regexp.MustCompile(`%([A-Za-z0-9_-]+)`)
+
+// expand replaces each %name of the template with the value of the column.
+func (r *Rules) expand(record []string, template string) string {
+	return templateField.ReplaceAllStringFunc(template, func(ref string) string {
+		v, _ := r.value(record, strings.ToLower(ref[1:]))
+		return v
+	})
+}
+
+func (c *conditional) matches(r *Rules, record []string) bool {
+	for _, m := range c.matchers {
+		text := strings.Join(record, ",")
+		if m.field != "" {
+			text, _ = r.value(record, m.field)
+		}
+		if m.regexp.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply returns the transaction of the record, or false when a rule skips
+// it.
+func (r *Rules) Apply(record []string) (Record, bool) {
+	templates := make(map[string]string, len(assignable))
+	for name := range assignable {
+		if _, ok := r.value(record, name); ok {
+			templates[name] = "%" + name
+		}
+	}
+	for name, template := range r.assignments {
+		templates[name] = template
+	}
+	for _, c := range r.conditionals {
+		if !c.matches(r, record) {
+			continue
+		}
+		if c.skip {
+			return Record{}, false
+		}
+		for name, template := range c.assignments {
+			templates[name] = template
+		}
+	}
+
+	field := func(name string) string {
+		return strings.TrimSpace(r.expand(record, templates[name]))
+	}
+	amount := field("amount")
+	if amount == "" {
+		amount = signedAmount(field("amount-in"), field("amount-out"))
+	}
+	return Record{
+		Date:        field("date"),
+		Description: field("description"),
+		Amount:      amount,
+		Comment:     field("comment"),
+		Account1:    field("account1"),
+		Account2:    field("account2"),
+		Currency:    field("currency"),
+	}, true
+}
+
+// signedAmount returns the amount of a record with separate columns for money
+// coming in and going out, one of which is usually blank or zero: the amount
+// in, or else the amount out made negative. Either may be written with a sign.
+func signedAmount(in, out string) string {
+	if !blankAmount(in) || blankAmount(out) {
+		return strings.TrimLeft(in, "+-")
+	}
+	return "-" + strings.TrimLeft(out, "+-")
+}
+
+// blankAmount returns whether the amount is empty or zero.
+func blankAmount(amount string) bool {
+	f, err := strconv.ParseFloat(amount, 64)
+	return amount == "" || (err == nil && f == 0)
+}
+
+// Records reads the CSV file from reader and returns its transactions,
+// leaving out the skipped records.
+func (r *Rules) Records(reader io.Reader) ([]Record, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.Comma = r.Separator
+	csvReader.FieldsPerRecord = -1
+	csvRecords, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for i, csvRecord := range csvRecords {
+		if i < r.Skip {
+			continue
+		}
+		if rec, ok := r.Apply(csvRecord); ok {
+			records = append(records, rec)
+		}
+	}
+	return records, nil
+}
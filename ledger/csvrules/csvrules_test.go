@@ -0,0 +1,102 @@
+package csvrules_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/howeyc/ledger/ledger/csvrules"
+)
+
+const sampleRules = `# bank export
+skip 1
+separator ;
+fields date, description, , amount, note
+date-format 02.01.2006
+amount-sign negate
+account1 Assets:Checking
+comment %note (%3)
+
+if grocer
+supermarket
+  account2 Expenses:Food
+
+if %description ^ATM
+  account2 Assets:Cash
+  description Cash withdrawal
+
+if %note ^internal
+  skip
+`
+
+const sampleCSV = `Date;Description;Ref;Amount;Note
+01.02.2024;Corner Grocer;r1;12.50;weekly
+02.02.2024;ATM Main St;r2;100;
+03.02.2024;SUPERMARKET;r3;30;
+04.02.2024;Transfer;r4;5;internal move
+05.02.2024;Bookshop;r5;20;gift
+`
+
+func TestRecords(t *testing.T) {
+	rules, err := csvrules.Parse(strings.NewReader(sampleRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules.DateFormat != "02.01.2006" || !rules.NegateAmount || rules.Separator != ';' || rules.Skip != 1 {
+		t.Errorf("unexpected rules: %+v", rules)
+	}
+
+	records, err := rules.Records(strings.NewReader(sampleCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []csvrules.Record{
+		{Date: "01.02.2024", Description: "Corner Grocer", Amount: "12.50", Comment: "weekly (r1)", Account1: "Assets:Checking", Account2: "Expenses:Food"},
+		{Date: "02.02.2024", Description: "Cash withdrawal", Amount: "100", Comment: "(r2)", Account1: "Assets:Checking", Account2: "Assets:Cash"},
+		{Date: "03.02.2024", Description: "SUPERMARKET", Amount: "30", Comment: "(r3)", Account1: "Assets:Checking", Account2: "Expenses:Food"},
+		{Date: "05.02.2024", Description: "Bookshop", Amount: "20", Comment: "gift (r5)", Account1: "Assets:Checking"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("got %d records, want %d: %+v", len(records), len(want), records)
+	}
+	for i := range want {
+		if records[i] != want[i] {
+			t.Errorf("record %d = %+v, want %+v", i, records[i], want[i])
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"unknown directive":  "frobnicate yes\n",
+		"bad assignment":     "if x\n  fields a\n",
+		"if without pattern": "if\n  account2 A\n",
+		"bad separator":      "separator ab\n",
+		"bad regexp":         "if (\n  account2 A\n",
+		"stray indentation":  "  account2 A\n",
+	}
+	for name, rules := range tests {
+		if _, err := csvrules.Parse(strings.NewReader(rules)); err == nil {
+			t.Errorf("%s: Parse succeeded unexpectedly", name)
+		}
+	}
+}
+
+func TestAmountInOut(t *testing.T) {
+	rules, err := csvrules.Parse(strings.NewReader("skip 1\nfields date, description, amount-out, amount-in\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := rules.Records(strings.NewReader("Date,Description,Debit,Credit\n2024-01-02,Grocer,50.00,\n2024-01-03,Salary,,1000\n2024-01-04,Refund,0.00,-5\n2024-01-05,Fee,-2,0\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"-50.00", "1000", "5", "-2"}
+	if len(records) != len(want) {
+		t.Fatalf("got %d records, want %d", len(records), len(want))
+	}
+	for i, w := range want {
+		if records[i].Amount != w {
+			t.Errorf("entry %d: expected amount %q, got %q", i, w, records[i].Amount)
+		}
+	}
+}
@@ -2,7 +2,8 @@
 // ANSI colors on standard output.
 //
 // Modified to output the color string to a StringWriter with fixed-width
-// formatting (spaces for padding). Minimal color and attribute support.
+// formatting (spaces for padding). Minimal color and attribute support, with
+// 256-color and truecolor foregrounds and themes mapping report roles to colors.
 package fastcolor
 
 import (
@@ -19,6 +20,7 @@ type Color string
 const (
 	Reset     Color = "0"
 	Bold      Color = "1"
+	Underline Color = "4"
 	FgBlack   Color = "30"
 	FgRed     Color = "31"
 	FgGreen   Color = "32"
@@ -62,7 +64,16 @@ func (c Color) WriteStringFixed(w io.StringWriter, s string, width int, leftpad
 			w.WriteString(spaceStr[:spaces])
 		}
 	} else {
-		w.WriteString(s[:width])
+		// cut at a rune boundary, as symbols such as € take several bytes
+		cut := len(s)
+		for i := range s {
+			if width == 0 {
+				cut = i
+				break
+			}
+			width--
+		}
+		w.WriteString(s[:cut])
 	}
 
 	if !NoColor {
@@ -0,0 +1,108 @@
+package fastcolor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var colorNames = map[string]Color{
+	"reset":     Reset,
+	"default":   Reset,
+	"bold":      Bold,
+	"underline": Underline,
+	"black":     FgBlack,
+	"red":       FgRed,
+	"green":     FgGreen,
+	"yellow":    FgYellow,
+	"blue":      FgBlue,
+	"magenta":   FgMagenta,
+	"cyan":      FgCyan,
+	"white":     FgWhite,
+}
+
+// Fg256 returns the foreground color n of the 256-color palette.
+func Fg256(n uint8) Color {
+	return Color("38;5;" + strconv.Itoa(int(n)))
+}
+
+// FgRGB returns a 24-bit (truecolor) foreground color.
+func FgRGB(r, g, b uint8) Color {
+	return Color(fmt.Sprintf("38;2;%d;%d;%d", r, g, b))
+}
+
+// ParseColor parses a color from space separated words, each a color or
+// attribute name (such as "red" or "bold"), a 256-color palette number (such
+// as "208") or a truecolor hex value (such as "#ff8700"). For example
+// "bold #ff8700".
+func ParseColor(s string) (Color, error) {
+	var codes []string
+	for _, word := range strings.Fields(s) {
+		word = strings.ToLower(word)
+		if c, ok := colorNames[word]; ok {
+			codes = append(codes, string(c))
+			continue
+		}
+		if hex, ok := strings.CutPrefix(word, "#"); ok && len(hex) == 6 {
+			rgb, err := strconv.ParseUint(hex, 16, 32)
+			if err == nil {
+				codes = append(codes, string(FgRGB(uint8(rgb>>16), uint8(rgb>>8), uint8(rgb))))
+				continue
+			}
+		}
+		if n, err := strconv.ParseUint(word, 10, 8); err == nil {
+			codes = append(codes, string(Fg256(uint8(n))))
+			continue
+		}
+		return "", fmt.Errorf("unknown color %q", word)
+	}
+	if len(codes) == 0 {
+		return "", fmt.Errorf("empty color")
+	}
+	return Color(strings.Join(codes, ";")), nil
+}
+
+// Theme maps the roles of text in reports to colors.
+type Theme struct {
+	Negative Color // negative amounts
+	Positive Color // positive amounts where they are colored, such as chart bars
+	Account  Color // account names
+	Payee    Color // payees
+	Header   Color // column and section headers
+}
+
+// DefaultTheme is the theme used unless another is configured.
+var DefaultTheme = Theme{
+	Negative: FgRed,
+	Positive: FgGreen,
+	Account:  FgBlue,
+	Payee:    Bold,
+	Header:   Bold,
+}
+
+// CurrentTheme is the theme reports are colored with.
+var CurrentTheme = DefaultTheme
+
+// SetRole sets the color of the named role (negative, positive, account,
+// payee or header) to the color parsed by ParseColor.
+func (t *Theme) SetRole(role, color string) error {
+	c, err := ParseColor(color)
+	if err != nil {
+		return fmt.Errorf("%s: %w", role, err)
+	}
+	switch role {
+	case "negative":
+		t.Negative = c
+	case "positive":
+		t.Positive = c
+	case "account":
+		t.Account = c
+	case "payee":
+		t.Payee = c
+	case "header":
+		t.Header = c
+	default:
+		return fmt.Errorf("unknown theme role %q", role)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package fastcolor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Color
+		err  bool
+	}{
+		{"red", FgRed, false},
+		{"Bold  Blue", "1;34", false},
+		{"208", "38;5;208", false},
+		{"#ff8700", "38;2;255;135;0", false},
+		{"underline #00FF00", "4;38;2;0;255;0", false},
+		{"256", "", true},
+		{"#ff87", "", true},
+		{"purple", "", true},
+		{"", "", true},
+	}
+	for _, tc := range tests {
+		got, err := ParseColor(tc.in)
+		if (err != nil) != tc.err || got != tc.want {
+			t.Errorf("%q: got %q (%v), want %q", tc.in, got, err, tc.want)
+		}
+	}
+}
+
+func TestThemeSetRole(t *testing.T) {
+	theme := DefaultTheme
+	if err := theme.SetRole("negative", "#d70000"); err != nil {
+		t.Fatal(err)
+	}
+	if theme.Negative != "38;2;215;0;0" || theme.Account != FgBlue {
+		t.Errorf("unexpected theme %+v", theme)
+	}
+	if err := theme.SetRole("total", "red"); err == nil {
+		t.Error("expected error for unknown role")
+	}
+}
+
+func TestWriteStringFixed(t *testing.T) {
+	noColor := NoColor
+	defer func() { NoColor = noColor }()
+
+	var sb strings.Builder
+	NoColor = false
+	Fg256(208).WriteStringFixed(&sb, "abc", 5, true)
+	if got, want := sb.String(), "\x1b[38;5;208m  abc\x1b[0m"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	sb.Reset()
+	NoColor = true
+	FgRed.WriteStringFixed(&sb, "abcdef", 4, false)
+	if got, want := sb.String(), "abcd"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	sb.Reset()
+	FgRed.WriteStringFixed(&sb, "1,50 €€", 6, false)
+	if got, want := sb.String(), "1,50 €"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,300 @@
+// Package jsonmap reads mapping files that describe how the transactions of
+// a JSON export, such as the response of a bank or budgeting app API, are
+// found and read.
+//
+// A mapping file has a directive per line; blank lines and lines starting
+// with '#' or ';' are ignored:
+//
+//	records $.data.transactions
+//	date booked_at
+//	date-format 2006-01-02T15:04:05Z07:00
+//	amount amount.value
+//	currency amount.currency
+//	payee merchant.name | description
+//	id id
+//	comment notes
+//	amount-sign negate
+//
+// records selects the array of transactions, the whole document when not
+// set. The selectors of the date, amount, payee, id, currency and comment
+// directives are read from each transaction. A selector is a path of object
+// keys and array indexes, as in "$.legs[0].amount" or "['booking date']",
+// where the leading "$." is optional. Alternatives separated by '|' are tried
+// in order; the first that is found and not empty is used.
+//
+// date-format is the layout of the dates in Go time format, or "unix" and
+// "unixms" for seconds and milliseconds since the epoch.
+package jsonmap
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record is a transaction read from a JSON export, with its values as
+// written in it. Values not found are empty.
+type Record struct {
+	Date     string
+	Payee    string
+	Amount   string
+	ID       string
+	Currency string
+	Comment  string
+}
+
+// Mapping is the mapping read from a mapping file.
+type Mapping struct {
+	// Transactions selects the array of transactions, set by "records",
+	// empty for the document.
+	Transactions string
+	// DateFormat is the layout of the dates, in Go time format or "unix"
+	// or "unixms", empty when not set.
+	DateFormat string
+	// NegateAmount is set by "amount-sign negate", for exports where money
+	// going out of the account is positive.
+	NegateAmount bool
+
+	selectors map[string][]string
+}
+
+// fields are the fields of a Record mapping files may select.
+var fields = map[string]bool{
+	"date":     true,
+	"payee":    true,
+	"amount":   true,
+	"id":       true,
+	"currency": true,
+	"comment":  true,
+}
+
+// Parse reads the mapping from r.
+func Parse(r io.Reader) (*Mapping, error) {
+	mapping := &Mapping{selectors: make(map[string][]string)}
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		directive, value, _ := strings.Cut(line, " ")
+		value = strings.TrimSpace(value)
+
+		switch {
+		case directive == "records":
+			if _, err := splitPath(value); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+			mapping.Transactions = value
+		case directive == "date-format":
+			mapping.DateFormat = value
+		case directive == "amount-sign":
+			switch value {
+			case "negate":
+				mapping.NegateAmount = true
+			case "normal":
+				mapping.NegateAmount = false
+			default:
+				return nil, fmt.Errorf("line %d: amount-sign must be normal or negate", lineNum)
+			}
+		case fields[directive]:
+			var selectors []string
+			for selector := range strings.SplitSeq(value, "|") {
+				selector = strings.TrimSpace(selector)
+				if _, err := splitPath(selector); err != nil {
+					return nil, fmt.Errorf("line %d: %w", lineNum, err)
+				}
+				selectors = append(selectors, selector)
+			}
+			mapping.selectors[directive] = selectors
+		default:
+			return nil, fmt.Errorf("line %d: unknown directive %q", lineNum, directive)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for _, name := range []string{"date", "amount"} {
+		if len(mapping.selectors[name]) == 0 {
+			return nil, fmt.Errorf("no %s selector", name)
+		}
+	}
+	return mapping, nil
+}
+
+// Records reads the JSON export from reader and returns its transactions.
+func (m *Mapping) Records(reader io.Reader) ([]Record, error) {
+	decoder := json.NewDecoder(reader)
+	decoder.UseNumber()
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	list := doc
+	if m.Transactions != "" {
+		var ok bool
+		if list, ok = Lookup(doc, m.Transactions); !ok {
+			return nil, fmt.Errorf("records %s not found", m.Transactions)
+		}
+	}
+	items, ok := list.([]any)
+	if !ok {
+		return nil, fmt.Errorf("records %s is not an array", cmp.Or(m.Transactions, "$"))
+	}
+
+	records := make([]Record, 0, len(items))
+	for _, item := range items {
+		records = append(records, Record{
+			Date:     m.value(item, "date"),
+			Payee:    m.value(item, "payee"),
+			Amount:   m.value(item, "amount"),
+			ID:       m.value(item, "id"),
+			Currency: m.value(item, "currency"),
+			Comment:  m.value(item, "comment"),
+		})
+	}
+	return records, nil
+}
+
+// value returns the value of the first selector of the field found in item,
+// or "".
+func (m *Mapping) value(item any, field string) string {
+	for _, selector := range m.selectors[field] {
+		v, ok := Lookup(item, selector)
+		if !ok {
+			continue
+		}
+		if s := strings.TrimSpace(text(v)); s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// ParseDate parses a date of a record in the date format of the mapping, or
+// in layout when it has none.
+func (m *Mapping) ParseDate(date, layout string) (time.Time, error) {
+	if m.DateFormat != "" {
+		layout = m.DateFormat
+	}
+	switch layout {
+	case "unix", "unixms":
+		n, err := strconv.ParseFloat(date, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if layout == "unixms" {
+			return time.UnixMilli(int64(n)).UTC(), nil
+		}
+		return time.Unix(int64(n), 0).UTC(), nil
+	}
+	return time.Parse(layout, date)
+}
+
+// text returns a JSON value as text: numbers as written and objects and
+// arrays as JSON.
+func text(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// Lookup returns the value selected by the path in a decoded JSON value.
+// Negative array indexes count from the end.
+func Lookup(v any, path string) (any, bool) {
+	steps, err := splitPath(path)
+	if err != nil {
+		return nil, false
+	}
+	for _, step := range steps {
+		switch node := v.(type) {
+		case map[string]any:
+			if step.isIndex {
+				return nil, false
+			}
+			var ok bool
+			if v, ok = node[step.key]; !ok {
+				return nil, false
+			}
+		case []any:
+			i := step.index
+			if !step.isIndex {
+				if i, err = strconv.Atoi(step.key); err != nil {
+					return nil, false
+				}
+			}
+			if i < 0 {
+				i += len(node)
+			}
+			if i < 0 || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// step is an object key or an array index.
+type step struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// splitPath splits a selector into its steps: keys separated by dots, and
+// indexes and quoted keys in brackets.
+func splitPath(path string) ([]step, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	var steps []step
+	for path != "" {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("missing ] in selector")
+			}
+			inner := strings.TrimSpace(path[1:end])
+			path = path[end+1:]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				steps = append(steps, step{key: inner[1 : len(inner)-1]})
+				continue
+			}
+			i, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("bad index [%s] in selector", inner)
+			}
+			steps = append(steps, step{index: i, isIndex: true})
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			steps = append(steps, step{key: path[:end]})
+			path = path[end:]
+		}
+	}
+	return steps, nil
+}
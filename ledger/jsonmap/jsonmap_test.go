@@ -0,0 +1,106 @@
+package jsonmap_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/howeyc/ledger/ledger/jsonmap"
+)
+
+const sampleMapping = `# budgeting app export
+records $.data.transactions
+date booked_at
+date-format unix
+amount ['amount'].value
+currency amount.currency
+payee merchant.name | description
+id id
+comment tags[-1]
+amount-sign negate
+`
+
+const sampleJSON = `{"data": {"transactions": [
+  {"id": "tx_1", "booked_at": 1704153600, "amount": {"value": 12.50, "currency": "EUR"},
+   "merchant": {"name": "Corner Grocer"}, "description": "CARD 1234", "tags": ["food", "weekly"]},
+  {"id": "tx_2", "booked_at": 1704240000, "amount": {"value": -1000, "currency": "EUR"},
+   "merchant": null, "description": "Salary", "tags": []}
+]}}`
+
+func TestRecords(t *testing.T) {
+	mapping, err := jsonmap.Parse(strings.NewReader(sampleMapping))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mapping.Transactions != "$.data.transactions" || mapping.DateFormat != "unix" || !mapping.NegateAmount {
+		t.Errorf("unexpected mapping: %+v", mapping)
+	}
+
+	records, err := mapping.Records(strings.NewReader(sampleJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []jsonmap.Record{
+		{Date: "1704153600", Payee: "Corner Grocer", Amount: "12.50", ID: "tx_1", Currency: "EUR", Comment: "weekly"},
+		{Date: "1704240000", Payee: "Salary", Amount: "-1000", ID: "tx_2", Currency: "EUR"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("got %d records, want %d: %+v", len(records), len(want), records)
+	}
+	for i := range want {
+		if records[i] != want[i] {
+			t.Errorf("record %d = %+v, want %+v", i, records[i], want[i])
+		}
+	}
+
+	date, err := mapping.ParseDate(records[0].Date, "2006-01-02")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !date.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("date = %v, want 2024-01-02", date)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		mapping string
+		err     string
+	}{
+		{"date d\namount a\nbalance b\n", `line 3: unknown directive "balance"`},
+		{"date d\namount a[x]\n", "line 2: bad index [x] in selector"},
+		{"date d\namount-sign flip\n", "line 2: amount-sign must be normal or negate"},
+		{"date d\npayee p\n", "no amount selector"},
+	}
+	for _, tt := range tests {
+		_, err := jsonmap.Parse(strings.NewReader(tt.mapping))
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("Parse(%q) error = %v, want %q", tt.mapping, err, tt.err)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	doc := map[string]any{
+		"legs": []any{map[string]any{"amount": "1"}, map[string]any{"amount": "2"}},
+		"a b":  "spaced",
+	}
+	tests := []struct {
+		path  string
+		want  any
+		found bool
+	}{
+		{"$.legs[0].amount", "1", true},
+		{"legs.1.amount", "2", true},
+		{"legs[-1].amount", "2", true},
+		{"['a b']", "spaced", true},
+		{"legs[2].amount", nil, false},
+		{"missing", nil, false},
+	}
+	for _, tt := range tests {
+		got, found := jsonmap.Lookup(doc, tt.path)
+		if found != tt.found || got != tt.want {
+			t.Errorf("Lookup(%q) = %v, %v, want %v, %v", tt.path, got, found, tt.want, tt.found)
+		}
+	}
+}
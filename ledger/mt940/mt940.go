@@ -0,0 +1,191 @@
+// Package mt940 parses SWIFT MT940 (customer statement) and MT942 (interim
+// transaction report) files, the statement exports of many European banks.
+package mt940
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Statement is a statement, or interim report, of an account.
+type Statement struct {
+	Reference    string // :20: transaction reference number
+	Account      string // :25: account identification
+	Number       string // :28C: statement number
+	Currency     string // of the opening balance, or of the floor limit of MT942
+	Transactions []Transaction
+}
+
+// Transaction is a statement line (:61:) with its information to the account
+// owner (:86:).
+type Transaction struct {
+	ValueDate     time.Time
+	EntryDate     time.Time // zero when not given
+	Amount        decimal.Decimal
+	TypeCode      string // such as NTRF, the identification code with its type letter
+	Reference     string // reference for the account owner
+	BankReference string // reference of the bank, after "//"
+	Supplementary string // supplementary details line of :61:
+	Payee         string // name of the other party of structured :86: information
+	Description   string // :86: information, the purpose when structured
+}
+
+// statementLine matches the :61: field.
+var statementLine = regexp.MustCompile(`^(\d{6})(\d{4})?(RC|RD|C|D)([A-Z])?(\d+,\d*)([NFS][A-Z0-9]{3})([^/]*)(?://(.*))?$`)
+
+// structuredInformation matches the start of structured :86: information.
+var structuredInformation = regexp.MustCompile(`^\d{3}\?`)
+
+// field is a tag with its content, continuation lines joined by newlines.
+type field struct {
+	tag     string
+	content string
+}
+
+// readFields returns the fields of the file, dropping the SWIFT block
+// wrapping ("{1:...}{2:...}{4:" and "-}") and the "-" lines between messages.
+func readFields(r io.Reader) ([]field, error) {
+	var fields []field
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		switch {
+		case strings.TrimSpace(line) == "", line == "-", line == "-}", strings.HasPrefix(line, "{"):
+			continue
+		case strings.HasPrefix(line, ":"):
+			tag, content, ok := strings.Cut(line[1:], ":")
+			if !ok {
+				return nil, fmt.Errorf("malformed tag line %q", line)
+			}
+			fields = append(fields, field{tag: tag, content: content})
+		case len(fields) > 0:
+			fields[len(fields)-1].content += "\n" + line
+		default:
+			return nil, fmt.Errorf("unexpected line %q before the first tag", line)
+		}
+	}
+	return fields, scanner.Err()
+}
+
+// Parse reads the statements of an MT940 or MT942 file.
+func Parse(r io.Reader) ([]Statement, error) {
+	fields, err := readFields(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var statements []Statement
+	var current *Statement
+	for _, f := range fields {
+		if f.tag == "20" || current == nil {
+			statements = append(statements, Statement{})
+			current = &statements[len(statements)-1]
+		}
+		switch f.tag {
+		case "20":
+			current.Reference = f.content
+		case "25":
+			current.Account = f.content
+		case "28C", "28":
+			current.Number = f.content
+		case "60F", "60M":
+			// C/D mark, date, currency and amount
+			if len(f.content) >= 10 {
+				current.Currency = f.content[7:10]
+			}
+		case "34F":
+			if current.Currency == "" && len(f.content) >= 3 {
+				current.Currency = f.content[:3]
+			}
+		case "61":
+			trans, err := parseStatementLine(f.content)
+			if err != nil {
+				return nil, err
+			}
+			current.Transactions = append(current.Transactions, trans)
+		case "86":
+			if n := len(current.Transactions); n > 0 {
+				current.Transactions[n-1].Payee, current.Transactions[n-1].Description = parseInformation(f.content)
+			}
+		}
+	}
+	return statements, nil
+}
+
+// parseStatementLine parses the :61: field: value date (YYMMDD), optional
+// entry date (MMDD), debit/credit mark, optional funds code, amount with a
+// decimal comma, transaction type, reference and bank reference, and a
+// supplementary details line.
+func parseStatementLine(content string) (Transaction, error) {
+	line, supplementary, _ := strings.Cut(content, "\n")
+	m := statementLine.FindStringSubmatch(line)
+	if m == nil {
+		return Transaction{}, fmt.Errorf("malformed statement line %q", line)
+	}
+
+	var trans Transaction
+	var err error
+	if trans.ValueDate, err = time.Parse("060102", m[1]); err != nil {
+		return trans, err
+	}
+	if m[2] != "" {
+		entry, err := time.Parse("0102", m[2])
+		if err != nil {
+			return trans, err
+		}
+		// the entry date is close to the value date, possibly across a
+		// year end
+		trans.EntryDate = time.Date(trans.ValueDate.Year(), entry.Month(), entry.Day(), 0, 0, 0, 0, time.UTC)
+		if trans.EntryDate.Sub(trans.ValueDate) > 180*24*time.Hour {
+			trans.EntryDate = trans.EntryDate.AddDate(-1, 0, 0)
+		} else if trans.ValueDate.Sub(trans.EntryDate) > 180*24*time.Hour {
+			trans.EntryDate = trans.EntryDate.AddDate(1, 0, 0)
+		}
+	}
+
+	if trans.Amount, err = decimal.NewFromString(strings.Replace(m[5], ",", ".", 1)); err != nil {
+		return trans, err
+	}
+	// debits and reversals of credits take money out of the account
+	if m[3] == "D" || m[3] == "RC" {
+		trans.Amount = trans.Amount.Neg()
+	}
+	trans.TypeCode = m[6]
+	trans.Reference = strings.TrimSpace(m[7])
+	trans.BankReference = strings.TrimSpace(m[8])
+	trans.Supplementary = strings.TrimSpace(supplementary)
+	return trans, nil
+}
+
+// parseInformation returns the payee and the description of the :86: field.
+// Structured information, as used by German banks, has a transaction code
+// followed by subfields "?NN": ?20 to ?29 and ?60 to ?63 are the purpose,
+// ?32 and ?33 the name of the other party. Other information is the
+// description as is, its lines joined.
+func parseInformation(content string) (payee, description string) {
+	content = strings.ReplaceAll(content, "\n", "")
+	if !structuredInformation.MatchString(content) {
+		return "", strings.TrimSpace(content)
+	}
+
+	var purpose, name []string
+	for i, sub := range strings.Split(content, "?") {
+		if i == 0 || len(sub) < 2 {
+			continue
+		}
+		code, value := sub[:2], strings.TrimSpace(sub[2:])
+		switch {
+		case code >= "20" && code <= "29", code >= "60" && code <= "63":
+			purpose = append(purpose, value)
+		case code == "32" || code == "33":
+			name = append(name, value)
+		}
+	}
+	return strings.TrimSpace(strings.Join(name, "")), strings.TrimSpace(strings.Join(purpose, " "))
+}
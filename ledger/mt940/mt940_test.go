@@ -0,0 +1,93 @@
+package mt940_test
+
+import (
+	"bytes"
+	_ "embed"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/howeyc/ledger/ledger/mt940"
+)
+
+//go:embed sample.sta
+var mt940Sample []byte
+
+func TestParse(t *testing.T) {
+	statements, err := mt940.Parse(bytes.NewReader(mt940Sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statements) != 1 {
+		t.Fatalf("Expected 1 statement, got %d", len(statements))
+	}
+	st := statements[0]
+	if st.Account != "10020030/1234567" || st.Currency != "EUR" || st.Number != "00001/001" {
+		t.Errorf("unexpected statement: %+v", st)
+	}
+	if len(st.Transactions) != 3 {
+		t.Fatalf("Expected 3 transactions, got %d", len(st.Transactions))
+	}
+
+	tests := []struct {
+		index       int
+		date        time.Time
+		amount      string
+		typeCode    string
+		reference   string
+		payee       string
+		description string
+	}{
+		{0, time.Date(2023, time.December, 29, 0, 0, 0, 0, time.UTC), "-100", "NTRF", "NONREF", "Hausverwaltung Muster GmbH", "EREF+20231229 SVWZ+Miete Januar"},
+		{1, time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC), "2500", "NTRF", "PAYROLL", "", "Salary December ACME Corp"},
+		{2, time.Date(2024, time.January, 3, 0, 0, 0, 0, time.UTC), "-15.5", "NCHG", "NONREF", "", "Fee reversal"},
+	}
+	for _, tt := range tests {
+		tr := st.Transactions[tt.index]
+		if !tr.ValueDate.Equal(tt.date) {
+			t.Errorf("transaction %d: expected ValueDate %s, got %s", tt.index, tt.date, tr.ValueDate)
+		}
+		if tr.Amount.String() != tt.amount {
+			t.Errorf("transaction %d: expected Amount %s, got %s", tt.index, tt.amount, tr.Amount)
+		}
+		if tr.TypeCode != tt.typeCode || tr.Reference != tt.reference {
+			t.Errorf("transaction %d: expected %s %s, got %s %s", tt.index, tt.typeCode, tt.reference, tr.TypeCode, tr.Reference)
+		}
+		if tr.Payee != tt.payee {
+			t.Errorf("transaction %d: expected Payee %q, got %q", tt.index, tt.payee, tr.Payee)
+		}
+		if tr.Description != tt.description {
+			t.Errorf("transaction %d: expected Description %q, got %q", tt.index, tt.description, tr.Description)
+		}
+	}
+	if first := st.Transactions[0]; first.BankReference != "B1229" || first.Supplementary != "/OCMT/EUR100,00/" {
+		t.Errorf("unexpected references: %+v", first)
+	}
+}
+
+func TestParseMT942(t *testing.T) {
+	report := `:20:INTRADAY
+:25:DE89370400440532013000
+:28C:1/1
+:34F:EURD0,
+:13D:2401151200+0100
+:61:240115D42,00NDDTNONREF
+:86:Card payment
+:90D:1EUR42,00
+:90C:0EUR0,
+`
+	statements, err := mt940.Parse(strings.NewReader(report))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statements) != 1 || statements[0].Currency != "EUR" || len(statements[0].Transactions) != 1 {
+		t.Fatalf("unexpected statements: %+v", statements)
+	}
+	if tr := statements[0].Transactions[0]; tr.Amount.String() != "-42" || !tr.EntryDate.IsZero() {
+		t.Errorf("unexpected transaction: %+v", tr)
+	}
+
+	if _, err := mt940.Parse(strings.NewReader(":20:X\n:61:garbage\n")); err == nil {
+		t.Error("malformed statement line parsed")
+	}
+}
@@ -0,0 +1,172 @@
+package qfx
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// Investment statement structures (INVSTMTMSGSRSV1) and the security list
+// (SECLISTMSGSRSV1) naming their securities.
+
+type InvStmtMsgsRsV1 struct {
+	InvStmtTrnRs InvStmtTrnRs `xml:"INVSTMTTRNRS"`
+}
+
+type InvStmtTrnRs struct {
+	InvStmtRs InvStmtRs `xml:"INVSTMTRS"`
+}
+
+type InvStmtRs struct {
+	CurDef      string      `xml:"CURDEF"`
+	InvTranList InvTranList `xml:"INVTRANLIST"`
+}
+
+// InvTranList holds the transactions of an investment statement in document
+// order, each element a kind of transaction such as BUYSTOCK.
+type InvTranList struct {
+	DtStart string          `xml:"DTSTART"`
+	DtEnd   string          `xml:"DTEND"`
+	Records []InvTranRecord `xml:",any"`
+}
+
+// InvTranRecord is a transaction element of the list. Buys and sells hold
+// their fields in an INVBUY or INVSELL aggregate, other kinds directly.
+type InvTranRecord struct {
+	XMLName xml.Name
+	InvBuy  InvTrade `xml:"INVBUY"`
+	InvSell InvTrade `xml:"INVSELL"`
+	InvTrade
+	IncomeType string `xml:"INCOMETYPE"`
+}
+
+// InvTrade are the fields of a buy, sell, income or reinvestment.
+type InvTrade struct {
+	InvTran    InvTran `xml:"INVTRAN"`
+	SecID      SecID   `xml:"SECID"`
+	Units      string  `xml:"UNITS"`
+	UnitPrice  string  `xml:"UNITPRICE"`
+	Commission string  `xml:"COMMISSION"`
+	Fees       string  `xml:"FEES"`
+	Total      string  `xml:"TOTAL"`
+}
+
+type InvTran struct {
+	FitID    string `xml:"FITID"`
+	DtTrade  string `xml:"DTTRADE"`
+	DtSettle string `xml:"DTSETTLE"`
+	Memo     string `xml:"MEMO"`
+}
+
+type SecID struct {
+	UniqueID     string `xml:"UNIQUEID"`
+	UniqueIDType string `xml:"UNIQUEIDTYPE"`
+}
+
+type SecListMsgsRsV1 struct {
+	SecList SecList `xml:"SECLIST"`
+}
+
+// SecList lists the securities by kind; only their common SECINFO is read.
+type SecList struct {
+	StockInfo []SecInfoHolder `xml:"STOCKINFO"`
+	MFInfo    []SecInfoHolder `xml:"MFINFO"`
+	DebtInfo  []SecInfoHolder `xml:"DEBTINFO"`
+	OptInfo   []SecInfoHolder `xml:"OPTINFO"`
+	OtherInfo []SecInfoHolder `xml:"OTHERINFO"`
+}
+
+type SecInfoHolder struct {
+	SecInfo SecInfo `xml:"SECINFO"`
+}
+
+type SecInfo struct {
+	SecID   SecID  `xml:"SECID"`
+	SecName string `xml:"SECNAME"`
+	Ticker  string `xml:"TICKER"`
+}
+
+// InvStmtTrn is a transaction of an investment statement with the security
+// it trades, flattened from the kinds of transactions.
+type InvStmtTrn struct {
+	// Kind is the element of the transaction: BUYSTOCK, BUYMF, SELLSTOCK,
+	// SELLMF, INCOME or REINVEST.
+	Kind       string
+	FitID      string
+	DtTrade    string
+	DtSettle   string
+	Memo       string
+	UniqueID   string // CUSIP or other identifier of the security
+	Ticker     string // from the security list, empty when not listed
+	SecName    string // from the security list, empty when not listed
+	Units      string // negative for sells
+	UnitPrice  string
+	Commission string
+	Fees       string
+	Total      string // change of cash: negative for buys and reinvestments
+	IncomeType string // of INCOME and REINVEST, such as DIV or INTEREST
+	Currency   string // CURDEF of the statement
+}
+
+// investmentKinds are the kinds of transactions ParseInvestments reads.
+var investmentKinds = map[string]bool{
+	"BUYSTOCK":  true,
+	"BUYMF":     true,
+	"SELLSTOCK": true,
+	"SELLMF":    true,
+	"INCOME":    true,
+	"REINVEST":  true,
+}
+
+// ParseInvestments parses a QFX/OFX document and returns the buys, sells,
+// income and reinvestments of the first investment statement response, in
+// the order of the document, with the ticker and name of their securities.
+func ParseInvestments(reader io.Reader) ([]InvStmtTrn, error) {
+	ofx, err := decode(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	securities := make(map[string]SecInfo)
+	list := ofx.SecListMsgsRsV1.SecList
+	for _, infos := range [][]SecInfoHolder{list.StockInfo, list.MFInfo, list.DebtInfo, list.OptInfo, list.OtherInfo} {
+		for _, info := range infos {
+			securities[info.SecInfo.SecID.UniqueID] = info.SecInfo
+		}
+	}
+
+	stmt := ofx.InvStmtMsgsRsV1.InvStmtTrnRs.InvStmtRs
+	var trns []InvStmtTrn
+	for _, record := range stmt.InvTranList.Records {
+		kind := strings.ToUpper(record.XMLName.Local)
+		if !investmentKinds[kind] {
+			continue
+		}
+		trade := record.InvTrade
+		switch {
+		case strings.HasPrefix(kind, "BUY"):
+			trade = record.InvBuy
+		case strings.HasPrefix(kind, "SELL"):
+			trade = record.InvSell
+		}
+		security := securities[trade.SecID.UniqueID]
+		trns = append(trns, InvStmtTrn{
+			Kind:       kind,
+			FitID:      trade.InvTran.FitID,
+			DtTrade:    trade.InvTran.DtTrade,
+			DtSettle:   trade.InvTran.DtSettle,
+			Memo:       trade.InvTran.Memo,
+			UniqueID:   trade.SecID.UniqueID,
+			Ticker:     security.Ticker,
+			SecName:    security.SecName,
+			Units:      trade.Units,
+			UnitPrice:  trade.UnitPrice,
+			Commission: trade.Commission,
+			Fees:       trade.Fees,
+			Total:      trade.Total,
+			IncomeType: record.IncomeType,
+			Currency:   stmt.CurDef,
+		})
+	}
+	return trns, nil
+}
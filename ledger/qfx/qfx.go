@@ -1,14 +1,23 @@
 package qfx
 
 import (
+	"bytes"
 	"encoding/xml"
+	"fmt"
 	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
 )
 
 // QFX/OFX XML structures (simplified for bank statement transactions)
 
 type OFX struct {
-	BankMsgsRsV1 BankMsgsRsV1 `xml:"BANKMSGSRSV1"`
+	BankMsgsRsV1       BankMsgsRsV1       `xml:"BANKMSGSRSV1"`
+	CreditCardMsgsRsV1 CreditCardMsgsRsV1 `xml:"CREDITCARDMSGSRSV1"`
+	InvStmtMsgsRsV1    InvStmtMsgsRsV1    `xml:"INVSTMTMSGSRSV1"`
+	SecListMsgsRsV1    SecListMsgsRsV1    `xml:"SECLISTMSGSRSV1"`
 }
 
 type BankMsgsRsV1 struct {
@@ -19,29 +28,214 @@ type StmtTrnRs struct {
 	StmtRs StmtRs `xml:"STMTRS"`
 }
 
+// CreditCardMsgsRsV1 holds a credit card statement, which has the elements
+// of a bank statement except for the account, CCACCTFROM.
+type CreditCardMsgsRsV1 struct {
+	CCStmtTrnRs CCStmtTrnRs `xml:"CCSTMTTRNRS"`
+}
+
+type CCStmtTrnRs struct {
+	CCStmtRs StmtRs `xml:"CCSTMTRS"`
+}
+
 type StmtRs struct {
+	CurDef       string       `xml:"CURDEF"`
+	BankAcctFrom AcctFrom     `xml:"BANKACCTFROM"`
+	CCAcctFrom   AcctFrom     `xml:"CCACCTFROM"`
 	BankTranList BankTranList `xml:"BANKTRANLIST"`
+	LedgerBal    Bal          `xml:"LEDGERBAL"`
+	AvailBal     Bal          `xml:"AVAILBAL"`
+}
+
+// AcctFrom identifies the account of a statement.
+type AcctFrom struct {
+	BankID   string `xml:"BANKID"`
+	AcctID   string `xml:"ACCTID"`
+	AcctType string `xml:"ACCTTYPE"`
+}
+
+// Bal is a balance of the account, the ledger balance (LEDGERBAL) with all
+// posted transactions or the balance available (AVAILBAL), as of a date. Both
+// are empty when the statement has none.
+type Bal struct {
+	BalAmt string `xml:"BALAMT"`
+	DtAsOf string `xml:"DTASOF"`
 }
 
 type BankTranList struct {
 	StmtTrn []StmtTrn `xml:"STMTTRN"`
 }
 
+// StmtTrn is a statement transaction. TrnAmt is signed from the account
+// holder's side: negative for debits of a bank account and for charges to a
+// credit card, positive for deposits and card payments or refunds.
 type StmtTrn struct {
 	TrnType  string `xml:"TRNTYPE"`
 	DtPosted string `xml:"DTPOSTED"`
 	TrnAmt   string `xml:"TRNAMT"`
 	FitID    string `xml:"FITID"`
+	Name     string `xml:"NAME"` // payee, the merchant of card transactions
 	Memo     string `xml:"MEMO"`
+	SIC      string `xml:"SIC"` // standard industrial code of the merchant
 }
 
-// ParseQFX parses a QFX/OFX XML document and returns the list of statement
-// transactions contained in the first bank statement response.
+// ParseQFX parses a QFX/OFX document and returns the list of statement
+// transactions contained in the first bank statement response, followed by
+// those of the first credit card statement response.
+//
+// Both OFX 2.x documents, which are XML, and OFX 1.x documents, which are
+// SGML with a header of "NAME:VALUE" lines and elements that are not closed,
+// are read; see Version.
 func ParseQFX(reader io.Reader) ([]StmtTrn, error) {
+	ofx, err := decode(reader)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Concat(ofx.BankMsgsRsV1.StmtTrnRs.StmtRs.BankTranList.StmtTrn,
+		ofx.CreditCardMsgsRsV1.CCStmtTrnRs.CCStmtRs.BankTranList.StmtTrn), nil
+}
+
+// ParseStatement parses a QFX/OFX document and returns the first bank
+// statement response, with its transactions and balances, or the first
+// credit card statement response of documents without a bank statement.
+func ParseStatement(reader io.Reader) (*StmtRs, error) {
+	ofx, err := decode(reader)
+	if err != nil {
+		return nil, err
+	}
+	if ofx.BankMsgsRsV1.StmtTrnRs.StmtRs.BankAcctFrom == (AcctFrom{}) &&
+		len(ofx.BankMsgsRsV1.StmtTrnRs.StmtRs.BankTranList.StmtTrn) == 0 {
+		return &ofx.CreditCardMsgsRsV1.CCStmtTrnRs.CCStmtRs, nil
+	}
+	return &ofx.BankMsgsRsV1.StmtTrnRs.StmtRs, nil
+}
+
+// decode reads an OFX document of either version.
+func decode(reader io.Reader) (*OFX, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	data = trimHeaderSpace(data)
+	if Version(data) == 1 {
+		if data, err = sgmlToXML(data); err != nil {
+			return nil, err
+		}
+	}
+
 	var ofx OFX
-	if err := xml.NewDecoder(reader).Decode(&ofx); err != nil {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = charsetReader
+	if err := decoder.Decode(&ofx); err != nil {
 		return nil, err
 	}
+	return &ofx, nil
+}
 
-	return ofx.BankMsgsRsV1.StmtTrnRs.StmtRs.BankTranList.StmtTrn, nil
+// Version returns the major OFX version of the document: 1 for SGML documents,
+// starting with an "OFXHEADER:100" header, otherwise 2.
+func Version(data []byte) int {
+	if bytes.HasPrefix(trimHeaderSpace(data), []byte("OFXHEADER:")) {
+		return 1
+	}
+	return 2
+}
+
+// trimHeaderSpace removes a byte order mark and blank lines before the header.
+func trimHeaderSpace(data []byte) []byte {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	return bytes.TrimLeft(data, " \t\r\n")
+}
+
+// charsetReader decodes the single byte character sets OFX documents are
+// declared in. Windows-1252 is read as ISO-8859-1, which only differs in
+// rarely used punctuation.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToUpper(strings.ReplaceAll(charset, "-", "")) {
+	case "USASCII", "ASCII", "UTF8":
+		return input, nil
+	case "ISO88591", "LATIN1", "WINDOWS1252", "CP1252", "1252":
+		data, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		return strings.NewReader(latin1ToUTF8(data)), nil
+	}
+	return nil, fmt.Errorf("unsupported charset %q", charset)
+}
+
+func latin1ToUTF8(data []byte) string {
+	var sb strings.Builder
+	for _, b := range data {
+		sb.WriteRune(rune(b))
+	}
+	return sb.String()
+}
+
+// sgmlTag matches the tags of an SGML document.
+var sgmlTag = regexp.MustCompile(`<(/?)([A-Za-z0-9._]+)>`)
+
+// entity matches the entity references of a value.
+var entity = regexp.MustCompile(`^&([A-Za-z]+|#[0-9]+|#x[0-9A-Fa-f]+);`)
+
+// sgmlToXML converts an OFX 1.x document to XML: the header is read for the
+// character set and dropped, and elements holding a value are closed where
+// the next tag starts.
+func sgmlToXML(data []byte) ([]byte, error) {
+	start := bytes.IndexByte(data, '<')
+	if start < 0 {
+		return nil, fmt.Errorf("missing OFX element")
+	}
+	header, body := data[:start], data[start:]
+
+	text := string(body)
+	for line := range strings.SplitSeq(string(header), "\n") {
+		name, value, _ := strings.Cut(strings.TrimSpace(line), ":")
+		if name == "CHARSET" && value != "" && !strings.EqualFold(value, "NONE") && !utf8.Valid(body) {
+			text = latin1ToUTF8(body)
+		}
+	}
+
+	var out strings.Builder
+	out.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
+	out.WriteString("\n")
+	tags := sgmlTag.FindAllStringSubmatchIndex(text, -1)
+	for i, tag := range tags {
+		closing := tag[3] > tag[2]
+		name := text[tag[4]:tag[5]]
+		out.WriteString(text[tag[0]:tag[1]])
+
+		valueEnd := len(text)
+		if i+1 < len(tags) {
+			valueEnd = tags[i+1][0]
+		}
+		value := text[tag[1]:valueEnd]
+		trimmed := strings.TrimSpace(value)
+		if closing || trimmed == "" {
+			out.WriteString(value)
+			continue
+		}
+
+		out.WriteString(escapeAmpersands(trimmed))
+		// the value ends the element unless it is closed explicitly
+		if i+1 >= len(tags) || text[tags[i+1][0]:tags[i+1][1]] != "</"+name+">" {
+			out.WriteString("</" + name + ">")
+		}
+		out.WriteString(value[len(strings.TrimRight(value, " \t\r\n")):])
+	}
+	return []byte(out.String()), nil
+}
+
+// escapeAmpersands escapes the '&' of a value that do not start an entity
+// reference, as in "AT&T".
+func escapeAmpersands(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '&' && !entity.MatchString(s[i:]) {
+			sb.WriteString("&amp;")
+			continue
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
 }
@@ -94,3 +94,194 @@ func TestParseQFX(t *testing.T) {
 		}
 	}
 }
+
+const sgmlSample = "OFXHEADER:100\r\nDATA:OFXSGML\r\nVERSION:102\r\nSECURITY:NONE\r\nENCODING:USASCII\r\nCHARSET:1252\r\nCOMPRESSION:NONE\r\nOLDFILEUID:NONE\r\nNEWFILEUID:NONE\r\n\r\n" +
+	`<OFX>
+<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0<SEVERITY>INFO</STATUS><DTSERVER>20250131120000</SONRS></SIGNONMSGSRSV1>
+<BANKMSGSRSV1>
+<STMTTRNRS>
+<TRNUID>1
+<STMTRS>
+<CURDEF>USD
+<BANKTRANLIST>
+<DTSTART>20250101
+<DTEND>20250131
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20250105
+<TRNAMT>-42.10
+<FITID>A1
+<MEMO>AT&T WIRELESS &amp; CO
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20250115120000.000[-5:EST]
+<TRNAMT>1000.00
+<FITID>A2</FITID>
+<MEMO>Caf` + "\xe9" + ` payroll
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS>
+</STMTTRNRS>
+</BANKMSGSRSV1>
+</OFX>
+`
+
+func TestParseQFXVersion1(t *testing.T) {
+	if v := qfx.Version([]byte(sgmlSample)); v != 1 {
+		t.Errorf("Version() = %d, want 1", v)
+	}
+	if v := qfx.Version(qfxSample); v != 2 {
+		t.Errorf("Version() of the xml sample = %d, want 2", v)
+	}
+
+	entries, err := qfx.ParseQFX(bytes.NewBufferString(sgmlSample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []qfx.StmtTrn{
+		{TrnType: "DEBIT", DtPosted: "20250105", TrnAmt: "-42.10", FitID: "A1", Memo: "AT&T WIRELESS & CO"},
+		{TrnType: "CREDIT", DtPosted: "20250115120000.000[-5:EST]", TrnAmt: "1000.00", FitID: "A2", Memo: "Café payroll"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("Expected %d entries, got %d", len(want), len(entries))
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("entry %d: expected %+v, got %+v", i, want[i], entries[i])
+		}
+	}
+}
+
+func TestParseQFXVersion2Header(t *testing.T) {
+	// byte order mark, non UTF-8 encoding declaration and OFX processing
+	// instruction
+	doc := "\xef\xbb\xbf\n" + `<?xml version="1.0" encoding="US-ASCII" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="211" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS><BANKTRANLIST>
+<STMTTRN><TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>20250105</DTPOSTED><TRNAMT>-5</TRNAMT><FITID>B1</FITID><MEMO>Coffee</MEMO></STMTTRN>
+</BANKTRANLIST></STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>
+`
+	entries, err := qfx.ParseQFX(bytes.NewBufferString(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].FitID != "B1" || entries[0].Memo != "Coffee" {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+}
+
+//go:embed sample_invest.qfx
+var investSample []byte
+
+func TestParseInvestments(t *testing.T) {
+	trns, err := qfx.ParseInvestments(bytes.NewBuffer(investSample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []qfx.InvStmtTrn{
+		{Kind: "BUYSTOCK", FitID: "T1", DtTrade: "20250106", DtSettle: "20250108", Memo: "BOUGHT VTI", UniqueID: "922908769", Ticker: "VTI", SecName: "Vanguard Total Stock Market ETF", Units: "10", UnitPrice: "250.25", Commission: "4.95", Fees: "0.05", Total: "-2507.50", Currency: "USD"},
+		{Kind: "INCOME", FitID: "T2", DtTrade: "20250131", UniqueID: "922908769", Ticker: "VTI", SecName: "Vanguard Total Stock Market ETF", Total: "8.12", IncomeType: "DIV", Currency: "USD"},
+		{Kind: "REINVEST", FitID: "T3", DtTrade: "20250131", UniqueID: "922908769", Ticker: "VTI", SecName: "Vanguard Total Stock Market ETF", Units: "0.032", UnitPrice: "253.75", Total: "-8.12", IncomeType: "DIV", Currency: "USD"},
+		{Kind: "SELLMF", FitID: "T5", DtTrade: "20250215", Memo: "SOLD FUND", UniqueID: "315911750", SecName: "Fidelity 500 Index Fund", Units: "-5.5", UnitPrice: "100", Total: "550.00", Currency: "USD"},
+	}
+	if len(trns) != len(want) {
+		t.Fatalf("Expected %d entries, got %d: %+v", len(want), len(trns), trns)
+	}
+	for i := range want {
+		if trns[i] != want[i] {
+			t.Errorf("entry %d: expected %+v, got %+v", i, want[i], trns[i])
+		}
+	}
+
+	// a bank statement has no investments
+	if trns, err := qfx.ParseInvestments(bytes.NewBuffer(qfxSample)); err != nil || len(trns) != 0 {
+		t.Errorf("ParseInvestments of a bank statement = %+v, %v", trns, err)
+	}
+}
+
+func TestParseStatement(t *testing.T) {
+	stmt, err := qfx.ParseStatement(bytes.NewBuffer(qfxSample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stmt.BankTranList.StmtTrn) != 26 {
+		t.Errorf("Expected 26 entries, got %d", len(stmt.BankTranList.StmtTrn))
+	}
+	want := qfx.Bal{BalAmt: "273.18", DtAsOf: "20260211073846.061"}
+	if stmt.LedgerBal != want {
+		t.Errorf("expected LedgerBal %+v, got %+v", want, stmt.LedgerBal)
+	}
+	if stmt.AvailBal != (qfx.Bal{}) {
+		t.Errorf("expected no AvailBal, got %+v", stmt.AvailBal)
+	}
+}
+
+const creditCardSample = "OFXHEADER:100\r\nDATA:OFXSGML\r\nVERSION:102\r\nSECURITY:NONE\r\nENCODING:USASCII\r\nCHARSET:1252\r\nCOMPRESSION:NONE\r\nOLDFILEUID:NONE\r\nNEWFILEUID:NONE\r\n\r\n" +
+	`<OFX>
+<CREDITCARDMSGSRSV1>
+<CCSTMTTRNRS>
+<TRNUID>1
+<CCSTMTRS>
+<CURDEF>USD
+<CCACCTFROM><ACCTID>4111111111111111</CCACCTFROM>
+<BANKTRANLIST>
+<DTSTART>20250101
+<DTEND>20250131
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20250110
+<TRNAMT>-25.40
+<FITID>C1
+<SIC>5411
+<NAME>CORNER GROCER
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20250125
+<TRNAMT>200.00
+<FITID>C2
+<NAME>PAYMENT - THANK YOU
+<MEMO>AUTOPAY
+</STMTTRN>
+</BANKTRANLIST>
+<LEDGERBAL><BALAMT>-325.40<DTASOF>20250131</LEDGERBAL>
+<AVAILBAL><BALAMT>4674.60<DTASOF>20250131</AVAILBAL>
+</CCSTMTRS>
+</CCSTMTTRNRS>
+</CREDITCARDMSGSRSV1>
+</OFX>
+`
+
+func TestParseCreditCard(t *testing.T) {
+	entries, err := qfx.ParseQFX(bytes.NewBufferString(creditCardSample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []qfx.StmtTrn{
+		{TrnType: "DEBIT", DtPosted: "20250110", TrnAmt: "-25.40", FitID: "C1", Name: "CORNER GROCER", SIC: "5411"},
+		{TrnType: "CREDIT", DtPosted: "20250125", TrnAmt: "200.00", FitID: "C2", Name: "PAYMENT - THANK YOU", Memo: "AUTOPAY"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("Expected %d entries, got %d", len(want), len(entries))
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("entry %d: expected %+v, got %+v", i, want[i], entries[i])
+		}
+	}
+
+	stmt, err := qfx.ParseStatement(bytes.NewBufferString(creditCardSample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stmt.CCAcctFrom.AcctID != "4111111111111111" || stmt.CurDef != "USD" {
+		t.Errorf("unexpected statement %+v", stmt)
+	}
+	if stmt.LedgerBal.BalAmt != "-325.40" || stmt.AvailBal.BalAmt != "4674.60" {
+		t.Errorf("unexpected balances %+v, %+v", stmt.LedgerBal, stmt.AvailBal)
+	}
+	if len(stmt.BankTranList.StmtTrn) != 2 {
+		t.Errorf("Expected 2 statement entries, got %d", len(stmt.BankTranList.StmtTrn))
+	}
+}
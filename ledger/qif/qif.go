@@ -13,6 +13,10 @@ type Transaction struct {
 	// Header/type line, e.g. "!Type:Cash"
 	Type string `qif:"header"`
 
+	// Account is the name of the account of the "!Account" block before
+	// the transactions, empty for files of a single account without one.
+	Account string `qif:"-"`
+
 	// Core transaction fields
 	Date   string `qif:"D"` // D - Date
 	Amount string `qif:"T"` // T - Amount
@@ -23,16 +27,53 @@ type Transaction struct {
 	Cleared string `qif:"C"` // C - Cleared status
 	Category string `qif:"L"` // L - Category (or transfer/class)
 
-	// Split fields – repeated groups, flattened for now to first occurrence
-	SplitCategory string `qif:"S"` // S - Category in split
-	SplitMemo     string `qif:"E"` // E - Memo in split
-	SplitAmount   string `qif:"$"` // $ - Dollar amount of split
+	// Splits of the amount among categories, in order; none when the
+	// transaction is not split.
+	Splits []Split `qif:"-"`
+
+	// Deprecated: SplitCategory is the category of the first split; use Splits.
+	SplitCategory string `qif:"S"`
+	// Deprecated: SplitMemo is the memo of the first split; use Splits.
+	SplitMemo string `qif:"E"`
+	// Deprecated: SplitAmount is the amount of the first split; use Splits.
+	SplitAmount string `qif:"$"`
 
 	// RawLines contains the raw QIF lines (without trailing newline) that
 	// composed this transaction, excluding the header and trailing '^'.
 	RawLines []string `qif:"-"`
 }
 
+// Split is a part of a split transaction, from a group of S, E and $ lines.
+type Split struct {
+	Category string `qif:"S"` // S - Category in split
+	Memo     string `qif:"E"` // E - Memo in split
+	Amount   string `qif:"$"` // $ - Dollar amount of split
+}
+
+// Account is an account of an "!Account" block, which precedes the
+// transactions of the account in files exporting several accounts, or lists
+// the accounts between "!Option:AutoSwitch" and "!Clear:AutoSwitch".
+type Account struct {
+	Name        string `qif:"N"` // N - Name
+	Type        string `qif:"T"` // T - Type, such as Bank or CCard
+	Description string `qif:"D"` // D - Description
+}
+
+// Category is a category of the "!Type:Cat" category list.
+type Category struct {
+	Name        string `qif:"N"` // N - Name, subcategories separated by ':'
+	Description string `qif:"D"` // D - Description
+	Income      bool   `qif:"I"` // I - Income category, otherwise expense
+	Tax         bool   `qif:"T"` // T - Tax related
+}
+
+// File is the content of a QIF file.
+type File struct {
+	Transactions []*Transaction
+	Accounts     []Account
+	Categories   []Category
+}
+
 // Decoder reads QIF data from an input stream.
 type Decoder struct {
 	r *bufio.Reader
@@ -49,16 +90,30 @@ func NewDecoder(r io.Reader) *Decoder {
 // non-investment transactions. For now this is a convenience wrapper around
 // a streaming decode; it reads the whole file.
 func (d *Decoder) Decode() ([]*Transaction, error) {
+	file, err := d.DecodeFile()
+	if err != nil {
+		return nil, err
+	}
+	return file.Transactions, nil
+}
+
+// DecodeFile reads QIF data from the underlying reader and returns its
+// transactions, each with the account of the "!Account" block before it, its
+// accounts and its category list.
+func (d *Decoder) DecodeFile() (*File, error) {
 	var (
-		transactions []*Transaction
-		currentType  string
+		file           File
+		currentType    string
+		currentAccount string
+		inAccounts     bool // in an "!Account" block
+		autoSwitch     bool // "!Account" blocks list the accounts
 	)
 
 	for {
 		line, err := d.readLine()
 		if err == io.EOF {
 			// No partial transaction handling – QIF files should end with '^'
-			return transactions, nil
+			return &file, nil
 		}
 		if err != nil {
 			return nil, err
@@ -68,9 +123,74 @@ func (d *Decoder) Decode() ([]*Transaction, error) {
 			continue
 		}
 
-		// Header / account-type line: !Type:Cash, !Type:Bank, ...
-		if strings.HasPrefix(line, "!Type:") {
+		switch {
+		case strings.HasPrefix(line, "!Type:"):
+			// Header / account-type line: !Type:Cash, !Type:Bank, ...
 			currentType = strings.TrimSpace(line[len("!Type:"):])
+			inAccounts = false
+			continue
+		case strings.TrimSpace(line) == "!Account":
+			inAccounts = true
+			continue
+		case strings.TrimSpace(line) == "!Option:AutoSwitch":
+			autoSwitch = true
+			continue
+		case strings.TrimSpace(line) == "!Clear:AutoSwitch":
+			autoSwitch = false
+			continue
+		case line[0] == '!' || line[0] == '^':
+			// other options
+			continue
+		}
+
+		if inAccounts {
+			fields, err := d.readRecord(line)
+			if err != nil {
+				return nil, err
+			}
+			var acc Account
+			for _, field := range fields {
+				switch field[0] {
+				case 'N':
+					acc.Name = field[1:]
+				case 'T':
+					acc.Type = field[1:]
+				case 'D':
+					acc.Description = field[1:]
+				}
+			}
+			file.Accounts = append(file.Accounts, acc)
+			if !autoSwitch {
+				currentAccount = acc.Name
+			}
+			continue
+		}
+
+		switch strings.ToLower(currentType) {
+		case "cat":
+			fields, err := d.readRecord(line)
+			if err != nil {
+				return nil, err
+			}
+			var cat Category
+			for _, field := range fields {
+				switch field[0] {
+				case 'N':
+					cat.Name = field[1:]
+				case 'D':
+					cat.Description = field[1:]
+				case 'I':
+					cat.Income = true
+				case 'T':
+					cat.Tax = true
+				}
+			}
+			file.Categories = append(file.Categories, cat)
+			continue
+		case "class", "memorized":
+			if _, err := d.readRecord(line); err != nil {
+				return nil, err
+			}
 			continue
 		}
 
@@ -80,13 +200,35 @@ func (d *Decoder) Decode() ([]*Transaction, error) {
 			if err != nil {
 				return nil, err
 			}
-			transactions = append(transactions, tx)
+			tx.Account = currentAccount
+			file.Transactions = append(file.Transactions, tx)
 			continue
 		}
 
 		// Lines outside of transactions are currently ignored.
 	}
+}
 
+// readRecord reads the field lines of a record other than a transaction,
+// given its first line, until its '^' end marker.
+func (d *Decoder) readRecord(firstLine string) ([]string, error) {
+	fields := []string{firstLine}
+	for {
+		line, err := d.readLine()
+		if err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("unexpected EOF while reading record")
+			}
+			return nil, err
+		}
+		if len(line) == 0 {
+			continue
+		}
+		if line[0] == '^' {
+			return fields, nil
+		}
+		fields = append(fields, line)
+	}
 }
 
 // decodeTransaction parses a single transaction, given that the first line
@@ -115,6 +257,11 @@ func (d *Decoder) decodeTransaction(txType string, firstLine string) (*Transacti
 		}
 		if line[0] == '^' {
 			// end of transaction
+			if len(tx.Splits) > 0 {
+				tx.SplitCategory = tx.Splits[0].Category
+				tx.SplitMemo = tx.Splits[0].Memo
+				tx.SplitAmount = tx.Splits[0].Amount
+			}
 			return tx, nil
 		}
 
@@ -164,18 +311,18 @@ func assignField(tx *Transaction, line string) {
 	case 'L':
 		tx.Category = value
 	case 'S':
-		// For now we keep only first split; real-world usage may need a slice.
-		if tx.SplitCategory == "" {
-			tx.SplitCategory = value
-		}
+		tx.Splits = append(tx.Splits, Split{Category: value})
 	case 'E':
-		if tx.SplitMemo == "" {
-			tx.SplitMemo = value
+		// a split starts with its category, but may have none
+		if n := len(tx.Splits); n == 0 || tx.Splits[n-1].Memo != "" || tx.Splits[n-1].Amount != "" {
+			tx.Splits = append(tx.Splits, Split{})
 		}
+		tx.Splits[len(tx.Splits)-1].Memo = value
 	case '$':
-		if tx.SplitAmount == "" {
-			tx.SplitAmount = value
+		if n := len(tx.Splits); n == 0 || tx.Splits[n-1].Amount != "" {
+			tx.Splits = append(tx.Splits, Split{})
 		}
+		tx.Splits[len(tx.Splits)-1].Amount = value
 	}
 }
 
@@ -198,3 +345,8 @@ func (d *Decoder) readLine() (string, error) {
 func ParseQIF(reader io.Reader) ([]*Transaction, error) {
 	return NewDecoder(reader).Decode()
 }
+
+// ParseFile parses the transactions, accounts and categories of a QIF stream.
+func ParseFile(reader io.Reader) (*File, error) {
+	return NewDecoder(reader).DecodeFile()
+}
@@ -3,6 +3,7 @@ package qif_test
 import (
 	"bytes"
 	_ "embed"
+	"strings"
 	"testing"
 
 	"github.com/howeyc/ledger/ledger/qif"
@@ -91,6 +92,18 @@ func TestParseQIF(t *testing.T) {
 		if e.Category != tt.cat {
 			t.Errorf("entry %d: expected Category %q, got %q", tt.index, tt.cat, e.Category)
 		}
+		if len(e.Splits) != 2 {
+			t.Fatalf("entry %d: expected 2 splits, got %d", tt.index, len(e.Splits))
+		}
+		if e.Splits[0].Category != tt.splitCt {
+			t.Errorf("entry %d: expected split Category %q, got %q", tt.index, tt.splitCt, e.Splits[0].Category)
+		}
+		if e.Splits[0].Amount != tt.splitAm {
+			t.Errorf("entry %d: expected split Amount %q, got %q", tt.index, tt.splitAm, e.Splits[0].Amount)
+		}
+		if e.Splits[1] != (qif.Split{Category: "Fee", Amount: "0.00"}) {
+			t.Errorf("entry %d: expected Fee split, got %+v", tt.index, e.Splits[1])
+		}
 		if e.SplitCategory != tt.splitCt {
 			t.Errorf("entry %d: expected SplitCategory %q, got %q", tt.index, tt.splitCt, e.SplitCategory)
 		}
@@ -99,3 +112,114 @@ func TestParseQIF(t *testing.T) {
 		}
 	}
 }
+
+func TestParseQIFSplits(t *testing.T) {
+	data := "!Type:Bank\nD01/05/2024\nT-120.00\nPSuperstore\nLGroceries\nSGroceries\nEweekly shop\n$-80.00\nSHousehold:Cleaning\n$-30.00\nEno category\n$-10.00\n^\n"
+	entries, err := qif.ParseQIF(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	want := []qif.Split{
+		{Category: "Groceries", Memo: "weekly shop", Amount: "-80.00"},
+		{Category: "Household:Cleaning", Amount: "-30.00"},
+		{Memo: "no category", Amount: "-10.00"},
+	}
+	splits := entries[0].Splits
+	if len(splits) != len(want) {
+		t.Fatalf("Expected %d splits, got %d: %+v", len(want), len(splits), splits)
+	}
+	for i := range want {
+		if splits[i] != want[i] {
+			t.Errorf("split %d: expected %+v, got %+v", i, want[i], splits[i])
+		}
+	}
+}
+
+const multiAccountQIF = `!Type:Cat
+NGroceries
+DFood shopping
+E
+^
+NSalary
+I
+T
+^
+!Option:AutoSwitch
+!Account
+NChecking
+TBank
+^
+NSavings
+TBank
+^
+!Clear:AutoSwitch
+!Account
+NChecking
+TBank
+DEveryday account
+^
+!Type:Bank
+D01/05/2024
+T-50.00
+PGrocer
+LGroceries
+^
+D01/06/2024
+T-100.00
+PTransfer
+L[Savings]
+^
+!Account
+NSavings
+TBank
+^
+!Type:Bank
+D01/06/2024
+T100.00
+PTransfer
+L[Checking]
+^
+`
+
+func TestParseFile(t *testing.T) {
+	file, err := qif.ParseFile(strings.NewReader(multiAccountQIF))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantCategories := []qif.Category{
+		{Name: "Groceries", Description: "Food shopping"},
+		{Name: "Salary", Income: true, Tax: true},
+	}
+	if len(file.Categories) != len(wantCategories) {
+		t.Fatalf("Expected %d categories, got %d: %+v", len(wantCategories), len(file.Categories), file.Categories)
+	}
+	for i := range wantCategories {
+		if file.Categories[i] != wantCategories[i] {
+			t.Errorf("category %d: expected %+v, got %+v", i, wantCategories[i], file.Categories[i])
+		}
+	}
+
+	if len(file.Accounts) != 4 {
+		t.Errorf("Expected 4 accounts, got %d: %+v", len(file.Accounts), file.Accounts)
+	} else if file.Accounts[2].Description != "Everyday account" {
+		t.Errorf("account 2: expected Description %q, got %q", "Everyday account", file.Accounts[2].Description)
+	}
+
+	wantAccounts := []string{"Checking", "Checking", "Savings"}
+	if len(file.Transactions) != len(wantAccounts) {
+		t.Fatalf("Expected %d transactions, got %d", len(wantAccounts), len(file.Transactions))
+	}
+	for i, want := range wantAccounts {
+		e := file.Transactions[i]
+		if e.Account != want {
+			t.Errorf("entry %d: expected Account %q, got %q", i, want, e.Account)
+		}
+		if e.Type != "Bank" {
+			t.Errorf("entry %d: expected Type %q, got %q", i, "Bank", e.Type)
+		}
+	}
+}
@@ -0,0 +1,554 @@
+// Package query parses the query language of ledger reports into an
+// expression tree, and evaluates it against postings.
+//
+// A query is made of terms combined with "and", "or", "not" and parentheses.
+// Terms next to each other without an operator are combined with "or". The
+// terms are:
+//
+//	WORD                account name contains WORD
+//	acct:WORD           account name contains WORD
+//	payee:WORD          payee contains WORD
+//	tag:NAME[=VALUE]    posting or transaction has the tag (with the value)
+//	cur:CUR             posting is in the currency CUR
+//	status:STATUS       posting is cleared, pending or uncleared
+//	amount OP NUMBER    posting amount compares to NUMBER
+//	date OP DATE        transaction date compares to DATE
+//
+// where OP is one of =, !=, <, <=, > and >=. Values containing spaces are
+// quoted with double or single quotes, for example acct:"Dining Out".
+package query
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	date "github.com/joyt/godate"
+	"github.com/shopspring/decimal"
+)
+
+// Status is the clearing status of a posting.
+type Status int
+
+// Clearing statuses, in order of progress.
+const (
+	Uncleared Status = iota
+	Pending
+	Cleared
+)
+
+// Posting is a posting of a transaction a query is evaluated against.
+type Posting interface {
+	Account() string
+	Payee() string
+	Currency() string
+	Amount() decimal.Decimal
+	Date() time.Time
+	Status() Status
+	// Tags returns the tags of the posting and of its transaction.
+	Tags() map[string]string
+}
+
+// Expr is a node of a parsed query.
+type Expr interface {
+	// Match reports whether the posting matches the expression.
+	Match(p Posting) bool
+	// String returns the expression in the query language.
+	String() string
+}
+
+// Parse parses a query. An empty query returns a nil Expr.
+func Parse(s string) (Expr, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	p := parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in query", p.tokens[p.pos].text)
+	}
+	return expr, nil
+}
+
+// Match reports whether the posting matches the expression. A nil Expr
+// matches every posting.
+func Match(e Expr, p Posting) bool {
+	return e == nil || e.Match(p)
+}
+
+// MayMatchAccount reports whether the expression may match a posting to the
+// account, judging by the name of the account alone: it is false only if the
+// expression matches no posting to the account, whatever its other fields.
+func MayMatchAccount(e Expr, account string) bool {
+	match, known := matchAccount(e, account)
+	return match || !known
+}
+
+// matchAccount evaluates the expression for a posting to the account, known
+// only if the result does not depend on the other fields of the posting.
+func matchAccount(e Expr, account string) (match, known bool) {
+	switch e := e.(type) {
+	case nil:
+		return true, true
+	case Account:
+		return e.Match(accountPosting(account)), true
+	case Or:
+		known = true
+		for _, x := range e {
+			m, k := matchAccount(x, account)
+			if m && k {
+				return true, true
+			}
+			known = known && k
+		}
+		return false, known
+	case And:
+		known = true
+		for _, x := range e {
+			m, k := matchAccount(x, account)
+			if !m && k {
+				return false, true
+			}
+			known = known && k
+		}
+		return true, known
+	case Not:
+		m, k := matchAccount(e.X, account)
+		return !m, k
+	}
+	return false, false
+}
+
+// accountPosting is a posting with only an account, for matching the
+// account terms of an expression.
+type accountPosting string
+
+func (p accountPosting) Account() string         { return string(p) }
+func (p accountPosting) Payee() string           { return "" }
+func (p accountPosting) Currency() string        { return "" }
+func (p accountPosting) Amount() decimal.Decimal { return decimal.Zero }
+func (p accountPosting) Date() time.Time         { return time.Time{} }
+func (p accountPosting) Status() Status          { return Uncleared }
+func (p accountPosting) Tags() map[string]string { return nil }
+
+type token struct {
+	text   string
+	quoted bool
+}
+
+// tokenize splits a query on spaces and parentheses, keeping quoted parts of
+// a token together.
+func tokenize(s string) (tokens []token, err error) {
+	var current strings.Builder
+	var inToken, quoted bool
+	var quote rune
+	flush := func() {
+		if inToken {
+			tokens = append(tokens, token{text: current.String(), quoted: quoted})
+		}
+		current.Reset()
+		inToken, quoted = false, false
+	}
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inToken, quoted = true, true
+		case unicode.IsSpace(r):
+			flush()
+		case (r == '(' || r == ')') && !inToken:
+			tokens = append(tokens, token{text: string(r)})
+		case r == ')':
+			flush()
+			tokens = append(tokens, token{text: string(r)})
+		default:
+			current.WriteRune(r)
+			inToken = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quote in query")
+	}
+	flush()
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// keyword reports whether the next token is the (unquoted) keyword.
+func (p *parser) keyword(kw string) bool {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].quoted {
+		return false
+	}
+	return strings.EqualFold(p.tokens[p.pos].text, kw)
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	var exprs Or
+	for {
+		expr, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+
+		if p.keyword("or") {
+			p.pos++
+			continue
+		}
+		// terms without an operator between them are alternatives
+		if p.pos < len(p.tokens) && !p.keyword(")") {
+			continue
+		}
+		break
+	}
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+	return exprs, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	var exprs And
+	for {
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+		if !p.keyword("and") {
+			break
+		}
+		p.pos++
+	}
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+	return exprs, nil
+}
+
+func (p *parser) parseNot() (Expr, error) {
+	if p.keyword("not") {
+		p.pos++
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return Not{expr}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, errors.New("unexpected end of query")
+	}
+	if p.keyword("(") {
+		p.pos++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.keyword(")") {
+			return nil, errors.New("missing ) in query")
+		}
+		p.pos++
+		return expr, nil
+	}
+	for _, kw := range []string{")", "and", "or"} {
+		if p.keyword(kw) {
+			return nil, fmt.Errorf("unexpected %q in query", p.tokens[p.pos].text)
+		}
+	}
+
+	t := p.tokens[p.pos]
+	p.pos++
+	if t.quoted {
+		return parseTerm(t.text)
+	}
+	// a comparison may be spaced, as "amount > 100"
+	term := t.text
+	if comparisonFieldRegex.MatchString(term) && p.pos < len(p.tokens) &&
+		!p.tokens[p.pos].quoted && strings.ContainsAny(p.tokens[p.pos].text[:1], "<>=!") {
+		term += p.tokens[p.pos].text
+		p.pos++
+	}
+	if comparisonOpRegex.MatchString(term) {
+		if p.pos >= len(p.tokens) || p.keyword(")") {
+			return nil, fmt.Errorf("missing value of comparison in query(%s)", term)
+		}
+		term += p.tokens[p.pos].text
+		p.pos++
+	}
+	if operatorRegex.MatchString(term) {
+		return nil, fmt.Errorf("unexpected %q in query", term)
+	}
+	return parseTerm(term)
+}
+
+var (
+	comparisonRegex      = regexp.MustCompile(`^(?i:(amount|amt|date)):?(<=|>=|!=|<|>|=)(.+)$`)
+	comparisonFieldRegex = regexp.MustCompile(`^(?i:amount|amt|date):?$`)
+	comparisonOpRegex    = regexp.MustCompile(`^(?i:amount|amt|date):?(<=|>=|!=|<|>|=)$`)
+	operatorRegex        = regexp.MustCompile(`^(<=|>=|!=|<|>|=)$`)
+)
+
+// parseTerm parses a single query term.
+func parseTerm(term string) (Expr, error) {
+	if m := comparisonRegex.FindStringSubmatch(term); m != nil {
+		op, value := Op(m[2]), m[3]
+		switch strings.ToLower(m[1]) {
+		case "date":
+			d, err := date.Parse(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid date in query(%s): %w", term, err)
+			}
+			return Date{Op: op, Date: d}, nil
+		default:
+			amount, err := decimal.NewFromString(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid amount in query(%s): %w", term, err)
+			}
+			return Amount{Op: op, Amount: amount}, nil
+		}
+	}
+
+	field, value, found := strings.Cut(term, ":")
+	if !found {
+		return Account(term), nil
+	}
+	switch strings.ToLower(field) {
+	case "acct", "account":
+		return Account(value), nil
+	case "payee", "desc":
+		return Payee(value), nil
+	case "cur", "currency":
+		return Currency(value), nil
+	case "tag":
+		name, tagValue, hasValue := strings.Cut(value, "=")
+		return Tag{Name: name, Value: tagValue, HasValue: hasValue}, nil
+	case "status":
+		switch strings.ToLower(value) {
+		case "cleared", "*":
+			return StatusIs(Cleared), nil
+		case "pending", "!":
+			return StatusIs(Pending), nil
+		case "uncleared", "":
+			return StatusIs(Uncleared), nil
+		}
+		return nil, fmt.Errorf("unknown status in query(%s)", value)
+	}
+	// account names contain ":" too
+	return Account(term), nil
+}
+
+// quote quotes a value of a term when it would not be read back as one
+// token.
+func quote(s string) string {
+	if s != "" && !strings.ContainsAny(s, ` "'()`) && !strings.ContainsFunc(s, unicode.IsSpace) {
+		return s
+	}
+	if strings.Contains(s, `"`) {
+		return "'" + s + "'"
+	}
+	return `"` + s + `"`
+}
+
+// group returns the expression in parentheses when it combines others.
+func group(e Expr) string {
+	switch e.(type) {
+	case Or, And:
+		return "(" + e.String() + ")"
+	}
+	return e.String()
+}
+
+// Or matches postings that match any of its expressions.
+type Or []Expr
+
+func (e Or) Match(p Posting) bool {
+	for _, x := range e {
+		if x.Match(p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e Or) String() string {
+	parts := make([]string, len(e))
+	for i, x := range e {
+		parts[i] = group(x)
+	}
+	return strings.Join(parts, " or ")
+}
+
+// And matches postings that match all of its expressions.
+type And []Expr
+
+func (e And) Match(p Posting) bool {
+	for _, x := range e {
+		if !x.Match(p) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e And) String() string {
+	parts := make([]string, len(e))
+	for i, x := range e {
+		parts[i] = group(x)
+	}
+	return strings.Join(parts, " and ")
+}
+
+// Not matches postings that do not match its expression.
+type Not struct {
+	X Expr
+}
+
+func (e Not) Match(p Posting) bool {
+	return !e.X.Match(p)
+}
+
+func (e Not) String() string {
+	return "not " + group(e.X)
+}
+
+// Account matches postings to accounts whose name contains it.
+type Account string
+
+func (e Account) Match(p Posting) bool {
+	return strings.Contains(p.Account(), string(e))
+}
+
+func (e Account) String() string {
+	return "acct:" + quote(string(e))
+}
+
+// Payee matches postings of transactions whose payee contains it.
+type Payee string
+
+func (e Payee) Match(p Posting) bool {
+	return strings.Contains(p.Payee(), string(e))
+}
+
+func (e Payee) String() string {
+	return "payee:" + quote(string(e))
+}
+
+// Currency matches postings in the currency.
+type Currency string
+
+func (e Currency) Match(p Posting) bool {
+	return p.Currency() == string(e)
+}
+
+func (e Currency) String() string {
+	return "cur:" + quote(string(e))
+}
+
+// StatusIs matches postings of the status.
+type StatusIs Status
+
+func (e StatusIs) Match(p Posting) bool {
+	return p.Status() == Status(e)
+}
+
+func (e StatusIs) String() string {
+	switch Status(e) {
+	case Cleared:
+		return "status:cleared"
+	case Pending:
+		return "status:pending"
+	}
+	return "status:uncleared"
+}
+
+// Tag matches postings with the tag, with the value if HasValue.
+type Tag struct {
+	Name, Value string
+	HasValue    bool
+}
+
+func (e Tag) Match(p Posting) bool {
+	value, ok := p.Tags()[e.Name]
+	return ok && (!e.HasValue || value == e.Value)
+}
+
+func (e Tag) String() string {
+	if e.HasValue {
+		return "tag:" + quote(e.Name+"="+e.Value)
+	}
+	return "tag:" + quote(e.Name)
+}
+
+// Op is a comparison operator: =, !=, <, <=, > or >=.
+type Op string
+
+// Compare reports whether the result of a comparison, as of cmp.Compare,
+// satisfies the operator.
+func (op Op) Compare(c int) bool {
+	switch op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	case "!=":
+		return c != 0
+	}
+	return c == 0
+}
+
+// Amount matches postings whose amount compares to it.
+type Amount struct {
+	Op     Op
+	Amount decimal.Decimal
+}
+
+func (e Amount) Match(p Posting) bool {
+	return e.Op.Compare(p.Amount().Cmp(e.Amount))
+}
+
+func (e Amount) String() string {
+	return "amount" + string(e.Op) + e.Amount.String()
+}
+
+// Date matches postings of transactions whose date compares to it.
+type Date struct {
+	Op   Op
+	Date time.Time
+}
+
+func (e Date) Match(p Posting) bool {
+	return e.Op.Compare(p.Date().Compare(e.Date))
+}
+
+func (e Date) String() string {
+	return "date" + string(e.Op) + e.Date.Format("2006/01/02")
+}
@@ -0,0 +1,139 @@
+package query_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/howeyc/ledger/ledger/query"
+	"github.com/shopspring/decimal"
+)
+
+type posting struct {
+	account, payee, currency string
+	amount                   int64
+	date                     time.Time
+	status                   query.Status
+	tags                     map[string]string
+}
+
+func (p posting) Account() string         { return p.account }
+func (p posting) Payee() string           { return p.payee }
+func (p posting) Currency() string        { return p.currency }
+func (p posting) Amount() decimal.Decimal { return decimal.NewFromInt(p.amount) }
+func (p posting) Date() time.Time         { return p.date }
+func (p posting) Status() query.Status    { return p.status }
+func (p posting) Tags() map[string]string { return p.tags }
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"Food", "acct:Food"},
+		{"Food Lodging", "acct:Food or acct:Lodging"},
+		{`acct:"Dining Out" and amount>100`, `acct:"Dining Out" and amount>100`},
+		{"not (Expenses or payee:Hotel) and cur:EUR", "not (acct:Expenses or payee:Hotel) and cur:EUR"},
+		{"tag:meal=dinner status:*", "tag:meal=dinner or status:cleared"},
+		{"date>=2024/01/10", "date>=2024/01/10"},
+		{"Assets:Checking", "acct:Assets:Checking"},
+		{"amount > 100", "amount>100"},
+		{"Food and amount >= -5 or amt< 0", "(acct:Food and amount>=-5) or amount<0"},
+		{"date = 2024/01/10 amount", "date=2024/01/10 or acct:amount"},
+	}
+	for _, tt := range tests {
+		expr, err := query.Parse(tt.query)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.query, err)
+			continue
+		}
+		if got := expr.String(); got != tt.want {
+			t.Errorf("Parse(%q).String() = %q, want %q", tt.query, got, tt.want)
+		}
+		again, err := query.Parse(expr.String())
+		if err != nil || again.String() != tt.want {
+			t.Errorf("Parse(%q) does not read back: %v, %v", expr.String(), again, err)
+		}
+	}
+
+	and, _ := query.Parse("acct:Expenses and not amount<0")
+	if e, ok := and.(query.And); !ok || len(e) != 2 || e[0] != query.Account("Expenses") {
+		t.Errorf("Parse() = %#v, want an And of an Account and a Not", and)
+	} else if not, ok := e[1].(query.Not); !ok || not.X.(query.Amount).Op != "<" {
+		t.Errorf("Parse() second term = %#v", e[1])
+	}
+
+	if expr, err := query.Parse("  "); expr != nil || err != nil {
+		t.Errorf("Parse of an empty query = %v, %v", expr, err)
+	}
+	for _, bad := range []string{`acct:"open`, "(Food", "Food )", "and Food", "status:done", "amount>ten", "date<someday", "> 100", "amount >", "(amount >)"} {
+		if _, err := query.Parse(bad); err == nil {
+			t.Errorf("Parse(%q) did not fail", bad)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	hotel := posting{account: "Expenses:Travel:Lodging", payee: "Hotel Paris", currency: "EUR", amount: 250,
+		date: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), status: query.Cleared, tags: map[string]string{"trip": ""}}
+	dinner := posting{account: "Expenses:Food", payee: "Grocery Store", amount: 80,
+		date: time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC), tags: map[string]string{"meal": "dinner"}}
+
+	tests := []struct {
+		query string
+		p     posting
+		want  bool
+	}{
+		{"Food Lodging", hotel, true},
+		{"acct:Expenses and amount>100", dinner, false},
+		{"acct:Expenses and amount>100 and tag:trip", hotel, true},
+		{"tag:meal=dinner", dinner, true},
+		{"tag:meal=lunch", dinner, false},
+		{"payee:Hotel and status:cleared", hotel, true},
+		{"status:uncleared", hotel, false},
+		{"not cur:EUR", dinner, true},
+		{"date<2024/01/10", dinner, false},
+		{"date<2024/01/10", hotel, true},
+	}
+	for _, tt := range tests {
+		expr, err := query.Parse(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := query.Match(expr, tt.p); got != tt.want {
+			t.Errorf("Match(%q, %s) = %v, want %v", tt.query, tt.p.payee, got, tt.want)
+		}
+	}
+	if !query.Match(nil, dinner) {
+		t.Error("nil Expr did not match")
+	}
+}
+
+func TestMayMatchAccount(t *testing.T) {
+	tests := []struct {
+		query   string
+		account string
+		want    bool
+	}{
+		{"Food", "Expenses:Food", true},
+		{"Food", "Assets:Checking", false},
+		{"Food or payee:Market", "Assets:Checking", true},
+		{"Food and payee:Market", "Assets:Checking", false},
+		{"Food and payee:Market", "Expenses:Food", true},
+		{"not Food", "Expenses:Food", false},
+		{"not (Food and amount>10)", "Expenses:Food", true},
+		{"not (Food or amount>10)", "Assets:Checking", true},
+		{"status:cleared", "Assets:Checking", true},
+	}
+	for _, tt := range tests {
+		expr, err := query.Parse(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := query.MayMatchAccount(expr, tt.account); got != tt.want {
+			t.Errorf("MayMatchAccount(%q, %s) = %v, want %v", tt.query, tt.account, got, tt.want)
+		}
+	}
+	if !query.MayMatchAccount(nil, "Assets") {
+		t.Error("nil Expr did not match")
+	}
+}
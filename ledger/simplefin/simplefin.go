@@ -0,0 +1,142 @@
+// Package simplefin fetches accounts and their transactions from a SimpleFIN
+// bridge, see https://www.simplefin.org/protocol.html.
+//
+// A bridge hands out a setup token, which Claim exchanges once for an access
+// URL. The access URL holds the credentials of the connection and is what
+// Fetch reads from.
+package simplefin
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AccountSet is the response of the accounts endpoint.
+type AccountSet struct {
+	// Errors are messages of the bridge for the user, such as a connection
+	// that needs attention.
+	Errors   []string  `json:"errors"`
+	Accounts []Account `json:"accounts"`
+}
+
+// Account is an account of a financial institution.
+type Account struct {
+	Org              Org           `json:"org"`
+	ID               string        `json:"id"`
+	Name             string        `json:"name"`
+	Currency         string        `json:"currency"`
+	Balance          string        `json:"balance"`
+	AvailableBalance string        `json:"available-balance"`
+	BalanceDate      int64         `json:"balance-date"`
+	Transactions     []Transaction `json:"transactions"`
+}
+
+// Org is the financial institution of an account.
+type Org struct {
+	Domain  string `json:"domain"`
+	Name    string `json:"name"`
+	SFinURL string `json:"sfin-url"`
+}
+
+// Transaction is a transaction of an account. Amount is as written by the
+// bridge, negative for money leaving the account.
+type Transaction struct {
+	ID           string `json:"id"`
+	Posted       int64  `json:"posted"`
+	Amount       string `json:"amount"`
+	Description  string `json:"description"`
+	Payee        string `json:"payee"`
+	Memo         string `json:"memo"`
+	Pending      bool   `json:"pending"`
+	TransactedAt int64  `json:"transacted_at"`
+}
+
+// Date returns the date the transaction was posted, in UTC.
+func (t Transaction) Date() time.Time {
+	return time.Unix(t.Posted, 0).UTC()
+}
+
+// Claim exchanges a setup token for the access URL of the connection. A
+// setup token can be claimed only once.
+func Claim(ctx context.Context, client *http.Client, setupToken string) (string, error) {
+	claimURL, err := base64.StdEncoding.DecodeString(strings.TrimSpace(setupToken))
+	if err != nil {
+		return "", fmt.Errorf("setup token: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, string(claimURL), http.NoBody)
+	if err != nil {
+		return "", err
+	}
+	body, err := do(client, req)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// Fetch returns the accounts of the access URL with their transactions posted
+// on or after start and before end. Pending transactions are left out.
+func Fetch(ctx context.Context, client *http.Client, accessURL string, start, end time.Time) (*AccountSet, error) {
+	u, err := url.Parse(accessURL)
+	if err != nil {
+		return nil, fmt.Errorf("access URL: %w", err)
+	}
+	username := u.User.Username()
+	password, _ := u.User.Password()
+	u.User = nil
+	u = u.JoinPath("accounts")
+	query := url.Values{}
+	query.Set("start-date", strconv.FormatInt(start.Unix(), 10))
+	query.Set("end-date", strconv.FormatInt(end.Unix(), 10))
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(username, password)
+	body, err := do(client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var set AccountSet
+	if err := json.Unmarshal(body, &set); err != nil {
+		return nil, fmt.Errorf("accounts: %w", err)
+	}
+	for i := range set.Accounts {
+		set.Accounts[i].Transactions = slices.DeleteFunc(set.Accounts[i].Transactions, func(t Transaction) bool {
+			return t.Pending
+		})
+	}
+	return &set, nil
+}
+
+// do sends the request and returns the body of a successful response.
+func do(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusForbidden:
+		return nil, fmt.Errorf("%s: access denied, the token was revoked or already claimed", req.URL.Redacted())
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%s: %s", req.URL.Redacted(), resp.Status)
+	}
+	return body, nil
+}
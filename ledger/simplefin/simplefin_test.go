@@ -0,0 +1,84 @@
+package simplefin_test
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/howeyc/ledger/ledger/simplefin"
+)
+
+const sampleAccounts = `{"errors": [], "accounts": [{
+  "org": {"domain": "bank.example", "name": "Example Bank"},
+  "id": "ACT-1", "name": "Checking", "currency": "USD",
+  "balance": "950.00", "available-balance": "950.00", "balance-date": 1706745600,
+  "transactions": [
+    {"id": "T1", "posted": 1704153600, "amount": "-50.00", "description": "CORNER GROCER", "payee": "Corner Grocer"},
+    {"id": "T2", "posted": 0, "amount": "-9.99", "description": "STREAMING", "pending": true}
+  ]}]}`
+
+func TestClaimAndFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/claim/abc":
+			if r.Method != http.MethodPost {
+				http.Error(w, "method", http.StatusMethodNotAllowed)
+				return
+			}
+			w.Write([]byte("http://user:secret@" + r.Host + "/simplefin\n"))
+		case "/simplefin/accounts":
+			if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "secret" {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			if r.URL.Query().Get("start-date") != "1704067200" {
+				http.Error(w, "start-date", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(sampleAccounts))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	setupToken := base64.StdEncoding.EncodeToString([]byte(server.URL + "/claim/abc"))
+	accessURL, err := simplefin.Claim(ctx, server.Client(), setupToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(accessURL, "/simplefin") {
+		t.Fatalf("access URL = %q", accessURL)
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	set, err := simplefin.Fetch(ctx, server.Client(), accessURL, start, start.AddDate(0, 1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set.Accounts) != 1 {
+		t.Fatalf("got %d accounts, want 1", len(set.Accounts))
+	}
+	acct := set.Accounts[0]
+	if acct.ID != "ACT-1" || acct.Org.Name != "Example Bank" || acct.Balance != "950.00" {
+		t.Errorf("unexpected account: %+v", acct)
+	}
+	// the pending transaction is left out
+	if len(acct.Transactions) != 1 {
+		t.Fatalf("got %d transactions, want 1: %+v", len(acct.Transactions), acct.Transactions)
+	}
+	trn := acct.Transactions[0]
+	if trn.ID != "T1" || trn.Amount != "-50.00" || trn.Payee != "Corner Grocer" || !trn.Date().Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected transaction: %+v", trn)
+	}
+
+	// without credentials
+	if _, err := simplefin.Fetch(ctx, server.Client(), server.URL+"/simplefin", start, start); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Errorf("Fetch without credentials error = %v, want access denied", err)
+	}
+}
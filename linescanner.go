@@ -2,57 +2,80 @@ package ledger
 
 import (
 	"bufio"
+	"bytes"
 	"io"
 	"os"
 	"unsafe"
 )
 
+// linescanner splits a ledger into lines without copying them: the ledger is
+// read into a single buffer, and lines are strings sharing its memory. The
+// buffer is never written to after reading, so the strings stay valid for as
+// long as they are used, such as in the account names and payees of parsed
+// transactions. A linescanner of newStreamLineScanner reads each line into
+// its own buffer instead, so the ledger is not held in memory.
 type linescanner struct {
-	scanner *bufio.Scanner
-	unsafe  bool
+	data   []byte
+	pos    int
+	line   []byte
+	reader *bufio.Reader
 
 	filename  string
 	lineCount int
 }
 
-// NewLineScanner creates a wrapper around bufio.Scanner with pre-allocated
-// buffer. Significantly reduces memory allocations and reduces runtime.
+// newLineScanner reads the ledger of r, sizing its buffer by the size of the
+// file of the name if it exists. A read error ends the ledger where it
+// occurred, as the end of the file would.
 func newLineScanner(filename string, r io.Reader) *linescanner {
-	lp := &linescanner{}
-	lp.scanner = bufio.NewScanner(r)
+	var buf bytes.Buffer
 	if fs, fserr := os.Stat(filename); fserr == nil {
-		size := int(fs.Size())
-		// only pre-alloc for large files
-		if size > bufio.MaxScanTokenSize {
-			// allocate large enough such that scanner in bufio doesn't need to
-			// move anything during scanning
-			size *= 2
-			lp.scanner.Buffer(make([]byte, size), size)
-			lp.unsafe = true
-		}
+		// one more byte so reading ends without growing the buffer
+		buf.Grow(int(fs.Size()) + bytes.MinRead)
 	}
-	lp.filename = filename
+	buf.ReadFrom(r)
+	return &linescanner{data: buf.Bytes(), filename: filename}
+}
 
-	return lp
+// newStreamLineScanner reads the ledger of r a line at a time. A read error
+// ends the ledger where it occurred, as the end of the file would.
+func newStreamLineScanner(filename string, r io.Reader) *linescanner {
+	return &linescanner{reader: bufio.NewReader(r), filename: filename}
 }
 
+// Scan advances to the next line, returning false at the end of the ledger.
+// As bufio.ScanLines, a line ends with "\n" or "\r\n", and the last line may
+// end without one.
 func (lp *linescanner) Scan() bool {
-	return lp.scanner.Scan()
+	if lp.reader != nil {
+		// a new slice for each line, never written to
+		line, err := lp.reader.ReadBytes('\n')
+		if len(line) == 0 && err != nil {
+			return false
+		}
+		lp.line = bytes.TrimSuffix(bytes.TrimSuffix(line, []byte{'\n'}), []byte{'\r'})
+		return true
+	}
+	if lp.pos >= len(lp.data) {
+		return false
+	}
+	rest := lp.data[lp.pos:]
+	end := bytes.IndexByte(rest, '\n')
+	if end < 0 {
+		end = len(rest)
+	}
+	lp.pos += end + 1
+	lp.line = bytes.TrimSuffix(rest[:end], []byte{'\r'})
+	return true
 }
 
+// Text returns the current line, counting it for LineNumber.
 func (lp *linescanner) Text() string {
-	var line string
-	if lp.unsafe {
-		if lbytes := lp.scanner.Bytes(); len(lbytes) > 0 {
-			line = unsafe.String(unsafe.SliceData(lbytes), len(lbytes))
-		} else {
-			line = ""
-		}
-	} else {
-		line = lp.scanner.Text()
-	}
 	lp.lineCount++
-	return line
+	if len(lp.line) == 0 {
+		return ""
+	}
+	return unsafe.String(unsafe.SliceData(lp.line), len(lp.line))
 }
 
 func (lp *linescanner) LineNumber() int {
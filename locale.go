@@ -0,0 +1,83 @@
+package ledger
+
+import "strings"
+
+// Locale is how amounts are written in a language and region: the separators
+// of their digits, and where the currency symbol goes.
+type Locale struct {
+	// Thousands separates groups of three digits.
+	Thousands string
+	// Decimal is the decimal mark.
+	Decimal string
+	// Suffix writes the currency after the amount, separated by a space.
+	Suffix bool
+}
+
+// nbsp is the narrow no-break space separating thousands in some locales.
+const nbsp = "\u202f"
+
+// locales holds the locales by language, and by language and region where
+// the region differs from the language.
+var locales = map[string]Locale{
+	"en":    {Thousands: ",", Decimal: "."},
+	"ja":    {Thousands: ",", Decimal: "."},
+	"ko":    {Thousands: ",", Decimal: "."},
+	"zh":    {Thousands: ",", Decimal: "."},
+	"de":    {Thousands: ".", Decimal: ",", Suffix: true},
+	"de_CH": {Thousands: "'", Decimal: "."},
+	"es":    {Thousands: ".", Decimal: ",", Suffix: true},
+	"it":    {Thousands: ".", Decimal: ",", Suffix: true},
+	"nl":    {Thousands: ".", Decimal: ","},
+	"pt":    {Thousands: nbsp, Decimal: ",", Suffix: true},
+	"pt_BR": {Thousands: ".", Decimal: ","},
+	"fr":    {Thousands: nbsp, Decimal: ",", Suffix: true},
+	"fr_CH": {Thousands: nbsp, Decimal: "."},
+	"cs":    {Thousands: nbsp, Decimal: ",", Suffix: true},
+	"da":    {Thousands: ".", Decimal: ",", Suffix: true},
+	"fi":    {Thousands: nbsp, Decimal: ",", Suffix: true},
+	"nb":    {Thousands: nbsp, Decimal: ",", Suffix: true},
+	"pl":    {Thousands: nbsp, Decimal: ",", Suffix: true},
+	"ru":    {Thousands: nbsp, Decimal: ",", Suffix: true},
+	"sv":    {Thousands: nbsp, Decimal: ",", Suffix: true},
+}
+
+// currencySymbols holds the symbols of currencies written in place of their
+// ISO 4217 code by a locale.
+var currencySymbols = map[string]string{
+	"$":   "$",
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+	"INR": "₹",
+	"KRW": "₩",
+	"BRL": "R$",
+}
+
+// LookupLocale returns the locale of a name such as "de_DE", "de-DE",
+// "de_DE.UTF-8" or "de", falling back to the language when the region has no
+// locale of its own.
+func LookupLocale(name string) (Locale, bool) {
+	name, _, _ = strings.Cut(name, ".")
+	name = strings.ReplaceAll(name, "-", "_")
+	lang, region, _ := strings.Cut(name, "_")
+	lang = strings.ToLower(lang)
+	if l, ok := locales[lang+"_"+strings.ToUpper(region)]; ok {
+		return l, true
+	}
+	l, ok := locales[lang]
+	return l, ok
+}
+
+// Apply returns the format of the commodity of the name with the separators
+// of the locale, and the symbol of the currency where the locale puts it.
+func (l Locale) Apply(name string, f CommodityFormat) CommodityFormat {
+	f.Thousands, f.Decimal, f.Suffix = l.Thousands, l.Decimal, l.Suffix
+	if symbol, ok := currencySymbols[name]; ok {
+		f.Symbol = symbol
+		if l.Suffix {
+			f.Symbol = " " + symbol
+		}
+	}
+	return f
+}
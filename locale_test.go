@@ -0,0 +1,41 @@
+package ledger
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestLocale(t *testing.T) {
+	tests := []struct {
+		locale string
+		name   string
+		amount string
+		want   string
+	}{
+		{"de_DE", "EUR", "1234.56", "1.234,56 €"},
+		{"de-AT.UTF-8", "EUR", "-1234.56", "-1.234,56 €"},
+		{"de_CH", "CHF", "1234.5", "CHF 1'234.50"},
+		{"en_US", "USD", "-1234567.891", "-$1,234,567.89"},
+		{"en_GB", "GBP", "0.5", "£0.50"},
+		{"fr_FR", "EUR", "1234.5", "1\u202f234,50 €"},
+		{"sv_SE", "SEK", "1234.5", "1\u202f234,50 SEK"},
+		{"pt_BR", "BRL", "1234.5", "R$1.234,50"},
+		{"de", "", "1234.5", "1.234,50"},
+	}
+	for _, tt := range tests {
+		l, ok := LookupLocale(tt.locale)
+		if !ok {
+			t.Errorf("LookupLocale(%q) not found", tt.locale)
+			continue
+		}
+		f := l.Apply(tt.name, DefaultCommodityFormat)
+		if got := f.Format(tt.name, decimal.RequireFromString(tt.amount)); got != tt.want {
+			t.Errorf("%s: Format(%q, %s) = %q, want %q", tt.locale, tt.name, tt.amount, got, tt.want)
+		}
+	}
+
+	if _, ok := LookupLocale("xx_XX"); ok {
+		t.Error("LookupLocale(xx_XX) found")
+	}
+}
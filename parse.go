@@ -24,7 +24,7 @@ func ParseLedgerFile(filename string) (generalLedger []*Transaction, err error)
 	}
 	defer ifile.Close()
 	var mu sync.Mutex
-	parseLedger(filename, ifile, func(t []*Transaction, e error) (stop bool) {
+	parseLedger(filename, ifile, func(r *parseResult, e error) (stop bool) {
 		if e != nil {
 			err = e
 			stop = true
@@ -32,7 +32,41 @@ func ParseLedgerFile(filename string) (generalLedger []*Transaction, err error)
 		}
 
 		mu.Lock()
-		generalLedger = append(generalLedger, t...)
+		generalLedger = append(generalLedger, r.transactions...)
+		mu.Unlock()
+		return
+	})
+
+	return
+}
+
+// ParseLedgerFileRange parses a ledger file and returns the list of
+// Transactions dated from start up to but not including end, as ByDateRange.
+//
+// The transactions of included files that cannot hold any of them are not
+// parsed, only their other directives: those named for a year or a month,
+// such as "2021.ledger" or "ledger-2021-05.dat", and those declaring their
+// dates with a range directive, such as "range 2021/01/01 2021/12/31". Their
+// names and directives are trusted, so the transactions of a file dated
+// otherwise are missed, and so are their parse errors.
+func ParseLedgerFileRange(filename string, start, end time.Time) (generalLedger []*Transaction, err error) {
+	ifile, ierr := os.Open(filename)
+	if ierr != nil {
+		return nil, ierr
+	}
+	defer ifile.Close()
+	var mu sync.Mutex
+	dates := dateRange{start: start, end: end}
+	inRange := ByDateRange(start, end)
+	parseLedgerRange(filename, ifile, dates, func(r *parseResult, e error) (stop bool) {
+		if e != nil {
+			err = e
+			stop = true
+			return
+		}
+
+		mu.Lock()
+		generalLedger = append(generalLedger, FilterTransactions(r.transactions, inRange)...)
 		mu.Unlock()
 		return
 	})
@@ -42,14 +76,68 @@ func ParseLedgerFile(filename string) (generalLedger []*Transaction, err error)
 
 // ParseLedger parses a ledger file and returns a list of Transactions.
 func ParseLedger(ledgerReader io.Reader) (generalLedger []*Transaction, err error) {
-	parseLedger("", ledgerReader, func(t []*Transaction, e error) (stop bool) {
+	parseLedger("", ledgerReader, func(r *parseResult, e error) (stop bool) {
 		if e != nil {
 			err = e
 			stop = true
 			return
 		}
 
-		generalLedger = append(generalLedger, t...)
+		generalLedger = append(generalLedger, r.transactions...)
+		return
+	})
+
+	return
+}
+
+// ParsePeriodicTransactions parses a ledger file and returns the list of
+// periodic transactions ("~ Period" blocks) declared in it, including those of
+// any included files. Regular transactions are parsed, so parse errors are
+// reported, but they are not returned.
+func ParsePeriodicTransactions(filename string) (periodic []*PeriodicTransaction, err error) {
+	ifile, ierr := os.Open(filename)
+	if ierr != nil {
+		return nil, ierr
+	}
+	defer ifile.Close()
+	var mu sync.Mutex
+	parseLedger(filename, ifile, func(r *parseResult, e error) (stop bool) {
+		if e != nil {
+			err = e
+			stop = true
+			return
+		}
+
+		mu.Lock()
+		periodic = append(periodic, r.periodic...)
+		mu.Unlock()
+		return
+	})
+
+	return
+}
+
+// ParsePrices parses a ledger file and returns the list of prices ("P"
+// directives) declared in it, including those of any included files, in no
+// particular order. Transactions are parsed, so parse errors are reported, but
+// they are not returned.
+func ParsePrices(filename string) (prices []*Price, err error) {
+	ifile, ierr := os.Open(filename)
+	if ierr != nil {
+		return nil, ierr
+	}
+	defer ifile.Close()
+	var mu sync.Mutex
+	parseLedger(filename, ifile, func(r *parseResult, e error) (stop bool) {
+		if e != nil {
+			err = e
+			stop = true
+			return
+		}
+
+		mu.Lock()
+		prices = append(prices, r.prices...)
+		mu.Unlock()
 		return
 	})
 
@@ -62,42 +150,125 @@ func ParseLedgerAsync(ledgerReader io.Reader) (c chan *Transaction, e chan error
 	e = make(chan error)
 
 	go func() {
-		parseLedger("", ledgerReader, func(tlist []*Transaction, err error) (stop bool) {
-			if err != nil {
-				e <- err
-			} else {
-				for _, t := range tlist {
-					c <- t
-				}
-			}
-			return
-		})
+		parseLedgerAsync("", ledgerReader, c, e)
+	}()
+	return c, e
+}
+
+// ParseLedgerFileAsync parses a ledger file as ParseLedgerAsync, with the
+// files it includes relative to it. Transactions are sent as they are
+// parsed, in the order of the ledger with the included files in place of
+// their include directives, and the ledger is read a line at a time, so
+// it is not held in memory.
+func ParseLedgerFileAsync(filename string) (c chan *Transaction, e chan error) {
+	c = make(chan *Transaction)
+	e = make(chan error)
 
-		e <- nil
-		close(c)
-		close(e)
+	go func() {
+		ifile, err := os.Open(filename)
+		if err != nil {
+			e <- err
+			e <- nil
+			close(c)
+			close(e)
+			return
+		}
+		defer ifile.Close()
+		parseLedgerAsync(filename, ifile, c, e)
 	}()
 	return c, e
 }
 
+// parseLedgerAsync sends the Transactions and parse errors of a ledger to c and
+// e as they are parsed, then a nil error, and closes both.
+func parseLedgerAsync(filename string, ledgerReader io.Reader, c chan *Transaction, e chan error) {
+	parseLedgerStream(filename, ledgerReader, func(r *parseResult, err error) (stop bool) {
+		if err != nil {
+			e <- err
+		} else {
+			for _, t := range r.transactions {
+				c <- t
+			}
+		}
+		return
+	})
+
+	e <- nil
+	close(c)
+	close(e)
+}
+
 type parser struct {
 	scanner *linescanner
 
 	comments   []string
 	dateLayout string
 
+	// dates is the range of the transactions wanted, and declared the range
+	// of the transactions of the file declared by a range directive
+	dates    dateRange
+	declared *dateRange
+	// skipTransactions is set when the declared range is outside of dates,
+	// so only the other directives of the file are parsed
+	skipTransactions bool
+	// stream reads the ledger a line at a time, calls back with each
+	// transaction as it is parsed, and parses included files in order
+	stream bool
+
 	strPrevDate string
 	prevDateErr error
 	prevDate    time.Time
 }
 
-func parseLedger(filename string, ledgerReader io.Reader, callback func(t []*Transaction, err error) (stop bool)) (stop bool) {
-	var lp parser
-	lp.scanner = newLineScanner(filename, ledgerReader)
+// parseResult holds everything parsed from a single ledger file, not including
+// the files it includes.
+type parseResult struct {
+	filename     string
+	declared     *dateRange // by the range directive of the file, if any
+	transactions []*Transaction
+	periodic     []*PeriodicTransaction
+	accounts     []AccountInfo
+	prices       []*Price
+}
+
+func parseLedger(filename string, ledgerReader io.Reader, callback func(r *parseResult, err error) (stop bool)) (stop bool) {
+	return parseLedgerRange(filename, ledgerReader, dateRange{}, callback)
+}
+
+// parseLedgerRange parses a ledger as parseLedger, skipping the transactions
+// of included files named for other dates, and those after a range directive
+// of other dates.
+func parseLedgerRange(filename string, ledgerReader io.Reader, dates dateRange, callback func(r *parseResult, err error) (stop bool)) (stop bool) {
+	lp := parser{dates: dates}
+	return lp.parse(filename, ledgerReader, callback)
+}
+
+// parseLedgerStream parses a ledger as parseLedger, without holding it in
+// memory: the ledger is read a line at a time, each transaction is called
+// back with as it is parsed, and included files are parsed in order, so
+// transactions are called back with in the order of the ledger. The
+// accounts, prices and periodic transactions of each file are called back
+// with at its end.
+func parseLedgerStream(filename string, ledgerReader io.Reader, callback func(r *parseResult, err error) (stop bool)) (stop bool) {
+	lp := parser{stream: true}
+	return lp.parse(filename, ledgerReader, callback)
+}
+
+// parse parses a ledger with the dates and stream of lp.
+func (lp *parser) parse(filename string, ledgerReader io.Reader, callback func(r *parseResult, err error) (stop bool)) (stop bool) {
+	// start with the most common layout, so the layout of the dates is
+	// only searched for if they have another
+	lp.dateLayout = "2006/01/02"
+	if lp.stream {
+		lp.scanner = newStreamLineScanner(filename, ledgerReader)
+	} else {
+		lp.scanner = newLineScanner(filename, ledgerReader)
+	}
 
-	var tlist []*Transaction
+	result := parseResult{filename: filename}
 
 	blocks := []block{}
+	periodicBlocks := []periodicBlock{}
 	comments := []string{}
 	for lp.scanner.Scan() {
 		// remove heading and tailing space from the line
@@ -132,13 +303,56 @@ func parseLedger(filename string, ledgerReader io.Reader, callback func(t []*Tra
 		}
 		switch before {
 		case "account":
-			lp.skipAccount()
+			accounts, aerr := lp.parseAccounts(after)
+			result.accounts = append(result.accounts, accounts...)
+			if aerr != nil {
+				if callback(nil, fmt.Errorf("%s:%d: unable to parse account: %w", lp.scanner.Name(), lp.scanner.LineNumber(), aerr)) {
+					return true
+				}
+			}
 		case "include":
 			stop := lp.include(after, callback)
 			if stop {
 				return stop
 			}
+		case "range":
+			declared, rerr := lp.parseRange(after)
+			if rerr != nil {
+				if callback(nil, fmt.Errorf("%s:%d: unable to parse range: %w", lp.scanner.Name(), lp.scanner.LineNumber(), rerr)) {
+					return true
+				}
+				continue
+			}
+			lp.declared = &declared
+			if !lp.dates.overlaps(declared) {
+				lp.skipTransactions = true
+				blocks = blocks[:0]
+			}
+		case "P":
+			price, perr := lp.parsePrice(after)
+			if perr != nil {
+				if callback(nil, fmt.Errorf("%s:%d: unable to parse price: %w", lp.scanner.Name(), lp.scanner.LineNumber(), perr)) {
+					return true
+				}
+				continue
+			}
+			result.prices = append(result.prices, price)
+		case "~":
+			pblock, perr := lp.parsePeriodicHeader(after, currentComment, comments)
+			if perr != nil {
+				if callback(nil, fmt.Errorf("%s:%d: unable to parse periodic transaction: %w", lp.scanner.Name(), lp.scanner.LineNumber(), perr)) {
+					return true
+				}
+				continue
+			}
+			periodicBlocks = append(periodicBlocks, pblock)
+			comments = []string{}
 		default:
+			if lp.skipTransactions {
+				lp.skipBlock()
+				comments = []string{}
+				continue
+			}
 			transDate, derr := lp.parseDate(before)
 			if derr != nil {
 				if callback(nil, fmt.Errorf("%s:%d: unable to parse transaction: %w", lp.scanner.Name(), lp.scanner.LineNumber(), derr)) {
@@ -149,9 +363,41 @@ func parseLedger(filename string, ledgerReader io.Reader, callback func(t []*Tra
 
 			blocks = append(blocks, lp.parseBlock(transDate, after, currentComment, comments))
 			comments = []string{}
+			if lp.stream {
+				if lp.parseBlocks(blocks, &result, callback) {
+					return true
+				}
+				blocks = blocks[:0]
+				if len(result.transactions) > 0 {
+					if callback(&parseResult{filename: filename, transactions: result.transactions}, nil) {
+						return true
+					}
+					result.transactions = nil
+				}
+			}
+		}
+	}
+
+	if lp.parseBlocks(blocks, &result, callback) {
+		return true
+	}
+	for _, pblock := range periodicBlocks {
+		ptrans, ptransErr := pblock.parsePeriodicTransaction()
+		if ptransErr != nil {
+			if callback(nil, fmt.Errorf("%s:%d: unable to parse periodic transaction: %w", pblock.filename, pblock.lineNum, ptransErr)) {
+				return true
+			}
+			continue
 		}
+		result.periodic = append(result.periodic, ptrans)
 	}
+	result.declared = lp.declared
+	callback(&result, nil)
+	return false
+}
 
+// parseBlocks parses the transaction blocks into the result.
+func (lp *parser) parseBlocks(blocks []block, result *parseResult, callback func(r *parseResult, err error) (stop bool)) (stop bool) {
 	for _, block := range blocks {
 		trans, transErr := block.parseTransaction()
 		if transErr != nil {
@@ -160,34 +406,84 @@ func parseLedger(filename string, ledgerReader io.Reader, callback func(t []*Tra
 			}
 			continue
 		}
-		tlist = append(tlist, trans)
+		if lp.declared != nil && !lp.declared.contains(trans.Date) {
+			if callback(nil, fmt.Errorf("%s:%d: unable to parse transaction: %w", trans.Filename, trans.Line,
+				fmt.Errorf("date(%s) outside of declared range", trans.Date.Format(time.DateOnly)))) {
+				return true
+			}
+			continue
+		}
+		result.transactions = append(result.transactions, trans)
 	}
-	callback(tlist, nil)
 	return false
 }
 
-func (lp *parser) skipAccount() {
+// parseAccounts returns the declared account, and any account declarations
+// directly following it, with what their note, commodity and open
+// sub-directives declare. Other sub-directives are skipped.
+func (lp *parser) parseAccounts(name string) (accounts []AccountInfo, err error) {
+	accounts = []AccountInfo{{Name: strings.TrimSpace(name), Declared: true, Filename: lp.scanner.Name(), Line: lp.scanner.LineNumber()}}
 	for lp.scanner.Scan() {
-		// Read until blank line (ignore all sub-directives)
-		if len(lp.scanner.Text()) == 0 {
-			return
+		// Read until blank line
+		line := lp.scanner.Text()
+		if len(line) == 0 {
+			break
+		}
+		if commentIdx := strings.Index(line, ";"); commentIdx >= 0 {
+			line = line[:commentIdx]
+		}
+		if next, ok := strings.CutPrefix(line, "account "); ok {
+			accounts = append(accounts, AccountInfo{Name: strings.TrimSpace(next), Declared: true, Filename: lp.scanner.Name(), Line: lp.scanner.LineNumber()})
+			continue
+		}
+
+		acc := &accounts[len(accounts)-1]
+		directive, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+		value = strings.TrimSpace(value)
+		switch directive {
+		case "note":
+			acc.Note = value
+		case "commodity":
+			acc.Currency = value
+		case "open":
+			opened, derr := lp.parseDate(value)
+			if derr != nil && err == nil {
+				err = derr
+			}
+			acc.Opened = opened
 		}
 	}
+	return
 }
 
-func (lp *parser) include(after string, callback func(t []*Transaction, err error) (stop bool)) (stop bool) {
+func (lp *parser) include(after string, callback func(r *parseResult, err error) (stop bool)) (stop bool) {
 	paths, _ := filepath.Glob(filepath.Join(filepath.Dir(lp.scanner.Name()), after))
 	if len(paths) < 1 {
 		callback(nil, fmt.Errorf("%s:%d: unable to include file(%s): %w", lp.scanner.Name(), lp.scanner.LineNumber(), after, errors.New("not found")))
 		return true
 	}
+	// errors of the included files follow the include line, so those of
+	// nested includes give the whole chain of files to where they are
+	name, line := lp.scanner.Name(), lp.scanner.LineNumber()
+	included := func(r *parseResult, err error) (stop bool) {
+		if err != nil {
+			err = fmt.Errorf("%s:%d -> %w", name, line, err)
+		}
+		return callback(r, err)
+	}
 	var wg sync.WaitGroup
 	for _, incpath := range paths {
+		named, ok := fileDateRange(incpath)
+		skip := ok && !lp.dates.overlaps(named)
+		if lp.stream {
+			if lp.includeFile(incpath, skip, included) {
+				return true
+			}
+			continue
+		}
 		wg.Add(1)
 		go func(ipath string) {
-			ifile, _ := os.Open(ipath)
-			defer ifile.Close()
-			if parseLedger(ipath, ifile, callback) {
+			if lp.includeFile(ipath, skip, included) {
 				stop = true
 			}
 			wg.Done()
@@ -197,6 +493,15 @@ func (lp *parser) include(after string, callback func(t []*Transaction, err erro
 	return
 }
 
+// includeFile parses an included file with the dates and stream of lp, only
+// its directives other than transactions with skipTransactions.
+func (lp *parser) includeFile(ipath string, skipTransactions bool, callback func(r *parseResult, err error) (stop bool)) (stop bool) {
+	ifile, _ := os.Open(ipath)
+	defer ifile.Close()
+	ilp := parser{dates: lp.dates, stream: lp.stream, skipTransactions: skipTransactions}
+	return ilp.parse(ipath, ifile, callback)
+}
+
 func (lp *parser) parseDate(dateString string) (transDate time.Time, err error) {
 	// seen before, skip parse
 	if lp.strPrevDate == dateString {
@@ -221,26 +526,124 @@ func (lp *parser) parseDate(dateString string) (transDate time.Time, err error)
 	return
 }
 
-func (a *Account) parsePosting(trimmedLine string, comment string) (err error) {
-	trimmedLine = strings.TrimSpace(trimmedLine)
-
-	// Regex groups:
-	// 1: account name
-	// 2: amount (number or parenthesized expression)
-	// 3: @@ converted amount
-	// 4: @ conversion rate
-	re := regexp.MustCompile(
-		`^(?P<name>.+?)` +
-			`(?:(?:\s{2,}|\t)` +
-			`(?:(?P<currency>[A-Z\$]+)\s+)?` +
-			`(?P<amount>[\-]?\d+(?:\.\d+)?|\([0-9+\-*\/. ]+\))` +
-			`(?:\s*(?:@@\s*` +
-			`(?P<converted>[\-]?\d+(?:\.\d+)?)|@\s*` +
-			`(?P<factor>[\-]?\d+(?:\.\d+)?)))?)?\s*$`,
-	)
-
-	m := re.FindStringSubmatch(trimmedLine)
+var priceRegex = regexp.MustCompile(`^(\S+)\s+(\S+)\s+(?:([A-Z\$]+)\s+)?([\-]?\d+(?:\.\d+)?)$`)
+
+// parsePrice parses the rest of a "P" price directive line: the date, the
+// commodity, an optional currency and the price.
+func (lp *parser) parsePrice(after string) (*Price, error) {
+	m := priceRegex.FindStringSubmatch(strings.TrimSpace(after))
 	if m == nil {
+		return nil, fmt.Errorf("invalid price: %q", after)
+	}
+	priceDate, err := lp.parseDate(m[1])
+	if err != nil {
+		return nil, err
+	}
+	price, err := decimal.NewFromString(m[4])
+	if err != nil {
+		return nil, err
+	}
+	return &Price{Date: priceDate, Commodity: m[2], Currency: m[3], Price: price}, nil
+}
+
+// parseStatus removes a leading status marker ("*" or "!" followed by a
+// space) from s.
+func parseStatus(s string) (Status, string) {
+	trimmed := strings.TrimLeft(s, " \t")
+	if len(trimmed) == 0 || (len(trimmed) > 1 && trimmed[1] != ' ' && trimmed[1] != '\t') {
+		return StatusUncleared, s
+	}
+	switch trimmed[0] {
+	case '*':
+		return StatusCleared, strings.TrimLeft(trimmed[1:], " \t")
+	case '!':
+		return StatusPending, strings.TrimLeft(trimmed[1:], " \t")
+	}
+	return StatusUncleared, s
+}
+
+// postingRegex matches a posting line. Groups:
+// 1: account name
+// 2: currency
+// 3: amount (number or parenthesized expression)
+// 4: @@ converted amount
+// 5: @ conversion rate
+// 6: = balance assertion
+var postingRegex = regexp.MustCompile(
+	`^(?P<name>.+?)` +
+		`(?:(?:\s{2,}|\t)` +
+		`(?:(?P<currency>[A-Z\$]+)\s+)?` +
+		`(?P<amount>[\-]?\d+(?:\.\d+)?|\([0-9+\-*\/. ]+\))` +
+		`(?:\s*(?:@@\s*` +
+		`(?P<converted>[\-]?\d+(?:\.\d+)?)|@\s*` +
+		`(?P<factor>[\-]?\d+(?:\.\d+)?)))?` +
+		`(?:\s*=\s*(?:[A-Z\$]+\s+)?` +
+		`(?P<assertion>[\-]?\d+(?:\.\d+)?))?)?\s*$`,
+)
+
+// splitPosting splits a posting line of an account name and an optional
+// currency and plain amount, the most common postings, without the cost of
+// postingRegex. It returns false for other postings, which are left to
+// postingRegex.
+func splitPosting(line string) (name, currency, amount string, ok bool) {
+	sep := strings.IndexByte(line, '\t')
+	if i := strings.Index(line, "  "); i >= 0 && (sep < 0 || i < sep) {
+		sep = i
+	}
+	if sep < 0 {
+		return line, "", "", true
+	}
+	name, rest := strings.TrimRight(line[:sep], " \t"), strings.TrimLeft(line[sep:], " \t")
+
+	if i := strings.IndexAny(rest, " \t"); i >= 0 {
+		currency, rest = rest[:i], strings.TrimLeft(rest[i:], " \t")
+		for j := 0; j < len(currency); j++ {
+			if c := currency[j]; (c < 'A' || c > 'Z') && c != '$' {
+				return "", "", "", false
+			}
+		}
+	}
+
+	// -?digits(.digits)?
+	digits := func(s string) int {
+		n := 0
+		for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+			n++
+		}
+		return n
+	}
+	i := 0
+	if strings.HasPrefix(rest, "-") {
+		i++
+	}
+	n := digits(rest[i:])
+	if n == 0 {
+		return "", "", "", false
+	}
+	i += n
+	if i < len(rest) && rest[i] == '.' {
+		n = digits(rest[i+1:])
+		if n == 0 {
+			return "", "", "", false
+		}
+		i += 1 + n
+	}
+	if i != len(rest) {
+		return "", "", "", false
+	}
+	return name, currency, rest, true
+}
+
+func (a *Account) parsePosting(trimmedLine string, comment string) (err error) {
+	a.Status, trimmedLine = parseStatus(strings.TrimSpace(trimmedLine))
+
+	// the groups of postingRegex, the first unused
+	var m [7]string
+	if name, currency, amount, ok := splitPosting(trimmedLine); ok {
+		m[1], m[2], m[3] = name, currency, amount
+	} else if match := postingRegex.FindStringSubmatch(trimmedLine); match != nil {
+		copy(m[:], match)
+	} else {
 		return fmt.Errorf("invalid posting: %q", trimmedLine)
 	}
 
@@ -248,12 +651,20 @@ func (a *Account) parsePosting(trimmedLine string, comment string) (err error) {
 	a.Currency = m[2]
 	a.Comment = comment
 
-	if m[3] != "" {
+	if strings.HasPrefix(m[3], "(") {
+		// expressions are evaluated in floating point
 		bal, err := compute.Evaluate(m[3])
 		if err != nil {
 			return err
 		}
 		a.Balance = decimal.NewFromFloat(bal)
+	} else if m[3] != "" {
+		// plain amounts keep all of their decimals
+		bal, err := decimal.NewFromString(m[3])
+		if err != nil {
+			return err
+		}
+		a.Balance = bal
 	}
 
 	// @@ explicit converted amount
@@ -273,6 +684,15 @@ func (a *Account) parsePosting(trimmedLine string, comment string) (err error) {
 		}
 		a.ConversionFactor = &rate
 	}
+
+	// = balance assertion
+	if m[6] != "" {
+		assertion, err := decimal.NewFromString(m[6])
+		if err != nil {
+			return err
+		}
+		a.BalanceAssertion = &assertion
+	}
 	return
 }
 
@@ -284,9 +704,20 @@ type block struct {
 	lines        []string
 	filename     string
 	lineNum      int
+	startLine    int
+}
+
+// skipBlock reads the lines of a transaction without parsing them.
+func (lp *parser) skipBlock() {
+	for lp.scanner.Scan() {
+		if len(lp.scanner.Text()) == 0 {
+			break
+		}
+	}
 }
 
 func (lp *parser) parseBlock(transDate time.Time, payeeString, payeeComment string, comments []string) block {
+	startLine := lp.scanner.LineNumber()
 	lines := []string{}
 	for lp.scanner.Scan() {
 		trimmedLine := lp.scanner.Text()
@@ -304,6 +735,7 @@ func (lp *parser) parseBlock(transDate time.Time, payeeString, payeeComment stri
 		lines:        lines,
 		filename:     lp.scanner.Name(),
 		lineNum:      lp.scanner.LineNumber(),
+		startLine:    startLine,
 	}
 }
 
@@ -328,12 +760,16 @@ func (b *block) parseTransaction() (trans *Transaction, err error) {
 		}
 
 		posting := Account{}
-		posting.parsePosting(trimmedLine, postingComment)
+		if err = posting.parsePosting(trimmedLine, postingComment); err != nil {
+			return nil, err
+		}
 		trans.AccountChanges = append(trans.AccountChanges, posting)
 	}
 
-	trans.Payee = b.payeeString
+	trans.Status, trans.Payee = parseStatus(b.payeeString)
 	trans.Date = b.transDate
+	trans.Filename = b.filename
+	trans.Line = b.startLine
 	trans.PayeeComment = b.payeeComment
 	if len(b.comments) > 0 {
 		trans.Comments = b.comments
@@ -345,3 +781,70 @@ func (b *block) parseTransaction() (trans *Transaction, err error) {
 
 	return
 }
+
+type periodicBlock struct {
+	block
+	period     Period
+	start, end time.Time
+}
+
+// parsePeriodicHeader parses the header of a periodic transaction, the text
+// after "~", and reads the postings that follow it. The header is a period
+// name, optionally followed by "from DATE" and "to DATE" (or "until DATE"),
+// and optionally a description separated by two spaces or a tab.
+func (lp *parser) parsePeriodicHeader(header, payeeComment string, comments []string) (pblock periodicBlock, err error) {
+	header = strings.TrimSpace(header)
+	description := ""
+	if idx := strings.IndexByte(header, '\t'); idx >= 0 {
+		header, description = header[:idx], header[idx+1:]
+	} else if idx := strings.Index(header, "  "); idx >= 0 {
+		header, description = header[:idx], header[idx+2:]
+	}
+
+	fields := strings.Fields(header)
+	if len(fields) < 1 {
+		return pblock, errors.New("missing period")
+	}
+	var ok bool
+	if pblock.period, ok = ParsePeriod(fields[0]); !ok {
+		return pblock, fmt.Errorf("unknown period(%s)", fields[0])
+	}
+	fields = fields[1:]
+	for len(fields) > 0 {
+		if len(fields) < 2 {
+			return pblock, fmt.Errorf("missing date after %q", fields[0])
+		}
+		keyword, dateString := strings.ToLower(fields[0]), fields[1]
+		fields = fields[2:]
+		pdate, derr := lp.parseDate(dateString)
+		if derr != nil {
+			return pblock, derr
+		}
+		switch keyword {
+		case "from":
+			pblock.start = pdate
+		case "to", "until":
+			pblock.end = pdate
+		default:
+			return pblock, fmt.Errorf("unexpected %q in period", keyword)
+		}
+	}
+
+	pblock.block = lp.parseBlock(time.Time{}, strings.TrimSpace(description), payeeComment, comments)
+	return pblock, nil
+}
+
+func (pb *periodicBlock) parsePeriodicTransaction() (*PeriodicTransaction, error) {
+	trans, err := pb.parseTransaction()
+	if err != nil {
+		return nil, err
+	}
+	return &PeriodicTransaction{
+		Period:         pb.period,
+		Start:          pb.start,
+		End:            pb.end,
+		Payee:          trans.Payee,
+		AccountChanges: trans.AccountChanges,
+		Comments:       trans.Comments,
+	}, nil
+}
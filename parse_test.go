@@ -2,8 +2,14 @@ package ledger
 
 import (
 	"bytes"
+	"cmp"
 	"encoding/json"
 	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -169,6 +175,35 @@ var testCases = []testCase{
 		},
 		nil,
 	},
+	{
+		"mixed space and tab separators",
+		`1970/01/01 Payee
+	Assets:Cash 	10
+	Assets:Cash	 -4
+	Expense:Food
+`,
+		[]*Transaction{
+			{
+				Payee: "Payee",
+				Date:  time.Unix(0, 0).UTC(),
+				AccountChanges: []Account{
+					{
+						Name:    "Assets:Cash",
+						Balance: decimal.NewFromFloat(10.0),
+					},
+					{
+						Name:    "Assets:Cash",
+						Balance: decimal.NewFromFloat(-4.0),
+					},
+					{
+						Name:    "Expense:Food",
+						Balance: decimal.NewFromFloat(-6.0),
+					},
+				},
+			},
+		},
+		nil,
+	},
 	{
 		"accounts with spaces",
 		`1970/01/02 Payee
@@ -641,6 +676,52 @@ account Assets
 		},
 		nil,
 	},
+	{
+		"status markers",
+		`1970/01/01 * Payee
+	! Expense/test  10
+	Assets
+
+1970/01/01 ! Pending Payee
+	Expense/test  20
+	* Assets
+`,
+		[]*Transaction{
+			{
+				Payee:  "Payee",
+				Date:   time.Unix(0, 0).UTC(),
+				Status: StatusCleared,
+				AccountChanges: []Account{
+					{
+						Name:    "Expense/test",
+						Status:  StatusPending,
+						Balance: decimal.NewFromFloat(10.0),
+					},
+					{
+						Name:    "Assets",
+						Balance: decimal.NewFromFloat(-10.0),
+					},
+				},
+			},
+			{
+				Payee:  "Pending Payee",
+				Date:   time.Unix(0, 0).UTC(),
+				Status: StatusPending,
+				AccountChanges: []Account{
+					{
+						Name:    "Expense/test",
+						Balance: decimal.NewFromFloat(20.0),
+					},
+					{
+						Name:    "Assets",
+						Status:  StatusCleared,
+						Balance: decimal.NewFromFloat(-20.0),
+					},
+				},
+			},
+		},
+		nil,
+	},
 }
 
 func p(d decimal.Decimal) *decimal.Decimal {
@@ -708,12 +789,164 @@ account endofledger`)
 	}
 }
 
+func TestParseLedgerFileAsync(t *testing.T) {
+	collect := func(filename string) (trans []*Transaction, errs []error) {
+		tc, ec := ParseLedgerFileAsync(filename)
+		for tc != nil || ec != nil {
+			select {
+			case tr, ok := <-tc:
+				if !ok {
+					tc = nil
+					continue
+				}
+				trans = append(trans, tr)
+			case err, ok := <-ec:
+				if !ok {
+					ec = nil
+					continue
+				}
+				if err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+		return
+	}
+
+	trans, errs := collect("testdata/ledgerRootGlob.dat")
+	if len(trans) != 14 || len(errs) != 0 {
+		t.Errorf("got %d transactions, errors %v", len(trans), errs)
+	}
+	// in the order of the ledger, the included files in place of the include
+	files := []string{"testdata/ledger-2022-01.dat", "testdata/ledger-2022-02.dat", "testdata/ledger-2022-04.dat", "testdata/ledgerRootGlob.dat"}
+	if !slices.IsSortedFunc(trans, func(a, b *Transaction) int {
+		return cmp.Or(slices.Index(files, a.Filename)-slices.Index(files, b.Filename), a.Line-b.Line)
+	}) || slices.ContainsFunc(trans, func(tr *Transaction) bool { return !slices.Contains(files, tr.Filename) }) {
+		for _, tr := range trans {
+			t.Logf("%s:%d", tr.Filename, tr.Line)
+		}
+		t.Error("transactions out of the order of the ledger")
+	}
+
+	trans, errs = collect("testdata/ledger-xxxxx.dat")
+	if len(trans) != 0 || len(errs) != 1 || errs[0].Error() != "open testdata/ledger-xxxxx.dat: no such file or directory" {
+		t.Errorf("got %d transactions, errors %v", len(trans), errs)
+	}
+}
+
+func TestParseLedgerAsyncStreams(t *testing.T) {
+	r, w := io.Pipe()
+	tc, ec := ParseLedgerAsync(r)
+	go io.WriteString(w, "2024/01/01 First\n    Expenses:Food    10\n    Assets:Cash\n\n")
+
+	// sent before the rest of the ledger is written
+	select {
+	case tr := <-tc:
+		if tr.Payee != "First" {
+			t.Errorf("got %q, want First", tr.Payee)
+		}
+	case err := <-ec:
+		t.Fatalf("error before the first transaction: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("first transaction not sent before the end of the ledger")
+	}
+
+	go func() {
+		io.WriteString(w, "2024/01/02 Second\n    Expenses:Food    5\n    Assets:Cash\n")
+		w.Close()
+	}()
+	if tr := <-tc; tr == nil || tr.Payee != "Second" {
+		t.Errorf("got %v, want Second", tr)
+	}
+	if err := <-ec; err != nil {
+		t.Error(err)
+	}
+}
+
 func BenchmarkParseLedger(b *testing.B) {
+	b.ReportAllocs()
 	for b.Loop() {
 		_, _ = ParseLedgerFile("testdata/ledgerBench.dat")
 	}
 }
 
+func BenchmarkParseLedgerReader(b *testing.B) {
+	src, err := os.ReadFile("testdata/ledgerBench.dat")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.SetBytes(int64(len(src)))
+	for b.Loop() {
+		_, _ = ParseLedger(bytes.NewReader(src))
+	}
+}
+
+func BenchmarkParsePosting(b *testing.B) {
+	for _, line := range []string{
+		"Assets:Wallet                                                           5.00",
+		"Assets:Brokerage  AAPL 10 @ 150.25",
+	} {
+		b.Run(strings.Fields(line)[0], func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				var acc Account
+				_ = acc.parsePosting(line, "")
+			}
+		})
+	}
+}
+
+func TestSplitPosting(t *testing.T) {
+	// splitPosting agrees with postingRegex on the postings it splits
+	for _, line := range []string{
+		"Expense  123",
+		"Expense",
+		"Expense:Cranks Unlimited\t10",
+		"Assets:Checking  USD -10.50",
+		"Assets:Checking    $ 10",
+		"A  B  10",
+		"Assets:Wallet  BTC 0.000000000000000001",
+		"Expense  (123*2)",
+		"Expense  10 @ 2",
+		"Expense  10 = 20",
+		"Expense  10 USD",
+		"Expense  1.",
+		"Expense  .5",
+		"Expense\tUSD\t-4",
+		"Assets:Cash \t10",
+		"Assets:Cash\t 10",
+		"Assets:Cash \t USD \t10",
+	} {
+		name, currency, amount, ok := splitPosting(line)
+		m := postingRegex.FindStringSubmatch(line)
+		if !ok {
+			continue
+		}
+		if m == nil {
+			t.Errorf("%q: split, but not a posting", line)
+			continue
+		}
+		if name != m[1] || currency != m[2] || amount != m[3] || m[4]+m[5]+m[6] != "" {
+			t.Errorf("%q: split as %q %q %q, want %q", line, name, currency, amount, m[1:])
+		}
+	}
+}
+
+func TestLineScanner(t *testing.T) {
+	lp := newLineScanner("", strings.NewReader("one\r\n\ntwo\nthree"))
+	var lines []string
+	for lp.Scan() {
+		lines = append(lines, lp.Text())
+	}
+	if want := []string{"one", "", "two", "three"}; !slices.Equal(lines, want) {
+		t.Errorf("got %q, want %q", lines, want)
+	}
+	if lp.LineNumber() != 4 {
+		t.Errorf("got line %d, want 4", lp.LineNumber())
+	}
+}
+
 func TestAccount_parsePosting(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -839,3 +1072,96 @@ func TestAccount_parsePosting(t *testing.T) {
 		})
 	}
 }
+
+func TestParsePeriodicTransactions(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "budget.dat")
+	err := os.WriteFile(filename, []byte(`~ Monthly
+	Expenses:Food      500
+	Expenses:Rent     1200
+	Assets:Checking
+
+~ Yearly from 2024/03/01  Insurance
+	Expenses:Insurance   600
+	Assets:Checking
+
+2024/01/05 Grocery Store
+	Expenses:Food      50
+	Assets:Checking
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	periodic, perr := ParsePeriodicTransactions(filename)
+	if perr != nil {
+		t.Fatal(perr)
+	}
+	if len(periodic) != 2 {
+		t.Fatalf("expected 2 periodic transactions, got %d", len(periodic))
+	}
+	if periodic[0].Period != PeriodMonth || len(periodic[0].AccountChanges) != 3 {
+		t.Errorf("unexpected monthly transaction: %+v", periodic[0])
+	}
+	if !periodic[0].AccountChanges[2].Balance.Equal(decimal.NewFromInt(-1700)) {
+		t.Errorf("expected elided amount -1700, got %s", periodic[0].AccountChanges[2].Balance)
+	}
+	if periodic[1].Period != PeriodYear || periodic[1].Payee != "Insurance" ||
+		!periodic[1].Start.Equal(time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected yearly transaction: %+v", periodic[1])
+	}
+
+	trans, terr := ParseLedgerFile(filename)
+	if terr != nil {
+		t.Fatal(terr)
+	}
+	if len(trans) != 1 {
+		t.Errorf("periodic transactions should not be regular transactions, got %d", len(trans))
+	}
+
+	_, err = ParseLedger(bytes.NewBufferString("~ Fortnightly\n\tExpenses  5\n\tAssets\n"))
+	if err == nil || err.Error() != ":1: unable to parse periodic transaction: unknown period(Fortnightly)" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestParseHighPrecision(t *testing.T) {
+	trans, err := ParseLedger(bytes.NewBufferString(`2024/01/01 Dust
+    Assets:Ether    ETH 0.000000000000000001
+    Assets:Bitcoin    BTC 0.123456789
+    Equity:ETH    ETH -0.000000000000000001
+    Equity:BTC
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"0.000000000000000001", "0.123456789", "-0.000000000000000001", "-0.123456789"}
+	for i, acc := range trans[0].AccountChanges {
+		if acc.Balance.String() != want[i] {
+			t.Errorf("%s: got %s, want %s", acc.Name, acc.Balance, want[i])
+		}
+	}
+
+	var buf bytes.Buffer
+	TransactionFormat{}.Write(&buf, trans[0])
+	written, err := ParseLedger(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, acc := range written[0].AccountChanges {
+		i := slices.IndexFunc(trans[0].AccountChanges, func(a Account) bool { return a.Name == acc.Name })
+		if i < 0 || !acc.Balance.Equal(trans[0].AccountChanges[i].Balance) {
+			t.Errorf("%s: written as %s", acc.Name, acc.Balance)
+		}
+	}
+}
+
+func TestParseLongLine(t *testing.T) {
+	comment := "; " + strings.Repeat("x", 1<<20)
+	trans, err := ParseLedger(strings.NewReader("2024/01/01 Payee  " + comment + "\n\tAssets:Cash  5  " + comment + "\n\tIncome\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trans) != 1 || trans[0].PayeeComment != comment || trans[0].AccountChanges[0].Comment != comment {
+		t.Error("long comments not parsed")
+	}
+}
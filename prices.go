@@ -0,0 +1,180 @@
+package ledger
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// LatestPrices returns, per commodity, the latest of the prices dated on or
+// before at. When a commodity has several prices on the same date, the last
+// one listed wins.
+func LatestPrices(prices []*Price, at time.Time) map[string]*Price {
+	latest := make(map[string]*Price)
+	for _, p := range prices {
+		if p.Date.After(at) {
+			continue
+		}
+		if cur, ok := latest[p.Commodity]; !ok || !p.Date.Before(cur.Date) {
+			latest[p.Commodity] = p
+		}
+	}
+	return latest
+}
+
+// Value returns the value of amount of the commodity at the price, and the
+// currency it is in. Amounts of commodities without a price keep their
+// currency.
+func Value(latest map[string]*Price, commodity string, amount decimal.Decimal) (string, decimal.Decimal) {
+	if p, ok := latest[commodity]; ok {
+		return p.Currency, amount.Mul(p.Price)
+	}
+	return commodity, amount
+}
+
+// ExchangeRate returns the rate to convert an amount of from into to, using
+// the latest prices dated on or before at. Prices convert both ways and are
+// chained when needed, for example AAPL to USD to EUR.
+func ExchangeRate(prices []*Price, from, to string, at time.Time) (decimal.Decimal, bool) {
+	return NewPriceDB(prices).ExchangeRate(from, to, at)
+}
+
+// PriceDB holds prices for lookups by date. It converts between commodities
+// and currencies with the prices declared either way round, chaining them
+// through Base, or else through any currencies, when no price relates the two
+// directly. A PriceDB is safe for concurrent lookups, but not while prices
+// are added.
+type PriceDB struct {
+	// Base is the currency conversions go through first when no price
+	// relates two currencies, "" for none.
+	Base string
+
+	// prices by commodity, and by commodity and currency, sorted by date
+	byCommodity map[string][]*Price
+	byPair      map[pricePair][]*Price
+	// currencies priced against each other, either way round
+	related map[string][]string
+}
+
+type pricePair struct {
+	commodity, currency string
+}
+
+// NewPriceDB returns a price database of the prices.
+func NewPriceDB(prices []*Price) *PriceDB {
+	db := &PriceDB{
+		byCommodity: make(map[string][]*Price),
+		byPair:      make(map[pricePair][]*Price),
+		related:     make(map[string][]string),
+	}
+	db.Add(prices...)
+	return db
+}
+
+// Add adds prices to the database. Of prices of the same date, the last one
+// added wins.
+func (db *PriceDB) Add(prices ...*Price) {
+	byDate := func(a, b *Price) int {
+		return a.Date.Compare(b.Date)
+	}
+	commodities := make(map[string]bool)
+	pairs := make(map[pricePair]bool)
+	for _, p := range prices {
+		db.byCommodity[p.Commodity] = append(db.byCommodity[p.Commodity], p)
+		commodities[p.Commodity] = true
+
+		key := pricePair{p.Commodity, p.Currency}
+		if _, ok := db.byPair[key]; !ok {
+			if _, ok := db.byPair[pricePair{p.Currency, p.Commodity}]; !ok {
+				db.related[p.Commodity] = append(db.related[p.Commodity], p.Currency)
+				db.related[p.Currency] = append(db.related[p.Currency], p.Commodity)
+			}
+		}
+		db.byPair[key] = append(db.byPair[key], p)
+		pairs[key] = true
+	}
+	for commodity := range commodities {
+		slices.SortStableFunc(db.byCommodity[commodity], byDate)
+	}
+	for key := range pairs {
+		slices.SortStableFunc(db.byPair[key], byDate)
+	}
+}
+
+// latest returns the last of the prices, sorted by date, dated on or before
+// at.
+func latest(prices []*Price, at time.Time) (*Price, bool) {
+	i, _ := slices.BinarySearchFunc(prices, at, func(p *Price, at time.Time) int {
+		return cmp.Or(p.Date.Compare(at), -1)
+	})
+	if i == 0 {
+		return nil, false
+	}
+	return prices[i-1], true
+}
+
+// Latest returns the latest price of the commodity, in any currency, dated on
+// or before at, as LatestPrices.
+func (db *PriceDB) Latest(commodity string, at time.Time) (*Price, bool) {
+	return latest(db.byCommodity[commodity], at)
+}
+
+// Rate returns the rate to convert an amount of from into to with a single
+// price: the latest price dated on or before at, of from in to, or of to in
+// from for the inverse rate. Of prices of the same date either way round, the
+// price of from in to wins.
+func (db *PriceDB) Rate(from, to string, at time.Time) (decimal.Decimal, bool) {
+	if from == to {
+		return decimal.NewFromInt(1), true
+	}
+	direct, directOK := latest(db.byPair[pricePair{from, to}], at)
+	inverse, inverseOK := latest(db.byPair[pricePair{to, from}], at)
+	if inverseOK && !inverse.Price.IsZero() && (!directOK || inverse.Date.After(direct.Date)) {
+		return decimal.NewFromInt(1).Div(inverse.Price), true
+	}
+	if directOK && !direct.Price.IsZero() {
+		return direct.Price, true
+	}
+	return decimal.Zero, false
+}
+
+// ExchangeRate returns the rate to convert an amount of from into to, using
+// the prices dated on or before at: the rate of a single price, else through
+// Base, else through the fewest other currencies.
+func (db *PriceDB) ExchangeRate(from, to string, at time.Time) (decimal.Decimal, bool) {
+	if rate, ok := db.Rate(from, to, at); ok {
+		return rate, true
+	}
+	if db.Base != "" {
+		toBase, toOK := db.Rate(from, db.Base, at)
+		fromBase, fromOK := db.Rate(db.Base, to, at)
+		if toOK && fromOK {
+			return toBase.Mul(fromBase), true
+		}
+	}
+
+	// breadth first, so the fewest conversions are chained
+	found := map[string]decimal.Decimal{from: decimal.NewFromInt(1)}
+	queue := []string{from}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, next := range db.related[cur] {
+			if _, seen := found[next]; seen {
+				continue
+			}
+			rate, ok := db.Rate(cur, next, at)
+			if !ok || rate.IsZero() {
+				continue
+			}
+			found[next] = found[cur].Mul(rate)
+			if next == to {
+				return found[next], true
+			}
+			queue = append(queue, next)
+		}
+	}
+	return decimal.Zero, false
+}
@@ -0,0 +1,135 @@
+package ledger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestLatestPrices(t *testing.T) {
+	prices, err := ParsePrices("testdata/ledgerPrices.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prices) != 4 {
+		t.Fatalf("expected 4 prices, got %d", len(prices))
+	}
+
+	tests := []struct {
+		at     time.Time
+		prices map[string]string
+	}{
+		{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), map[string]string{}},
+		{time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), map[string]string{"AAPL": "186.86", "EUR": "1.08"}},
+		{time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC), map[string]string{"AAPL": "179.66", "EUR": "1.08"}},
+	}
+	for _, tc := range tests {
+		latest := LatestPrices(prices, tc.at)
+		if len(latest) != len(tc.prices) {
+			t.Errorf("%s: expected %d prices, got %d", tc.at.Format("2006/01/02"), len(tc.prices), len(latest))
+		}
+		for commodity, price := range tc.prices {
+			if p, ok := latest[commodity]; !ok || p.Currency != "USD" || p.Price.String() != price {
+				t.Errorf("%s: expected %s at USD %s, got %+v", tc.at.Format("2006/01/02"), commodity, price, p)
+			}
+		}
+	}
+
+	latest := LatestPrices(prices, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	if cur, amount := Value(latest, "AAPL", decimal.NewFromInt(10)); cur != "USD" || amount.String() != "1868.6" {
+		t.Errorf("expected USD 1868.6, got %s %s", cur, amount)
+	}
+	if cur, amount := Value(latest, "GBP", decimal.NewFromInt(10)); cur != "GBP" || amount.String() != "10" {
+		t.Errorf("expected GBP 10, got %s %s", cur, amount)
+	}
+}
+
+func TestExchangeRate(t *testing.T) {
+	prices, err := ParsePrices("testdata/ledgerPrices.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		from, to string
+		at       time.Time
+		rate     string
+		ok       bool
+	}{
+		{"AAPL", "AAPL", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "1", true},
+		{"AAPL", "USD", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "0", false},
+		{"AAPL", "USD", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), "185.64", true},
+		{"USD", "EUR", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), "0.93", true},
+		{"AAPL", "EUR", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), "166.35", true},
+		{"AAPL", "GBP", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), "0", false},
+	}
+	for _, tc := range tests {
+		rate, ok := ExchangeRate(prices, tc.from, tc.to, tc.at)
+		if ok != tc.ok || rate.StringFixed(2) != decimal.RequireFromString(tc.rate).StringFixed(2) {
+			t.Errorf("%s to %s on %s: expected %s (%v), got %s (%v)", tc.from, tc.to, tc.at.Format("2006/01/02"), tc.rate, tc.ok, rate.StringFixed(2), ok)
+		}
+	}
+}
+
+func TestPriceDB(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	price := func(d int, commodity, currency, p string) *Price {
+		return &Price{Date: day(d), Commodity: commodity, Currency: currency, Price: decimal.RequireFromString(p)}
+	}
+	db := NewPriceDB([]*Price{
+		price(10, "AAPL", "USD", "180"),
+		price(5, "AAPL", "USD", "170"),
+		price(5, "EUR", "USD", "1.25"),
+		price(8, "USD", "EUR", "0.5"),
+		price(3, "BTC", "CHF", "40000"),
+		price(3, "USD", "CHF", "0.8"),
+		price(4, "JPY", "GBP", "0.005"),
+	})
+
+	if p, ok := db.Latest("AAPL", day(9)); !ok || !p.Price.Equal(decimal.NewFromInt(170)) {
+		t.Errorf("Latest(AAPL, 01/09) = %v, %v", p, ok)
+	}
+	if _, ok := db.Latest("AAPL", day(4)); ok {
+		t.Error("Latest(AAPL, 01/04) found a later price")
+	}
+
+	tests := []struct {
+		name     string
+		from, to string
+		at       int
+		rate     string
+		ok       bool
+	}{
+		{"direct", "EUR", "USD", 6, "1.25", true},
+		{"inverse", "USD", "EUR", 6, "0.8", true},
+		{"later inverse wins", "EUR", "USD", 9, "2", true},
+		{"before any price", "EUR", "USD", 4, "0", false},
+		{"chained", "AAPL", "EUR", 10, "90", true},
+		{"chained through inverse", "BTC", "EUR", 10, "25000", true},
+		{"unrelated", "JPY", "USD", 10, "0", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, ok := db.ExchangeRate(tt.from, tt.to, day(tt.at))
+			if ok != tt.ok || !rate.Round(8).Equal(decimal.RequireFromString(tt.rate)) {
+				t.Errorf("ExchangeRate(%s, %s) = %s (%v), want %s (%v)", tt.from, tt.to, rate, ok, tt.rate, tt.ok)
+			}
+		})
+	}
+
+	if _, ok := db.Rate("AAPL", "EUR", day(10)); ok {
+		t.Error("Rate() chained prices")
+	}
+
+	// through the base currency rather than the first chain found
+	db.Add(price(20, "CAD", "JPY", "110"), price(20, "CAD", "USD", "0.75"), price(20, "GBP", "USD", "1.3"))
+	db.Base = "USD"
+	if rate, ok := db.ExchangeRate("CAD", "GBP", day(20)); !ok || !rate.Round(8).Equal(decimal.RequireFromString("0.57692308")) {
+		t.Errorf("ExchangeRate(CAD, GBP) through USD = %s (%v), want 0.57692308", rate, ok)
+	}
+	db.Base = ""
+	if rate, ok := db.ExchangeRate("CAD", "GBP", day(20)); !ok || !rate.Round(8).Equal(decimal.RequireFromString("0.55")) {
+		t.Errorf("ExchangeRate(CAD, GBP) = %s (%v), want 0.55", rate, ok)
+	}
+}
@@ -0,0 +1,128 @@
+package ledger
+
+import (
+	"time"
+
+	"github.com/howeyc/ledger/ledger/query"
+	"github.com/shopspring/decimal"
+)
+
+// Query is a parsed query expression that selects postings. A nil Query
+// matches every posting. The query language is that of package query.
+type Query struct {
+	expr query.Expr
+	text string
+}
+
+// ParseQuery parses a query expression. An empty expression returns a nil
+// Query, which matches everything.
+func ParseQuery(s string) (*Query, error) {
+	expr, err := query.Parse(s)
+	if err != nil || expr == nil {
+		return nil, err
+	}
+	return &Query{expr: expr, text: s}, nil
+}
+
+// String returns the query expression the query was parsed from.
+func (q *Query) String() string {
+	if q == nil {
+		return ""
+	}
+	return q.text
+}
+
+// Expr returns the parsed expression of the query, nil for a nil Query.
+func (q *Query) Expr() query.Expr {
+	if q == nil {
+		return nil
+	}
+	return q.expr
+}
+
+// Match reports whether the posting acc of trans matches the query.
+func (q *Query) Match(trans *Transaction, acc *Account) bool {
+	return q == nil || q.expr.Match(queryPosting{trans, acc})
+}
+
+// MatchTransaction reports whether any posting of trans matches the query.
+func (q *Query) MatchTransaction(trans *Transaction) bool {
+	if q == nil {
+		return true
+	}
+	for i := range trans.AccountChanges {
+		if q.Match(trans, &trans.AccountChanges[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns copies of the transactions that have postings matching the
+// query, holding only the matching postings.
+func (q *Query) Filter(generalLedger []*Transaction) []*Transaction {
+	if q == nil {
+		return generalLedger
+	}
+	var filtered []*Transaction
+	for _, trans := range generalLedger {
+		var postings []Account
+		for i := range trans.AccountChanges {
+			if q.Match(trans, &trans.AccountChanges[i]) {
+				postings = append(postings, trans.AccountChanges[i])
+			}
+		}
+		if len(postings) > 0 {
+			t := *trans
+			t.AccountChanges = postings
+			filtered = append(filtered, &t)
+		}
+	}
+	return filtered
+}
+
+// Related returns copies of the transactions that have postings matching the
+// query, holding only the other postings, the ones that are not matched.
+func (q *Query) Related(generalLedger []*Transaction) []*Transaction {
+	var related []*Transaction
+	for _, trans := range generalLedger {
+		var postings []Account
+		matched := false
+		for i := range trans.AccountChanges {
+			if q.Match(trans, &trans.AccountChanges[i]) {
+				matched = true
+			} else {
+				postings = append(postings, trans.AccountChanges[i])
+			}
+		}
+		if matched && len(postings) > 0 {
+			t := *trans
+			t.AccountChanges = postings
+			related = append(related, &t)
+		}
+	}
+	return related
+}
+
+// queryPosting is a posting of a transaction as package query evaluates it.
+type queryPosting struct {
+	trans *Transaction
+	acc   *Account
+}
+
+func (p queryPosting) Account() string         { return p.acc.Name }
+func (p queryPosting) Payee() string           { return p.trans.Payee }
+func (p queryPosting) Currency() string        { return p.acc.Currency }
+func (p queryPosting) Amount() decimal.Decimal { return p.acc.Balance }
+func (p queryPosting) Date() time.Time         { return p.trans.Date }
+func (p queryPosting) Tags() map[string]string { return p.trans.PostingTags(p.acc) }
+
+func (p queryPosting) Status() query.Status {
+	switch p.trans.PostingStatus(p.acc) {
+	case StatusCleared:
+		return query.Cleared
+	case StatusPending:
+		return query.Pending
+	}
+	return query.Uncleared
+}
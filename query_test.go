@@ -0,0 +1,81 @@
+package ledger
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	transactions, err := ParseLedger(bytes.NewBufferString(`; :trip:
+2024/01/05 * Hotel Paris
+    Expenses:Travel:Lodging    EUR 250
+    Liabilities:Credit Card    EUR -250
+
+2024/01/20 Grocery Store
+    Expenses:Food    80    ; meal: dinner
+    Expenses:Dining Out    120
+    Assets:Checking
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hotel, grocery := transactions[0], transactions[1]
+
+	tests := []struct {
+		query    string
+		trans    *Transaction
+		posting  int
+		expected bool
+	}{
+		{"", grocery, 0, true},
+		{"Expenses", grocery, 2, false},
+		{"Food Lodging", hotel, 0, true},
+		{"acct:Expenses and amount>100", grocery, 0, false},
+		{"acct:Expenses and amount>100", grocery, 1, true},
+		{"acct:Expenses and amount>100 and tag:trip", hotel, 0, true},
+		{"acct:Expenses and amount>100 and tag:trip", grocery, 1, false},
+		{"tag:meal=dinner", grocery, 0, true},
+		{"tag:meal=lunch", grocery, 0, false},
+		{`acct:"Dining Out"`, grocery, 1, true},
+		{"not Expenses", grocery, 2, true},
+		{"(Food or Lodging) and not cur:EUR", hotel, 0, false},
+		{"(Food or Lodging) and not cur:EUR", grocery, 0, true},
+		{"payee:Hotel", hotel, 1, true},
+		{"status:cleared", hotel, 1, true},
+		{"status:uncleared", grocery, 1, true},
+		{"date>=2024/01/10", hotel, 0, false},
+		{"date>=2024/01/10", grocery, 0, true},
+		{"amount<=-200", grocery, 2, true},
+	}
+	for _, tc := range tests {
+		q, err := ParseQuery(tc.query)
+		if err != nil {
+			t.Errorf("ParseQuery(%q): %v", tc.query, err)
+			continue
+		}
+		if got := q.Match(tc.trans, &tc.trans.AccountChanges[tc.posting]); got != tc.expected {
+			t.Errorf("%q on %s/%s: expected %v, got %v", tc.query, tc.trans.Payee, tc.trans.AccountChanges[tc.posting].Name, tc.expected, got)
+		}
+	}
+
+	for _, bad := range []string{"(Food", "Food and", "amount>abc", `acct:"Dining`, "status:done", ")"} {
+		if _, err := ParseQuery(bad); err == nil {
+			t.Errorf("ParseQuery(%q): expected error", bad)
+		}
+	}
+
+	q, _ := ParseQuery("Expenses and amount>=100")
+	filtered := q.Filter(transactions)
+	if len(filtered) != 2 || len(filtered[0].AccountChanges) != 1 || len(filtered[1].AccountChanges) != 1 {
+		t.Errorf("unexpected filter result: %v", filtered)
+	}
+	if len(grocery.AccountChanges) != 3 {
+		t.Error("filter should not modify transactions")
+	}
+
+	q, _ = ParseQuery("Checking")
+	related := q.Related(transactions)
+	if len(related) != 1 || len(related[0].AccountChanges) != 2 || related[0].AccountChanges[0].Name != "Expenses:Food" {
+		t.Errorf("unexpected related result: %v", related)
+	}
+}
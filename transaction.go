@@ -2,6 +2,9 @@ package ledger
 
 import (
 	"errors"
+	"iter"
+	"regexp"
+	"strings"
 
 	"github.com/shopspring/decimal"
 )
@@ -62,6 +65,8 @@ func (t *Transaction) inferConversionFactorForTwoCurrencyTx() error {
 	}
 
 	currencyMap := make(map[string]*currencyGroup)
+	// currencies in order of first posting, so the inferred side is stable
+	var currencyOrder []string
 
 	getCurrencyKey := func(a *Account) string {
 		if a.Converted != nil {
@@ -81,6 +86,7 @@ func (t *Transaction) inferConversionFactorForTwoCurrencyTx() error {
 		if !ok {
 			group = &currencyGroup{}
 			currencyMap[key] = group
+			currencyOrder = append(currencyOrder, key)
 		}
 		group.indices = append(group.indices, i)
 	}
@@ -89,19 +95,7 @@ func (t *Transaction) inferConversionFactorForTwoCurrencyTx() error {
 		return nil
 	}
 
-	var (
-		curKeys [2]string
-		groups  [2]*currencyGroup
-		i       int
-	)
-	for k, g := range currencyMap {
-		if i >= 2 {
-			break
-		}
-		curKeys[i] = k
-		groups[i] = g
-		i++
-	}
+	groups := [2]*currencyGroup{currencyMap[currencyOrder[0]], currencyMap[currencyOrder[1]]}
 
 	var baseCurIdx, otherCurIdx int
 	hasConv0 := false
@@ -177,3 +171,69 @@ func (t *Transaction) inferConversionFactorForTwoCurrencyTx() error {
 
 	return nil
 }
+
+// PostingStatus returns the status of a posting of the transaction: the
+// status of the transaction, unless the posting is marked further along.
+func (t *Transaction) PostingStatus(acc *Account) Status {
+	return max(t.Status, acc.Status)
+}
+
+// PostingRef is a posting of a transaction: the account change at Index of
+// the AccountChanges of Transaction.
+type PostingRef struct {
+	Transaction *Transaction
+	Posting     *Account
+	Index       int
+}
+
+// Postings returns an iterator over the postings of the transactions, in
+// order. The postings point into the transactions, so changes made through
+// them change the transactions.
+func Postings(trans []*Transaction) iter.Seq[PostingRef] {
+	return func(yield func(PostingRef) bool) {
+		for _, t := range trans {
+			for i := range t.AccountChanges {
+				if !yield(PostingRef{Transaction: t, Posting: &t.AccountChanges[i], Index: i}) {
+					return
+				}
+			}
+		}
+	}
+}
+
+var (
+	colonTagsRegex = regexp.MustCompile(`(?:^|\s):((?:[^\s:]+:)+)`)
+	valueTagRegex  = regexp.MustCompile(`(?:^|\s)([^\s:]+):(?:\s+(.*?))?\s*$`)
+)
+
+// commentTags adds the tags in comment to tags. Tags are written as
+// ":name1:name2:" or "name: value", separated by commas.
+func commentTags(comment string, tags map[string]string) {
+	comment = strings.TrimLeft(comment, "; \t")
+	for _, part := range strings.Split(comment, ",") {
+		for _, m := range colonTagsRegex.FindAllStringSubmatch(part, -1) {
+			for _, name := range strings.Split(strings.Trim(m[1], ":"), ":") {
+				tags[name] = ""
+			}
+		}
+		part = colonTagsRegex.ReplaceAllString(part, "")
+		if m := valueTagRegex.FindStringSubmatch(part); m != nil {
+			tags[m[1]] = m[2]
+		}
+	}
+}
+
+// PostingTags returns the tags of a posting of the transaction, found in the
+// comments of the transaction and of the posting. A tag of the posting
+// overrides a tag of the transaction with the same name.
+func (t *Transaction) PostingTags(acc *Account) map[string]string {
+	tags := make(map[string]string)
+	for _, c := range t.Comments {
+		commentTags(c, tags)
+	}
+	commentTags(t.PayeeComment, tags)
+	if acc != nil {
+		commentTags(acc.Comment, tags)
+	}
+	return tags
+}
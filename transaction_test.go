@@ -205,3 +205,59 @@ func TestIsBalanced(t *testing.T) {
 		})
 	}
 }
+
+func TestPostings(t *testing.T) {
+	trans := []*Transaction{
+		{Payee: "Grocery Store", AccountChanges: []Account{
+			{Name: "Expenses:Food", Balance: decimal.NewFromInt(30)},
+			{Name: "Assets:Checking", Balance: decimal.NewFromInt(-30)},
+		}},
+		{Payee: "Empty"},
+		{Payee: "Employer", AccountChanges: []Account{
+			{Name: "Assets:Checking", Balance: decimal.NewFromInt(1000)},
+			{Name: "Income:Salary", Balance: decimal.NewFromInt(-1000)},
+		}},
+	}
+
+	var got []string
+	for p := range Postings(trans) {
+		if p.Posting != &p.Transaction.AccountChanges[p.Index] {
+			t.Errorf("%s posting %d does not point into its transaction", p.Transaction.Payee, p.Index)
+		}
+		got = append(got, p.Transaction.Payee+" "+p.Posting.Name)
+	}
+	want := []string{"Grocery Store Expenses:Food", "Grocery Store Assets:Checking", "Employer Assets:Checking", "Employer Income:Salary"}
+	if len(got) != len(want) {
+		t.Fatalf("Postings() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Postings()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	for p := range Postings(trans) {
+		p.Posting.Balance = p.Posting.Balance.Neg()
+		break
+	}
+	if !trans[0].AccountChanges[0].Balance.Equal(decimal.NewFromInt(-30)) {
+		t.Error("change through the posting not made to the transaction")
+	}
+}
+
+func TestInferConversionFactorForTwoCurrencyTx(t *testing.T) {
+	// the currency of the first posting is the base, whatever the map order
+	for range 100 {
+		tx := &Transaction{AccountChanges: []Account{
+			{Name: "Assets:Wise:CZK", Currency: "CZK", Balance: decimal.NewFromInt(-2000)},
+			{Name: "Assets:Wise:EUR", Currency: "EUR", Balance: decimal.NewFromInt(1000)},
+		}}
+		if err := tx.inferConversionFactorForTwoCurrencyTx(); err != nil {
+			t.Fatal(err)
+		}
+		if tx.AccountChanges[0].Converted != nil || tx.AccountChanges[1].Converted == nil ||
+			!tx.AccountChanges[1].Converted.Equal(decimal.NewFromInt(-2000)) {
+			t.Fatalf("got conversions %v, %v", tx.AccountChanges[0].Converted, tx.AccountChanges[1].Converted)
+		}
+	}
+}
@@ -6,9 +6,22 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+// Status is the clearing status of a transaction or posting, marked with "*"
+// (cleared) or "!" (pending) before the payee or account name.
+type Status int
+
+// Clearing statuses, in order of progress.
+const (
+	StatusUncleared Status = iota
+	StatusPending
+	StatusCleared
+)
+
 // Account holds the name and balance
 type Account struct {
 	Name string
+	// Status of the posting, if marked separately from the transaction
+	Status Status `json:",omitempty"`
 	// Default "" for no currency/token displayed
 	Currency string
 	Balance  decimal.Decimal
@@ -18,6 +31,8 @@ type Account struct {
 	Converted *decimal.Decimal
 	// Conversion factor using @ notation
 	ConversionFactor *decimal.Decimal
+	// Expected balance of the account after this posting, using = notation
+	BalanceAssertion *decimal.Decimal
 }
 
 // Transaction is the basis of a ledger. The ledger holds a list of transactions.
@@ -30,4 +45,36 @@ type Transaction struct {
 	PayeeComment   string
 	AccountChanges []Account
 	Comments       []string
+	Status         Status `json:",omitempty"`
+
+	// Filename and Line locate the transaction header in the ledger file it
+	// was parsed from. They describe the file rather than the transaction, so
+	// they are not serialized.
+	Filename string `json:"-"`
+	Line     int    `json:"-"`
+}
+
+// PeriodicTransaction is a transaction that recurs every Period, declared in a
+// ledger file with a "~ Period" header line instead of a date. Periodic
+// transactions are used to declare budgets and forecasts; they do not affect
+// balances.
+//
+// Start and End optionally limit the dates on which the transaction recurs
+// (declared as "~ Monthly from 2024/01/01 to 2025/01/01"). End is exclusive.
+type PeriodicTransaction struct {
+	Period         Period
+	Start, End     time.Time
+	Payee          string
+	AccountChanges []Account
+	Comments       []string
+}
+
+// Price is the price of one unit of Commodity in Currency on Date, declared in
+// a ledger file with a "P DATE COMMODITY [CURRENCY] PRICE" line.
+type Price struct {
+	Date      time.Time
+	Commodity string
+	// Default "" for no currency/token displayed
+	Currency string
+	Price    decimal.Decimal
 }
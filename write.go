@@ -0,0 +1,351 @@
+package ledger
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/shopspring/decimal"
+)
+
+// TransactionFormat is how transactions are written to ledger files.
+type TransactionFormat struct {
+	// DateFormat is the layout of transaction dates, "2006/01/02" if empty.
+	DateFormat string
+	// Columns is the width amounts are aligned to, 80 if zero.
+	Columns int
+	// Commodities holds the formats of commodities, of which amounts are
+	// written with the decimal places, and as many more as they have.
+	// Symbols and separators are for reports and not written, as they would
+	// not be parsed back, unless FormatAmounts.
+	Commodities CommodityFormats
+	// FormatAmounts writes posting amounts entirely in the formats of their
+	// commodities, for reports rather than ledger files.
+	FormatAmounts bool
+	// LineEnding ends the lines written, "\n" if empty.
+	LineEnding string
+}
+
+// MaxPlaces is the most decimals amounts are written with, enough for the
+// smallest units of crypto currencies, such as the wei of ether.
+const MaxPlaces = 18
+
+// decimalPlaces returns the number of decimals of d, without trailing zeros.
+func decimalPlaces(d decimal.Decimal) int32 {
+	places := -d.Exponent()
+	for places > 0 && d.Truncate(places-1).Equal(d) {
+		places--
+	}
+	return places
+}
+
+// statusMarker returns the marker written before a payee or account name for
+// the status.
+func statusMarker(status Status) string {
+	switch status {
+	case StatusCleared:
+		return "* "
+	case StatusPending:
+		return "! "
+	}
+	return ""
+}
+
+// Write writes the transaction, its postings sorted by account name, followed
+// by a blank line. The transaction is not changed.
+func (f TransactionFormat) Write(w io.StringWriter, trans *Transaction) {
+	dateFormat := cmp.Or(f.DateFormat, "2006/01/02")
+	columns := cmp.Or(f.Columns, 80)
+	newLine := cmp.Or(f.LineEnding, "\n")
+	spaces := func(n int) string {
+		return strings.Repeat(" ", max(n, 1))
+	}
+
+	for _, c := range trans.Comments {
+		w.WriteString(c)
+		w.WriteString(newLine)
+	}
+
+	// Print accounts sorted by name
+	postings := slices.Clone(trans.AccountChanges)
+	slices.SortFunc(postings, func(a, b Account) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	w.WriteString(trans.Date.Format(dateFormat))
+	w.WriteString(" ")
+	w.WriteString(statusMarker(trans.Status))
+	w.WriteString(trans.Payee)
+	if len(trans.PayeeComment) > 0 {
+		w.WriteString(spaces(columns - 10 - utf8.RuneCountInString(trans.Payee)))
+		w.WriteString(trans.PayeeComment)
+	}
+	w.WriteString(newLine)
+
+	// amounts keep up to MaxPlaces decimals, such as quantities of crypto
+	// currencies; quantities of commodities priced in another keep all, such
+	// as fractions of shares, and so do the other amounts of their
+	// transaction, which would not balance rounded
+	priced := slices.ContainsFunc(postings, func(acc Account) bool {
+		return acc.ConversionFactor != nil || acc.Converted != nil
+	})
+	for _, accChange := range postings {
+		formatPlaces := f.Commodities.Lookup(accChange.Currency).Places
+		places := max(formatPlaces, decimalPlaces(accChange.Balance))
+		if !priced {
+			places = min(places, max(formatPlaces, MaxPlaces))
+		}
+		outBalanceString := accChange.Balance.StringFixedBank(places)
+		if f.FormatAmounts {
+			commodity := f.Commodities.Lookup(accChange.Currency)
+			commodity.Places = places
+			outBalanceString = commodity.Format(accChange.Currency, accChange.Balance)
+		} else if accChange.Currency != "" {
+			outBalanceString = accChange.Currency + " " + outBalanceString
+		}
+		// Show converted amount (@@) or conversion factor (@) similar to hledger
+		if accChange.Converted != nil {
+			convPlaces := min(max(2, decimalPlaces(*accChange.Converted)), MaxPlaces)
+			outBalanceString = outBalanceString + " @@ " + accChange.Converted.StringFixedBank(convPlaces)
+		} else if accChange.ConversionFactor != nil {
+			outBalanceString = outBalanceString + " @ " + accChange.ConversionFactor.String()
+		}
+		if accChange.BalanceAssertion != nil {
+			assertion := accChange.BalanceAssertion.StringFixedBank(max(formatPlaces, decimalPlaces(*accChange.BalanceAssertion)))
+			if accChange.Currency != "" {
+				assertion = accChange.Currency + " " + assertion
+			}
+			outBalanceString = outBalanceString + " = " + assertion
+		}
+		accName := statusMarker(accChange.Status) + accChange.Name
+		w.WriteString("    ")
+		w.WriteString(accName)
+		w.WriteString(spaces(columns - 4 - utf8.RuneCountInString(accName) - utf8.RuneCountInString(outBalanceString)))
+		w.WriteString(outBalanceString)
+		if len(accChange.Comment) > 0 {
+			w.WriteString(" ")
+			w.WriteString(accChange.Comment)
+		}
+		w.WriteString(newLine)
+	}
+	w.WriteString(newLine)
+}
+
+// AppendToFile writes transactions into a ledger file in date order, with the
+// default TransactionFormat. See TransactionFormat.AppendToFile.
+func AppendToFile(path string, trans ...*Transaction) error {
+	return TransactionFormat{}.AppendToFile(path, trans...)
+}
+
+// AppendToFile writes transactions into the ledger file at path, or the files
+// it includes, in date order. A file named for a year or a month, or with a
+// range directive, may only hold transactions of those dates; see
+// ParseLedgerFileRange. Of the files that may hold its date, each transaction
+// goes in the file of the latest transaction dated on or before it, or else of
+// the earliest transaction after it, or else in the first file named for or
+// declaring its date, or else the first file. In that file it goes before the
+// first transaction dated after it, and the comment lines above that
+// transaction, or else at the end of the file. The lines of the files are
+// otherwise kept as they are.
+//
+// The transactions are checked to balance, and to have a file, before any file
+// is written.
+func (f TransactionFormat) AppendToFile(path string, trans ...*Transaction) error {
+	for _, t := range trans {
+		if err := t.IsBalanced(); err != nil {
+			return err
+		}
+	}
+
+	existing, ledgerFiles, err := parseLedgerFiles(path)
+	if err != nil {
+		return err
+	}
+	slices.SortStableFunc(existing, func(a, b *Transaction) int {
+		return cmp.Or(a.Date.Compare(b.Date), strings.Compare(a.Filename, b.Filename), a.Line-b.Line)
+	})
+	holds := func(file string, t *Transaction) bool {
+		i := slices.IndexFunc(ledgerFiles, func(lf ledgerFile) bool { return lf.name == file })
+		return i >= 0 && ledgerFiles[i].dates.contains(t.Date)
+	}
+
+	byFile := make(map[string][]*Transaction)
+	var files []string
+	for _, t := range trans {
+		i, _ := slices.BinarySearchFunc(existing, t, func(e, t *Transaction) int {
+			return cmp.Or(e.Date.Compare(t.Date), -1)
+		})
+		file := ""
+		for j := i - 1; j >= 0 && file == ""; j-- {
+			if holds(existing[j].Filename, t) {
+				file = existing[j].Filename
+			}
+		}
+		for j := i; j < len(existing) && file == ""; j++ {
+			if holds(existing[j].Filename, t) {
+				file = existing[j].Filename
+			}
+		}
+		// files named for or declaring the date first
+		for _, dated := range []bool{true, false} {
+			for _, lf := range ledgerFiles {
+				if file == "" && (lf.dates != dateRange{}) == dated && lf.dates.contains(t.Date) {
+					file = lf.name
+				}
+			}
+		}
+		if file == "" {
+			return fmt.Errorf("%s: no ledger file may hold transactions dated %s", path, t.Date.Format(time.DateOnly))
+		}
+		if _, ok := byFile[file]; !ok {
+			files = append(files, file)
+		}
+		byFile[file] = append(byFile[file], t)
+	}
+
+	for _, file := range files {
+		if err := f.insertTransactions(file, existing, byFile[file]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ledgerFile is a ledger file, with the dates of the transactions it may hold.
+type ledgerFile struct {
+	name  string
+	dates dateRange
+}
+
+// parseLedgerFiles parses the ledger file at path as ParseLedgerFile,
+// returning also the files parsed, the ledger file first, then the files it
+// includes, sorted, with the dates of their names and range directives.
+func parseLedgerFiles(path string) ([]*Transaction, []ledgerFile, error) {
+	ifile, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer ifile.Close()
+
+	var mu sync.Mutex
+	var trans []*Transaction
+	var files []ledgerFile
+	parseLedger(path, ifile, func(r *parseResult, e error) (stop bool) {
+		if e != nil {
+			err = e
+			return true
+		}
+		lf := ledgerFile{name: r.filename}
+		if named, ok := fileDateRange(r.filename); ok {
+			lf.dates = named
+		}
+		if r.declared != nil {
+			lf.dates = lf.dates.intersect(*r.declared)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		trans = append(trans, r.transactions...)
+		files = append(files, lf)
+		return
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	slices.SortFunc(files, func(a, b ledgerFile) int {
+		switch {
+		case a.name == path:
+			return -1
+		case b.name == path:
+			return 1
+		}
+		return strings.Compare(a.name, b.name)
+	})
+	return trans, files, nil
+}
+
+// insertTransactions writes transactions into a single ledger file in date
+// order. The transactions of the file are those of existing parsed from it.
+func (f TransactionFormat) insertTransactions(filename string, existing, transactions []*Transaction) error {
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(string(src), "\n")
+
+	var headers []*Transaction
+	for _, trans := range existing {
+		if trans.Filename == filename && trans.Line >= 1 && trans.Line <= len(lines) {
+			headers = append(headers, trans)
+		}
+	}
+	slices.SortStableFunc(headers, func(a, b *Transaction) int {
+		return a.Line - b.Line
+	})
+
+	// the line each transaction goes before, len(lines) for the end
+	inserts := make(map[int][]*Transaction)
+	for _, trans := range transactions {
+		at := len(lines)
+		if i := slices.IndexFunc(headers, func(h *Transaction) bool {
+			return h.Date.After(trans.Date)
+		}); i >= 0 {
+			at = headers[i].Line - 1
+			for at > 0 && isCommentLine(lines[at-1]) {
+				at--
+			}
+		}
+		inserts[at] = append(inserts[at], trans)
+	}
+	for _, at := range inserts {
+		slices.SortStableFunc(at, func(a, b *Transaction) int {
+			return a.Date.Compare(b.Date)
+		})
+	}
+
+	newLine := cmp.Or(f.LineEnding, "\n")
+	var out strings.Builder
+	for i := 0; i <= len(lines); i++ {
+		if added := inserts[i]; len(added) > 0 {
+			if i == len(lines) {
+				// end the file with a blank line before the transactions
+				text := out.String()
+				switch {
+				case text == "", strings.HasSuffix(text, "\n\n"):
+				case strings.HasSuffix(text, "\n"):
+					out.WriteString(newLine)
+				default:
+					out.WriteString(newLine + newLine)
+				}
+			} else if i > 0 && strings.TrimSpace(lines[i-1]) != "" {
+				out.WriteString(newLine)
+			}
+			for _, trans := range added {
+				f.Write(&out, trans)
+			}
+		}
+		if i < len(lines) {
+			out.WriteString(lines[i])
+			if i < len(lines)-1 {
+				out.WriteString(newLine)
+			}
+		}
+	}
+	return os.WriteFile(filename, []byte(out.String()), fi.Mode())
+}
+
+// isCommentLine returns whether the line holds only a comment.
+func isCommentLine(line string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#")
+}
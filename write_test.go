@@ -0,0 +1,201 @@
+package ledger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func newAppendTransaction(date, payee string) *Transaction {
+	d, _ := time.Parse(time.DateOnly, date)
+	return &Transaction{Date: d, Payee: payee, AccountChanges: []Account{
+		{Name: "Assets:Checking", Balance: decimal.NewFromInt(-5)},
+		{Name: "Expenses:Food", Balance: decimal.NewFromInt(5)},
+	}}
+}
+
+func TestTransactionFormatWrite(t *testing.T) {
+	trans := newAppendTransaction("2024-01-05", "Grocer")
+	trans.AccountChanges[0], trans.AccountChanges[1] = trans.AccountChanges[1], trans.AccountChanges[0]
+	posting := &trans.AccountChanges[0]
+
+	var out strings.Builder
+	TransactionFormat{Columns: 40, LineEnding: "\r\n"}.Write(&out, trans)
+	want := "2024/01/05 Grocer\r\n" +
+		"    Assets:Checking                -5.00\r\n" +
+		"    Expenses:Food                   5.00\r\n" +
+		"\r\n"
+	if out.String() != want {
+		t.Errorf("got:\n%q\nwant:\n%q", out.String(), want)
+	}
+	if trans.AccountChanges[0].Name != "Expenses:Food" || posting.Name != "Expenses:Food" {
+		t.Errorf("postings reordered: %v", trans.AccountChanges)
+	}
+}
+
+func TestAppendToFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "ledger.dat")
+	src := `2024/01/01 Opening
+    Assets:Checking    100
+    Equity
+
+; rent is due on the first
+2024/02/01 Rent
+    Expenses:Rent    50
+    Assets:Checking
+`
+	if err := os.WriteFile(filename, []byte(src), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := AppendToFile(filename,
+		newAppendTransaction("2024-03-01", "Bakery"),
+		newAppendTransaction("2024-01-20", "Grocer"),
+		newAppendTransaction("2024-01-10", "Cafe"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `2024/01/01 Opening
+    Assets:Checking    100
+    Equity
+
+2024/01/10 Cafe
+    Assets:Checking                                                        -5.00
+    Expenses:Food                                                           5.00
+
+2024/01/20 Grocer
+    Assets:Checking                                                        -5.00
+    Expenses:Food                                                           5.00
+
+; rent is due on the first
+2024/02/01 Rent
+    Expenses:Rent    50
+    Assets:Checking
+
+2024/03/01 Bakery
+    Assets:Checking                                                        -5.00
+    Expenses:Food                                                           5.00
+
+`
+	if string(got) != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestAppendToFileIncludes(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.dat": "include 2023.dat\ninclude 2024.dat\n",
+		"2023.dat": "2023/06/01 Opening\n    Assets:Checking    100\n    Equity\n",
+		"2024.dat": "2024/01/15 Rent\n    Expenses:Rent    50\n    Assets:Checking\n",
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	format := TransactionFormat{DateFormat: "2006-01-02", Columns: 40}
+	err := format.AppendToFile(filepath.Join(dir, "main.dat"),
+		newAppendTransaction("2023-01-01", "Early"),
+		newAppendTransaction("2023-12-31", "Late"),
+		newAppendTransaction("2024-02-01", "Bakery"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"main.dat": files["main.dat"],
+		"2023.dat": `2023-01-01 Early
+    Assets:Checking                -5.00
+    Expenses:Food                   5.00
+
+2023/06/01 Opening
+    Assets:Checking    100
+    Equity
+
+2023-12-31 Late
+    Assets:Checking                -5.00
+    Expenses:Food                   5.00
+
+`,
+		"2024.dat": `2024/01/15 Rent
+    Expenses:Rent    50
+    Assets:Checking
+
+2024-02-01 Bakery
+    Assets:Checking                -5.00
+    Expenses:Food                   5.00
+
+`,
+	}
+	for name, src := range want {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != src {
+			t.Errorf("%s: got\n%s\nwant\n%s", name, got, src)
+		}
+	}
+
+	unbalanced := newAppendTransaction("2024-03-01", "Unbalanced")
+	unbalanced.AccountChanges[0].Balance = decimal.NewFromInt(-4)
+	if err := AppendToFile(filepath.Join(dir, "main.dat"), unbalanced); err == nil {
+		t.Error("appended an unbalanced transaction")
+	}
+}
+
+func TestAppendToFileDates(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.dat":  "include 2023.dat\ninclude older.dat\ninclude 2024.dat\n",
+		"2023.dat":  "2023/06/01 Opening\n    Assets:Checking    100\n    Equity\n",
+		"older.dat": "range 2022/01/01 2022/12/31\n\n2022/06/01 Opening\n    Assets:Savings    100\n    Equity\n",
+		"2024.dat":  "; transactions of 2024\n",
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the files of the latest transactions before them are named for, or
+	// declare, other dates
+	main := filepath.Join(dir, "main.dat")
+	if err := AppendToFile(main, newAppendTransaction("2024-02-01", "Bakery"), newAppendTransaction("2025-01-01", "Later")); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"2024.dat": "Bakery", "main.dat": "Later"} {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(got), want) {
+			t.Errorf("%s: %s not appended:\n%s", name, want, got)
+		}
+	}
+	for _, name := range []string{"2023.dat", "older.dat"} {
+		if got, _ := os.ReadFile(filepath.Join(dir, name)); string(got) != files[name] {
+			t.Errorf("%s: changed:\n%s", name, got)
+		}
+	}
+	if _, err := ParseLedgerFile(main); err != nil {
+		t.Error(err)
+	}
+
+	if err := AppendToFile(filepath.Join(dir, "2023.dat"), newAppendTransaction("2024-03-01", "Elsewhere")); err == nil {
+		t.Error("appended a transaction to a file of other dates")
+	}
+}